@@ -1,22 +1,24 @@
 package trading
 
-import "math/big"
-
 type AccountRepository interface {
 	CreateAccount(account *Account) error
 
 	Account(accountID ID) (*Account, error)
+
+	Accounts() ([]*Account, error)
 }
 
 type Account struct {
 	ID    ID
 	Email string
 
+	// Exchange credentials are kept in plain text only in memory,
+	// repositories must encrypt them using a SecretCipher.
 	Exchange          string
 	ExchangeApiKey    string
-	ExchangeSecretKey string // TODO: Store credentials in a secure way.
+	ExchangeSecretKey string
 
-	RiskFactor         *big.Float
+	RiskFactor         Decimal
 	OpenPositionsLimit int
 }
 
@@ -24,6 +26,10 @@ type AccountWalletItem struct {
 	*Account
 
 	Asset           Asset
-	Balance         *big.Float
-	TakerCommission *big.Float
+	Balance         Decimal
+	TakerCommission Decimal
+	// ShortSellingAllowed tells whether the account can sell assets it
+	// doesn't hold. Spot accounts can't do that so they cannot open
+	// SHORT positions.
+	ShortSellingAllowed bool
 }
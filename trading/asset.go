@@ -1,7 +1,5 @@
 package trading
 
-import "math/big"
-
 type Asset string
 
 type PairSymbol string
@@ -14,14 +12,14 @@ func (p Pair) Symbol() PairSymbol {
 	return PairSymbol(p.Base + p.Quote)
 }
 
-type Balances map[Asset]*big.Float
+type Balances map[Asset]Decimal
 
-func (bm Balances) BalanceOf(asset Asset) *big.Float {
+func (bm Balances) BalanceOf(asset Asset) Decimal {
 	for balanceAsset, balanceValue := range bm {
 		if balanceAsset == asset {
 			return balanceValue
 		}
 	}
 
-	return big.NewFloat(0)
+	return Decimal{}
 }
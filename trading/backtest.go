@@ -0,0 +1,262 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BacktestExchangeService is a simulated exchange service whose market state
+// is moved forward by the backtest, candle by candle.
+type BacktestExchangeService interface {
+	ExchangeService
+	MarketFollower
+}
+
+// RunBacktest replays the given historical candles through the same logic
+// the live WorkloadRunner uses. The virtual clock is moved to the close time
+// of each replayed candle. Actions are performed once per candle, as soon as
+// the candle window is full.
+func RunBacktest(
+	ctx context.Context,
+	workload *Workload,
+	candles []*Candle,
+	clock *VirtualClock,
+	idService IDService,
+	exchangeService BacktestExchangeService,
+	candleRepository CandleRepository,
+	signalGenerator SignalGenerator,
+	positionRepository PositionRepository,
+	orderRepository OrderRepository,
+	logger Logger,
+) (*BacktestReport, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles to replay")
+	}
+
+	sorted := make([]*Candle, len(candles))
+	copy(sorted, candles)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OpenTime.Before(sorted[j].OpenTime)
+	})
+
+	clock.Set(sorted[0].CloseTime)
+
+	initialBalances, err := exchangeService.AccountBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get initial balances: [%v]", err)
+	}
+
+	takerCommission, err := exchangeService.AccountTakerCommission(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get taker commission: [%v]", err)
+	}
+
+	candleKey := workload.CandleKey()
+	defer func() {
+		_ = candleRepository.DeleteCandles(candleKey)
+	}()
+
+	var workloadRunner *WorkloadRunner
+
+	for _, candle := range sorted {
+		clock.Set(candle.CloseTime)
+		exchangeService.Advance(candle)
+		if err := candleRepository.SaveCandles(candleKey, candle); err != nil {
+			return nil, fmt.Errorf("could not save candle: [%v]", err)
+		}
+
+		if workloadRunner == nil {
+			windowCandles, err := candleRepository.Candles(candleKey)
+			if err != nil {
+				return nil, fmt.Errorf("could not get candles: [%v]", err)
+			}
+
+			if len(windowCandles) < workload.CandleWindowSize {
+				continue
+			}
+
+			workloadRunner = newWorkloadRunner(
+				workload,
+				idService,
+				exchangeService,
+				&repositoryMarketData{
+					candleRepository: candleRepository,
+					candleKey:        candleKey,
+				},
+				signalGenerator,
+				positionRepository,
+				orderRepository,
+				&backtestEventService{},
+				clock,
+				&NoopMetrics{},
+				logger,
+			)
+		}
+
+		if err := workloadRunner.act(ctx); err != nil {
+			return nil, fmt.Errorf(
+				"backtest failed at candle [%v]: [%v]",
+				candle,
+				err,
+			)
+		}
+	}
+
+	finalBalances, err := exchangeService.AccountBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get final balances: [%v]", err)
+	}
+
+	closedPositions, err := positionRepository.Positions(
+		PositionFilter{
+			WorkloadID: workload.ID,
+			Status:     StatusClosed,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get closed positions: [%v]", err)
+	}
+
+	openPositionsCount, err := positionRepository.PositionsCount(
+		PositionFilter{
+			WorkloadID: workload.ID,
+			Status:     StatusOpen,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not count open positions: [%v]", err)
+	}
+
+	report, err := newBacktestReport(
+		sorted[0].OpenTime,
+		sorted[len(sorted)-1].CloseTime,
+		len(sorted),
+		closedPositions,
+		openPositionsCount,
+		takerCommission,
+		initialBalances.BalanceOf(workload.Pair.Quote),
+		finalBalances.BalanceOf(workload.Pair.Quote),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not build report: [%v]", err)
+	}
+
+	return report, nil
+}
+
+type BacktestReport struct {
+	Start        time.Time
+	End          time.Time
+	CandlesCount int
+
+	Trades             []*Trade
+	OpenPositionsCount int
+
+	// Trades are counted as winning or losing, and their statistics are
+	// computed, by their net profit including the commission.
+	TakerCommission Decimal
+	WinningTrades   int
+	LosingTrades    int
+	GrossProfit     Decimal
+	GrossLoss       Decimal
+	// MaxDrawdown is the largest peak-to-trough decline of the cumulative
+	// net profit, in the quote asset.
+	MaxDrawdown Decimal
+
+	// Balances of the quote asset. The final balance includes
+	// the commission paid for all executed orders.
+	InitialBalance Decimal
+	FinalBalance   Decimal
+}
+
+func newBacktestReport(
+	start, end time.Time,
+	candlesCount int,
+	closedPositions []*Position,
+	openPositionsCount int,
+	takerCommission Decimal,
+	initialBalance Decimal,
+	finalBalance Decimal,
+) (*BacktestReport, error) {
+	report := &BacktestReport{
+		Start:              start,
+		End:                end,
+		CandlesCount:       candlesCount,
+		Trades:             make([]*Trade, 0),
+		OpenPositionsCount: openPositionsCount,
+		TakerCommission:    takerCommission,
+		InitialBalance:     initialBalance,
+		FinalBalance:       finalBalance,
+	}
+
+	for _, position := range closedPositions {
+		trade, exited, err := NewTrade(position)
+		if err != nil {
+			return nil, err
+		}
+
+		// Positions closed before the entry order execution are not trades.
+		if !exited {
+			continue
+		}
+
+		report.Trades = append(report.Trades, trade)
+	}
+
+	sort.SliceStable(report.Trades, func(i, j int) bool {
+		return report.Trades[i].ExitOrder.Time.Before(
+			report.Trades[j].ExitOrder.Time,
+		)
+	})
+
+	var cumulativeProfit, peakProfit Decimal
+
+	for _, trade := range report.Trades {
+		netProfit := trade.NetProfit(takerCommission)
+
+		if netProfit.Sign() > 0 {
+			report.WinningTrades++
+			report.GrossProfit = report.GrossProfit.Add(netProfit)
+		} else {
+			report.LosingTrades++
+			report.GrossLoss = report.GrossLoss.Add(netProfit)
+		}
+
+		cumulativeProfit = cumulativeProfit.Add(netProfit)
+
+		if cumulativeProfit.Cmp(peakProfit) > 0 {
+			peakProfit = cumulativeProfit
+		}
+
+		drawdown := peakProfit.Sub(cumulativeProfit)
+		if drawdown.Cmp(report.MaxDrawdown) > 0 {
+			report.MaxDrawdown = drawdown
+		}
+	}
+
+	return report, nil
+}
+
+// NetProfit returns the change of the quote asset balance, including
+// the paid commission.
+func (br *BacktestReport) NetProfit() Decimal {
+	return br.FinalBalance.Sub(br.InitialBalance)
+}
+
+// WinRate returns the fraction of trades closed with a profit.
+func (br *BacktestReport) WinRate() float64 {
+	if len(br.Trades) == 0 {
+		return 0
+	}
+
+	return float64(br.WinningTrades) / float64(len(br.Trades))
+}
+
+// backtestEventService drops all events as there is no one to notify
+// during a backtest.
+type backtestEventService struct{}
+
+func (bes *backtestEventService) Publish(_ *Event) {}
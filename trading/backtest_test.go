@@ -0,0 +1,297 @@
+package trading_test
+
+import (
+	"context"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/inmem"
+	"github.com/lukasz-zimnoch/dexly/trading/logrus"
+	"github.com/lukasz-zimnoch/dexly/trading/simulation"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"testing"
+	"time"
+)
+
+func TestRunBacktest(t *testing.T) {
+	windowSize := trading.DefaultCandleWindowSize
+
+	// Prices of candles following the first full window, each repeated
+	// for the given number of candles. Signals are emitted once the given
+	// candle, counted from the last one of the first window, is
+	// evaluated, which happens a few candles later for the first signal.
+	type priceRange struct {
+		candles int
+		price   string
+	}
+
+	tests := map[string]struct {
+		prices  []priceRange
+		signals map[int]*trading.Signal
+
+		expectedTrades        int
+		expectedWinningTrades int
+		expectedLosingTrades  int
+		expectedGrossProfit   string
+		expectedGrossLoss     string
+		expectedMaxDrawdown   string
+		expectedNetProfit     string
+	}{
+		"winning long": {
+			prices: []priceRange{{40, "100"}, {20, "106"}},
+			signals: map[int]*trading.Signal{
+				0: newTestSignal(trading.TypeLong, "100", "105", "97.5"),
+			},
+			expectedTrades:        1,
+			expectedWinningTrades: 1,
+			expectedGrossProfit:   "23.176",
+			expectedGrossLoss:     "0",
+			expectedMaxDrawdown:   "0",
+			expectedNetProfit:     "23.176",
+		},
+		"losing short": {
+			prices: []priceRange{{40, "100"}, {20, "103"}},
+			signals: map[int]*trading.Signal{
+				0: newTestSignal(trading.TypeShort, "100", "95", "102.5"),
+			},
+			expectedTrades:       1,
+			expectedLosingTrades: 1,
+			expectedGrossProfit:  "0",
+			expectedGrossLoss:    "-12.812",
+			expectedMaxDrawdown:  "12.812",
+			expectedNetProfit:    "-12.812",
+		},
+		// The price gain doesn't cover the commission of both orders.
+		"long winning on price but losing on commission": {
+			prices: []priceRange{{40, "100"}, {20, "100.15"}},
+			signals: map[int]*trading.Signal{
+				0: newTestSignal(trading.TypeLong, "100", "100.01", "97.5"),
+			},
+			expectedTrades:       1,
+			expectedLosingTrades: 1,
+			expectedGrossProfit:  "0",
+			expectedGrossLoss:    "-0.2006",
+			expectedMaxDrawdown:  "0.2006",
+			expectedNetProfit:    "-0.2006",
+		},
+		"drawdown after winning trade": {
+			prices: []priceRange{
+				{25, "100"},
+				{20, "106"},
+				{20, "103"},
+				{20, "100"},
+			},
+			signals: map[int]*trading.Signal{
+				0:  newTestSignal(trading.TypeLong, "100", "105", "97.5"),
+				30: newTestSignal(trading.TypeLong, "106", "111.3", "103.35"),
+				50: newTestSignal(trading.TypeLong, "103", "108.15", "100.425"),
+			},
+			expectedTrades:        3,
+			expectedWinningTrades: 1,
+			expectedLosingTrades:  2,
+			expectedGrossProfit:   "23.176",
+			expectedGrossLoss:     "-24.9630695",
+			expectedMaxDrawdown:   "24.9630695",
+			expectedNetProfit:     "-1.7870695",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			idService := &uuid.IDService{}
+
+			workload := &trading.Workload{
+				ID: idService.NewID(),
+				Account: &trading.Account{
+					ID:                 idService.NewID(),
+					RiskFactor:         trading.NewDecimal(1, -2),
+					OpenPositionsLimit: 1,
+				},
+				Pair:             trading.Pair{Base: "BTC", Quote: "USDT"},
+				CandleInterval:   trading.CandleInterval1m,
+				CandleWindowSize: windowSize,
+			}
+
+			start := time.Date(2021, 6, 11, 0, 0, 0, 0, time.UTC)
+			candles := make([]*trading.Candle, 0)
+			signals := make(map[time.Time]*trading.Signal)
+
+			appendCandles := func(count int, price trading.Decimal) {
+				for i := 0; i < count; i++ {
+					openTime := start.Add(
+						time.Duration(len(candles)) * time.Minute,
+					)
+					closeTime := openTime.Add(time.Minute - time.Millisecond)
+
+					candles = append(candles, &trading.Candle{
+						OpenTime:   openTime,
+						CloseTime:  closeTime,
+						OpenPrice:  price,
+						ClosePrice: price,
+						MaxPrice:   price,
+						MinPrice:   price,
+						Volume:     trading.NewDecimal(1, 0),
+					})
+				}
+			}
+
+			appendCandles(windowSize-1, trading.MustParseDecimal(
+				test.prices[0].price,
+			))
+			for index, signal := range test.signals {
+				openTime := start.Add(
+					time.Duration(windowSize-1+index) * time.Minute,
+				)
+				signals[openTime] = signal
+			}
+			for _, prices := range test.prices {
+				appendCandles(
+					prices.candles,
+					trading.MustParseDecimal(prices.price),
+				)
+			}
+
+			clock := trading.NewVirtualClock(time.Time{})
+
+			wallet := simulation.NewWallet(
+				trading.Balances{"USDT": trading.NewDecimal(1000, 0)},
+				trading.NewDecimal(1, -3),
+			)
+			wallet.SetShortSellingAllowed(true)
+
+			exchangeService := simulation.NewExchangeService(
+				workload,
+				simulation.NewHistoricalCandleService(candles, clock),
+				&trading.TradingRules{
+					PriceTick:   trading.NewDecimal(1, -2),
+					LotStep:     trading.NewDecimal(1, -5),
+					MinNotional: trading.NewDecimal(10, 0),
+				},
+				wallet,
+			)
+
+			orderRepository := inmem.NewOrderRepository()
+
+			report, err := trading.RunBacktest(
+				context.Background(),
+				workload,
+				candles,
+				clock,
+				idService,
+				exchangeService,
+				inmem.NewCandleRepository(workload.CandleWindowSize),
+				&scriptedSignalGenerator{signals},
+				inmem.NewPositionRepository(orderRepository),
+				orderRepository,
+				logrus.ConfigureStandardLogger("text", "panic"),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(report.Trades) != test.expectedTrades {
+				t.Fatalf(
+					"unexpected trades count\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedTrades,
+					len(report.Trades),
+				)
+			}
+
+			if report.WinningTrades != test.expectedWinningTrades ||
+				report.LosingTrades != test.expectedLosingTrades {
+				t.Errorf(
+					"unexpected winning/losing trades\n"+
+						"expected: [%v/%v]\n"+
+						"actual:   [%v/%v]",
+					test.expectedWinningTrades,
+					test.expectedLosingTrades,
+					report.WinningTrades,
+					report.LosingTrades,
+				)
+			}
+
+			assertDecimalEqual(
+				t,
+				"gross profit",
+				test.expectedGrossProfit,
+				report.GrossProfit,
+			)
+			assertDecimalEqual(
+				t,
+				"gross loss",
+				test.expectedGrossLoss,
+				report.GrossLoss,
+			)
+			assertDecimalEqual(
+				t,
+				"max drawdown",
+				test.expectedMaxDrawdown,
+				report.MaxDrawdown,
+			)
+			assertDecimalEqual(
+				t,
+				"net profit",
+				test.expectedNetProfit,
+				report.NetProfit(),
+			)
+
+			if report.OpenPositionsCount != 0 {
+				t.Errorf(
+					"unexpected open positions count: [%v]",
+					report.OpenPositionsCount,
+				)
+			}
+		})
+	}
+}
+
+// scriptedSignalGenerator emits each signal at the first evaluation of
+// a candle opened at or after the time of the signal.
+type scriptedSignalGenerator struct {
+	signals map[time.Time]*trading.Signal
+}
+
+func (ssg *scriptedSignalGenerator) Evaluate(
+	candles []*trading.Candle,
+) (*trading.Signal, bool) {
+	openTime := candles[len(candles)-1].OpenTime
+
+	for signalTime, signal := range ssg.signals {
+		if !openTime.Before(signalTime) {
+			delete(ssg.signals, signalTime)
+			return signal, true
+		}
+	}
+
+	return nil, false
+}
+
+func newTestSignal(
+	positionType trading.PositionType,
+	entry, takeProfit, stopLoss string,
+) *trading.Signal {
+	return &trading.Signal{
+		Type:             positionType,
+		EntryTarget:      trading.MustParseDecimal(entry),
+		TakeProfitTarget: trading.MustParseDecimal(takeProfit),
+		StopLossTarget:   trading.MustParseDecimal(stopLoss),
+	}
+}
+
+func assertDecimalEqual(
+	t *testing.T,
+	name string,
+	expected string,
+	actual trading.Decimal,
+) {
+	if !trading.MustParseDecimal(expected).Equal(actual) {
+		t.Errorf(
+			"unexpected %v\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			name,
+			expected,
+			actual,
+		)
+	}
+}
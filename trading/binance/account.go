@@ -3,52 +3,83 @@ package binance
 import (
 	"context"
 	"fmt"
+	"github.com/adshao/go-binance"
 	"github.com/lukasz-zimnoch/dexly/trading"
-	"math/big"
 )
 
 func (es *ExchangeService) AccountBalances(
 	ctx context.Context,
 ) (trading.Balances, error) {
+	if balances, ok := es.accountState.accountBalances(); ok {
+		return balances, nil
+	}
+
+	balances, _, err := fetchAccount(ctx, es.client)
+	if err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+func (es *ExchangeService) AccountTakerCommission(
+	ctx context.Context,
+) (trading.Decimal, error) {
+	if takerCommission, ok := es.accountState.accountTakerCommission(); ok {
+		return takerCommission, nil
+	}
+
+	_, takerCommission, err := fetchAccount(ctx, es.client)
+	if err != nil {
+		return trading.Decimal{}, err
+	}
+
+	return takerCommission, nil
+}
+
+// fetchAccount gets balances and the taker commission of the account
+// using a single request.
+func fetchAccount(
+	ctx context.Context,
+	client *binance.Client,
+) (trading.Balances, trading.Decimal, error) {
 	requestCtx, cancelRequestCtx := context.WithTimeout(ctx, requestTimeout)
 	defer cancelRequestCtx()
 
-	account, err := es.client.NewGetAccountService().Do(requestCtx)
+	account, err := client.NewGetAccountService().Do(requestCtx)
 	if err != nil {
-		return nil, err
+		return nil, trading.Decimal{}, classifyError(err)
 	}
 
-	balances := make(map[trading.Asset]*big.Float)
+	balances := make(trading.Balances)
 
 	for _, balance := range account.Balances {
-		amount, ok := new(big.Float).SetString(balance.Free)
-		if !ok {
-			return nil, fmt.Errorf(
-				"could not parse balance for asset [%v]",
+		amount, err := trading.ParseDecimal(balance.Free)
+		if err != nil {
+			return nil, trading.Decimal{}, fmt.Errorf(
+				"could not parse balance for asset [%v]: [%v]",
 				balance.Asset,
+				err,
 			)
 		}
 
-		if amount.Cmp(big.NewFloat(0)) == 0 {
+		if amount.IsZero() {
 			continue
 		}
 
 		balances[trading.Asset(balance.Asset)] = amount
 	}
 
-	return balances, nil
-}
+	// The commission is expressed in basis points.
+	takerCommission := trading.NewDecimal(account.TakerCommission, -4)
 
-func (es *ExchangeService) AccountTakerCommission(
-	ctx context.Context,
-) (*big.Float, error) {
-	requestCtx, cancelRequestCtx := context.WithTimeout(ctx, requestTimeout)
-	defer cancelRequestCtx()
-
-	account, err := es.client.NewGetAccountService().Do(requestCtx)
-	if err != nil {
-		return nil, err
-	}
+	return balances, takerCommission, nil
+}
 
-	return big.NewFloat(float64(account.TakerCommission / 10000)), nil
+// AccountShortSellingAllowed always returns false as the service operates
+// on spot accounts only.
+func (es *ExchangeService) AccountShortSellingAllowed(
+	_ context.Context,
+) (bool, error) {
+	return false, nil
 }
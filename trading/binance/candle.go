@@ -2,13 +2,40 @@ package binance
 
 import (
 	"context"
+	"fmt"
 	"github.com/adshao/go-binance"
 	"github.com/lukasz-zimnoch/dexly/trading"
 	"time"
 )
 
+// klinesPageSize is the maximum number of klines Binance returns at once.
+const klinesPageSize = 1000
+
+// Candles streams candles page by page. Each page costs a request weight
+// so long ranges are paced by the account rate limiter.
 func (es *ExchangeService) Candles(
 	ctx context.Context,
+	interval trading.CandleInterval,
+	start,
+	end time.Time,
+) (<-chan *trading.Candle, <-chan error) {
+	return trading.StreamCandles(
+		ctx,
+		interval,
+		start,
+		end,
+		func(
+			ctx context.Context,
+			start, end time.Time,
+		) ([]*trading.Candle, error) {
+			return es.candlesPage(ctx, interval, start, end)
+		},
+	)
+}
+
+func (es *ExchangeService) candlesPage(
+	ctx context.Context,
+	interval trading.CandleInterval,
 	start,
 	end time.Time,
 ) ([]*trading.Candle, error) {
@@ -18,10 +45,10 @@ func (es *ExchangeService) Candles(
 	klines, err := es.client.
 		NewKlinesService().
 		Symbol(string(es.workload.Pair.Symbol())).
-		Interval(trading.CandleInterval).
+		Interval(interval.String()).
 		StartTime(start.UnixNano() / 1e6).
 		EndTime(end.UnixNano() / 1e6).
-		Limit(1000).
+		Limit(klinesPageSize).
 		Do(requestCtx)
 	if err != nil {
 		return nil, err
@@ -31,16 +58,25 @@ func (es *ExchangeService) Candles(
 	for index := range candles {
 		kline := klines[index]
 
-		candles[index] = &trading.Candle{
-			OpenTime:   parseMilliseconds(kline.OpenTime),
-			CloseTime:  parseMilliseconds(kline.CloseTime),
-			OpenPrice:  kline.Open,
-			ClosePrice: kline.Close,
-			MaxPrice:   kline.High,
-			MinPrice:   kline.Low,
-			Volume:     kline.Volume,
-			TradeCount: uint(kline.TradeNum),
+		candle, err := parseCandle(
+			kline.OpenTime,
+			kline.CloseTime,
+			kline.Open,
+			kline.Close,
+			kline.High,
+			kline.Low,
+			kline.Volume,
+			kline.TradeNum,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse kline [%v]: [%v]",
+				kline.OpenTime,
+				err,
+			)
 		}
+
+		candles[index] = candle
 	}
 
 	return candles, nil
@@ -48,23 +84,40 @@ func (es *ExchangeService) Candles(
 
 func (es *ExchangeService) CandlesTicker(
 	ctx context.Context,
+	interval trading.CandleInterval,
 ) (<-chan *trading.CandleTick, <-chan error) {
 	tickChannel := make(chan *trading.CandleTick)
 	errorChannel := make(chan error)
 
+	// Sends are abandoned once the context is done, otherwise the stream
+	// goroutine would block forever when the receiver is gone.
+	sendError := func(err error) {
+		select {
+		case errorChannel <- err:
+		case <-ctx.Done():
+		}
+	}
+
 	go func() {
 		_, stopChannel, err := binance.WsKlineServe(
 			string(es.workload.Pair.Symbol()),
-			trading.CandleInterval,
+			interval.String(),
 			func(event *binance.WsKlineEvent) {
-				tickChannel <- es.parseKlineEvent(event)
-			},
-			func(err error) {
-				errorChannel <- err
+				tick, err := es.parseKlineEvent(event)
+				if err != nil {
+					sendError(err)
+					return
+				}
+
+				select {
+				case tickChannel <- tick:
+				case <-ctx.Done():
+				}
 			},
+			sendError,
 		)
 		if err != nil {
-			errorChannel <- err
+			sendError(err)
 			return
 		}
 
@@ -77,18 +130,57 @@ func (es *ExchangeService) CandlesTicker(
 
 func (es *ExchangeService) parseKlineEvent(
 	event *binance.WsKlineEvent,
-) *trading.CandleTick {
+) (*trading.CandleTick, error) {
+	candle, err := parseCandle(
+		event.Kline.StartTime,
+		event.Kline.EndTime,
+		event.Kline.Open,
+		event.Kline.Close,
+		event.Kline.High,
+		event.Kline.Low,
+		event.Kline.Volume,
+		event.Kline.TradeNum,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not parse kline event [%v]: [%v]",
+			event.Time,
+			err,
+		)
+	}
+
 	return &trading.CandleTick{
-		Candle: &trading.Candle{
-			OpenTime:   parseMilliseconds(event.Kline.StartTime),
-			CloseTime:  parseMilliseconds(event.Kline.EndTime),
-			OpenPrice:  event.Kline.Open,
-			ClosePrice: event.Kline.Close,
-			MaxPrice:   event.Kline.High,
-			MinPrice:   event.Kline.Low,
-			Volume:     event.Kline.Volume,
-			TradeCount: uint(event.Kline.TradeNum),
-		},
+		Candle:   candle,
 		TickTime: parseMilliseconds(event.Time),
+	}, nil
+}
+
+func parseCandle(
+	openTime, closeTime int64,
+	openPrice, closePrice, maxPrice, minPrice, volume string,
+	tradeCount int64,
+) (*trading.Candle, error) {
+	values := make([]trading.Decimal, 5)
+
+	for index, value := range []string{
+		openPrice, closePrice, maxPrice, minPrice, volume,
+	} {
+		decimal, err := trading.ParseDecimal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		values[index] = decimal
 	}
+
+	return &trading.Candle{
+		OpenTime:   parseMilliseconds(openTime),
+		CloseTime:  parseMilliseconds(closeTime),
+		OpenPrice:  values[0],
+		ClosePrice: values[1],
+		MaxPrice:   values[2],
+		MinPrice:   values[3],
+		Volume:     values[4],
+		TradeCount: uint(tradeCount),
+	}, nil
 }
@@ -0,0 +1,29 @@
+package binance
+
+import (
+	"github.com/adshao/go-binance/common"
+	"github.com/lukasz-zimnoch/dexly/trading"
+)
+
+// Codes of errors returned when the API key is invalid, lacks permissions
+// or the request signature doesn't match the secret key. See
+// https://binance-docs.github.io/apidocs/spot/en/#error-codes
+var authErrorCodes = map[int64]bool{
+	-1002: true, // UNAUTHORIZED
+	-1022: true, // INVALID_SIGNATURE
+	-2014: true, // BAD_API_KEY_FMT
+	-2015: true, // REJECTED_MBX_KEY
+}
+
+// classifyError marks authentication errors as fatal as retrying requests
+// using rejected credentials makes no sense.
+func classifyError(err error) error {
+	if common.IsAPIError(err) {
+		apiErr := err.(*common.APIError)
+		if authErrorCodes[apiErr.Code] {
+			return trading.NewFatalError(err)
+		}
+	}
+
+	return err
+}
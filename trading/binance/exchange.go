@@ -2,27 +2,50 @@ package binance
 
 import (
 	"context"
+	"fmt"
 	"github.com/adshao/go-binance"
 	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/http"
 	"time"
 )
 
-const requestTimeout = 1 * time.Minute
+const (
+	apiBaseURL     = "https://api.binance.com"
+	requestTimeout = 1 * time.Minute
+)
 
 type ExchangeService struct {
 	client       *binance.Client
-	exchangeInfo *binance.ExchangeInfo
+	tradingRules *trading.TradingRules
 	workload     *trading.Workload
+	// accountState is shared with the user data stream of the account.
+	accountState   *accountState
+	userDataStream *userDataStream
 }
 
 func NewExchangeService(
 	ctx context.Context,
 	workload *trading.Workload,
+) (*ExchangeService, error) {
+	return newExchangeService(ctx, workload, apiBaseURL)
+}
+
+func newExchangeService(
+	ctx context.Context,
+	workload *trading.Workload,
+	baseURL string,
 ) (*ExchangeService, error) {
 	client := binance.NewClient(
 		workload.Account.ExchangeApiKey,
 		workload.Account.ExchangeSecretKey,
 	)
+	client.BaseURL = baseURL
+	client.HTTPClient = &http.Client{
+		Transport: &rateLimitedTransport{
+			limiter:   accountRateLimiter(workload.Account.ExchangeApiKey),
+			transport: http.DefaultTransport,
+		},
+	}
 
 	requestCtx, cancelRequestCtx := context.WithTimeout(ctx, requestTimeout)
 	defer cancelRequestCtx()
@@ -32,10 +55,22 @@ func NewExchangeService(
 		return nil, err
 	}
 
+	tradingRules, err := parseTradingRules(
+		exchangeInfo,
+		string(workload.Pair.Symbol()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse trading rules: [%v]", err)
+	}
+
+	userDataStream := accountUserDataStream(workload.Account.ExchangeApiKey)
+
 	return &ExchangeService{
-		client:       client,
-		exchangeInfo: exchangeInfo,
-		workload:     workload,
+		client:         client,
+		tradingRules:   tradingRules,
+		workload:       workload,
+		accountState:   userDataStream.accountState,
+		userDataStream: userDataStream,
 	}, nil
 }
 
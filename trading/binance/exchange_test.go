@@ -0,0 +1,348 @@
+package binance
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/exchangetest"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExchangeService_Conformance(t *testing.T) {
+	exchangetest.Run(t, func(
+		t *testing.T,
+		exchange *exchangetest.Exchange,
+		workload *trading.Workload,
+	) trading.ExchangeService {
+		handler := newStandInHandler(t, exchange)
+
+		apiServer := httptest.NewServer(handler)
+		t.Cleanup(apiServer.Close)
+
+		// Streams are served by the client library using a hardcoded URL
+		// so they are redirected to the stand-in server by the dialer.
+		streamServer := httptest.NewTLSServer(handler)
+		t.Cleanup(streamServer.Close)
+
+		defaultDialer := websocket.DefaultDialer
+		websocket.DefaultDialer = &websocket.Dialer{
+			NetDial: func(network, _ string) (net.Conn, error) {
+				return net.Dial(network, streamServer.Listener.Addr().String())
+			},
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+		t.Cleanup(func() {
+			websocket.DefaultDialer = defaultDialer
+		})
+
+		service, err := newExchangeService(
+			context.Background(),
+			workload,
+			apiServer.URL,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return service
+	})
+}
+
+// newStandInHandler serves the stand-in exchange using the Binance API.
+func newStandInHandler(
+	t *testing.T,
+	exchange *exchangetest.Exchange,
+) http.Handler {
+	symbol := string(exchange.Pair.Symbol())
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v3/exchangeInfo", func(
+		responseWriter http.ResponseWriter,
+		_ *http.Request,
+	) {
+		rules := exchange.TradingRules
+
+		writeJSON(t, responseWriter, http.StatusOK, map[string]interface{}{
+			"symbols": []map[string]interface{}{{
+				"symbol": symbol,
+				"filters": []map[string]interface{}{
+					{
+						"filterType": "PRICE_FILTER",
+						"tickSize":   rules.PriceTick.String(),
+					},
+					{
+						"filterType": "LOT_SIZE",
+						"stepSize":   rules.LotStep.String(),
+						"minQty":     rules.MinQuantity.String(),
+						"maxQty":     rules.MaxQuantity.String(),
+					},
+					{
+						"filterType":  "MIN_NOTIONAL",
+						"minNotional": rules.MinNotional.String(),
+					},
+				},
+			}},
+		})
+	})
+
+	mux.HandleFunc("/api/v3/klines", func(
+		responseWriter http.ResponseWriter,
+		request *http.Request,
+	) {
+		query := request.URL.Query()
+
+		if query.Get("interval") != exchange.CandleInterval.String() {
+			writeAPIError(t, responseWriter, -1120, "Invalid interval.")
+			return
+		}
+
+		startTime, _ := strconv.ParseInt(query.Get("startTime"), 10, 64)
+		endTime, _ := strconv.ParseInt(query.Get("endTime"), 10, 64)
+
+		klines := make([][]interface{}, 0)
+
+		for _, candle := range exchange.Candles(
+			parseMilliseconds(startTime),
+			parseMilliseconds(endTime),
+		) {
+			klines = append(klines, []interface{}{
+				milliseconds(candle.OpenTime),
+				candle.OpenPrice.String(),
+				candle.MaxPrice.String(),
+				candle.MinPrice.String(),
+				candle.ClosePrice.String(),
+				candle.Volume.String(),
+				milliseconds(candle.CloseTime),
+				"0",
+				candle.TradeCount,
+				"0",
+				"0",
+				"0",
+			})
+		}
+
+		writeJSON(t, responseWriter, http.StatusOK, klines)
+	})
+
+	mux.HandleFunc("/api/v3/account", func(
+		responseWriter http.ResponseWriter,
+		_ *http.Request,
+	) {
+		balances := make([]map[string]string, 0)
+		for asset, balance := range exchange.Balances() {
+			balances = append(balances, map[string]string{
+				"asset":  string(asset),
+				"free":   balance.String(),
+				"locked": "0",
+			})
+		}
+
+		// The commission is expressed in basis points.
+		takerCommission, _ := strconv.Atoi(
+			exchange.TakerCommission.Mul(trading.NewDecimal(1, 4)).String(),
+		)
+
+		writeJSON(t, responseWriter, http.StatusOK, map[string]interface{}{
+			"takerCommission": takerCommission,
+			"balances":        balances,
+		})
+	})
+
+	mux.HandleFunc("/api/v3/order", func(
+		responseWriter http.ResponseWriter,
+		request *http.Request,
+	) {
+		if err := request.ParseForm(); err != nil {
+			t.Error(err)
+			return
+		}
+
+		switch request.Method {
+		case http.MethodPost:
+			order, err := placeStandInOrder(exchange, request)
+			if err != nil {
+				writeAPIError(t, responseWriter, -2010, err.Error())
+				return
+			}
+
+			writeJSON(t, responseWriter, http.StatusOK, map[string]string{
+				"symbol":        symbol,
+				"clientOrderId": order.ID,
+				"status":        orderStatus(order),
+			})
+		case http.MethodGet:
+			id := request.Form.Get("origClientOrderId")
+
+			order, exists := exchange.Order(id)
+			if !exists {
+				writeAPIError(t, responseWriter, -2013, "Order does not exist.")
+				return
+			}
+
+			writeJSON(t, responseWriter, http.StatusOK, map[string]string{
+				"symbol":        symbol,
+				"clientOrderId": order.ID,
+				"status":        orderStatus(order),
+			})
+		}
+	})
+
+	klineStream := fmt.Sprintf(
+		"/ws/%v@kline_%v",
+		strings.ToLower(symbol),
+		exchange.CandleInterval,
+	)
+
+	mux.HandleFunc(klineStream, func(
+		responseWriter http.ResponseWriter,
+		request *http.Request,
+	) {
+		serveStandInStream(
+			t,
+			responseWriter,
+			request,
+			exchange,
+			func(tick *trading.CandleTick) interface{} {
+				return map[string]interface{}{
+					"e": "kline",
+					"E": milliseconds(tick.TickTime),
+					"s": symbol,
+					"k": map[string]interface{}{
+						"t": milliseconds(tick.OpenTime),
+						"T": milliseconds(tick.CloseTime),
+						"s": symbol,
+						"i": exchange.CandleInterval.String(),
+						"o": tick.OpenPrice.String(),
+						"c": tick.ClosePrice.String(),
+						"h": tick.MaxPrice.String(),
+						"l": tick.MinPrice.String(),
+						"v": tick.Volume.String(),
+						"n": tick.TradeCount,
+						"x": false,
+					},
+				}
+			},
+		)
+	})
+
+	return mux
+}
+
+func placeStandInOrder(
+	exchange *exchangetest.Exchange,
+	request *http.Request,
+) (*exchangetest.Order, error) {
+	side, err := trading.ParseOrderSide(request.Form.Get("side"))
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := trading.ParseDecimal(request.Form.Get("price"))
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := trading.ParseDecimal(request.Form.Get("quantity"))
+	if err != nil {
+		return nil, err
+	}
+
+	return exchange.PlaceOrder(
+		request.Form.Get("newClientOrderId"),
+		side,
+		price,
+		size,
+	)
+}
+
+func orderStatus(order *exchangetest.Order) string {
+	if order.Filled {
+		return "FILLED"
+	}
+
+	// Not filled FOK orders expire.
+	return "EXPIRED"
+}
+
+// serveStandInStream sends candle ticks published by the exchange to
+// the websocket client until it disconnects.
+func serveStandInStream(
+	t *testing.T,
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	exchange *exchangetest.Exchange,
+	event func(tick *trading.CandleTick) interface{},
+) {
+	upgrader := websocket.Upgrader{}
+
+	conn, err := upgrader.Upgrade(responseWriter, request, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	tickChannel, unsubscribe := exchange.SubscribeCandles()
+	defer unsubscribe()
+
+	closedChannel := make(chan struct{})
+	go func() {
+		defer close(closedChannel)
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case tick := <-tickChannel:
+			if err := conn.WriteJSON(event(tick)); err != nil {
+				return
+			}
+		case <-closedChannel:
+			return
+		}
+	}
+}
+
+func writeAPIError(
+	t *testing.T,
+	responseWriter http.ResponseWriter,
+	code int64,
+	message string,
+) {
+	writeJSON(t, responseWriter, http.StatusBadRequest, map[string]interface{}{
+		"code": code,
+		"msg":  message,
+	})
+}
+
+func writeJSON(
+	t *testing.T,
+	responseWriter http.ResponseWriter,
+	status int,
+	value interface{},
+) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(status)
+
+	if err := json.NewEncoder(responseWriter).Encode(value); err != nil {
+		t.Error(err)
+	}
+}
+
+func milliseconds(value time.Time) int64 {
+	return value.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,325 @@
+package binance
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// requestWeightLimit is the request weight allowed per minute. It's
+	// set below the actual Binance limit to leave room for requests sent
+	// by other clients using the same IP.
+	requestWeightLimit  = 1000
+	requestWeightWindow = 1 * time.Minute
+
+	// Binance sends Retry-After along with 429 and 418 responses. These
+	// are used if the header is missing.
+	defaultRateLimitBackoff = 1 * time.Minute
+	defaultIPBanBackoff     = 5 * time.Minute
+
+	usedWeightHeader = "X-MBX-USED-WEIGHT-1M"
+	retryAfterHeader = "Retry-After"
+)
+
+type requestPriority int
+
+// Requests with higher priorities are sent first once the limit is hit.
+const (
+	priorityLow requestPriority = iota
+	priorityNormal
+	priorityHigh
+)
+
+type endpointKey struct {
+	method string
+	path   string
+}
+
+type endpointLimit struct {
+	weight   int
+	priority requestPriority
+}
+
+// endpointLimits holds weights of used endpoints. See
+// https://binance-docs.github.io/apidocs/spot/en/#limits
+var endpointLimits = map[endpointKey]endpointLimit{
+	{http.MethodPost, "/api/v3/order"}:       {1, priorityHigh},
+	{http.MethodDelete, "/api/v3/order"}:     {1, priorityHigh},
+	{http.MethodGet, "/api/v3/order"}:        {2, priorityNormal},
+	{http.MethodGet, "/api/v3/account"}:      {10, priorityNormal},
+	{http.MethodGet, "/api/v3/exchangeInfo"}: {10, priorityLow},
+	{http.MethodGet, "/api/v3/klines"}:       {1, priorityLow},
+}
+
+var defaultEndpointLimit = endpointLimit{1, priorityNormal}
+
+func limitOf(request *http.Request) endpointLimit {
+	limit, exists := endpointLimits[endpointKey{
+		method: request.Method,
+		path:   request.URL.Path,
+	}]
+	if !exists {
+		return defaultEndpointLimit
+	}
+
+	return limit
+}
+
+var (
+	rateLimitersMutex sync.Mutex
+	// rateLimiters are shared by all exchange services of an account.
+	rateLimiters = make(map[string]*rateLimiter)
+)
+
+// accountRateLimiter returns the rate limiter of the account identified
+// by the given API key.
+func accountRateLimiter(apiKey string) *rateLimiter {
+	rateLimitersMutex.Lock()
+	defer rateLimitersMutex.Unlock()
+
+	limiter, exists := rateLimiters[apiKey]
+	if !exists {
+		limiter = newRateLimiter(requestWeightLimit, time.Now)
+		rateLimiters[apiKey] = limiter
+	}
+
+	return limiter
+}
+
+type rateLimitWaiter struct {
+	priority requestPriority
+	sequence uint64
+	weight   int
+	ready    chan struct{}
+	index    int
+}
+
+// rateLimitQueue orders waiters by priority and then by arrival.
+type rateLimitQueue []*rateLimitWaiter
+
+func (rlq rateLimitQueue) Len() int {
+	return len(rlq)
+}
+
+func (rlq rateLimitQueue) Less(i, j int) bool {
+	if rlq[i].priority != rlq[j].priority {
+		return rlq[i].priority > rlq[j].priority
+	}
+
+	return rlq[i].sequence < rlq[j].sequence
+}
+
+func (rlq rateLimitQueue) Swap(i, j int) {
+	rlq[i], rlq[j] = rlq[j], rlq[i]
+	rlq[i].index = i
+	rlq[j].index = j
+}
+
+func (rlq *rateLimitQueue) Push(value interface{}) {
+	waiter := value.(*rateLimitWaiter)
+	waiter.index = len(*rlq)
+	*rlq = append(*rlq, waiter)
+}
+
+func (rlq *rateLimitQueue) Pop() interface{} {
+	old := *rlq
+	waiter := old[len(old)-1]
+	old[len(old)-1] = nil
+	waiter.index = -1
+	*rlq = old[:len(old)-1]
+	return waiter
+}
+
+// rateLimiter keeps the request weight used within the current minute
+// below the limit. The weight is estimated using endpoint weights and
+// corrected using the weight reported by Binance. Requests which would
+// exceed the limit, or are sent while the client is banned, wait in
+// a priority queue.
+type rateLimiter struct {
+	limit int
+	now   func() time.Time
+
+	mutex        sync.Mutex
+	windowStart  time.Time
+	usedWeight   int
+	blockedUntil time.Time
+	queue        rateLimitQueue
+	sequence     uint64
+	timer        *time.Timer
+}
+
+func newRateLimiter(limit int, now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		limit: limit,
+		now:   now,
+	}
+}
+
+// acquire blocks until the request of the given weight can be sent or
+// the context is done.
+func (rl *rateLimiter) acquire(
+	ctx context.Context,
+	priority requestPriority,
+	weight int,
+) error {
+	rl.mutex.Lock()
+
+	rl.sequence++
+	waiter := &rateLimitWaiter{
+		priority: priority,
+		sequence: rl.sequence,
+		weight:   weight,
+		ready:    make(chan struct{}),
+	}
+	heap.Push(&rl.queue, waiter)
+
+	rl.dispatch()
+
+	rl.mutex.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+		rl.mutex.Lock()
+		defer rl.mutex.Unlock()
+
+		// The waiter could have been dispatched in the meantime.
+		if waiter.index < 0 {
+			return nil
+		}
+
+		heap.Remove(&rl.queue, waiter.index)
+		rl.dispatch()
+
+		return ctx.Err()
+	}
+}
+
+// update corrects the limiter state using the response. Must be called
+// for each sent request.
+func (rl *rateLimiter) update(response *http.Response) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := rl.now()
+	rl.rollWindow(now)
+
+	// The reported weight doesn't include requests which are still in
+	// flight but does include requests of other clients using the same IP.
+	if usedWeight, err := strconv.Atoi(
+		response.Header.Get(usedWeightHeader),
+	); err == nil && usedWeight > rl.usedWeight {
+		rl.usedWeight = usedWeight
+	}
+
+	var backoff time.Duration
+	switch response.StatusCode {
+	case http.StatusTooManyRequests:
+		backoff = defaultRateLimitBackoff
+	case http.StatusTeapot:
+		// The IP has been banned for repeatedly violating limits.
+		backoff = defaultIPBanBackoff
+	}
+
+	if backoff > 0 {
+		retryAfter, err := strconv.Atoi(response.Header.Get(retryAfterHeader))
+		if err == nil && retryAfter > 0 {
+			backoff = time.Duration(retryAfter) * time.Second
+		}
+
+		if blockedUntil := now.Add(backoff); blockedUntil.After(
+			rl.blockedUntil,
+		) {
+			rl.blockedUntil = blockedUntil
+		}
+	}
+
+	rl.dispatch()
+}
+
+// dispatch releases queued requests which can be sent. Must be called
+// with the mutex held.
+func (rl *rateLimiter) dispatch() {
+	for len(rl.queue) > 0 {
+		now := rl.now()
+		rl.rollWindow(now)
+
+		if now.Before(rl.blockedUntil) {
+			rl.scheduleDispatch(rl.blockedUntil.Sub(now))
+			return
+		}
+
+		waiter := rl.queue[0]
+
+		// Requests heavier than the limit are sent in an empty window.
+		if rl.usedWeight > 0 && rl.usedWeight+waiter.weight > rl.limit {
+			windowEnd := rl.windowStart.Add(requestWeightWindow)
+			rl.scheduleDispatch(windowEnd.Sub(now))
+			return
+		}
+
+		heap.Pop(&rl.queue)
+		rl.usedWeight += waiter.weight
+		close(waiter.ready)
+	}
+}
+
+// rollWindow resets the used weight once the minute changes, as Binance
+// does. Must be called with the mutex held.
+func (rl *rateLimiter) rollWindow(now time.Time) {
+	windowStart := now.Truncate(requestWeightWindow)
+
+	if !windowStart.Equal(rl.windowStart) {
+		rl.windowStart = windowStart
+		rl.usedWeight = 0
+	}
+}
+
+// scheduleDispatch makes queued requests dispatched after the given
+// delay. Must be called with the mutex held.
+func (rl *rateLimiter) scheduleDispatch(delay time.Duration) {
+	if rl.timer != nil {
+		rl.timer.Stop()
+	}
+
+	rl.timer = time.AfterFunc(delay, func() {
+		rl.mutex.Lock()
+		defer rl.mutex.Unlock()
+
+		rl.dispatch()
+	})
+}
+
+// rateLimitedTransport sends requests through the rate limiter.
+type rateLimitedTransport struct {
+	limiter   *rateLimiter
+	transport http.RoundTripper
+}
+
+func (rlt *rateLimitedTransport) RoundTrip(
+	request *http.Request,
+) (*http.Response, error) {
+	limit := limitOf(request)
+
+	if err := rlt.limiter.acquire(
+		request.Context(),
+		limit.priority,
+		limit.weight,
+	); err != nil {
+		return nil, err
+	}
+
+	response, err := rlt.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	rlt.limiter.update(response)
+
+	return response, nil
+}
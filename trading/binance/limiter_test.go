@@ -0,0 +1,199 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Priorities(t *testing.T) {
+	now := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(10, func() time.Time { return now })
+
+	// Use the whole limit of the current window.
+	err := limiter.acquire(context.Background(), priorityLow, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var orderMutex sync.Mutex
+	order := make([]requestPriority, 0)
+
+	orderLength := func() int {
+		orderMutex.Lock()
+		defer orderMutex.Unlock()
+		return len(order)
+	}
+
+	for _, priority := range []requestPriority{
+		priorityLow,
+		priorityNormal,
+		priorityHigh,
+	} {
+		priority := priority
+
+		go func() {
+			err := limiter.acquire(context.Background(), priority, 10)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			orderMutex.Lock()
+			order = append(order, priority)
+			orderMutex.Unlock()
+		}()
+	}
+
+	waitForQueuedRequests(t, limiter, 3)
+
+	// Each window fits only one request so they are released one by one.
+	for released := 1; released <= 3; released++ {
+		limiter.mutex.Lock()
+		now = now.Add(requestWeightWindow)
+		limiter.dispatch()
+		limiter.mutex.Unlock()
+
+		deadline := time.Now().Add(time.Second)
+		for orderLength() < released && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	orderMutex.Lock()
+	defer orderMutex.Unlock()
+
+	if len(order) != 3 {
+		t.Fatalf("unexpected number of released requests: [%v]", len(order))
+	}
+
+	expectedOrder := []requestPriority{
+		priorityHigh,
+		priorityNormal,
+		priorityLow,
+	}
+	for index := range expectedOrder {
+		if order[index] != expectedOrder[index] {
+			t.Fatalf(
+				"unexpected order of requests\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				expectedOrder,
+				order,
+			)
+		}
+	}
+}
+
+func TestRateLimiter_ContextDone(t *testing.T) {
+	now := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(10, func() time.Time { return now })
+
+	err := limiter.acquire(context.Background(), priorityLow, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancelCtx := context.WithTimeout(
+		context.Background(),
+		10*time.Millisecond,
+	)
+	defer cancelCtx()
+
+	if err := limiter.acquire(ctx, priorityHigh, 1); err == nil {
+		t.Errorf("request exceeding the limit has been allowed")
+	}
+
+	waitForQueuedRequests(t, limiter, 0)
+}
+
+func TestRateLimitedTransport(t *testing.T) {
+	responses := []func(http.ResponseWriter){
+		func(responseWriter http.ResponseWriter) {
+			responseWriter.Header().Set(usedWeightHeader, "1185")
+			responseWriter.WriteHeader(http.StatusOK)
+		},
+		func(responseWriter http.ResponseWriter) {
+			responseWriter.Header().Set(retryAfterHeader, "30")
+			responseWriter.WriteHeader(http.StatusTooManyRequests)
+		},
+	}
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(responseWriter http.ResponseWriter, _ *http.Request) {
+			responses[requests](responseWriter)
+			requests++
+		},
+	))
+	defer server.Close()
+
+	now := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(1200, func() time.Time { return now })
+
+	client := &http.Client{
+		Transport: &rateLimitedTransport{
+			limiter:   limiter,
+			transport: http.DefaultTransport,
+		},
+	}
+
+	for range responses {
+		response, err := client.Get(server.URL + "/api/v3/account")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = response.Body.Close()
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	// The weight reported by the exchange has been taken into account.
+	if limiter.usedWeight != 1195 {
+		t.Errorf(
+			"unexpected used weight\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			1195,
+			limiter.usedWeight,
+		)
+	}
+
+	expectedBlockedUntil := now.Add(30 * time.Second)
+	if !limiter.blockedUntil.Equal(expectedBlockedUntil) {
+		t.Errorf(
+			"unexpected block time\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expectedBlockedUntil,
+			limiter.blockedUntil,
+		)
+	}
+}
+
+func waitForQueuedRequests(
+	t *testing.T,
+	limiter *rateLimiter,
+	expectedCount int,
+) {
+	deadline := time.Now().Add(time.Second)
+
+	for time.Now().Before(deadline) {
+		limiter.mutex.Lock()
+		count := len(limiter.queue)
+		limiter.mutex.Unlock()
+
+		if count == expectedCount {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("expected [%v] queued requests", expectedCount)
+}
@@ -2,7 +2,6 @@ package binance
 
 import (
 	"context"
-	"fmt"
 	"github.com/adshao/go-binance"
 	"github.com/adshao/go-binance/common"
 	"github.com/lukasz-zimnoch/dexly/trading"
@@ -15,27 +14,23 @@ func (es *ExchangeService) ExecuteOrder(
 	requestCtx, cancelRequestCtx := context.WithTimeout(ctx, requestTimeout)
 	defer cancelRequestCtx()
 
-	symbol := string(es.workload.Pair.Symbol())
-	symbolInfo, ok := es.findSymbolInfo(symbol)
-	if !ok {
-		return false, fmt.Errorf("could not find info for symbol: [%v]", symbol)
-	}
-
 	response, err := es.client.NewCreateOrderService().
-		Symbol(symbol).
+		Symbol(string(es.workload.Pair.Symbol())).
 		Side(binance.SideType(order.Side.String())).
 		Type(binance.OrderTypeLimit).
 		NewClientOrderID(order.ID.String()).
-		Price(order.Price.Text('f', symbolInfo.QuotePrecision)).
-		Quantity(order.Size.Text('f', symbolInfo.BaseAssetPrecision)).
+		Price(es.tradingRules.RoundPrice(order.Price).String()).
+		Quantity(es.tradingRules.RoundQuantity(order.Size).String()).
 		// fill or kill (FOK) orders are either filled immediately or cancelled
 		TimeInForce(binance.TimeInForceTypeFOK).
 		Do(requestCtx)
 	if err != nil {
 		// Request error - return it to the caller.
-		return false, err
+		return false, classifyError(err)
 	}
 
+	es.accountState.updateOrderStatus(order.ID.String(), response.Status)
+
 	if response.Status != binance.OrderStatusTypeFilled {
 		// The order's status is other than FILLED. Because the order is FOK,
 		// it has been probably cancelled. Return that info to the caller
@@ -47,22 +42,15 @@ func (es *ExchangeService) ExecuteOrder(
 	return true, nil
 }
 
-func (es *ExchangeService) findSymbolInfo(
-	symbol string,
-) (*binance.Symbol, bool) {
-	for _, symbolInfo := range es.exchangeInfo.Symbols {
-		if symbolInfo.Symbol == symbol {
-			return &symbolInfo, true
-		}
-	}
-
-	return nil, false
-}
-
 func (es *ExchangeService) IsOrderExecuted(
 	ctx context.Context,
 	order *trading.Order,
 ) (bool, error) {
+	// Pushed updates make polling unnecessary.
+	if status, ok := es.accountState.orderStatus(order); ok {
+		return status == binance.OrderStatusTypeFilled, nil
+	}
+
 	requestCtx, cancelRequestCtx := context.WithTimeout(ctx, requestTimeout)
 	defer cancelRequestCtx()
 
@@ -83,9 +71,11 @@ func (es *ExchangeService) IsOrderExecuted(
 		}
 
 		// Other request error - return it to the caller
-		return false, err
+		return false, classifyError(err)
 	}
 
+	es.accountState.updateOrderStatus(order.ID.String(), response.Status)
+
 	// We send FOK orders so an executed order will always have FILLED status.
 	return response.Status == binance.OrderStatusTypeFilled, nil
 }
@@ -0,0 +1,104 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"github.com/adshao/go-binance"
+	"github.com/lukasz-zimnoch/dexly/trading"
+)
+
+// notionalFilterType is the successor of the MIN_NOTIONAL filter which is
+// not known by the client library yet.
+const notionalFilterType = "NOTIONAL"
+
+func (es *ExchangeService) TradingRules(
+	_ context.Context,
+) (*trading.TradingRules, error) {
+	// Rules are loaded along with the exchange info when the service
+	// is created so there is no need to reach the exchange here.
+	return es.tradingRules, nil
+}
+
+func parseTradingRules(
+	exchangeInfo *binance.ExchangeInfo,
+	symbol string,
+) (*trading.TradingRules, error) {
+	symbolInfo, ok := findSymbolInfo(exchangeInfo, symbol)
+	if !ok {
+		return nil, fmt.Errorf("could not find info for symbol: [%v]", symbol)
+	}
+
+	tradingRules := &trading.TradingRules{}
+
+	if priceFilter := symbolInfo.PriceFilter(); priceFilter != nil {
+		priceTick, err := trading.ParseDecimal(priceFilter.TickSize)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse tick size: [%v]", err)
+		}
+
+		tradingRules.PriceTick = priceTick
+	}
+
+	if lotSizeFilter := symbolInfo.LotSizeFilter(); lotSizeFilter != nil {
+		lotStep, err := trading.ParseDecimal(lotSizeFilter.StepSize)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse step size: [%v]", err)
+		}
+
+		minQuantity, err := trading.ParseDecimal(lotSizeFilter.MinQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse min quantity: [%v]", err)
+		}
+
+		maxQuantity, err := trading.ParseDecimal(lotSizeFilter.MaxQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse max quantity: [%v]", err)
+		}
+
+		tradingRules.LotStep = lotStep
+		tradingRules.MinQuantity = minQuantity
+		tradingRules.MaxQuantity = maxQuantity
+	}
+
+	if minNotional, ok := findMinNotional(symbolInfo); ok {
+		value, err := trading.ParseDecimal(minNotional)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse min notional: [%v]", err)
+		}
+
+		tradingRules.MinNotional = value
+	}
+
+	return tradingRules, nil
+}
+
+func findSymbolInfo(
+	exchangeInfo *binance.ExchangeInfo,
+	symbol string,
+) (*binance.Symbol, bool) {
+	for _, symbolInfo := range exchangeInfo.Symbols {
+		if symbolInfo.Symbol == symbol {
+			return &symbolInfo, true
+		}
+	}
+
+	return nil, false
+}
+
+func findMinNotional(symbolInfo *binance.Symbol) (string, bool) {
+	if filter := symbolInfo.MinNotionalFilter(); filter != nil {
+		return filter.MinNotional, true
+	}
+
+	for _, filter := range symbolInfo.Filters {
+		if filter["filterType"] != notionalFilterType {
+			continue
+		}
+
+		if minNotional, ok := filter["minNotional"].(string); ok {
+			return minNotional, true
+		}
+	}
+
+	return "", false
+}
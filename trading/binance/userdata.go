@@ -0,0 +1,521 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/adshao/go-binance"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
+	"time"
+)
+
+const (
+	// Listen keys expire after 60 minutes unless kept alive.
+	listenKeyKeepaliveInterval = 30 * time.Minute
+	userDataReconnectDelay     = 5 * time.Second
+	userDataMaxReconnectDelay  = 5 * time.Minute
+	// userDataStableTime is the time after which a working stream is
+	// considered recovered and the reconnect delay is reset.
+	userDataStableTime = 10 * time.Minute
+
+	outboundAccountPositionEvent = "outboundAccountPosition"
+	executionReportEvent         = "executionReport"
+	listenKeyExpiredEvent        = "listenKeyExpired"
+
+	// orderStatusRetention is the time for which order statuses are kept.
+	// Statuses of older orders are polled from the exchange.
+	orderStatusRetention     = 24 * time.Hour
+	orderStatusPruneInterval = 1 * time.Hour
+)
+
+// accountState holds the account state pushed by the user data stream.
+// The state can be used only while it's synchronized with the exchange.
+type accountState struct {
+	now func() time.Time
+
+	mutex sync.RWMutex
+	// syncTime is the time of the last synchronization. It's zero if
+	// the state is not synchronized, e.g. because the stream is down.
+	syncTime        time.Time
+	balances        trading.Balances
+	takerCommission trading.Decimal
+	// orderStatuses are indexed by client order IDs.
+	orderStatuses map[string]orderStatusEntry
+	// prunedBefore is the time before which statuses could be pruned.
+	prunedBefore  time.Time
+	lastPruneTime time.Time
+}
+
+type orderStatusEntry struct {
+	status     binance.OrderStatusType
+	updateTime time.Time
+}
+
+func newAccountState(now func() time.Time) *accountState {
+	return &accountState{
+		now:           now,
+		orderStatuses: make(map[string]orderStatusEntry),
+	}
+}
+
+func (as *accountState) synchronize(
+	balances trading.Balances,
+	takerCommission trading.Decimal,
+	syncTime time.Time,
+) {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	as.balances = balances
+	as.takerCommission = takerCommission
+	as.syncTime = syncTime
+}
+
+func (as *accountState) invalidate() {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	as.syncTime = time.Time{}
+}
+
+// accountBalances returns a copy of balances if the state is synchronized.
+func (as *accountState) accountBalances() (trading.Balances, bool) {
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	if as.syncTime.IsZero() {
+		return nil, false
+	}
+
+	balances := make(trading.Balances)
+	for asset, balance := range as.balances {
+		balances[asset] = balance
+	}
+
+	return balances, true
+}
+
+func (as *accountState) accountTakerCommission() (trading.Decimal, bool) {
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	if as.syncTime.IsZero() {
+		return trading.Decimal{}, false
+	}
+
+	return as.takerCommission, true
+}
+
+// updateBalances sets free balances of the given assets. Balances of
+// other assets remain unchanged.
+func (as *accountState) updateBalances(balances trading.Balances) {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	if as.balances == nil {
+		return
+	}
+
+	for asset, balance := range balances {
+		if balance.IsZero() {
+			delete(as.balances, asset)
+			continue
+		}
+
+		as.balances[asset] = balance
+	}
+}
+
+func (as *accountState) updateOrderStatus(
+	clientOrderID string,
+	status binance.OrderStatusType,
+) {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	now := as.now()
+
+	as.orderStatuses[clientOrderID] = orderStatusEntry{
+		status:     status,
+		updateTime: now,
+	}
+
+	if now.Sub(as.lastPruneTime) >= orderStatusPruneInterval {
+		as.pruneOrderStatuses(now)
+	}
+}
+
+// pruneOrderStatuses drops statuses not updated within the retention
+// time. Must be called with the mutex held.
+func (as *accountState) pruneOrderStatuses(now time.Time) {
+	as.prunedBefore = now.Add(-orderStatusRetention)
+	as.lastPruneTime = now
+
+	for clientOrderID, entry := range as.orderStatuses {
+		if entry.updateTime.Before(as.prunedBefore) {
+			delete(as.orderStatuses, clientOrderID)
+		}
+	}
+}
+
+// orderStatus returns the status of the order if it can be determined
+// without asking the exchange. Orders which were created after the state
+// had been synchronized and are not known yet have not been sent to the
+// exchange. In such a case, the returned status is empty. Statuses of
+// orders created before the pruning time could have been pruned.
+func (as *accountState) orderStatus(
+	order *trading.Order,
+) (binance.OrderStatusType, bool) {
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	if entry, exists := as.orderStatuses[order.ID.String()]; exists {
+		return entry.status, true
+	}
+
+	if as.syncTime.IsZero() || !order.Time.After(as.syncTime) ||
+		order.Time.Before(as.prunedBefore) {
+		return "", false
+	}
+
+	return "", true
+}
+
+var (
+	userDataStreamsMutex sync.Mutex
+	// userDataStreams are shared by all exchange services of an account.
+	userDataStreams = make(map[string]*userDataStream)
+)
+
+// accountUserDataStream returns the user data stream of the account
+// identified by the given API key.
+func accountUserDataStream(apiKey string) *userDataStream {
+	userDataStreamsMutex.Lock()
+	defer userDataStreamsMutex.Unlock()
+
+	stream, exists := userDataStreams[apiKey]
+	if !exists {
+		stream = newUserDataStream(time.Now)
+		userDataStreams[apiKey] = stream
+	}
+
+	return stream
+}
+
+// userDataStream pushes balance updates and execution reports of an
+// account to its account state. The stream is connected while it has at
+// least one subscriber.
+type userDataStream struct {
+	accountState *accountState
+
+	mutex       sync.Mutex
+	subscribers map[chan error]struct{}
+	cancel      context.CancelFunc
+	// done is closed once the stream started most recently is stopped.
+	done chan struct{}
+}
+
+func newUserDataStream(now func() time.Time) *userDataStream {
+	done := make(chan struct{})
+	close(done)
+
+	return &userDataStream{
+		accountState: newAccountState(now),
+		subscribers:  make(map[chan error]struct{}),
+		done:         done,
+	}
+}
+
+// subscribe returns a channel receiving errors of the stream until the
+// given context is done. The first subscriber starts the stream using its
+// client and the last one stops it.
+func (uds *userDataStream) subscribe(
+	ctx context.Context,
+	client *binance.Client,
+) <-chan error {
+	errChan := make(chan error, 1)
+
+	uds.mutex.Lock()
+	defer uds.mutex.Unlock()
+
+	if len(uds.subscribers) == 0 {
+		streamCtx, cancelStreamCtx := context.WithCancel(
+			context.Background(),
+		)
+
+		previousDone, done := uds.done, make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			// The state must not be invalidated by the stopped stream
+			// once the new one has synchronized it.
+			<-previousDone
+
+			uds.run(streamCtx, client)
+		}()
+
+		uds.cancel = cancelStreamCtx
+		uds.done = done
+	}
+
+	uds.subscribers[errChan] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		uds.unsubscribe(errChan)
+	}()
+
+	return errChan
+}
+
+func (uds *userDataStream) unsubscribe(errChan chan error) {
+	uds.mutex.Lock()
+	defer uds.mutex.Unlock()
+
+	delete(uds.subscribers, errChan)
+
+	if len(uds.subscribers) == 0 {
+		uds.cancel()
+	}
+}
+
+func (uds *userDataStream) references() int {
+	uds.mutex.Lock()
+	defer uds.mutex.Unlock()
+
+	return len(uds.subscribers)
+}
+
+// report sends the error to all subscribers. The receivers could be busy
+// and the error is informative only so it can be dropped.
+func (uds *userDataStream) report(err error) {
+	uds.mutex.Lock()
+	defer uds.mutex.Unlock()
+
+	for errChan := range uds.subscribers {
+		select {
+		case errChan <- err:
+		default:
+		}
+	}
+}
+
+// AccountUpdates subscribes to the user data stream of the account, which
+// is shared by all workloads of the account. The stream is reconnected
+// once it fails. Until then, the account and order methods poll the
+// exchange.
+func (es *ExchangeService) AccountUpdates(ctx context.Context) <-chan error {
+	return es.userDataStream.subscribe(ctx, es.client)
+}
+
+// run serves the stream until the context is done.
+func (uds *userDataStream) run(ctx context.Context, client *binance.Client) {
+	reconnectDelay := userDataReconnectDelay
+
+	for {
+		startTime := time.Now()
+
+		err := uds.serve(ctx, client)
+
+		uds.accountState.invalidate()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(startTime) > userDataStableTime {
+			reconnectDelay = userDataReconnectDelay
+		}
+
+		uds.report(fmt.Errorf(
+			"user data stream failed; reconnecting in [%v]: [%v]",
+			reconnectDelay,
+			err,
+		))
+
+		select {
+		case <-time.After(reconnectDelay):
+		case <-ctx.Done():
+			return
+		}
+
+		reconnectDelay *= 2
+		if reconnectDelay > userDataMaxReconnectDelay {
+			reconnectDelay = userDataMaxReconnectDelay
+		}
+	}
+}
+
+// serve connects the user data stream, synchronizes the account state
+// and keeps the stream alive. It returns once the stream fails or
+// the context is done.
+func (uds *userDataStream) serve(
+	ctx context.Context,
+	client *binance.Client,
+) error {
+	requestCtx, cancelRequestCtx := context.WithTimeout(ctx, requestTimeout)
+	listenKey, err := client.NewStartUserStreamService().Do(requestCtx)
+	cancelRequestCtx()
+	if err != nil {
+		return fmt.Errorf(
+			"could not create listen key: [%v]",
+			classifyError(err),
+		)
+	}
+
+	streamErrChan := make(chan error, 1)
+	reportStreamErr := func(err error) {
+		select {
+		case streamErrChan <- err:
+		default:
+		}
+	}
+
+	doneChan, stopChan, err := binance.WsUserDataServe(
+		listenKey,
+		func(message []byte) {
+			if err := uds.handleMessage(message); err != nil {
+				reportStreamErr(err)
+			}
+		},
+		reportStreamErr,
+	)
+	if err != nil {
+		return fmt.Errorf("could not connect user data stream: [%v]", err)
+	}
+	defer func() {
+		close(stopChan)
+		<-doneChan
+	}()
+
+	// The snapshot is taken once the stream is connected so no updates
+	// are missed.
+	balances, takerCommission, err := fetchAccount(ctx, client)
+	if err != nil {
+		return fmt.Errorf("could not synchronize account state: [%v]", err)
+	}
+
+	uds.accountState.synchronize(balances, takerCommission, time.Now())
+
+	keepaliveTicker := time.NewTicker(listenKeyKeepaliveInterval)
+	defer keepaliveTicker.Stop()
+
+	for {
+		select {
+		case <-keepaliveTicker.C:
+			requestCtx, cancelRequestCtx := context.WithTimeout(
+				ctx,
+				requestTimeout,
+			)
+			err := client.NewKeepaliveUserStreamService().
+				ListenKey(listenKey).
+				Do(requestCtx)
+			cancelRequestCtx()
+			if err != nil {
+				return fmt.Errorf(
+					"could not keep listen key alive: [%v]",
+					classifyError(err),
+				)
+			}
+		case err := <-streamErrChan:
+			return err
+		case <-doneChan:
+			return fmt.Errorf("user data stream closed")
+		case <-ctx.Done():
+			// The listen key is not closed as it's shared with other
+			// clients of the account.
+			return nil
+		}
+	}
+}
+
+// handleMessage applies the event to the account state. Events of all
+// pairs are applied as the stream is shared by all workloads of
+// the account.
+func (uds *userDataStream) handleMessage(message []byte) error {
+	// Keys of user data events differ only by case so all keys which
+	// collide with the decoded ones must be declared.
+	var header struct {
+		EventType string `json:"e"`
+		EventTime int64  `json:"E"`
+	}
+
+	if err := json.Unmarshal(message, &header); err != nil {
+		return fmt.Errorf("could not unmarshal user data event: [%v]", err)
+	}
+
+	switch header.EventType {
+	case outboundAccountPositionEvent:
+		var event struct {
+			EventType string `json:"e"`
+			EventTime int64  `json:"E"`
+			Balances  []struct {
+				Asset  string `json:"a"`
+				Free   string `json:"f"`
+				Locked string `json:"l"`
+			} `json:"B"`
+		}
+
+		if err := json.Unmarshal(message, &event); err != nil {
+			return fmt.Errorf(
+				"could not unmarshal account position event: [%v]",
+				err,
+			)
+		}
+
+		balances := make(trading.Balances)
+
+		for _, balance := range event.Balances {
+			amount, err := trading.ParseDecimal(balance.Free)
+			if err != nil {
+				return fmt.Errorf(
+					"could not parse balance for asset [%v]: [%v]",
+					balance.Asset,
+					err,
+				)
+			}
+
+			balances[trading.Asset(balance.Asset)] = amount
+		}
+
+		uds.accountState.updateBalances(balances)
+	case executionReportEvent:
+		var event struct {
+			EventType string `json:"e"`
+			EventTime int64  `json:"E"`
+			Symbol    string `json:"s"`
+			Side      string `json:"S"`
+			// ClientOrderID of cancelled orders is the ID of the cancel
+			// request and the original ID is sent separately.
+			ClientOrderID     string `json:"c"`
+			OrigClientOrderID string `json:"C"`
+			ExecutionType     string `json:"x"`
+			OrderStatus       string `json:"X"`
+		}
+
+		if err := json.Unmarshal(message, &event); err != nil {
+			return fmt.Errorf(
+				"could not unmarshal execution report event: [%v]",
+				err,
+			)
+		}
+
+		clientOrderID := event.ClientOrderID
+		if len(event.OrigClientOrderID) > 0 {
+			clientOrderID = event.OrigClientOrderID
+		}
+
+		uds.accountState.updateOrderStatus(
+			clientOrderID,
+			binance.OrderStatusType(event.OrderStatus),
+		)
+	case listenKeyExpiredEvent:
+		return fmt.Errorf("listen key expired")
+	}
+
+	// Other events, like balanceUpdate sent on deposits, are followed by
+	// the account position event holding the actual balances.
+	return nil
+}
@@ -0,0 +1,278 @@
+package binance
+
+import (
+	"context"
+	"github.com/adshao/go-binance"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testID string
+
+func (ti testID) String() string {
+	return string(ti)
+}
+
+func TestUserDataStream_HandleMessage(t *testing.T) {
+	syncTime := time.Unix(1600000000, 0)
+
+	stream := newUserDataStream(func() time.Time {
+		return syncTime.Add(time.Second)
+	})
+
+	stream.accountState.synchronize(
+		trading.Balances{
+			"BTC":  trading.MustParseDecimal("1"),
+			"USDT": trading.MustParseDecimal("1000"),
+		},
+		trading.MustParseDecimal("0.001"),
+		syncTime,
+	)
+
+	messages := []string{
+		`{"e":"outboundAccountPosition","E":1600000001000,"u":1600000001000,` +
+			`"B":[{"a":"BTC","f":"0.00000000","l":"0.00000000"},` +
+			`{"a":"USDT","f":"1500.50000000","l":"0.00000000"}]}`,
+		`{"e":"executionReport","E":1600000001000,"s":"BTCUSDT",` +
+			`"c":"order-1","S":"SELL","o":"LIMIT","f":"FOK",` +
+			`"x":"TRADE","X":"FILLED","C":""}`,
+		`{"e":"executionReport","E":1600000001000,"s":"BTCUSDT",` +
+			`"c":"cancel-1","S":"BUY","o":"LIMIT","f":"GTC",` +
+			`"x":"CANCELED","X":"CANCELED","C":"order-2"}`,
+		`{"e":"executionReport","E":1600000001000,"s":"ETHUSDT",` +
+			`"c":"order-3","S":"BUY","o":"LIMIT","f":"FOK",` +
+			`"x":"TRADE","X":"FILLED","C":""}`,
+		`{"e":"balanceUpdate","E":1600000001000,"a":"BTC","d":"1.0",` +
+			`"T":1600000001000}`,
+	}
+
+	for _, message := range messages {
+		if err := stream.handleMessage(
+			[]byte(message),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balances, ok := stream.accountState.accountBalances()
+	if !ok {
+		t.Fatal("account state is not synchronized")
+	}
+
+	if len(balances) != 1 ||
+		!balances.BalanceOf("USDT").Equal(trading.MustParseDecimal("1500.5")) {
+		t.Errorf("unexpected balances: [%v]", balances)
+	}
+
+	orders := map[string]struct {
+		orderTime      time.Time
+		expectedStatus binance.OrderStatusType
+		expectedKnown  bool
+	}{
+		"order-1": {
+			orderTime:      syncTime.Add(-time.Minute),
+			expectedStatus: binance.OrderStatusTypeFilled,
+			expectedKnown:  true,
+		},
+		"order-2": {
+			orderTime:      syncTime.Add(-time.Minute),
+			expectedStatus: binance.OrderStatusTypeCanceled,
+			expectedKnown:  true,
+		},
+		// The stream is shared by workloads of all pairs.
+		"order-3": {
+			orderTime:      syncTime.Add(-time.Minute),
+			expectedStatus: binance.OrderStatusTypeFilled,
+			expectedKnown:  true,
+		},
+		// Orders created after the synchronization and not reported yet
+		// have not been sent.
+		"order-4": {
+			orderTime:      syncTime.Add(time.Minute),
+			expectedStatus: "",
+			expectedKnown:  true,
+		},
+	}
+
+	for orderID, test := range orders {
+		status, known := stream.accountState.orderStatus(
+			&trading.Order{ID: testID(orderID), Time: test.orderTime},
+		)
+
+		if known != test.expectedKnown || status != test.expectedStatus {
+			t.Errorf(
+				"unexpected status of order [%v]\n"+
+					"expected: [%v %v]\n"+
+					"actual:   [%v %v]",
+				orderID,
+				test.expectedStatus,
+				test.expectedKnown,
+				status,
+				known,
+			)
+		}
+	}
+
+	stream.accountState.invalidate()
+
+	if _, ok := stream.accountState.accountBalances(); ok {
+		t.Errorf("invalidated account state is still synchronized")
+	}
+}
+
+func TestAccountState_PruneOrderStatuses(t *testing.T) {
+	syncTime := time.Unix(1600000000, 0)
+	now := syncTime
+
+	accountState := newAccountState(func() time.Time {
+		return now
+	})
+	accountState.synchronize(
+		trading.Balances{},
+		trading.MustParseDecimal("0.001"),
+		syncTime,
+	)
+
+	now = syncTime.Add(time.Minute)
+	accountState.updateOrderStatus("order-1", binance.OrderStatusTypeFilled)
+
+	now = now.Add(orderStatusRetention + orderStatusPruneInterval)
+	accountState.updateOrderStatus("order-2", binance.OrderStatusTypeFilled)
+
+	if len(accountState.orderStatuses) != 1 {
+		t.Errorf(
+			"unexpected order statuses count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			1,
+			len(accountState.orderStatuses),
+		)
+	}
+
+	orders := map[string]struct {
+		orderTime      time.Time
+		expectedStatus binance.OrderStatusType
+		expectedKnown  bool
+	}{
+		// The status could have been pruned so it must be polled.
+		"order-1": {
+			orderTime:     syncTime.Add(time.Minute),
+			expectedKnown: false,
+		},
+		"order-2": {
+			orderTime:      now,
+			expectedStatus: binance.OrderStatusTypeFilled,
+			expectedKnown:  true,
+		},
+		// Orders created after the pruning time and not reported yet
+		// have not been sent.
+		"order-3": {
+			orderTime:      now,
+			expectedStatus: "",
+			expectedKnown:  true,
+		},
+	}
+
+	for orderID, test := range orders {
+		status, known := accountState.orderStatus(
+			&trading.Order{ID: testID(orderID), Time: test.orderTime},
+		)
+
+		if known != test.expectedKnown || status != test.expectedStatus {
+			t.Errorf(
+				"unexpected status of order [%v]\n"+
+					"expected: [%v %v]\n"+
+					"actual:   [%v %v]",
+				orderID,
+				test.expectedStatus,
+				test.expectedKnown,
+				status,
+				known,
+			)
+		}
+	}
+}
+
+func TestUserDataStream_Subscribe(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(
+		func(responseWriter http.ResponseWriter, _ *http.Request) {
+			responseWriter.WriteHeader(http.StatusServiceUnavailable)
+		},
+	))
+	defer apiServer.Close()
+
+	client := binance.NewClient("api-key", "secret-key")
+	client.BaseURL = apiServer.URL
+
+	stream := accountUserDataStream("stream-api-key")
+
+	if otherStream := accountUserDataStream(
+		"stream-api-key",
+	); otherStream != stream {
+		t.Errorf("user data stream is not shared by the account")
+	}
+
+	if otherStream := accountUserDataStream(
+		"other-api-key",
+	); otherStream == stream {
+		t.Errorf("user data stream is shared by different accounts")
+	}
+
+	firstCtx, cancelFirstCtx := context.WithCancel(context.Background())
+	defer cancelFirstCtx()
+
+	secondCtx, cancelSecondCtx := context.WithCancel(context.Background())
+	defer cancelSecondCtx()
+
+	firstErrChan := stream.subscribe(firstCtx, client)
+	stream.subscribe(secondCtx, client)
+
+	stream.mutex.Lock()
+	done := stream.done
+	stream.mutex.Unlock()
+
+	select {
+	case <-firstErrChan:
+	case <-time.After(time.Second):
+		t.Fatal("stream failure has not been reported")
+	}
+
+	cancelFirstCtx()
+	waitForReferences(t, stream, 1)
+
+	select {
+	case <-done:
+		t.Fatal("stream stopped while still subscribed")
+	default:
+	}
+
+	cancelSecondCtx()
+	waitForReferences(t, stream, 0)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream not stopped after the last unsubscription")
+	}
+}
+
+func waitForReferences(t *testing.T, stream *userDataStream, expected int) {
+	deadline := time.Now().Add(time.Second)
+
+	for stream.references() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf(
+				"unexpected references count\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				expected,
+				stream.references(),
+			)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
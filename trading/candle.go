@@ -1,24 +1,102 @@
 package trading
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
 
-// For the time being, we always use the 1m interval and a 12h window size.
+// Workloads use the 1m interval and a 12h window size unless configured
+// otherwise.
 const (
-	CandleInterval   = "1m"
-	CandleWindowSize = 720
+	DefaultCandleInterval   = CandleInterval1m
+	DefaultCandleWindowSize = 720
 )
 
+// Only intervals supported by all exchanges are available.
+type CandleInterval int
+
+const (
+	CandleInterval1m CandleInterval = iota
+	CandleInterval5m
+	CandleInterval15m
+	CandleInterval30m
+	CandleInterval1h
+	CandleInterval4h
+	CandleInterval1d
+)
+
+func ParseCandleInterval(value string) (CandleInterval, error) {
+	switch value {
+	case "1m":
+		return CandleInterval1m, nil
+	case "5m":
+		return CandleInterval5m, nil
+	case "15m":
+		return CandleInterval15m, nil
+	case "30m":
+		return CandleInterval30m, nil
+	case "1h":
+		return CandleInterval1h, nil
+	case "4h":
+		return CandleInterval4h, nil
+	case "1d":
+		return CandleInterval1d, nil
+	}
+
+	return -1, fmt.Errorf("unknown candle interval: [%v]", value)
+}
+
+func (ci CandleInterval) String() string {
+	switch ci {
+	case CandleInterval1m:
+		return "1m"
+	case CandleInterval5m:
+		return "5m"
+	case CandleInterval15m:
+		return "15m"
+	case CandleInterval30m:
+		return "30m"
+	case CandleInterval1h:
+		return "1h"
+	case CandleInterval4h:
+		return "4h"
+	case CandleInterval1d:
+		return "1d"
+	default:
+		panic("unknown candle interval")
+	}
+}
+
+func (ci CandleInterval) Duration() time.Duration {
+	switch ci {
+	case CandleInterval1m:
+		return 1 * time.Minute
+	case CandleInterval5m:
+		return 5 * time.Minute
+	case CandleInterval15m:
+		return 15 * time.Minute
+	case CandleInterval30m:
+		return 30 * time.Minute
+	case CandleInterval1h:
+		return 1 * time.Hour
+	case CandleInterval4h:
+		return 4 * time.Hour
+	case CandleInterval1d:
+		return 24 * time.Hour
+	default:
+		panic("unknown candle interval")
+	}
+}
+
 type Candle struct {
 	OpenTime   time.Time
 	CloseTime  time.Time
-	OpenPrice  string
-	ClosePrice string
-	MaxPrice   string
-	MinPrice   string
-	Volume     string
+	OpenPrice  Decimal
+	ClosePrice Decimal
+	MaxPrice   Decimal
+	MinPrice   Decimal
+	Volume     Decimal
 	TradeCount uint
 }
 
@@ -35,19 +113,176 @@ func (c *Candle) String() string {
 	)
 }
 
+// CandleTick holds no candle if it's a heartbeat sent by exchanges which
+// push candles only once a trade happens.
 type CandleTick struct {
 	*Candle
 	TickTime time.Time
 }
 
+func (ct *CandleTick) IsHeartbeat() bool {
+	return ct.Candle == nil
+}
+
 func (ct *CandleTick) String() string {
+	if ct.IsHeartbeat() {
+		return fmt.Sprintf("heartbeat at [%v]", ct.TickTime)
+	}
+
 	return ct.Candle.String()
 }
 
+// CandlesPageFunc may return only the beginning of the range as exchanges
+// limit the number of candles returned at once.
+type CandlesPageFunc func(
+	ctx context.Context,
+	start, end time.Time,
+) ([]*Candle, error)
+
+// StreamCandles closes the candle channel once the range is exhausted or
+// fetching fails, after sending the error. Candles out of order within
+// a page are an error while gaps, e.g. exchange maintenance windows, are
+// passed through. The context must be cancelled if the receiver stops
+// reading before the candle channel gets closed.
+func StreamCandles(
+	ctx context.Context,
+	interval CandleInterval,
+	start, end time.Time,
+	fetchPage CandlesPageFunc,
+) (<-chan *Candle, <-chan error) {
+	candleChannel := make(chan *Candle)
+	errorChannel := make(chan error, 1)
+
+	go func() {
+		defer close(errorChannel)
+		defer close(candleChannel)
+
+		err := streamCandles(
+			ctx,
+			interval,
+			start,
+			end,
+			fetchPage,
+			candleChannel,
+		)
+		if err != nil {
+			errorChannel <- err
+		}
+	}()
+
+	return candleChannel, errorChannel
+}
+
+func streamCandles(
+	ctx context.Context,
+	interval CandleInterval,
+	start, end time.Time,
+	fetchPage CandlesPageFunc,
+	candleChannel chan<- *Candle,
+) error {
+	var lastCandle *Candle
+
+	for pageStart := start; !pageStart.After(end); {
+		page, err := fetchPage(ctx, pageStart, end)
+		if err != nil {
+			return fmt.Errorf(
+				"could not fetch candles since [%v]: [%v]",
+				pageStart,
+				err,
+			)
+		}
+
+		sent := 0
+
+		for _, candle := range page {
+			if lastCandle != nil &&
+				!candle.OpenTime.After(lastCandle.OpenTime) {
+				// Candles repeated at page boundaries have been sent
+				// with the former page.
+				if sent == 0 {
+					continue
+				}
+
+				return fmt.Errorf(
+					"candle opened at [%v] is not after the one "+
+						"opened at [%v]",
+					candle.OpenTime,
+					lastCandle.OpenTime,
+				)
+			}
+
+			select {
+			case candleChannel <- candle:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			lastCandle = candle
+			sent++
+		}
+
+		// A page which doesn't bring anything new means there are no
+		// more candles within the range.
+		if sent == 0 {
+			return nil
+		}
+
+		pageStart = lastCandle.OpenTime.Add(interval.Duration())
+	}
+
+	return nil
+}
+
+// CollectCandles should be used only for ranges small enough to be kept
+// in memory.
+func CollectCandles(
+	candleChannel <-chan *Candle,
+	errorChannel <-chan error,
+) ([]*Candle, error) {
+	candles := make([]*Candle, 0)
+
+	for candle := range candleChannel {
+		candles = append(candles, candle)
+	}
+
+	if err := <-errorChannel; err != nil {
+		return nil, err
+	}
+
+	return candles, nil
+}
+
+type CandleKey struct {
+	Exchange string
+	Pair     Pair
+	Interval CandleInterval
+}
+
+func (ck CandleKey) String() string {
+	return fmt.Sprintf("%v:%v:%v", ck.Exchange, ck.Pair.Symbol(), ck.Interval)
+}
+
 type CandleRepository interface {
-	SaveCandles(key string, candles ...*Candle)
+	SaveCandles(key CandleKey, candles ...*Candle) error
+
+	Candles(key CandleKey) ([]*Candle, error)
+
+	// DeleteCandles doesn't affect candles kept by a durable store.
+	DeleteCandles(key CandleKey) error
+}
+
+type CandleRepositoryFactory func(windowSize int) CandleRepository
+
+// CandleStore backs candle repositories which keep only windows.
+type CandleStore interface {
+	SaveCandles(key CandleKey, candles ...*Candle) error
+
+	CandlesRange(key CandleKey, start, end time.Time) ([]*Candle, error)
 
-	Candles(key string) []*Candle
+	LatestCandles(key CandleKey, count int) ([]*Candle, error)
 
-	DeleteCandles(key string)
+	DeleteCandlesBefore(
+		interval CandleInterval,
+		before time.Time,
+	) (int64, error)
 }
@@ -0,0 +1,224 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStreamCandles(t *testing.T) {
+	interval := CandleInterval5m
+	start := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	newCandles := func(indexes ...int) []*Candle {
+		candles := make([]*Candle, len(indexes))
+		for i, index := range indexes {
+			openTime := start.Add(time.Duration(index) * interval.Duration())
+
+			candles[i] = &Candle{
+				OpenTime:  openTime,
+				CloseTime: openTime.Add(interval.Duration() - time.Millisecond),
+			}
+		}
+
+		return candles
+	}
+
+	tests := map[string]struct {
+		pages              [][]*Candle
+		expectedCandles    []*Candle
+		expectedPagesCount int
+		expectedErr        bool
+	}{
+		"single page": {
+			pages:              [][]*Candle{newCandles(0, 1, 2)},
+			expectedCandles:    newCandles(0, 1, 2),
+			expectedPagesCount: 2,
+		},
+		"multiple pages": {
+			pages: [][]*Candle{
+				newCandles(0, 1),
+				newCandles(2, 3),
+				newCandles(4),
+			},
+			expectedCandles:    newCandles(0, 1, 2, 3, 4),
+			expectedPagesCount: 4,
+		},
+		"candles repeated at page boundaries": {
+			pages: [][]*Candle{
+				newCandles(0, 1, 2),
+				newCandles(2, 3),
+				newCandles(3),
+			},
+			expectedCandles:    newCandles(0, 1, 2, 3),
+			expectedPagesCount: 3,
+		},
+		"gap within page": {
+			pages:              [][]*Candle{newCandles(0, 1, 3)},
+			expectedCandles:    newCandles(0, 1, 3),
+			expectedPagesCount: 2,
+		},
+		"gap between pages": {
+			pages: [][]*Candle{
+				newCandles(0, 1),
+				newCandles(3, 4),
+			},
+			expectedCandles:    newCandles(0, 1, 3, 4),
+			expectedPagesCount: 3,
+		},
+		"candles out of order within page": {
+			pages:           [][]*Candle{newCandles(0, 2, 1)},
+			expectedCandles: newCandles(0, 2),
+			expectedErr:     true,
+		},
+		"candle repeated within page": {
+			pages: [][]*Candle{
+				newCandles(0, 1),
+				newCandles(1, 2, 2),
+			},
+			expectedCandles: newCandles(0, 1, 2),
+			expectedErr:     true,
+		},
+		"empty range": {
+			pages:              [][]*Candle{},
+			expectedCandles:    newCandles(),
+			expectedPagesCount: 1,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			pagesCount := 0
+
+			candleChannel, errorChannel := StreamCandles(
+				context.Background(),
+				interval,
+				start,
+				start.Add(time.Hour),
+				func(_ context.Context, _, _ time.Time) ([]*Candle, error) {
+					pagesCount++
+
+					if pagesCount > len(test.pages) {
+						return []*Candle{}, nil
+					}
+
+					return test.pages[pagesCount-1], nil
+				},
+			)
+
+			candles := make([]*Candle, 0)
+			for candle := range candleChannel {
+				candles = append(candles, candle)
+			}
+
+			err := <-errorChannel
+
+			if test.expectedErr != (err != nil) {
+				t.Errorf(
+					"unexpected error\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			assertCandleTimes(t, test.expectedCandles, candles)
+
+			if !test.expectedErr && pagesCount != test.expectedPagesCount {
+				t.Errorf(
+					"unexpected pages count\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedPagesCount,
+					pagesCount,
+				)
+			}
+		})
+	}
+}
+
+func TestStreamCandles_PageError(t *testing.T) {
+	start := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	candles, err := CollectCandles(
+		StreamCandles(
+			context.Background(),
+			CandleInterval1m,
+			start,
+			start.Add(time.Hour),
+			func(_ context.Context, _, _ time.Time) ([]*Candle, error) {
+				return nil, fmt.Errorf("unavailable")
+			},
+		),
+	)
+
+	if err == nil {
+		t.Errorf("page error should be returned")
+	}
+
+	if candles != nil {
+		t.Errorf("candles should not be returned: [%v]", candles)
+	}
+}
+
+func TestStreamCandles_ContextDone(t *testing.T) {
+	start := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+
+	candleChannel, errorChannel := StreamCandles(
+		ctx,
+		CandleInterval1m,
+		start,
+		start.Add(time.Hour),
+		func(_ context.Context, pageStart, _ time.Time) ([]*Candle, error) {
+			return []*Candle{{OpenTime: pageStart}}, nil
+		},
+	)
+
+	<-candleChannel
+	cancelCtx()
+
+	// The stream must stop without the receiver reading further.
+	select {
+	case err := <-errorChannel:
+		if err != context.Canceled {
+			t.Errorf(
+				"unexpected error\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				context.Canceled,
+				err,
+			)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("stream has not been stopped")
+	}
+}
+
+func assertCandleTimes(t *testing.T, expected, actual []*Candle) {
+	if len(expected) != len(actual) {
+		t.Fatalf(
+			"unexpected candles count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			len(expected),
+			len(actual),
+		)
+	}
+
+	for index := range expected {
+		if !expected[index].Equal(actual[index]) {
+			t.Errorf(
+				"unexpected candle [%v]\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				index,
+				expected[index].OpenTime,
+				actual[index].OpenTime,
+			)
+		}
+	}
+}
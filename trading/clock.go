@@ -0,0 +1,43 @@
+package trading
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of the current time used by the trading logic.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a clock backed by the wall clock.
+type SystemClock struct{}
+
+func (sc *SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// VirtualClock is a clock whose time is set explicitly. It is used to drive
+// the trading logic through historical data, e.g. during backtests.
+type VirtualClock struct {
+	mutex sync.RWMutex
+	now   time.Time
+}
+
+func NewVirtualClock(now time.Time) *VirtualClock {
+	return &VirtualClock{now: now}
+}
+
+func (vc *VirtualClock) Now() time.Time {
+	vc.mutex.RLock()
+	defer vc.mutex.RUnlock()
+
+	return vc.now
+}
+
+func (vc *VirtualClock) Set(now time.Time) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	vc.now = now
+}
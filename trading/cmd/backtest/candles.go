@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// readCandles reads candles from a CSV file using the Binance klines
+// layout: open time, open, high, low, close, volume, close time,
+// quote asset volume, number of trades. Times are in milliseconds and
+// any further columns are ignored. A header row is skipped if present.
+func readCandles(path string) ([]*trading.Candle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	candles := make([]*trading.Candle, 0)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read line [%v]: [%v]", line, err)
+		}
+
+		if line == 1 {
+			if _, err := strconv.ParseInt(record[0], 10, 64); err != nil {
+				continue // header row
+			}
+		}
+
+		candle, err := parseCandleRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse line [%v]: [%v]",
+				line,
+				err,
+			)
+		}
+
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+func parseCandleRecord(record []string) (*trading.Candle, error) {
+	if len(record) < 9 {
+		return nil, fmt.Errorf("expected at least 9 columns")
+	}
+
+	openTime, err := strconv.ParseInt(record[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse open time: [%v]", err)
+	}
+
+	closeTime, err := strconv.ParseInt(record[6], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse close time: [%v]", err)
+	}
+
+	tradeCount, err := strconv.ParseUint(record[8], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse trade count: [%v]", err)
+	}
+
+	// Open, high, low, close and volume columns.
+	values := make([]trading.Decimal, 5)
+	for index := range values {
+		value, err := trading.ParseDecimal(record[index+1])
+		if err != nil {
+			return nil, err
+		}
+
+		values[index] = value
+	}
+
+	return &trading.Candle{
+		OpenTime:   parseMilliseconds(openTime),
+		CloseTime:  parseMilliseconds(closeTime),
+		OpenPrice:  values[0],
+		ClosePrice: values[3],
+		MaxPrice:   values[1],
+		MinPrice:   values[2],
+		Volume:     values[4],
+		TradeCount: uint(tradeCount),
+	}, nil
+}
+
+func parseMilliseconds(milliseconds int64) time.Time {
+	return time.Unix(0, milliseconds*int64(time.Millisecond))
+}
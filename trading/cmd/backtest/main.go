@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/inmem"
+	"github.com/lukasz-zimnoch/dexly/trading/logrus"
+	"github.com/lukasz-zimnoch/dexly/trading/simulation"
+	"github.com/lukasz-zimnoch/dexly/trading/strategy"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	candlesPath := flag.String(
+		"candles",
+		"",
+		"path to the CSV file with historical candles",
+	)
+	baseAsset := flag.String("base", "BTC", "base asset of the pair")
+	quoteAsset := flag.String("quote", "USDT", "quote asset of the pair")
+	candleInterval := flag.String(
+		"interval",
+		trading.DefaultCandleInterval.String(),
+		"interval of the historical candles",
+	)
+	candleWindowSize := flag.Int(
+		"window",
+		trading.DefaultCandleWindowSize,
+		"number of candles the signal generator works on",
+	)
+	strategiesDir := flag.String(
+		"strategies",
+		"",
+		"directory with strategy specs, only built-in strategies if empty",
+	)
+	strategyName := flag.String(
+		"strategy",
+		trading.DefaultStrategy.Name,
+		"name of the tested strategy",
+	)
+	strategyVersion := flag.Int(
+		"strategy-version",
+		trading.DefaultStrategy.Version,
+		"version of the tested strategy",
+	)
+	stopLossATRMultiplier := flag.String(
+		"stop-loss-atr",
+		trading.DefaultStopLossATRMultiplier.String(),
+		"number of ATRs between the entry and the stop loss",
+	)
+	rewardRiskRatio := flag.String(
+		"reward-risk",
+		trading.DefaultRewardRiskRatio.String(),
+		"ratio of the take profit distance to the stop loss distance",
+	)
+	initialBalance := flag.String(
+		"balance",
+		"1000",
+		"initial balance of the quote asset",
+	)
+	takerCommission := flag.String("commission", "0.001", "taker commission")
+	riskFactor := flag.String("risk", "0.01", "account risk factor")
+	openPositionsLimit := flag.Int("positions", 1, "open positions limit")
+	shortSellingAllowed := flag.Bool(
+		"shorts",
+		false,
+		"allow short selling, i.e. opening SHORT positions",
+	)
+	priceTick := flag.String("price-tick", "0.01", "price tick of the pair")
+	lotStep := flag.String("lot-step", "0.00001", "lot step of the pair")
+	minQuantity := flag.String("min-quantity", "0", "minimum order quantity")
+	maxQuantity := flag.String(
+		"max-quantity",
+		"0",
+		"maximum order quantity, zero means no limit",
+	)
+	minNotional := flag.String("min-notional", "10", "minimum order value")
+	logLevel := flag.String("log-level", "warning", "log level")
+	flag.Parse()
+
+	logger := logrus.ConfigureStandardLogger("text", *logLevel)
+
+	if len(*candlesPath) == 0 {
+		logger.Fatalf("path to the candles file must be provided")
+	}
+
+	candles, err := readCandles(*candlesPath)
+	if err != nil {
+		logger.Fatalf("could not read candles: [%v]", err)
+	}
+
+	interval, err := trading.ParseCandleInterval(*candleInterval)
+	if err != nil {
+		logger.Fatalf("could not parse candle interval: [%v]", err)
+	}
+
+	if *candleWindowSize <= 0 {
+		logger.Fatalf("candle window size must be positive")
+	}
+
+	strategyRegistry := strategy.NewRegistry(logger)
+	if len(*strategiesDir) > 0 {
+		if err := strategyRegistry.LoadDir(*strategiesDir); err != nil {
+			logger.Fatalf("could not load strategies: [%v]", err)
+		}
+	}
+
+	strategyRef := trading.StrategyRef{
+		Name:    *strategyName,
+		Version: *strategyVersion,
+	}
+
+	signalGenerator, err := strategyRegistry.SignalGenerator(strategyRef)
+	if err != nil {
+		logger.Fatalf("could not get strategy [%v]: [%v]", strategyRef, err)
+	}
+
+	idService := &uuid.IDService{}
+
+	workload := &trading.Workload{
+		ID: idService.NewID(),
+		Account: &trading.Account{
+			ID:                 idService.NewID(),
+			Exchange:           "BACKTEST",
+			RiskFactor:         parseDecimal(logger, "risk", *riskFactor),
+			OpenPositionsLimit: *openPositionsLimit,
+		},
+		Pair: trading.Pair{
+			Base:  trading.Asset(*baseAsset),
+			Quote: trading.Asset(*quoteAsset),
+		},
+		CandleInterval:   interval,
+		CandleWindowSize: *candleWindowSize,
+		StopLossATRMultiplier: parseDecimal(
+			logger,
+			"stop-loss-atr",
+			*stopLossATRMultiplier,
+		),
+		RewardRiskRatio: parseDecimal(
+			logger,
+			"reward-risk",
+			*rewardRiskRatio,
+		),
+		Strategy: strategyRef,
+	}
+
+	if err := trading.ValidateATRTargets(
+		workload.StopLossATRMultiplier,
+		workload.RewardRiskRatio,
+	); err != nil {
+		logger.Fatalf("invalid signal targets: [%v]", err)
+	}
+
+	clock := trading.NewVirtualClock(time.Time{})
+
+	wallet := simulation.NewWallet(
+		trading.Balances{
+			workload.Pair.Quote: parseDecimal(logger, "balance", *initialBalance),
+		},
+		parseDecimal(logger, "commission", *takerCommission),
+	)
+	wallet.SetShortSellingAllowed(*shortSellingAllowed)
+
+	tradingRules := &trading.TradingRules{
+		PriceTick:   parseDecimal(logger, "price-tick", *priceTick),
+		LotStep:     parseDecimal(logger, "lot-step", *lotStep),
+		MinQuantity: parseDecimal(logger, "min-quantity", *minQuantity),
+		MaxQuantity: parseDecimal(logger, "max-quantity", *maxQuantity),
+		MinNotional: parseDecimal(logger, "min-notional", *minNotional),
+	}
+
+	exchangeService := simulation.NewExchangeService(
+		workload,
+		simulation.NewHistoricalCandleService(candles, clock),
+		tradingRules,
+		wallet,
+	)
+
+	orderRepository := inmem.NewOrderRepository()
+
+	report, err := trading.RunBacktest(
+		context.Background(),
+		workload,
+		candles,
+		clock,
+		idService,
+		exchangeService,
+		inmem.NewCandleRepository(workload.CandleWindowSize),
+		signalGenerator,
+		inmem.NewPositionRepository(orderRepository),
+		orderRepository,
+		logger,
+	)
+	if err != nil {
+		logger.Fatalf("could not run backtest: [%v]", err)
+	}
+
+	printReport(report)
+}
+
+func parseDecimal(
+	logger trading.Logger,
+	name, value string,
+) trading.Decimal {
+	result, err := trading.ParseDecimal(value)
+	if err != nil {
+		logger.Fatalf("could not parse [%v] value: [%v]", name, err)
+	}
+
+	return result
+}
+
+func printReport(report *trading.BacktestReport) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(
+		writer,
+		"TYPE\tENTRY TIME\tENTRY PRICE\tEXIT TIME\tEXIT PRICE\tSIZE\tPROFIT",
+	)
+
+	for _, trade := range report.Trades {
+		_, _ = fmt.Fprintf(
+			writer,
+			"%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			trade.Position.Type,
+			trade.EntryOrder.Time.Format(time.RFC3339),
+			trade.EntryOrder.Price.StringFixed(4),
+			trade.ExitOrder.Time.Format(time.RFC3339),
+			trade.ExitOrder.Price.StringFixed(4),
+			trade.EntryOrder.Size.StringFixed(4),
+			trade.NetProfit(report.TakerCommission).StringFixed(4),
+		)
+	}
+
+	_ = writer.Flush()
+
+	_, _ = fmt.Fprintf(
+		os.Stdout,
+		"\n"+
+			"Period:          %v - %v\n"+
+			"Candles:         %v\n"+
+			"Trades:          %v (%v winning, %v losing)\n"+
+			"Win rate:        %.2f%%\n"+
+			"Open positions:  %v\n"+
+			"Gross profit:    %v\n"+
+			"Gross loss:      %v\n"+
+			"Max drawdown:    %v\n"+
+			"Initial balance: %v\n"+
+			"Final balance:   %v\n"+
+			"Net profit:      %v\n",
+		report.Start.Format(time.RFC3339),
+		report.End.Format(time.RFC3339),
+		report.CandlesCount,
+		len(report.Trades),
+		report.WinningTrades,
+		report.LosingTrades,
+		report.WinRate()*100,
+		report.OpenPositionsCount,
+		report.GrossProfit.StringFixed(4),
+		report.GrossLoss.StringFixed(4),
+		report.MaxDrawdown.StringFixed(4),
+		report.InitialBalance.StringFixed(4),
+		report.FinalBalance.StringFixed(4),
+		report.NetProfit().StringFixed(4),
+	)
+}
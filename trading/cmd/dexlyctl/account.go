@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/postgres"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func addAccount(ctx context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("account add", flag.ExitOnError)
+	email := flagSet.String("email", "", "account email")
+	exchange := flagSet.String(
+		"exchange",
+		"BINANCE",
+		"exchange name, BINANCE, KRAKEN or PAPER",
+	)
+	apiKey := flagSet.String("api-key", "", "exchange API key")
+	secretKey := flagSet.String(
+		"secret-key",
+		"",
+		"exchange secret key, read from standard input if not set",
+	)
+	riskFactor := flagSet.String("risk", "0.01", "account risk factor")
+	openPositionsLimit := flagSet.Int("positions", 1, "open positions limit")
+	_ = flagSet.Parse(args)
+
+	if !strings.Contains(*email, "@") {
+		return fmt.Errorf("invalid -email value [%v]", *email)
+	}
+
+	risk, err := trading.ParseDecimal(*riskFactor)
+	if err != nil {
+		return fmt.Errorf("invalid -risk value: [%v]", err)
+	}
+
+	if risk.Sign() <= 0 || risk.Cmp(trading.NewDecimal(1, 0)) > 0 {
+		return fmt.Errorf("risk factor [%v] must be within (0, 1]", risk)
+	}
+
+	if *openPositionsLimit <= 0 {
+		return fmt.Errorf("open positions limit must be positive")
+	}
+
+	// Passing secrets as flags leaves them in the shell history.
+	if len(*secretKey) == 0 && len(*apiKey) > 0 {
+		*secretKey, err = readSecret("exchange secret key: ")
+		if err != nil {
+			return fmt.Errorf("could not read secret key: [%v]", err)
+		}
+	}
+
+	secretCipher, err := newSecretCipher(config)
+	if err != nil {
+		return err
+	}
+
+	client, err := connectPostgres(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	idService := &uuid.IDService{}
+
+	account := &trading.Account{
+		ID:                 idService.NewID(),
+		Email:              *email,
+		Exchange:           *exchange,
+		ExchangeApiKey:     *apiKey,
+		ExchangeSecretKey:  *secretKey,
+		RiskFactor:         risk,
+		OpenPositionsLimit: *openPositionsLimit,
+	}
+
+	accountRepository := postgres.NewAccountRepository(
+		client,
+		idService,
+		secretCipher,
+	)
+
+	if err := accountRepository.CreateAccount(account); err != nil {
+		return err
+	}
+
+	fmt.Println(account.ID)
+
+	return nil
+}
+
+func listAccounts(ctx context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("account list", flag.ExitOnError)
+	output := outputFlag(flagSet)
+	_ = flagSet.Parse(args)
+
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	secretCipher, err := newSecretCipher(config)
+	if err != nil {
+		return err
+	}
+
+	client, err := connectPostgres(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := postgres.NewAccountRepository(
+		client,
+		&uuid.IDService{},
+		secretCipher,
+	).Accounts()
+	if err != nil {
+		return err
+	}
+
+	return newAccountsTable(accounts).print(*output)
+}
+
+// rotateAccountKeys re-encrypts credentials of all accounts using the
+// primary master key. Once it's done, old master keys can be removed
+// from the configuration. It also encrypts credentials stored in plain
+// text and upgrades ciphertexts of older formats, which the service
+// doesn't do on its own so it never writes credentials on startup.
+func rotateAccountKeys(
+	ctx context.Context,
+	config *Config,
+	args []string,
+) error {
+	flagSet := flag.NewFlagSet("account rotate-keys", flag.ExitOnError)
+	_ = flagSet.Parse(args)
+
+	secretCipher, err := newSecretCipher(config)
+	if err != nil {
+		return err
+	}
+
+	client, err := connectPostgres(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	updated, err := postgres.NewAccountRepository(
+		client,
+		&uuid.IDService{},
+		secretCipher,
+	).ReencryptCredentials()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("re-encrypted credentials of [%v] account(s)\n", updated)
+
+	return nil
+}
+
+// accountRecord never exposes the exchange credentials.
+type accountRecord struct {
+	ID                 string          `json:"id"`
+	Email              string          `json:"email"`
+	Exchange           string          `json:"exchange"`
+	RiskFactor         trading.Decimal `json:"riskFactor"`
+	OpenPositionsLimit int             `json:"openPositionsLimit"`
+}
+
+func newAccountsTable(accounts []*trading.Account) *table {
+	records := make([]*accountRecord, 0)
+	rows := make([][]string, 0)
+
+	for _, account := range accounts {
+		record := &accountRecord{
+			ID:                 account.ID.String(),
+			Email:              account.Email,
+			Exchange:           account.Exchange,
+			RiskFactor:         account.RiskFactor,
+			OpenPositionsLimit: account.OpenPositionsLimit,
+		}
+
+		records = append(records, record)
+		rows = append(rows, []string{
+			record.ID,
+			record.Email,
+			record.Exchange,
+			record.RiskFactor.String(),
+			strconv.Itoa(record.OpenPositionsLimit),
+		})
+	}
+
+	return &table{
+		headers: []string{"ID", "EMAIL", "EXCHANGE", "RISK", "POSITIONS"},
+		rows:    rows,
+		records: records,
+	}
+}
+
+func readSecret(prompt string) (string, error) {
+	_, _ = fmt.Fprint(os.Stderr, prompt)
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && len(line) == 0 {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/sherifabdlnaby/configuro"
+)
+
+// Config values can be set using either environment variables with `CONFIG_`
+// prefix or config.yml file placed in working directory, exactly as for
+// the trading service. See https://github.com/sherifabdlnaby/configuro.
+type Config struct {
+	Database   Database
+	Encryption Encryption
+}
+
+type Database struct {
+	Address      string
+	User         string
+	Password     string
+	Name         string
+	SSLMode      string
+	MigrationDir string
+}
+
+type Encryption struct {
+	MasterKeys     string
+	MasterKeysFile string
+	PrimaryKeyID   string
+}
+
+func readConfig() (*Config, error) {
+	loader, err := configuro.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Default config values.
+	config := &Config{
+		Database: Database{
+			Address:      "localhost:5432",
+			User:         "postgres",
+			Password:     "postgres",
+			Name:         "postgres",
+			SSLMode:      "disable",
+			MigrationDir: "postgres/migration",
+		},
+	}
+
+	err = loader.Load(config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = loader.Validate(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
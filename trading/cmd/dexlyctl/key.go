@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading/envelope"
+)
+
+// generateKey prints a new master key which can be appended to the
+// configured master keys.
+func generateKey(_ context.Context, _ *Config, args []string) error {
+	flagSet := flag.NewFlagSet("key generate", flag.ExitOnError)
+	keyID := flagSet.String("id", "", "ID of the new master key")
+	_ = flagSet.Parse(args)
+
+	if len(*keyID) == 0 {
+		return fmt.Errorf("flag -id is required")
+	}
+
+	key, err := envelope.GenerateMasterKey(*keyID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(key)
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/envelope"
+	"github.com/lukasz-zimnoch/dexly/trading/postgres"
+	"os"
+	"strings"
+)
+
+// command is a single dexlyctl subcommand identified by a group
+// and an action, e.g. `account add`.
+type command struct {
+	group       string
+	action      string
+	description string
+	run         func(ctx context.Context, config *Config, args []string) error
+}
+
+var commands = []*command{
+	{"account", "add", "add a new account", addAccount},
+	{"account", "list", "list accounts", listAccounts},
+	{"account", "rotate-keys", "re-encrypt credentials", rotateAccountKeys},
+	{"workload", "add", "attach a workload for a pair", addWorkload},
+	{"workload", "list", "list workloads", listWorkloads},
+	{"workload", "set-status", "activate, pause or disable", setWorkloadStatus},
+	{"position", "list", "list positions of a workload", listPositions},
+	{"order", "list", "list orders of a workload", listOrders},
+	{"trade", "export", "export trades of a workload", exportTrades},
+	{"migrate", "up", "apply all pending migrations", migrateUp},
+	{"migrate", "down", "roll back applied migrations", migrateDown},
+	{"migrate", "version", "print the current migration", migrateVersion},
+	{"key", "generate", "generate a new master key", generateKey},
+}
+
+func main() {
+	if len(os.Args) < 3 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	command, ok := findCommand(os.Args[1], os.Args[2])
+	if !ok {
+		printUsage()
+		os.Exit(2)
+	}
+
+	config, err := readConfig()
+	if err != nil {
+		exitWithError(fmt.Errorf("could not read config: [%v]", err))
+	}
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	if err := command.run(ctx, config, os.Args[3:]); err != nil {
+		exitWithError(err)
+	}
+}
+
+func findCommand(group, action string) (*command, bool) {
+	for _, command := range commands {
+		if command.group == group && command.action == action {
+			return command, true
+		}
+	}
+
+	return nil, false
+}
+
+func printUsage() {
+	var builder strings.Builder
+
+	builder.WriteString("Usage: dexlyctl <group> <action> [flags]\n\n")
+	builder.WriteString("Commands:\n")
+
+	for _, command := range commands {
+		builder.WriteString(fmt.Sprintf(
+			"  %-22s %v\n",
+			command.group+" "+command.action,
+			command.description,
+		))
+	}
+
+	builder.WriteString(
+		"\nRun `dexlyctl <group> <action> -h` to see command flags.\n" +
+			"Database connection is configured the same way as for the " +
+			"trading service, the same applies to master keys used to " +
+			"encrypt exchange credentials.\n",
+	)
+
+	_, _ = fmt.Fprint(os.Stderr, builder.String())
+}
+
+func exitWithError(err error) {
+	_, _ = fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	os.Exit(1)
+}
+
+func connectPostgres(
+	ctx context.Context,
+	config *Config,
+) (*postgres.Client, error) {
+	client, err := postgres.NewClient(ctx, (*postgres.Config)(&config.Database))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not create postgres client: [%v]",
+			err,
+		)
+	}
+
+	return client, nil
+}
+
+// newSecretCipher creates the cipher used to encrypt and decrypt account
+// credentials. It must use the same master keys as the trading service.
+func newSecretCipher(config *Config) (trading.SecretCipher, error) {
+	keyProvider, err := envelope.LoadLocalKeyProvider(
+		config.Encryption.MasterKeys,
+		config.Encryption.MasterKeysFile,
+		config.Encryption.PrimaryKeyID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not load master keys: [%v]", err)
+	}
+
+	return envelope.NewCipher(keyProvider), nil
+}
+
+// parseID parses the ID passed as the value of the given flag.
+func parseID(
+	idService trading.IDService,
+	flagName string,
+	value string,
+) (trading.ID, error) {
+	if len(value) == 0 {
+		return nil, fmt.Errorf("flag -%v is required", flagName)
+	}
+
+	id, err := idService.NewIDFromString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -%v value: [%v]", flagName, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading/logrus"
+	"github.com/lukasz-zimnoch/dexly/trading/postgres"
+)
+
+func migrateUp(_ context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("migrate up", flag.ExitOnError)
+	_ = flagSet.Parse(args)
+
+	return postgres.RunMigration(
+		logrus.ConfigureStandardLogger("text", "info"),
+		(*postgres.Config)(&config.Database),
+	)
+}
+
+func migrateDown(_ context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("migrate down", flag.ExitOnError)
+	steps := flagSet.Int("steps", 1, "number of migrations to roll back")
+	_ = flagSet.Parse(args)
+
+	return postgres.RollbackMigration(
+		logrus.ConfigureStandardLogger("text", "info"),
+		(*postgres.Config)(&config.Database),
+		*steps,
+	)
+}
+
+func migrateVersion(_ context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("migrate version", flag.ExitOnError)
+	_ = flagSet.Parse(args)
+
+	version, dirty, err := postgres.MigrationVersion(
+		(*postgres.Config)(&config.Database),
+	)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		fmt.Printf("%v (dirty)\n", version)
+		return nil
+	}
+
+	fmt.Println(version)
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func outputFlag(flagSet *flag.FlagSet) *string {
+	return flagSet.String(
+		"output",
+		outputTable,
+		"output format, either "+outputTable+" or "+outputJSON,
+	)
+}
+
+func validateOutput(output string) error {
+	if output != outputTable && output != outputJSON {
+		return fmt.Errorf("unknown output format [%v]", output)
+	}
+
+	return nil
+}
+
+// table is a printable list of records. The same records are used for
+// both table and JSON outputs.
+type table struct {
+	headers []string
+	rows    [][]string
+	records interface{}
+}
+
+func (t *table) print(output string) error {
+	return t.write(os.Stdout, output)
+}
+
+func (t *table) write(writer io.Writer, output string) error {
+	switch output {
+	case outputJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t.records)
+	case outputTable:
+		tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+		_, _ = fmt.Fprintln(tabWriter, strings.Join(t.headers, "\t"))
+		for _, row := range t.rows {
+			_, _ = fmt.Fprintln(tabWriter, strings.Join(row, "\t"))
+		}
+
+		return tabWriter.Flush()
+	default:
+		return fmt.Errorf("unknown output format [%v]", output)
+	}
+}
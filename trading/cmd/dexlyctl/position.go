@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/postgres"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func listPositions(ctx context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("position list", flag.ExitOnError)
+	workloadIDFlag := flagSet.String("workload", "", "workload ID")
+	statusFlag := flagSet.String(
+		"status",
+		"",
+		"position status, OPEN or CLOSED; all positions if not set",
+	)
+	output := outputFlag(flagSet)
+	_ = flagSet.Parse(args)
+
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	positions, err := fetchPositions(ctx, config, *workloadIDFlag, *statusFlag)
+	if err != nil {
+		return err
+	}
+
+	return newPositionsTable(positions).print(*output)
+}
+
+func listOrders(ctx context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("order list", flag.ExitOnError)
+	workloadIDFlag := flagSet.String("workload", "", "workload ID")
+	statusFlag := flagSet.String(
+		"status",
+		"",
+		"status of the positions the orders belong to, OPEN or CLOSED; "+
+			"all orders if not set",
+	)
+	output := outputFlag(flagSet)
+	_ = flagSet.Parse(args)
+
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	positions, err := fetchPositions(ctx, config, *workloadIDFlag, *statusFlag)
+	if err != nil {
+		return err
+	}
+
+	orders := make([]*trading.Order, 0)
+	for _, position := range positions {
+		orders = append(orders, position.Orders...)
+	}
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].Time.Before(orders[j].Time)
+	})
+
+	return newOrdersTable(orders).print(*output)
+}
+
+// fetchPositions returns positions of the given workload, sorted by
+// their opening time. Positions of all statuses are returned if the
+// status is empty.
+func fetchPositions(
+	ctx context.Context,
+	config *Config,
+	workloadIDValue string,
+	statusValue string,
+) ([]*trading.Position, error) {
+	idService := &uuid.IDService{}
+
+	workloadID, err := parseID(idService, "workload", workloadIDValue)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []trading.PositionStatus{
+		trading.StatusOpen,
+		trading.StatusClosed,
+	}
+
+	if len(statusValue) > 0 {
+		status, err := trading.ParsePositionStatus(statusValue)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = []trading.PositionStatus{status}
+	}
+
+	client, err := connectPostgres(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	positionRepository := postgres.NewPositionRepository(client, idService)
+
+	positions := make([]*trading.Position, 0)
+
+	for _, status := range statuses {
+		statusPositions, err := positionRepository.Positions(
+			trading.PositionFilter{
+				WorkloadID: workloadID,
+				Status:     status,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		positions = append(positions, statusPositions...)
+	}
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].Time.Before(positions[j].Time)
+	})
+
+	return positions, nil
+}
+
+type orderRecord struct {
+	ID         string          `json:"id"`
+	PositionID string          `json:"positionId"`
+	Side       string          `json:"side"`
+	Price      trading.Decimal `json:"price"`
+	Size       trading.Decimal `json:"size"`
+	Time       time.Time       `json:"time"`
+	Executed   bool            `json:"executed"`
+}
+
+func newOrderRecord(order *trading.Order) *orderRecord {
+	return &orderRecord{
+		ID:         order.ID.String(),
+		PositionID: order.Position.ID.String(),
+		Side:       order.Side.String(),
+		Price:      order.Price,
+		Size:       order.Size,
+		Time:       order.Time,
+		Executed:   order.Executed,
+	}
+}
+
+func newOrdersTable(orders []*trading.Order) *table {
+	records := make([]*orderRecord, 0)
+	rows := make([][]string, 0)
+
+	for _, order := range orders {
+		record := newOrderRecord(order)
+
+		records = append(records, record)
+		rows = append(rows, []string{
+			record.ID,
+			record.PositionID,
+			record.Side,
+			record.Price.String(),
+			record.Size.String(),
+			record.Time.Format(time.RFC3339),
+			strconv.FormatBool(record.Executed),
+		})
+	}
+
+	return &table{
+		headers: []string{
+			"ID",
+			"POSITION",
+			"SIDE",
+			"PRICE",
+			"SIZE",
+			"TIME",
+			"EXECUTED",
+		},
+		rows:    rows,
+		records: records,
+	}
+}
+
+type positionRecord struct {
+	ID              string          `json:"id"`
+	WorkloadID      string          `json:"workloadId"`
+	Type            string          `json:"type"`
+	Status          string          `json:"status"`
+	EntryPrice      trading.Decimal `json:"entryPrice"`
+	Size            trading.Decimal `json:"size"`
+	TakeProfitPrice trading.Decimal `json:"takeProfitPrice"`
+	StopLossPrice   trading.Decimal `json:"stopLossPrice"`
+	Time            time.Time       `json:"time"`
+	ExitRequested   bool            `json:"exitRequested"`
+	Orders          []*orderRecord  `json:"orders"`
+}
+
+func newPositionsTable(positions []*trading.Position) *table {
+	records := make([]*positionRecord, 0)
+	rows := make([][]string, 0)
+
+	for _, position := range positions {
+		orders := make([]*orderRecord, 0)
+		for _, order := range position.Orders {
+			orders = append(orders, newOrderRecord(order))
+		}
+
+		record := &positionRecord{
+			ID:              position.ID.String(),
+			WorkloadID:      position.WorkloadID.String(),
+			Type:            position.Type.String(),
+			Status:          position.Status.String(),
+			EntryPrice:      position.EntryPrice,
+			Size:            position.Size,
+			TakeProfitPrice: position.TakeProfitPrice,
+			StopLossPrice:   position.StopLossPrice,
+			Time:            position.Time,
+			ExitRequested:   position.ExitRequested,
+			Orders:          orders,
+		}
+
+		records = append(records, record)
+		rows = append(rows, []string{
+			record.ID,
+			record.Type,
+			record.Status,
+			record.EntryPrice.String(),
+			record.Size.String(),
+			record.TakeProfitPrice.String(),
+			record.StopLossPrice.String(),
+			record.Time.Format(time.RFC3339),
+			fmt.Sprintf("%v", len(record.Orders)),
+		})
+	}
+
+	return &table{
+		headers: []string{
+			"ID",
+			"TYPE",
+			"STATUS",
+			"ENTRY",
+			"SIZE",
+			"TAKE PROFIT",
+			"STOP LOSS",
+			"TIME",
+			"ORDERS",
+		},
+		rows:    rows,
+		records: records,
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	exportCSV  = "csv"
+	exportJSON = "json"
+)
+
+func exportTrades(ctx context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("trade export", flag.ExitOnError)
+	workloadIDFlag := flagSet.String("workload", "", "workload ID")
+	format := flagSet.String(
+		"format",
+		exportCSV,
+		"export format, either "+exportCSV+" or "+exportJSON,
+	)
+	filePath := flagSet.String(
+		"file",
+		"",
+		"path of the output file; standard output if not set",
+	)
+	sinceFlag := flagSet.String(
+		"since",
+		"",
+		"export only trades exited at or after the given RFC3339 time",
+	)
+	_ = flagSet.Parse(args)
+
+	if *format != exportCSV && *format != exportJSON {
+		return fmt.Errorf("unknown export format [%v]", *format)
+	}
+
+	var since time.Time
+	if len(*sinceFlag) > 0 {
+		var err error
+		since, err = time.Parse(time.RFC3339, *sinceFlag)
+		if err != nil {
+			return fmt.Errorf("invalid -since value: [%v]", err)
+		}
+	}
+
+	positions, err := fetchPositions(
+		ctx,
+		config,
+		*workloadIDFlag,
+		trading.StatusClosed.String(),
+	)
+	if err != nil {
+		return err
+	}
+
+	trades := make([]*trading.Trade, 0)
+	for _, position := range positions {
+		trade, exited, err := trading.NewTrade(position)
+		if err != nil {
+			return err
+		}
+
+		if !exited || trade.ExitOrder.Time.Before(since) {
+			continue
+		}
+
+		trades = append(trades, trade)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].ExitOrder.Time.Before(trades[j].ExitOrder.Time)
+	})
+
+	var writer io.Writer = os.Stdout
+	if len(*filePath) > 0 {
+		file, err := os.Create(*filePath)
+		if err != nil {
+			return fmt.Errorf("could not create output file: [%v]", err)
+		}
+		defer file.Close()
+
+		writer = file
+	}
+
+	switch *format {
+	case exportJSON:
+		return writeTradesJSON(writer, trades)
+	default:
+		return writeTradesCSV(writer, trades)
+	}
+}
+
+type tradeRecord struct {
+	PositionID string          `json:"positionId"`
+	Type       string          `json:"type"`
+	EntryTime  time.Time       `json:"entryTime"`
+	EntryPrice trading.Decimal `json:"entryPrice"`
+	ExitTime   time.Time       `json:"exitTime"`
+	ExitPrice  trading.Decimal `json:"exitPrice"`
+	Size       trading.Decimal `json:"size"`
+	Profit     trading.Decimal `json:"profit"`
+}
+
+func newTradeRecord(trade *trading.Trade) *tradeRecord {
+	return &tradeRecord{
+		PositionID: trade.Position.ID.String(),
+		Type:       trade.Position.Type.String(),
+		EntryTime:  trade.EntryOrder.Time,
+		EntryPrice: trade.EntryOrder.Price,
+		ExitTime:   trade.ExitOrder.Time,
+		ExitPrice:  trade.ExitOrder.Price,
+		Size:       trade.EntryOrder.Size,
+		Profit:     trade.Profit,
+	}
+}
+
+func writeTradesJSON(writer io.Writer, trades []*trading.Trade) error {
+	records := make([]*tradeRecord, 0)
+	for _, trade := range trades {
+		records = append(records, newTradeRecord(trade))
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(records)
+}
+
+func writeTradesCSV(writer io.Writer, trades []*trading.Trade) error {
+	csvWriter := csv.NewWriter(writer)
+
+	err := csvWriter.Write([]string{
+		"position_id",
+		"type",
+		"entry_time",
+		"entry_price",
+		"exit_time",
+		"exit_price",
+		"size",
+		"profit",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, trade := range trades {
+		record := newTradeRecord(trade)
+
+		err := csvWriter.Write([]string{
+			record.PositionID,
+			record.Type,
+			record.EntryTime.Format(time.RFC3339),
+			record.EntryPrice.String(),
+			record.ExitTime.Format(time.RFC3339),
+			record.ExitPrice.String(),
+			record.Size.String(),
+			record.Profit.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/logrus"
+	"github.com/lukasz-zimnoch/dexly/trading/postgres"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"strconv"
+)
+
+func addWorkload(ctx context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("workload add", flag.ExitOnError)
+	accountIDFlag := flagSet.String("account", "", "account ID")
+	baseAsset := flagSet.String("base", "", "base asset of the pair")
+	quoteAsset := flagSet.String("quote", "", "quote asset of the pair")
+	modeFlag := flagSet.String("mode", "LIVE", "workload mode, LIVE or PAPER")
+	statusFlag := flagSet.String(
+		"status",
+		"ACTIVE",
+		"workload status, ACTIVE, PAUSED or DISABLED",
+	)
+	intervalFlag := flagSet.String(
+		"interval",
+		trading.DefaultCandleInterval.String(),
+		"candle interval, 1m, 5m, 15m, 30m, 1h, 4h or 1d",
+	)
+	windowSize := flagSet.Int(
+		"window",
+		trading.DefaultCandleWindowSize,
+		"number of candles the signal generator works on",
+	)
+	stopLossFlag := flagSet.String(
+		"stop-loss-atr",
+		trading.DefaultStopLossATRMultiplier.String(),
+		"number of ATRs between the entry and the stop loss",
+	)
+	rewardRiskFlag := flagSet.String(
+		"reward-risk",
+		trading.DefaultRewardRiskRatio.String(),
+		"ratio of the take profit distance to the stop loss distance",
+	)
+	strategyFlag := flagSet.String(
+		"strategy",
+		trading.DefaultStrategy.Name,
+		"name of the strategy, unknown strategies disable the workload",
+	)
+	strategyVersion := flagSet.Int(
+		"strategy-version",
+		trading.DefaultStrategy.Version,
+		"version of the strategy",
+	)
+	_ = flagSet.Parse(args)
+
+	idService := &uuid.IDService{}
+
+	accountID, err := parseID(idService, "account", *accountIDFlag)
+	if err != nil {
+		return err
+	}
+
+	if len(*baseAsset) == 0 || len(*quoteAsset) == 0 {
+		return fmt.Errorf("flags -base and -quote are required")
+	}
+
+	mode, err := trading.ParseWorkloadMode(*modeFlag)
+	if err != nil {
+		return err
+	}
+
+	status, err := trading.ParseWorkloadStatus(*statusFlag)
+	if err != nil {
+		return err
+	}
+
+	interval, err := trading.ParseCandleInterval(*intervalFlag)
+	if err != nil {
+		return err
+	}
+
+	if *windowSize <= 0 {
+		return fmt.Errorf("flag -window must be positive")
+	}
+
+	stopLossATRMultiplier, err := trading.ParseDecimal(*stopLossFlag)
+	if err != nil {
+		return err
+	}
+
+	rewardRiskRatio, err := trading.ParseDecimal(*rewardRiskFlag)
+	if err != nil {
+		return err
+	}
+
+	if err := trading.ValidateATRTargets(
+		stopLossATRMultiplier,
+		rewardRiskRatio,
+	); err != nil {
+		return fmt.Errorf("invalid -stop-loss-atr or -reward-risk: [%v]", err)
+	}
+
+	if len(*strategyFlag) == 0 {
+		return fmt.Errorf("flag -strategy is required")
+	}
+
+	if *strategyVersion <= 0 {
+		return fmt.Errorf("flag -strategy-version must be positive")
+	}
+
+	secretCipher, err := newSecretCipher(config)
+	if err != nil {
+		return err
+	}
+
+	client, err := connectPostgres(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	account, err := postgres.NewAccountRepository(
+		client,
+		idService,
+		secretCipher,
+	).Account(accountID)
+	if err != nil {
+		if err == trading.ErrNotFound {
+			return fmt.Errorf("account [%v] does not exist", accountID)
+		}
+
+		return err
+	}
+
+	workload := &trading.Workload{
+		ID:      idService.NewID(),
+		Account: account,
+		Pair: trading.Pair{
+			Base:  trading.Asset(*baseAsset),
+			Quote: trading.Asset(*quoteAsset),
+		},
+		Mode:                  mode,
+		Status:                status,
+		CandleInterval:        interval,
+		CandleWindowSize:      *windowSize,
+		StopLossATRMultiplier: stopLossATRMultiplier,
+		RewardRiskRatio:       rewardRiskRatio,
+		Strategy: trading.StrategyRef{
+			Name:    *strategyFlag,
+			Version: *strategyVersion,
+		},
+	}
+
+	err = postgres.NewWorkloadRepository(
+		client,
+		idService,
+		secretCipher,
+		logrus.ConfigureStandardLogger("text", "info"),
+	).CreateWorkload(workload)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(workload.ID)
+
+	return nil
+}
+
+func listWorkloads(ctx context.Context, config *Config, args []string) error {
+	flagSet := flag.NewFlagSet("workload list", flag.ExitOnError)
+	output := outputFlag(flagSet)
+	_ = flagSet.Parse(args)
+
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	secretCipher, err := newSecretCipher(config)
+	if err != nil {
+		return err
+	}
+
+	client, err := connectPostgres(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	workloads, err := postgres.NewWorkloadRepository(
+		client,
+		&uuid.IDService{},
+		secretCipher,
+		logrus.ConfigureStandardLogger("text", "info"),
+	).Workloads()
+	if err != nil {
+		return err
+	}
+
+	return newWorkloadsTable(workloads).print(*output)
+}
+
+func setWorkloadStatus(
+	ctx context.Context,
+	config *Config,
+	args []string,
+) error {
+	flagSet := flag.NewFlagSet("workload set-status", flag.ExitOnError)
+	workloadIDFlag := flagSet.String("id", "", "workload ID")
+	statusFlag := flagSet.String(
+		"status",
+		"",
+		"workload status, ACTIVE, PAUSED or DISABLED",
+	)
+	_ = flagSet.Parse(args)
+
+	idService := &uuid.IDService{}
+
+	workloadID, err := parseID(idService, "id", *workloadIDFlag)
+	if err != nil {
+		return err
+	}
+
+	status, err := trading.ParseWorkloadStatus(*statusFlag)
+	if err != nil {
+		return err
+	}
+
+	secretCipher, err := newSecretCipher(config)
+	if err != nil {
+		return err
+	}
+
+	client, err := connectPostgres(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	workloadRepository := postgres.NewWorkloadRepository(
+		client,
+		idService,
+		secretCipher,
+		logrus.ConfigureStandardLogger("text", "info"),
+	)
+
+	workload, err := workloadRepository.Workload(workloadID)
+	if err != nil {
+		if err == trading.ErrNotFound {
+			return fmt.Errorf("workload [%v] does not exist", workloadID)
+		}
+
+		return err
+	}
+
+	workload.Status = status
+
+	return workloadRepository.UpdateWorkload(workload)
+}
+
+type workloadRecord struct {
+	ID         string `json:"id"`
+	AccountID  string `json:"accountId"`
+	Exchange   string `json:"exchange"`
+	BaseAsset  string `json:"baseAsset"`
+	QuoteAsset string `json:"quoteAsset"`
+	Mode       string `json:"mode"`
+	Status     string `json:"status"`
+	Interval   string `json:"candleInterval"`
+	WindowSize int    `json:"candleWindowSize"`
+	StopLoss   string `json:"stopLossAtrMultiplier"`
+	RewardRisk string `json:"rewardRiskRatio"`
+	Strategy   string `json:"strategy"`
+}
+
+func newWorkloadsTable(workloads []*trading.Workload) *table {
+	records := make([]*workloadRecord, 0)
+	rows := make([][]string, 0)
+
+	for _, workload := range workloads {
+		record := &workloadRecord{
+			ID:         workload.ID.String(),
+			AccountID:  workload.Account.ID.String(),
+			Exchange:   workload.Account.Exchange,
+			BaseAsset:  string(workload.Pair.Base),
+			QuoteAsset: string(workload.Pair.Quote),
+			Mode:       workload.Mode.String(),
+			Status:     workload.Status.String(),
+			Interval:   workload.CandleInterval.String(),
+			WindowSize: workload.CandleWindowSize,
+			StopLoss:   workload.StopLossATRMultiplier.String(),
+			RewardRisk: workload.RewardRiskRatio.String(),
+			Strategy:   workload.Strategy.String(),
+		}
+
+		records = append(records, record)
+		rows = append(rows, []string{
+			record.ID,
+			record.AccountID,
+			record.Exchange,
+			record.BaseAsset + record.QuoteAsset,
+			record.Mode,
+			record.Status,
+			record.Interval,
+			strconv.Itoa(record.WindowSize),
+			record.StopLoss,
+			record.RewardRisk,
+			record.Strategy,
+		})
+	}
+
+	return &table{
+		headers: []string{
+			"ID",
+			"ACCOUNT",
+			"EXCHANGE",
+			"PAIR",
+			"MODE",
+			"STATUS",
+			"INTERVAL",
+			"WINDOW",
+			"SL ATR",
+			"REWARD:RISK",
+			"STRATEGY",
+		},
+		rows:    rows,
+		records: records,
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/inmem"
+	"github.com/lukasz-zimnoch/dexly/trading/postgres"
+)
+
+const (
+	candlesStoragePostgres = "postgres"
+	candlesStorageMemory   = "memory"
+)
+
+// newCandleRepositoryFactory returns the factory of candle repositories
+// matching the configured storage. The retention of stored candles is
+// enforced in the background until the context is done.
+func newCandleRepositoryFactory(
+	ctx context.Context,
+	config *Candles,
+	postgresClient *postgres.Client,
+	logger trading.Logger,
+) (trading.CandleRepositoryFactory, error) {
+	switch config.Storage {
+	case candlesStoragePostgres:
+		retentionPolicy, err := trading.ParseCandleRetentionPolicy(
+			config.Retention,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse retention policy: [%v]",
+				err,
+			)
+		}
+
+		candleStore := postgres.NewCandleRepository(postgresClient)
+
+		go trading.RunCandleRetention(
+			ctx,
+			candleStore,
+			retentionPolicy,
+			&trading.SystemClock{},
+			logger,
+		)
+
+		return func(windowSize int) trading.CandleRepository {
+			return inmem.NewCachedCandleRepository(candleStore, windowSize)
+		}, nil
+	case candlesStorageMemory:
+		return func(windowSize int) trading.CandleRepository {
+			return inmem.NewCandleRepository(windowSize)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage [%v]", config.Storage)
+	}
+}
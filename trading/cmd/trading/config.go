@@ -1,16 +1,26 @@
 package main
 
 import (
+	"github.com/lukasz-zimnoch/dexly/trading"
 	"github.com/sherifabdlnaby/configuro"
+	"time"
 )
 
 // Config values can be set using either environment variables with `CONFIG_`
 // prefix or config.yml file placed in working directory.
 // See https://github.com/sherifabdlnaby/configuro.
 type Config struct {
-	Logging  Logging
-	Database Database
-	Pubsub   Pubsub
+	Logging    Logging
+	Database   Database
+	Encryption Encryption
+	Pubsub     Pubsub
+	Paper      Paper
+	API        API
+	Shutdown   Shutdown
+	Supervisor Supervisor
+	Cluster    Cluster
+	Candles    Candles
+	Strategies Strategies
 }
 
 type Logging struct {
@@ -27,11 +37,63 @@ type Database struct {
 	MigrationDir string
 }
 
+// Encryption master keys are encoded as `<id>:<base64 key>` entries
+// separated by commas or new lines, see `dexlyctl key generate`. Keys are
+// rotated using `dexlyctl account rotate-keys`.
+type Encryption struct {
+	MasterKeys     string
+	MasterKeysFile string
+	// PrimaryKeyID is the first configured key by default.
+	PrimaryKeyID string
+}
+
 type Pubsub struct {
 	ProjectID            string
 	NotificationsTopicID string
 }
 
+type Paper struct {
+	// InitialBalance funds each quote asset the first time a workload of
+	// the account trades against it.
+	InitialBalance      string
+	TakerCommission     string
+	ShortSellingAllowed bool
+}
+
+type API struct {
+	Address string
+	// The administration API is disabled if the token is not set.
+	AuthToken string
+}
+
+type Shutdown struct {
+	Timeout time.Duration
+}
+
+type Supervisor struct {
+	RestartBackoff         time.Duration
+	MaxRestartBackoff      time.Duration
+	MaxConsecutiveFailures int
+}
+
+type Cluster struct {
+	// InstanceID is the host name by default.
+	InstanceID string
+}
+
+type Candles struct {
+	// Storage is either `postgres` or `memory`.
+	Storage string
+	// Retention is encoded as `<interval>=<duration>` entries separated
+	// by commas, e.g. `1m=720h`. Candles are kept forever by default.
+	Retention string
+}
+
+type Strategies struct {
+	// Dir holds strategy specs which are loaded on start.
+	Dir string
+}
+
 func readConfig() (*Config, error) {
 	loader, err := configuro.NewConfig()
 	if err != nil {
@@ -50,6 +112,27 @@ func readConfig() (*Config, error) {
 			Name:     "postgres",
 			SSLMode:  "disable",
 		},
+		Paper: Paper{
+			InitialBalance:  "1000",
+			TakerCommission: "0.001",
+		},
+		API: API{
+			Address: ":8080",
+		},
+		Shutdown: Shutdown{
+			Timeout: 30 * time.Second,
+		},
+		Supervisor: Supervisor{
+			RestartBackoff: trading.DefaultWorkloadSupervisorConfig.
+				RestartBackoff,
+			MaxRestartBackoff: trading.DefaultWorkloadSupervisorConfig.
+				MaxRestartBackoff,
+			MaxConsecutiveFailures: trading.DefaultWorkloadSupervisorConfig.
+				MaxConsecutiveFailures,
+		},
+		Candles: Candles{
+			Storage: candlesStoragePostgres,
+		},
 	}
 
 	err = loader.Load(config)
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/binance"
+	"github.com/lukasz-zimnoch/dexly/trading/kraken"
+	"github.com/lukasz-zimnoch/dexly/trading/simulation"
+	"sync"
+)
+
+const (
+	binanceExchange = "BINANCE"
+	krakenExchange  = "KRAKEN"
+	// Paper accounts trade on Binance market data but their orders are
+	// filled against a simulated wallet.
+	paperExchange = trading.PaperExchange
+)
+
+type exchangeConnector struct {
+	paperWalletRepository simulation.WalletRepository
+	paperConfig           *Paper
+
+	// Workloads of the same paper account share a single wallet.
+	paperWalletsMutex sync.Mutex
+	paperWallets      map[string]*simulation.Wallet
+}
+
+func newExchangeConnector(
+	paperWalletRepository simulation.WalletRepository,
+	paperConfig *Paper,
+) *exchangeConnector {
+	return &exchangeConnector{
+		paperWalletRepository: paperWalletRepository,
+		paperConfig:           paperConfig,
+		paperWallets:          make(map[string]*simulation.Wallet),
+	}
+}
+
+func (ec *exchangeConnector) Connect(
+	ctx context.Context,
+	workload *trading.Workload,
+) (trading.ExchangeService, error) {
+	paperAccount := workload.Account.Exchange == paperExchange
+	paperWorkload := workload.Mode == trading.ModePaper
+
+	if paperAccount != paperWorkload {
+		return nil, fmt.Errorf(
+			"workload mode [%v] does not match account exchange [%v]",
+			workload.Mode,
+			workload.Account.Exchange,
+		)
+	}
+
+	switch workload.Account.Exchange {
+	case binanceExchange:
+		return binance.NewExchangeService(ctx, workload)
+	case krakenExchange:
+		return kraken.NewExchangeService(ctx, workload)
+	case paperExchange:
+		return ec.connectPaper(ctx, workload)
+	default:
+		// Retrying won't help until the account gets fixed.
+		return nil, trading.NewFatalError(fmt.Errorf(
+			"unknown exchange [%v]",
+			workload.Account.Exchange,
+		))
+	}
+}
+
+func (ec *exchangeConnector) connectPaper(
+	ctx context.Context,
+	workload *trading.Workload,
+) (trading.ExchangeService, error) {
+	marketDataService, err := binance.NewExchangeService(ctx, workload)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not connect market data service: [%v]",
+			err,
+		)
+	}
+
+	// Paper orders are subject to the same rules as the live ones.
+	tradingRules, err := marketDataService.TradingRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get trading rules: [%v]", err)
+	}
+
+	wallet, err := ec.paperWallet(workload)
+	if err != nil {
+		return nil, fmt.Errorf("could not get paper wallet: [%v]", err)
+	}
+
+	return simulation.NewExchangeService(
+		workload,
+		marketDataService,
+		tradingRules,
+		wallet,
+	), nil
+}
+
+func (ec *exchangeConnector) paperWallet(
+	workload *trading.Workload,
+) (*simulation.Wallet, error) {
+	ec.paperWalletsMutex.Lock()
+	defer ec.paperWalletsMutex.Unlock()
+
+	initialBalance, err := trading.ParseDecimal(ec.paperConfig.InitialBalance)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse initial balance: [%v]", err)
+	}
+
+	accountID := workload.Account.ID.String()
+
+	wallet, exists := ec.paperWallets[accountID]
+	if !exists {
+		takerCommission, err := trading.ParseDecimal(
+			ec.paperConfig.TakerCommission,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse taker commission: [%v]",
+				err,
+			)
+		}
+
+		wallet, err = simulation.LoadWallet(
+			ec.paperWalletRepository,
+			workload.Account.ID,
+			trading.Balances{},
+			takerCommission,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		wallet.SetShortSellingAllowed(ec.paperConfig.ShortSellingAllowed)
+
+		ec.paperWallets[accountID] = wallet
+	}
+
+	// Workloads of the same account may trade against different quote
+	// assets, each of them is funded once it's used for the first time.
+	if _, err := wallet.Fund(workload.Pair.Quote, initialBalance); err != nil {
+		return nil, fmt.Errorf("could not fund quote asset: [%v]", err)
+	}
+
+	return wallet, nil
+}
@@ -4,17 +4,20 @@ import (
 	"context"
 	"fmt"
 	"github.com/lukasz-zimnoch/dexly/trading"
-	"github.com/lukasz-zimnoch/dexly/trading/binance"
-	"github.com/lukasz-zimnoch/dexly/trading/inmem"
+	"github.com/lukasz-zimnoch/dexly/trading/envelope"
 	"github.com/lukasz-zimnoch/dexly/trading/logrus"
 	"github.com/lukasz-zimnoch/dexly/trading/postgres"
+	"github.com/lukasz-zimnoch/dexly/trading/prometheus"
 	"github.com/lukasz-zimnoch/dexly/trading/pubsub"
-	"github.com/lukasz-zimnoch/dexly/trading/techan"
+	"github.com/lukasz-zimnoch/dexly/trading/rest"
+	"github.com/lukasz-zimnoch/dexly/trading/strategy"
 	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
-// TODO: Metrics and monitoring.
 func main() {
 	ctx, cancelCtx := context.WithCancel(context.Background())
 	defer cancelCtx()
@@ -30,6 +33,17 @@ func main() {
 		config.Logging.Level,
 	)
 
+	keyProvider, err := envelope.LoadLocalKeyProvider(
+		config.Encryption.MasterKeys,
+		config.Encryption.MasterKeysFile,
+		config.Encryption.PrimaryKeyID,
+	)
+	if err != nil {
+		logger.Fatalf("could not load master keys: [%v]", err)
+	}
+
+	secretCipher := envelope.NewCipher(keyProvider)
+
 	postgresClient, err := connectPostgres(ctx, logger, &config.Database)
 	if err != nil {
 		logger.Fatalf("could not connect postgres: [%v]", err)
@@ -46,20 +60,151 @@ func main() {
 		logger.Fatalf("could not get pubsub client: [%v]", err)
 	}
 
-	trading.RunWorkloadController(
+	accountRepository := postgres.NewAccountRepository(
+		postgresClient,
+		idService,
+		secretCipher,
+	)
+	encrypted, err := accountRepository.EncryptPlaintextCredentials()
+	if err != nil {
+		logger.Fatalf("could not encrypt account credentials: [%v]", err)
+	}
+	if encrypted > 0 {
+		logger.Infof("encrypted credentials of [%v] account(s)", encrypted)
+	}
+
+	workloadRepository := postgres.NewWorkloadRepository(
+		postgresClient,
+		idService,
+		secretCipher,
+		logger,
+	)
+	positionRepository := postgres.NewPositionRepository(
+		postgresClient,
+		idService,
+	)
+
+	instanceID := config.Cluster.InstanceID
+	if len(instanceID) == 0 {
+		instanceID, err = os.Hostname()
+		if err != nil {
+			logger.Fatalf("could not determine instance ID: [%v]", err)
+		}
+	}
+
+	candleRepositoryFactory, err := newCandleRepositoryFactory(
 		ctx,
-		postgres.NewWorkloadRepository(postgresClient, idService),
+		&config.Candles,
+		postgresClient,
+		logger,
+	)
+	if err != nil {
+		logger.Fatalf("could not configure candles storage: [%v]", err)
+	}
+
+	strategyRegistry := strategy.NewRegistry(logger)
+	if len(config.Strategies.Dir) > 0 {
+		if err := strategyRegistry.LoadDir(config.Strategies.Dir); err != nil {
+			logger.Fatalf("could not load strategies: [%v]", err)
+		}
+	}
+	logger.Infof("available strategies: [%v]", strategyRegistry.Strategies())
+
+	supervisorConfig := trading.WorkloadSupervisorConfig{
+		RestartBackoff:         config.Supervisor.RestartBackoff,
+		MaxRestartBackoff:      config.Supervisor.MaxRestartBackoff,
+		MaxConsecutiveFailures: config.Supervisor.MaxConsecutiveFailures,
+	}
+	if err := supervisorConfig.Validate(); err != nil {
+		logger.Fatalf("invalid supervisor config: [%v]", err)
+	}
+
+	metrics := prometheus.NewMetrics()
+	eventService := pubsub.NewEventService(pubsubClient, logger)
+
+	workloadController := trading.RunWorkloadController(
+		ctx,
+		workloadRepository,
 		idService,
-		&exchangeConnector{},
-		inmem.NewCandleRepository(trading.CandleWindowSize),
-		techan.NewSignalGenerator(logger),
-		postgres.NewPositionRepository(postgresClient, idService),
+		newExchangeConnector(
+			postgres.NewPaperWalletRepository(postgresClient),
+			&config.Paper,
+		),
+		trading.NewMarketDataHub(
+			ctx,
+			candleRepositoryFactory,
+			&trading.SystemClock{},
+			metrics,
+			logger,
+		),
+		strategyRegistry,
+		positionRepository,
 		postgres.NewOrderRepository(postgresClient, idService),
-		pubsub.NewEventService(pubsubClient, logger),
+		eventService,
+		&trading.SystemClock{},
+		metrics,
+		supervisorConfig,
+		trading.NewWorkloadLeaser(
+			instanceID,
+			postgres.NewLeaseRepository(postgresClient, idService),
+			&trading.SystemClock{},
+			logger,
+		),
 		logger,
 	)
 
-	<-ctx.Done()
+	handler := http.NewServeMux()
+	handler.Handle("/metrics", metrics.Handler())
+
+	probeServer := rest.NewProbeServer(
+		workloadController,
+		map[string]trading.HealthChecker{
+			"postgres": postgresClient,
+			"pubsub":   eventService,
+		},
+		logger,
+	)
+	handler.Handle("/healthz", probeServer)
+	handler.Handle("/readyz", probeServer)
+	handler.Handle("/status", probeServer)
+
+	if len(config.API.AuthToken) > 0 {
+		handler.Handle("/v1/", rest.NewServer(
+			accountRepository,
+			workloadRepository,
+			positionRepository,
+			strategyRegistry,
+			idService,
+			config.API.AuthToken,
+			logger,
+		))
+	} else {
+		logger.Warningf("API auth token not set; admin API is disabled")
+	}
+
+	go runHTTPServer(ctx, config.API.Address, handler, logger)
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+
+	receivedSignal := <-signalChan
+	logger.Infof("received [%v] signal; shutting down", receivedSignal)
+
+	shutdownCtx, cancelShutdownCtx := context.WithTimeout(
+		context.Background(),
+		config.Shutdown.Timeout,
+	)
+	defer cancelShutdownCtx()
+
+	if err := workloadController.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("could not shutdown workloads gracefully: [%v]", err)
+	}
+
+	if err := eventService.Flush(shutdownCtx); err != nil {
+		logger.Errorf("could not flush events: [%v]", err)
+	}
+
+	logger.Infof("shutdown completed")
 }
 
 func connectPostgres(
@@ -90,17 +235,3 @@ func connectPostgres(
 
 	return client, nil
 }
-
-type exchangeConnector struct{}
-
-func (ec *exchangeConnector) Connect(
-	ctx context.Context,
-	workload *trading.Workload,
-) (trading.ExchangeService, error) {
-	switch workload.Account.Exchange {
-	case "BINANCE":
-		return binance.NewExchangeService(ctx, workload)
-	default:
-		panic("unknown exchange")
-	}
-}
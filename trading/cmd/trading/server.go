@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/http"
+	"time"
+)
+
+const (
+	httpReadTimeout     = 30 * time.Second
+	httpWriteTimeout    = 30 * time.Second
+	httpShutdownTimeout = 10 * time.Second
+)
+
+// runHTTPServer serves the given handler until the context is done.
+func runHTTPServer(
+	ctx context.Context,
+	address string,
+	handler http.Handler,
+	logger trading.Logger,
+) {
+	server := &http.Server{
+		Addr:         address,
+		Handler:      handler,
+		ReadTimeout:  httpReadTimeout,
+		WriteTimeout: httpWriteTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancelShutdownCtx := context.WithTimeout(
+			context.Background(),
+			httpShutdownTimeout,
+		)
+		defer cancelShutdownCtx()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Errorf("could not shutdown HTTP server: [%v]", err)
+		}
+	}()
+
+	logger.Infof("serving HTTP on [%v]", address)
+
+	err := server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		logger.Fatalf("could not serve HTTP: [%v]", err)
+	}
+}
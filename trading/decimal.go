@@ -0,0 +1,190 @@
+package trading
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"math"
+	"math/big"
+)
+
+// DecimalDivisionPrecision is the number of decimal places kept by
+// Decimal.Div when the exact quotient cannot be represented.
+const DecimalDivisionPrecision = 16
+
+// Decimal is an exact decimal number used for all prices, sizes and balances.
+// Addition, subtraction and multiplication are exact. Division keeps
+// DecimalDivisionPrecision decimal places and rounds half away from zero.
+// The zero value is a valid decimal equal to 0.
+type Decimal struct {
+	value decimal.Decimal
+}
+
+// NewDecimal returns a decimal equal to value * 10^exp.
+func NewDecimal(value int64, exp int32) Decimal {
+	return Decimal{decimal.New(value, exp)}
+}
+
+// NewDecimalFromBigInt returns a decimal equal to value * 10^exp.
+func NewDecimalFromBigInt(value *big.Int, exp int32) Decimal {
+	return Decimal{decimal.NewFromBigInt(value, exp)}
+}
+
+// ParseDecimal parses a decimal from its string representation, for
+// example "123.456" or "-0.001". Scientific notation is accepted as well.
+func ParseDecimal(value string) (Decimal, error) {
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return Decimal{}, fmt.Errorf(
+			"could not parse decimal [%v]: [%v]",
+			value,
+			err,
+		)
+	}
+
+	return Decimal{parsed}, nil
+}
+
+// MustParseDecimal works like ParseDecimal but panics on error. It should be
+// used only for values known to be correct, like constants.
+func MustParseDecimal(value string) Decimal {
+	parsed, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{d.value.Add(other.value)}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{d.value.Sub(other.value)}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{d.value.Mul(other.value)}
+}
+
+// Div returns the quotient rounded half away from zero to
+// DecimalDivisionPrecision decimal places. It panics if other is zero.
+func (d Decimal) Div(other Decimal) Decimal {
+	return Decimal{d.value.DivRound(other.value, DecimalDivisionPrecision)}
+}
+
+// Sqrt returns the square root rounded half away from zero to
+// DecimalDivisionPrecision decimal places. It panics if the decimal is
+// negative.
+func (d Decimal) Sqrt() Decimal {
+	if d.Sign() < 0 {
+		panic(fmt.Sprintf("square root of negative decimal [%v]", d))
+	}
+
+	if d.IsZero() {
+		return Decimal{}
+	}
+
+	// Newton's iterations refine the float64 estimate using a few extra
+	// places so the result is correctly rounded.
+	precision := int32(DecimalDivisionPrecision + 10)
+	two := decimal.New(2, 0)
+	tolerance := decimal.New(1, -precision+2)
+
+	root := decimal.NewFromFloat(math.Sqrt(d.Float64()))
+	for i := 0; i < 100; i++ {
+		next := root.Add(d.value.DivRound(root, precision)).
+			DivRound(two, precision)
+
+		if next.Sub(root).Abs().LessThan(tolerance) {
+			root = next
+			break
+		}
+
+		root = next
+	}
+
+	return Decimal{root.Round(DecimalDivisionPrecision)}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{d.value.Neg()}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{d.value.Abs()}
+}
+
+// Sign returns -1, 0 or 1 if the decimal is negative, zero or positive.
+func (d Decimal) Sign() int {
+	return d.value.Sign()
+}
+
+// Cmp returns -1, 0 or 1 if the decimal is less than, equal to or greater
+// than the other one.
+func (d Decimal) Cmp(other Decimal) int {
+	return d.value.Cmp(other.value)
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.value.Equal(other.value)
+}
+
+func (d Decimal) IsZero() bool {
+	return d.value.IsZero()
+}
+
+// Round rounds the decimal to the given number of decimal places, half away
+// from zero. For example, 1.25 becomes 1.3 and -1.25 becomes -1.3 when
+// rounded to one place.
+func (d Decimal) Round(places int32) Decimal {
+	return Decimal{d.value.Round(places)}
+}
+
+// RoundDown truncates the decimal to the given number of decimal places,
+// towards zero. For example, 1.29 becomes 1.2 and -1.29 becomes -1.2 when
+// rounded to one place.
+func (d Decimal) RoundDown(places int32) Decimal {
+	return Decimal{d.value.RoundDown(places)}
+}
+
+// String returns the shortest exact representation of the decimal, without
+// trailing zeros and exponent, for example "0.0015" or "120".
+func (d Decimal) String() string {
+	return d.value.String()
+}
+
+// StringFixed returns the decimal rounded half away from zero to the given
+// number of decimal places, padded with trailing zeros if needed,
+// for example "1.50" for 1.5 and two places.
+func (d Decimal) StringFixed(places int32) string {
+	return d.value.StringFixed(places)
+}
+
+// Float64 returns the nearest float64 value. The result may be inexact
+// so it should be used only for statistics and presentation.
+func (d Decimal) Float64() float64 {
+	value, _ := d.value.Float64()
+	return value
+}
+
+// BigInt returns the coefficient and exponent of the decimal, such that
+// the decimal is equal to coefficient * 10^exponent.
+func (d Decimal) BigInt() (*big.Int, int32) {
+	return d.value.Coefficient(), d.value.Exponent()
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
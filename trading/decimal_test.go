@@ -0,0 +1,218 @@
+package trading
+
+import (
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]struct {
+		value       string
+		expected    string
+		expectedErr bool
+	}{
+		"integer":               {"120", "120", false},
+		"fraction":              {"0.0015", "0.0015", false},
+		"trailing zeros":        {"1.500000", "1.5", false},
+		"negative":              {"-34.01", "-34.01", false},
+		"scientific notation":   {"1.5e-3", "0.0015", false},
+		"binance price":         {"35234.12000000", "35234.12", false},
+		"beyond float64 digits": {"0.12345678901234567890123", "0.12345678901234567890123", false},
+		"empty":                 {"", "", true},
+		"invalid":               {"1.2.3", "", true},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual, err := ParseDecimal(test.value)
+
+			if test.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error for value [%v]", test.value)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertDecimalString(t, test.expected, actual.String())
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a := MustParseDecimal("0.1")
+	b := MustParseDecimal("0.2")
+
+	// The classic float64 pitfall must not happen.
+	assertDecimalString(t, "0.3", a.Add(b).String())
+	assertDecimalString(t, "-0.1", a.Sub(b).String())
+	assertDecimalString(t, "0.02", a.Mul(b).String())
+	assertDecimalString(t, "0.5", a.Div(b).String())
+}
+
+func TestDecimal_Div(t *testing.T) {
+	tests := map[string]struct {
+		dividend string
+		divisor  string
+		expected string
+	}{
+		"exact":               {"10", "4", "2.5"},
+		"precision exhausted": {"1", "3", "0.3333333333333333"},
+		"rounded up":          {"2", "3", "0.6666666666666667"},
+		"negative rounded":    {"-2", "3", "-0.6666666666666667"},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual := MustParseDecimal(test.dividend).Div(
+				MustParseDecimal(test.divisor),
+			)
+
+			assertDecimalString(t, test.expected, actual.String())
+		})
+	}
+}
+
+func TestDecimal_Sqrt(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected string
+	}{
+		"zero":           {"0", "0"},
+		"perfect square": {"6.25", "2.5"},
+		"small fraction": {"0.0004", "0.02"},
+		"irrational":     {"2", "1.414213562373095"},
+		"rounded up":     {"3", "1.7320508075688773"},
+		"large":          {"12345678901234567890", "3513641828.8201442530936542"},
+		"beyond float64": {"1.0000000000000004", "1.0000000000000002"},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual := MustParseDecimal(test.value).Sqrt()
+
+			assertDecimalString(t, test.expected, actual.String())
+		})
+	}
+}
+
+func TestDecimal_Rounding(t *testing.T) {
+	tests := map[string]struct {
+		value             string
+		places            int32
+		expectedRound     string
+		expectedRoundDown string
+	}{
+		"half":                 {"1.25", 1, "1.3", "1.2"},
+		"negative half":        {"-1.25", 1, "-1.3", "-1.2"},
+		"below half":           {"1.24", 1, "1.2", "1.2"},
+		"above half":           {"1.29", 1, "1.3", "1.2"},
+		"negative above half":  {"-1.29", 1, "-1.3", "-1.2"},
+		"fewer digits":         {"1.2", 4, "1.2", "1.2"},
+		"integer places":       {"1234.5", 0, "1235", "1234"},
+		"negative places":      {"1250", -2, "1300", "1200"},
+		"many fraction digits": {"0.123456789", 4, "0.1235", "0.1234"},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			value := MustParseDecimal(test.value)
+
+			assertDecimalString(
+				t,
+				test.expectedRound,
+				value.Round(test.places).String(),
+			)
+			assertDecimalString(
+				t,
+				test.expectedRoundDown,
+				value.RoundDown(test.places).String(),
+			)
+		})
+	}
+}
+
+func TestDecimal_StringFixed(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		places   int32
+		expected string
+	}{
+		"padded":        {"1.5", 2, "1.50"},
+		"rounded":       {"1.005", 2, "1.01"},
+		"negative":      {"-1.005", 2, "-1.01"},
+		"integer":       {"42", 0, "42"},
+		"integer pad":   {"42", 3, "42.000"},
+		"zero value":    {"0", 2, "0.00"},
+		"small":         {"0.00001", 4, "0.0000"},
+		"rounded carry": {"9.999", 2, "10.00"},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual := MustParseDecimal(test.value).StringFixed(test.places)
+
+			assertDecimalString(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDecimal_ZeroValue(t *testing.T) {
+	var zero Decimal
+
+	if !zero.IsZero() || zero.Sign() != 0 {
+		t.Errorf("zero value decimal is not zero")
+	}
+
+	assertDecimalString(t, "0", zero.String())
+	assertDecimalString(t, "1", zero.Add(NewDecimal(1, 0)).String())
+}
+
+func TestDecimal_BigIntRoundTrip(t *testing.T) {
+	value := MustParseDecimal("-123456789.000000000123456789")
+
+	coefficient, exponent := value.BigInt()
+	actual := NewDecimalFromBigInt(coefficient, exponent)
+
+	if !actual.Equal(value) {
+		t.Errorf(
+			"unexpected decimal\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			value,
+			actual,
+		)
+	}
+}
+
+func TestDecimal_Text(t *testing.T) {
+	value := MustParseDecimal("0.00012300")
+
+	text, err := value.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertDecimalString(t, "0.000123", string(text))
+
+	var actual Decimal
+	if err := actual.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+
+	assertDecimalString(t, "0.000123", actual.String())
+}
+
+func assertDecimalString(t *testing.T, expected, actual string) {
+	if expected != actual {
+		t.Errorf(
+			"unexpected decimal\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expected,
+			actual,
+		)
+	}
+}
@@ -0,0 +1,252 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"io"
+	"strings"
+)
+
+const (
+	ciphertextPrefix = "enc:v2:"
+	// Legacy values are not bound to associated data.
+	legacyCiphertextPrefix = "enc:v1:"
+
+	KeySize = 32
+)
+
+// Cipher implements envelope encryption using AES-256-GCM. Each value
+// is encrypted using a fresh data key which is then encrypted using
+// the primary master key. The ciphertext has the following format:
+//
+//	enc:v2:<master key ID>:<encrypted data key>:<encrypted value>
+//
+// where the encrypted parts are base64 encoded and prefixed with their
+// GCM nonces. The value is authenticated along with the associated data
+// which is not a part of the ciphertext.
+type Cipher struct {
+	keyProvider trading.KeyProvider
+}
+
+func NewCipher(keyProvider trading.KeyProvider) *Cipher {
+	return &Cipher{keyProvider}
+}
+
+func (c *Cipher) Encrypt(plaintext, associatedData string) (string, error) {
+	masterKey, err := c.keyProvider.PrimaryKey()
+	if err != nil {
+		return "", fmt.Errorf("could not get primary master key: [%v]", err)
+	}
+
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("could not generate data key: [%v]", err)
+	}
+
+	encryptedDataKey, err := seal(masterKey.Material, dataKey, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not encrypt data key: [%v]", err)
+	}
+
+	encryptedValue, err := seal(
+		dataKey,
+		[]byte(plaintext),
+		[]byte(associatedData),
+	)
+	if err != nil {
+		return "", fmt.Errorf("could not encrypt value: [%v]", err)
+	}
+
+	return ciphertextPrefix + strings.Join(
+		[]string{
+			masterKey.ID,
+			base64.StdEncoding.EncodeToString(encryptedDataKey),
+			base64.StdEncoding.EncodeToString(encryptedValue),
+		},
+		":",
+	), nil
+}
+
+func (c *Cipher) Decrypt(ciphertext, associatedData string) (string, error) {
+	parsed, err := parseCiphertext(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	masterKey, err := c.keyProvider.Key(parsed.masterKeyID)
+	if err != nil {
+		return "", fmt.Errorf(
+			"could not get master key [%v]: [%v]",
+			parsed.masterKeyID,
+			err,
+		)
+	}
+
+	dataKey, err := open(masterKey.Material, parsed.encryptedDataKey, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt data key: [%v]", err)
+	}
+
+	// Legacy values are not bound to any associated data.
+	if parsed.legacy {
+		associatedData = ""
+	}
+
+	plaintext, err := open(
+		dataKey,
+		parsed.encryptedValue,
+		[]byte(associatedData),
+	)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt value: [%v]", err)
+	}
+
+	return string(plaintext), nil
+}
+
+func (c *Cipher) Reencrypt(
+	value, associatedData string,
+) (string, bool, error) {
+	if !IsEncrypted(value) {
+		ciphertext, err := c.Encrypt(value, associatedData)
+		return ciphertext, err == nil, err
+	}
+
+	parsed, err := parseCiphertext(value)
+	if err != nil {
+		return "", false, err
+	}
+
+	primaryKey, err := c.keyProvider.PrimaryKey()
+	if err != nil {
+		return "", false, fmt.Errorf(
+			"could not get primary master key: [%v]",
+			err,
+		)
+	}
+
+	if !parsed.legacy && parsed.masterKeyID == primaryKey.ID {
+		return value, false, nil
+	}
+
+	plaintext, err := c.Decrypt(value, associatedData)
+	if err != nil {
+		return "", false, err
+	}
+
+	ciphertext, err := c.Encrypt(plaintext, associatedData)
+	if err != nil {
+		return "", false, err
+	}
+
+	return ciphertext, true, nil
+}
+
+func (c *Cipher) IsEncrypted(value string) bool {
+	return IsEncrypted(value)
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, ciphertextPrefix) ||
+		strings.HasPrefix(value, legacyCiphertextPrefix)
+}
+
+type parsedCiphertext struct {
+	legacy           bool
+	masterKeyID      string
+	encryptedDataKey []byte
+	encryptedValue   []byte
+}
+
+func parseCiphertext(ciphertext string) (*parsedCiphertext, error) {
+	if !IsEncrypted(ciphertext) {
+		return nil, fmt.Errorf("value is not encrypted")
+	}
+
+	legacy := strings.HasPrefix(ciphertext, legacyCiphertextPrefix)
+
+	prefix := ciphertextPrefix
+	if legacy {
+		prefix = legacyCiphertextPrefix
+	}
+
+	parts := strings.Split(strings.TrimPrefix(ciphertext, prefix), ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed ciphertext")
+	}
+
+	encryptedDataKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed data key: [%v]", err)
+	}
+
+	encryptedValue, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed value: [%v]", err)
+	}
+
+	return &parsedCiphertext{
+		legacy:           legacy,
+		masterKeyID:      parts[0],
+		encryptedDataKey: encryptedDataKey,
+		encryptedValue:   encryptedValue,
+	}, nil
+}
+
+// seal encrypts the plaintext and prepends the random nonce used
+// for encryption. The associated data is authenticated but not
+// encrypted.
+func seal(key, plaintext, associatedData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(key, ciphertext, associatedData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce := ciphertext[:aead.NonceSize()]
+
+	return aead.Open(
+		nil,
+		nonce,
+		ciphertext[aead.NonceSize():],
+		associatedData,
+	)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf(
+			"key must be [%v] bytes long; got [%v]",
+			KeySize,
+			len(key),
+		)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,347 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"strings"
+	"testing"
+)
+
+func TestCipher_RoundTrip(t *testing.T) {
+	cipher := NewCipher(newTestKeyProvider(t, "k1", "k1"))
+
+	values := []string{"", "api-key", strings.Repeat("secret", 100)}
+
+	for _, value := range values {
+		ciphertext, err := cipher.Encrypt(value, testAssociatedData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(value) > 0 && strings.Contains(ciphertext, value) {
+			t.Errorf("ciphertext contains the plaintext")
+		}
+
+		if !strings.HasPrefix(ciphertext, "enc:v2:k1:") {
+			t.Errorf("unexpected ciphertext format: [%v]", ciphertext)
+		}
+
+		plaintext, err := cipher.Decrypt(ciphertext, testAssociatedData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if plaintext != value {
+			t.Errorf(
+				"unexpected plaintext\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				value,
+				plaintext,
+			)
+		}
+	}
+}
+
+func TestCipher_EncryptUsesFreshDataKeys(t *testing.T) {
+	cipher := NewCipher(newTestKeyProvider(t, "k1", "k1"))
+
+	first, err := cipher.Encrypt("secret", testAssociatedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := cipher.Encrypt("secret", testAssociatedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Errorf("equal plaintexts produced equal ciphertexts")
+	}
+}
+
+func TestCipher_DecryptInvalid(t *testing.T) {
+	cipher := NewCipher(newTestKeyProvider(t, "k1", "k1"))
+
+	ciphertext, err := cipher.Encrypt("secret", testAssociatedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(ciphertext, ":")
+	tampered := []byte(parts[4])
+	tampered[len(tampered)-3] ^= 1
+	parts[4] = string(tampered)
+
+	otherCipher := NewCipher(newTestKeyProvider(t, "k2", "k2"))
+
+	tests := map[string]struct {
+		cipher         *Cipher
+		ciphertext     string
+		associatedData string
+	}{
+		"plaintext": {
+			cipher:         cipher,
+			ciphertext:     "secret",
+			associatedData: testAssociatedData,
+		},
+		"malformed": {
+			cipher:         cipher,
+			ciphertext:     "enc:v2:k1:abc",
+			associatedData: testAssociatedData,
+		},
+		"tampered": {
+			cipher:         cipher,
+			ciphertext:     strings.Join(parts, ":"),
+			associatedData: testAssociatedData,
+		},
+		"unknown master key": {
+			cipher:         otherCipher,
+			ciphertext:     ciphertext,
+			associatedData: testAssociatedData,
+		},
+		"other associated data": {
+			cipher:         cipher,
+			ciphertext:     ciphertext,
+			associatedData: "account:2:exchange_api_key",
+		},
+		"downgraded to legacy format": {
+			cipher: cipher,
+			ciphertext: strings.Replace(
+				ciphertext,
+				ciphertextPrefix,
+				legacyCiphertextPrefix,
+				1,
+			),
+			associatedData: testAssociatedData,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if _, err := test.cipher.Decrypt(
+				test.ciphertext,
+				test.associatedData,
+			); err == nil {
+				t.Errorf("expected decryption error")
+			}
+		})
+	}
+}
+
+func TestCipher_Reencrypt(t *testing.T) {
+	oldCipher := NewCipher(newTestKeyProvider(t, "k1", "k1"))
+
+	oldCiphertext, err := oldCipher.Encrypt("secret", testAssociatedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := newTestKeyProvider(t, "k2", "k1", "k2")
+	cipher := NewCipher(provider)
+
+	currentCiphertext, err := cipher.Encrypt("secret", testAssociatedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primaryKey, err := provider.PrimaryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacyCiphertext := encryptLegacy(t, primaryKey, "secret")
+
+	tests := map[string]struct {
+		value           string
+		expectedChanged bool
+	}{
+		"plaintext": {
+			value:           "secret",
+			expectedChanged: true,
+		},
+		"encrypted with old key": {
+			value:           oldCiphertext,
+			expectedChanged: true,
+		},
+		"encrypted with primary key": {
+			value:           currentCiphertext,
+			expectedChanged: false,
+		},
+		// Legacy values are not bound to associated data.
+		"encrypted in legacy format": {
+			value:           legacyCiphertext,
+			expectedChanged: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ciphertext, changed, err := cipher.Reencrypt(
+				test.value,
+				testAssociatedData,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if changed != test.expectedChanged {
+				t.Errorf(
+					"unexpected changed flag\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedChanged,
+					changed,
+				)
+			}
+
+			if !strings.HasPrefix(ciphertext, "enc:v2:k2:") {
+				t.Errorf("value not encrypted with primary key")
+			}
+
+			plaintext, err := cipher.Decrypt(ciphertext, testAssociatedData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if plaintext != "secret" {
+				t.Errorf("unexpected plaintext: [%v]", plaintext)
+			}
+		})
+	}
+}
+
+func TestParseMasterKeys(t *testing.T) {
+	k1, err := GenerateMasterKey("k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k2, err := GenerateMasterKey("k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := ParseMasterKeys(k1 + ",\n " + k2 + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 || keys[0].ID != "k1" || keys[1].ID != "k2" {
+		t.Fatalf("unexpected keys: [%v]", keys)
+	}
+
+	provider, err := NewLocalKeyProvider("", keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primaryKey, err := provider.PrimaryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if primaryKey.ID != "k1" {
+		t.Errorf("first key is not primary")
+	}
+
+	if _, err := provider.Key("k3"); err != trading.ErrNotFound {
+		t.Errorf("unexpected error for unknown key: [%v]", err)
+	}
+}
+
+func TestNewLocalKeyProvider_Invalid(t *testing.T) {
+	validKey := &trading.MasterKey{
+		ID:       "k1",
+		Material: bytes.Repeat([]byte{1}, KeySize),
+	}
+
+	tests := map[string]struct {
+		primaryKeyID string
+		keys         []*trading.MasterKey
+	}{
+		"no keys": {},
+		"short key": {
+			keys: []*trading.MasterKey{{
+				ID:       "k1",
+				Material: bytes.Repeat([]byte{1}, 16),
+			}},
+		},
+		"invalid key id": {
+			keys: []*trading.MasterKey{{
+				ID:       "k:1",
+				Material: validKey.Material,
+			}},
+		},
+		"duplicated key": {
+			keys: []*trading.MasterKey{validKey, validKey},
+		},
+		"unknown primary key": {
+			primaryKeyID: "k2",
+			keys:         []*trading.MasterKey{validKey},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := NewLocalKeyProvider(test.primaryKeyID, test.keys...)
+			if err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+const testAssociatedData = "account:1:exchange_api_key"
+
+// encryptLegacy encrypts the value without associated data, as the legacy
+// format does.
+func encryptLegacy(
+	t *testing.T,
+	masterKey *trading.MasterKey,
+	plaintext string,
+) string {
+	dataKey := bytes.Repeat([]byte{9}, KeySize)
+
+	encryptedDataKey, err := seal(masterKey.Material, dataKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedValue, err := seal(dataKey, []byte(plaintext), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return legacyCiphertextPrefix + strings.Join(
+		[]string{
+			masterKey.ID,
+			base64.StdEncoding.EncodeToString(encryptedDataKey),
+			base64.StdEncoding.EncodeToString(encryptedValue),
+		},
+		":",
+	)
+}
+
+func newTestKeyProvider(
+	t *testing.T,
+	primaryKeyID string,
+	keyIDs ...string,
+) *LocalKeyProvider {
+	keys := make([]*trading.MasterKey, 0)
+	for i, keyID := range keyIDs {
+		keys = append(keys, &trading.MasterKey{
+			ID:       keyID,
+			Material: bytes.Repeat([]byte{byte(i + 1)}, KeySize),
+		})
+	}
+
+	provider, err := NewLocalKeyProvider(primaryKeyID, keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return provider
+}
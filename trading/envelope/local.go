@@ -0,0 +1,175 @@
+package envelope
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// LocalKeyProvider holds master keys in memory. Keys are usually passed
+// through an environment variable or a file mounted from a secret store.
+//
+// Keys are rotated by adding a new key, making it primary and
+// re-encrypting all stored values, e.g. using `dexlyctl account
+// rotate-keys`. Old keys must be kept until all values encrypted with
+// them are re-encrypted.
+type LocalKeyProvider struct {
+	keys         map[string]*trading.MasterKey
+	primaryKeyID string
+}
+
+// NewLocalKeyProvider creates a provider holding the given keys. If the
+// primary key ID is empty, the first key becomes primary.
+func NewLocalKeyProvider(
+	primaryKeyID string,
+	keys ...*trading.MasterKey,
+) (*LocalKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no master keys provided")
+	}
+
+	if len(primaryKeyID) == 0 {
+		primaryKeyID = keys[0].ID
+	}
+
+	keysByID := make(map[string]*trading.MasterKey)
+
+	for _, key := range keys {
+		if err := validateMasterKey(key); err != nil {
+			return nil, err
+		}
+
+		if _, exists := keysByID[key.ID]; exists {
+			return nil, fmt.Errorf("duplicated master key [%v]", key.ID)
+		}
+
+		keysByID[key.ID] = key
+	}
+
+	if _, exists := keysByID[primaryKeyID]; !exists {
+		return nil, fmt.Errorf("unknown primary master key [%v]", primaryKeyID)
+	}
+
+	return &LocalKeyProvider{
+		keys:         keysByID,
+		primaryKeyID: primaryKeyID,
+	}, nil
+}
+
+// LoadLocalKeyProvider creates a provider holding keys passed as an
+// encoded string and keys read from the file. Both sources use the
+// ParseMasterKeys format and each of them can be empty.
+func LoadLocalKeyProvider(
+	encodedKeys string,
+	keysFile string,
+	primaryKeyID string,
+) (*LocalKeyProvider, error) {
+	keys, err := ParseMasterKeys(encodedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keysFile) > 0 {
+		content, err := ioutil.ReadFile(keysFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read master keys file: [%v]", err)
+		}
+
+		fileKeys, err := ParseMasterKeys(string(content))
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, fileKeys...)
+	}
+
+	return NewLocalKeyProvider(primaryKeyID, keys...)
+}
+
+func (lkp *LocalKeyProvider) PrimaryKey() (*trading.MasterKey, error) {
+	return lkp.keys[lkp.primaryKeyID], nil
+}
+
+func (lkp *LocalKeyProvider) Key(keyID string) (*trading.MasterKey, error) {
+	key, exists := lkp.keys[keyID]
+	if !exists {
+		return nil, trading.ErrNotFound
+	}
+
+	return key, nil
+}
+
+// ParseMasterKeys parses master keys encoded as `<id>:<base64 key>`
+// entries separated by commas or new lines.
+func ParseMasterKeys(encoded string) ([]*trading.MasterKey, error) {
+	keys := make([]*trading.MasterKey, 0)
+
+	entries := strings.FieldsFunc(encoded, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("master key entry must be <id>:<key>")
+		}
+
+		material, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not decode master key [%v]: [%v]",
+				parts[0],
+				err,
+			)
+		}
+
+		keys = append(keys, &trading.MasterKey{
+			ID:       parts[0],
+			Material: material,
+		})
+	}
+
+	return keys, nil
+}
+
+// GenerateMasterKey returns a new random master key encoded in the
+// ParseMasterKeys format.
+func GenerateMasterKey(keyID string) (string, error) {
+	key := &trading.MasterKey{ID: keyID, Material: make([]byte, KeySize)}
+
+	if _, err := io.ReadFull(rand.Reader, key.Material); err != nil {
+		return "", fmt.Errorf("could not generate master key: [%v]", err)
+	}
+
+	if err := validateMasterKey(key); err != nil {
+		return "", err
+	}
+
+	return key.ID + ":" + base64.StdEncoding.EncodeToString(key.Material), nil
+}
+
+func validateMasterKey(key *trading.MasterKey) error {
+	if len(key.ID) == 0 || strings.ContainsAny(key.ID, ":, \n") {
+		return fmt.Errorf("invalid master key ID [%v]", key.ID)
+	}
+
+	if len(key.Material) != KeySize {
+		return fmt.Errorf(
+			"master key [%v] must be [%v] bytes long; got [%v]",
+			key.ID,
+			KeySize,
+			len(key.Material),
+		)
+	}
+
+	return nil
+}
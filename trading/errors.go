@@ -0,0 +1,36 @@
+package trading
+
+import (
+	"errors"
+)
+
+// ErrNotFound is returned by repositories when the requested entity
+// does not exist.
+var ErrNotFound = errors.New("not found")
+
+// FatalError marks errors which won't be resolved by retrying the failed
+// operation, e.g. exchange credentials rejected by the exchange or
+// an inconsistent state of stored data. Other errors are considered
+// transient.
+type FatalError struct {
+	Err error
+}
+
+func NewFatalError(err error) error {
+	return &FatalError{err}
+}
+
+func (fe *FatalError) Error() string {
+	return fe.Err.Error()
+}
+
+func (fe *FatalError) Unwrap() error {
+	return fe.Err
+}
+
+// IsFatal tells whether the error or any error it wraps is fatal. Errors
+// must be wrapped using the `%w` verb to retain that information.
+func IsFatal(err error) bool {
+	var fatalError *FatalError
+	return errors.As(err, &fatalError)
+}
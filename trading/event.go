@@ -24,10 +24,10 @@ func NewPositionOpenedEvent(workload *Workload, position *Position) *Event {
 			position.ID.String(),
 			workload.Account.Exchange,
 			string(workload.Pair.Symbol()),
-			position.Size.Text('f', 2),
-			position.EntryPrice.Text('f', 2),
-			position.TakeProfitPrice.Text('f', 2),
-			position.StopLossPrice.Text('f', 2),
+			position.Size.StringFixed(2),
+			position.EntryPrice.StringFixed(2),
+			position.TakeProfitPrice.StringFixed(2),
+			position.StopLossPrice.StringFixed(2),
 		),
 	}
 }
@@ -47,6 +47,24 @@ func NewPositionClosedEvent(workload *Workload, position *Position) *Event {
 	}
 }
 
+func NewWorkloadDisabledEvent(workload *Workload, reason error) *Event {
+	return &Event{
+		Account: workload.Account,
+		Payload: fmt.Sprintf(
+			"Workload has been disabled due to failures:\n"+
+				"- ID: %v\n"+
+				"- Exchange: %v\n"+
+				"- Pair: %v\n"+
+				"- Reason: %v\n"+
+				"Fix the problem and activate the workload again.",
+			workload.ID.String(),
+			workload.Account.Exchange,
+			string(workload.Pair.Symbol()),
+			reason,
+		),
+	}
+}
+
 type EventService interface {
 	Publish(event *Event)
 }
@@ -2,7 +2,6 @@ package trading
 
 import (
 	"context"
-	"math/big"
 	"time"
 )
 
@@ -14,20 +13,43 @@ type ExchangeService interface {
 	ExchangeCandleService
 	ExchangeAccountService
 	ExchangeOrderService
+	ExchangeRulesService
 
 	Workload() *Workload
 }
 
 type ExchangeCandleService interface {
-	Candles(ctx context.Context, start, end time.Time) ([]*Candle, error)
-
-	CandlesTicker(ctx context.Context) (<-chan *CandleTick, <-chan error)
+	// Candles streams candles opened within the given time range. Ranges
+	// exceeding the exchange's page size are fetched page by page. See
+	// StreamCandles for the semantics of the returned channels.
+	Candles(
+		ctx context.Context,
+		interval CandleInterval,
+		start, end time.Time,
+	) (<-chan *Candle, <-chan error)
+
+	// CandlesTicker streams ticks of the current candle. Tickers which
+	// push candles only once a trade happens send heartbeat ticks, so
+	// quiet pairs are not taken for dead tickers.
+	CandlesTicker(
+		ctx context.Context,
+		interval CandleInterval,
+	) (<-chan *CandleTick, <-chan error)
 }
 
 type ExchangeAccountService interface {
-	AccountTakerCommission(ctx context.Context) (*big.Float, error)
+	AccountTakerCommission(ctx context.Context) (Decimal, error)
 
 	AccountBalances(ctx context.Context) (Balances, error)
+
+	AccountShortSellingAllowed(ctx context.Context) (bool, error)
+
+	// AccountUpdates keeps the account and orders state up to date using
+	// updates pushed by the exchange until the context is done. Meanwhile,
+	// account and order methods don't have to poll the exchange. Errors
+	// sent on the returned channel are not fatal as services fall back
+	// to polling when updates are not available.
+	AccountUpdates(ctx context.Context) <-chan error
 }
 
 type ExchangeOrderService interface {
@@ -35,3 +57,7 @@ type ExchangeOrderService interface {
 
 	IsOrderExecuted(ctx context.Context, order *Order) (bool, error)
 }
+
+type ExchangeRulesService interface {
+	TradingRules(ctx context.Context) (*TradingRules, error)
+}
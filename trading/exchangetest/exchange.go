@@ -0,0 +1,241 @@
+// Package exchangetest provides a conformance test suite for exchange
+// service implementations. Adapters serve the stand-in Exchange using
+// the wire protocol of their venue and run the suite against it.
+package exchangetest
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
+	"time"
+)
+
+// Order is an order placed on the stand-in exchange.
+type Order struct {
+	ID     string
+	Side   trading.OrderSide
+	Price  trading.Decimal
+	Size   trading.Decimal
+	Filled bool
+}
+
+// Exchange is an in-memory stand-in of a spot exchange listing a single
+// pair and serving candles of a single interval. Orders are either filled
+// immediately using the close price of the last candle or not filled at
+// all. No commission is charged.
+type Exchange struct {
+	Pair           trading.Pair
+	CandleInterval trading.CandleInterval
+	// CandlesPageSize limits the number of candles returned at once so
+	// the suite verifies fetching ranges page by page.
+	CandlesPageSize int
+	TradingRules    *trading.TradingRules
+	TakerCommission trading.Decimal
+
+	mutex       sync.Mutex
+	candles     []*trading.Candle
+	balances    trading.Balances
+	orders      map[string]*Order
+	subscribers map[chan *trading.CandleTick]bool
+}
+
+// NewExchange creates a stand-in exchange holding the fixture state the
+// suite expects.
+func NewExchange() *Exchange {
+	interval := trading.CandleInterval15m
+	openTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	candles := make([]*trading.Candle, 10)
+	for index := range candles {
+		price := trading.NewDecimal(35000+int64(index)*10, 0)
+
+		candles[index] = &trading.Candle{
+			OpenTime:   openTime,
+			CloseTime:  openTime.Add(interval.Duration() - time.Millisecond),
+			OpenPrice:  price.Sub(trading.NewDecimal(5, 0)),
+			ClosePrice: price,
+			MaxPrice:   price.Add(trading.NewDecimal(125, -1)),
+			MinPrice:   price.Sub(trading.NewDecimal(75, -1)),
+			Volume:     trading.NewDecimal(1234567, -5),
+			TradeCount: uint(100 + index),
+		}
+
+		openTime = openTime.Add(interval.Duration())
+	}
+
+	return &Exchange{
+		Pair:            trading.Pair{Base: "BTC", Quote: "USDT"},
+		CandleInterval:  interval,
+		CandlesPageSize: 4,
+		TradingRules: &trading.TradingRules{
+			PriceTick:   trading.NewDecimal(1, -2),
+			LotStep:     trading.NewDecimal(1, -5),
+			MinQuantity: trading.NewDecimal(1, -5),
+			MinNotional: trading.NewDecimal(10, 0),
+		},
+		TakerCommission: trading.NewDecimal(1, -3),
+		candles:         candles,
+		balances: trading.Balances{
+			"BTC":  trading.NewDecimal(5, -1),
+			"USDT": trading.NewDecimal(1000, 0),
+		},
+		orders:      make(map[string]*Order),
+		subscribers: make(map[chan *trading.CandleTick]bool),
+	}
+}
+
+// Candles returns candles opened within the given time range, up to the
+// page size.
+func (e *Exchange) Candles(start, end time.Time) []*trading.Candle {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	candles := make([]*trading.Candle, 0)
+
+	for _, candle := range e.candles {
+		if candle.OpenTime.Before(start) || candle.OpenTime.After(end) {
+			continue
+		}
+
+		candles = append(candles, candle)
+
+		if len(candles) == e.CandlesPageSize {
+			break
+		}
+	}
+
+	return candles
+}
+
+// Balances returns a copy of the account balances.
+func (e *Exchange) Balances() trading.Balances {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	balances := make(trading.Balances)
+	for asset, balance := range e.balances {
+		balances[asset] = balance
+	}
+
+	return balances
+}
+
+// PlaceOrder places a limit order. An error is returned if the order
+// violates the trading rules, its ID is already used or the account
+// holds insufficient funds.
+func (e *Exchange) PlaceOrder(
+	id string,
+	side trading.OrderSide,
+	price trading.Decimal,
+	size trading.Decimal,
+) (*Order, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if _, exists := e.orders[id]; exists {
+		return nil, fmt.Errorf("duplicate order ID [%v]", id)
+	}
+
+	if err := e.TradingRules.Validate(price, size); err != nil {
+		return nil, err
+	}
+
+	debitedAsset, debitedAmount := e.Pair.Quote, price.Mul(size)
+	creditedAsset, creditedAmount := e.Pair.Base, size
+	if side == trading.SideSell {
+		debitedAsset, debitedAmount = e.Pair.Base, size
+		creditedAsset, creditedAmount = e.Pair.Quote, price.Mul(size)
+	}
+
+	if e.balances.BalanceOf(debitedAsset).Cmp(debitedAmount) < 0 {
+		return nil, fmt.Errorf("insufficient [%v] balance", debitedAsset)
+	}
+
+	order := &Order{
+		ID:    id,
+		Side:  side,
+		Price: price,
+		Size:  size,
+	}
+
+	marketPrice := e.candles[len(e.candles)-1].ClosePrice
+
+	switch side {
+	case trading.SideBuy:
+		order.Filled = marketPrice.Cmp(price) <= 0
+	case trading.SideSell:
+		order.Filled = marketPrice.Cmp(price) >= 0
+	}
+
+	if order.Filled {
+		e.balances[debitedAsset] = e.balances.BalanceOf(debitedAsset).
+			Sub(debitedAmount)
+		e.balances[creditedAsset] = e.balances.BalanceOf(creditedAsset).
+			Add(creditedAmount)
+	}
+
+	e.orders[id] = order
+
+	return order, nil
+}
+
+// Order returns the order of the given ID if it has been placed.
+func (e *Exchange) Order(id string) (*Order, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	order, exists := e.orders[id]
+	return order, exists
+}
+
+// SubscribeCandles returns a channel receiving published candle ticks
+// and a function which cancels the subscription.
+func (e *Exchange) SubscribeCandles() (<-chan *trading.CandleTick, func()) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	tickChannel := make(chan *trading.CandleTick, 10)
+	e.subscribers[tickChannel] = true
+
+	return tickChannel, func() {
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+
+		delete(e.subscribers, tickChannel)
+	}
+}
+
+// PublishCandleTick makes the tick the current market state and sends it
+// to all subscribers.
+func (e *Exchange) PublishCandleTick(tick *trading.CandleTick) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	lastCandle := e.candles[len(e.candles)-1]
+	if lastCandle.Equal(tick.Candle) {
+		e.candles[len(e.candles)-1] = tick.Candle
+	} else {
+		e.candles = append(e.candles, tick.Candle)
+	}
+
+	for subscriber := range e.subscribers {
+		subscriber <- tick
+	}
+}
+
+func (e *Exchange) allCandles() []*trading.Candle {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	candles := make([]*trading.Candle, len(e.candles))
+	copy(candles, e.candles)
+
+	return candles
+}
+
+func (e *Exchange) subscribersCount() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return len(e.subscribers)
+}
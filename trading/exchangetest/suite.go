@@ -0,0 +1,467 @@
+package exchangetest
+
+import (
+	"context"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"testing"
+	"time"
+)
+
+// waitTimeout limits the time the suite waits for asynchronous events.
+const waitTimeout = 5 * time.Second
+
+// ConnectFunc serves the stand-in exchange using the wire protocol of the
+// tested venue and returns a service of the workload connected to it.
+type ConnectFunc func(
+	t *testing.T,
+	exchange *Exchange,
+	workload *trading.Workload,
+) trading.ExchangeService
+
+// Run runs the conformance test suite. Each test gets a fresh stand-in
+// exchange and a service connected to it.
+func Run(t *testing.T, connect ConnectFunc) {
+	tests := map[string]func(*testing.T, *Exchange, trading.ExchangeService){
+		"workload":                   testWorkload,
+		"trading rules":              testTradingRules,
+		"candles":                    testCandles,
+		"candles across pages":       testCandlesAcrossPages,
+		"candles ticker":             testCandlesTicker,
+		"account balances":           testAccountBalances,
+		"account taker commission":   testAccountTakerCommission,
+		"filled order":               testFilledOrder,
+		"not filled order":           testNotFilledOrder,
+		"rejected order":             testRejectedOrder,
+		"execution of unknown order": testUnknownOrderExecution,
+	}
+
+	for testName, test := range tests {
+		test := test
+
+		t.Run(testName, func(t *testing.T) {
+			exchange := NewExchange()
+			workload := newWorkload(exchange)
+
+			test(t, exchange, connect(t, exchange, workload))
+		})
+	}
+}
+
+func newWorkload(exchange *Exchange) *trading.Workload {
+	idService := &uuid.IDService{}
+
+	return &trading.Workload{
+		ID: idService.NewID(),
+		Account: &trading.Account{
+			ID:                idService.NewID(),
+			ExchangeApiKey:    "api-key",
+			ExchangeSecretKey: "c2VjcmV0LWtleQ==",
+		},
+		Pair:                  exchange.Pair,
+		Mode:                  trading.ModeLive,
+		Status:                trading.WorkloadActive,
+		CandleInterval:        exchange.CandleInterval,
+		CandleWindowSize:      trading.DefaultCandleWindowSize,
+		StopLossATRMultiplier: trading.DefaultStopLossATRMultiplier,
+		RewardRiskRatio:       trading.DefaultRewardRiskRatio,
+		Strategy:              trading.DefaultStrategy,
+	}
+}
+
+func newOrder(
+	side trading.OrderSide,
+	price trading.Decimal,
+	size trading.Decimal,
+) *trading.Order {
+	return &trading.Order{
+		ID:    (&uuid.IDService{}).NewID(),
+		Side:  side,
+		Price: price,
+		Size:  size,
+		Time:  time.Now(),
+	}
+}
+
+func testWorkload(
+	t *testing.T,
+	_ *Exchange,
+	service trading.ExchangeService,
+) {
+	if service.Workload() == nil {
+		t.Errorf("service should return its workload")
+	}
+}
+
+func testTradingRules(
+	t *testing.T,
+	exchange *Exchange,
+	service trading.ExchangeService,
+) {
+	tradingRules, err := service.TradingRules(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := exchange.TradingRules
+
+	for name, values := range map[string][2]trading.Decimal{
+		"price tick":   {expected.PriceTick, tradingRules.PriceTick},
+		"lot step":     {expected.LotStep, tradingRules.LotStep},
+		"min quantity": {expected.MinQuantity, tradingRules.MinQuantity},
+		"max quantity": {expected.MaxQuantity, tradingRules.MaxQuantity},
+		"min notional": {expected.MinNotional, tradingRules.MinNotional},
+	} {
+		if !values[0].Equal(values[1]) {
+			t.Errorf(
+				"unexpected %v\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				name,
+				values[0],
+				values[1],
+			)
+		}
+	}
+}
+
+func testCandles(
+	t *testing.T,
+	exchange *Exchange,
+	service trading.ExchangeService,
+) {
+	all := exchange.allCandles()
+	start, end := all[2].OpenTime, all[4].CloseTime
+
+	candles, err := trading.CollectCandles(
+		service.Candles(
+			context.Background(),
+			exchange.CandleInterval,
+			start,
+			end,
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertCandles(t, all[2:5], candles)
+}
+
+func testCandlesAcrossPages(
+	t *testing.T,
+	exchange *Exchange,
+	service trading.ExchangeService,
+) {
+	all := exchange.allCandles()
+	start, end := all[0].OpenTime, all[len(all)-1].CloseTime
+
+	if len(all) <= 2*exchange.CandlesPageSize {
+		t.Fatalf("fixture should span at least three pages")
+	}
+
+	candles, err := trading.CollectCandles(
+		service.Candles(
+			context.Background(),
+			exchange.CandleInterval,
+			start,
+			end,
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertCandles(t, all, candles)
+}
+
+func testCandlesTicker(
+	t *testing.T,
+	exchange *Exchange,
+	service trading.ExchangeService,
+) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	tickChannel, errorChannel := service.CandlesTicker(
+		ctx,
+		exchange.CandleInterval,
+	)
+
+	deadline := time.Now().Add(waitTimeout)
+	for exchange.subscribersCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("ticker has not subscribed candles")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	interval := exchange.CandleInterval.Duration()
+	all := exchange.allCandles()
+	lastCandle := all[len(all)-1]
+	openTime := lastCandle.OpenTime.Add(interval)
+
+	tick := &trading.CandleTick{
+		Candle: &trading.Candle{
+			OpenTime:   openTime,
+			CloseTime:  openTime.Add(interval - time.Millisecond),
+			OpenPrice:  trading.MustParseDecimal("35090"),
+			ClosePrice: trading.MustParseDecimal("35101.5"),
+			MaxPrice:   trading.MustParseDecimal("35110.25"),
+			MinPrice:   trading.MustParseDecimal("35085"),
+			Volume:     trading.MustParseDecimal("0.75"),
+			TradeCount: 42,
+		},
+		TickTime: openTime.Add(15 * time.Second),
+	}
+
+	exchange.PublishCandleTick(tick)
+
+	timeout := time.After(waitTimeout)
+
+	for {
+		select {
+		case actualTick := <-tickChannel:
+			// Heartbeats could be sent before the published tick.
+			if actualTick.IsHeartbeat() {
+				continue
+			}
+
+			assertCandles(
+				t,
+				[]*trading.Candle{tick.Candle},
+				[]*trading.Candle{actualTick.Candle},
+			)
+
+			if !actualTick.TickTime.Equal(tick.TickTime) {
+				t.Errorf(
+					"unexpected tick time\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					tick.TickTime,
+					actualTick.TickTime,
+				)
+			}
+
+			return
+		case err := <-errorChannel:
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatalf("tick has not been received")
+		}
+	}
+}
+
+func testAccountBalances(
+	t *testing.T,
+	exchange *Exchange,
+	service trading.ExchangeService,
+) {
+	balances, err := service.AccountBalances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertBalances(t, exchange.Balances(), balances)
+}
+
+func testAccountTakerCommission(
+	t *testing.T,
+	exchange *Exchange,
+	service trading.ExchangeService,
+) {
+	takerCommission, err := service.AccountTakerCommission(
+		context.Background(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !takerCommission.Equal(exchange.TakerCommission) {
+		t.Errorf(
+			"unexpected taker commission\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			exchange.TakerCommission,
+			takerCommission,
+		)
+	}
+}
+
+func testFilledOrder(
+	t *testing.T,
+	exchange *Exchange,
+	service trading.ExchangeService,
+) {
+	ctx := context.Background()
+
+	order := newOrder(
+		trading.SideBuy,
+		trading.MustParseDecimal("35090"),
+		trading.MustParseDecimal("0.01"),
+	)
+
+	executed, err := service.ExecuteOrder(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !executed {
+		t.Errorf("order should be executed")
+	}
+
+	assertOrderExecuted(t, service, order, true)
+
+	balances, err := service.AccountBalances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertBalances(t, exchange.Balances(), balances)
+}
+
+func testNotFilledOrder(
+	t *testing.T,
+	_ *Exchange,
+	service trading.ExchangeService,
+) {
+	order := newOrder(
+		trading.SideBuy,
+		trading.MustParseDecimal("35000"),
+		trading.MustParseDecimal("0.01"),
+	)
+
+	executed, err := service.ExecuteOrder(context.Background(), order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if executed {
+		t.Errorf("order below the market price should not be executed")
+	}
+
+	assertOrderExecuted(t, service, order, false)
+}
+
+func testRejectedOrder(
+	t *testing.T,
+	_ *Exchange,
+	service trading.ExchangeService,
+) {
+	order := newOrder(
+		trading.SideBuy,
+		trading.MustParseDecimal("35090"),
+		trading.MustParseDecimal("1"),
+	)
+
+	executed, err := service.ExecuteOrder(context.Background(), order)
+	if err == nil {
+		t.Errorf("order exceeding the balance should be rejected")
+	}
+
+	if executed {
+		t.Errorf("rejected order should not be executed")
+	}
+}
+
+func testUnknownOrderExecution(
+	t *testing.T,
+	_ *Exchange,
+	service trading.ExchangeService,
+) {
+	order := newOrder(
+		trading.SideSell,
+		trading.MustParseDecimal("35090"),
+		trading.MustParseDecimal("0.01"),
+	)
+
+	assertOrderExecuted(t, service, order, false)
+}
+
+func assertOrderExecuted(
+	t *testing.T,
+	service trading.ExchangeService,
+	order *trading.Order,
+	expected bool,
+) {
+	executed, err := service.IsOrderExecuted(context.Background(), order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if executed != expected {
+		t.Errorf(
+			"unexpected order execution state\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expected,
+			executed,
+		)
+	}
+}
+
+func assertCandles(
+	t *testing.T,
+	expected []*trading.Candle,
+	actual []*trading.Candle,
+) {
+	if len(expected) != len(actual) {
+		t.Fatalf(
+			"unexpected candles count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			len(expected),
+			len(actual),
+		)
+	}
+
+	for index := range expected {
+		if !candlesEqual(expected[index], actual[index]) {
+			t.Errorf(
+				"unexpected candle [%v]\n"+
+					"expected: [%+v]\n"+
+					"actual:   [%+v]",
+				index,
+				*expected[index],
+				*actual[index],
+			)
+		}
+	}
+}
+
+func candlesEqual(a, b *trading.Candle) bool {
+	return a.Equal(b) &&
+		a.OpenPrice.Equal(b.OpenPrice) &&
+		a.ClosePrice.Equal(b.ClosePrice) &&
+		a.MaxPrice.Equal(b.MaxPrice) &&
+		a.MinPrice.Equal(b.MinPrice) &&
+		a.Volume.Equal(b.Volume) &&
+		a.TradeCount == b.TradeCount
+}
+
+// assertBalances compares balances ignoring the empty ones which may be
+// omitted by the exchange.
+func assertBalances(
+	t *testing.T,
+	expected trading.Balances,
+	actual trading.Balances,
+) {
+	for _, balances := range []trading.Balances{expected, actual} {
+		for asset := range balances {
+			expectedBalance := expected.BalanceOf(asset)
+			actualBalance := actual.BalanceOf(asset)
+
+			if !expectedBalance.Equal(actualBalance) {
+				t.Errorf(
+					"unexpected balance of [%v]\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					asset,
+					expectedBalance,
+					actualBalance,
+				)
+			}
+		}
+	}
+}
@@ -7,11 +7,13 @@ require (
 	github.com/adshao/go-binance v0.0.0-20201221124815-35bd9c8231f3
 	github.com/golang-migrate/migrate/v4 v4.14.1
 	github.com/google/uuid v1.2.0
+	github.com/gorilla/websocket v1.4.0
 	github.com/jackc/pgtype v1.6.2
 	github.com/jackc/pgx/v4 v4.10.1
 	github.com/jmoiron/sqlx v1.3.1
-	github.com/sdcoffey/big v0.4.1
-	github.com/sdcoffey/techan v0.12.0
+	github.com/prometheus/client_golang v1.11.1
 	github.com/sherifabdlnaby/configuro v0.0.2
+	github.com/shopspring/decimal v1.3.1
 	github.com/sirupsen/logrus v1.7.0
+	gopkg.in/yaml.v2 v2.3.0
 )
@@ -0,0 +1,133 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HealthChecker is implemented by components and dependencies whose
+// health can be probed.
+type HealthChecker interface {
+	// CheckHealth returns an error describing the problem if the
+	// component is unhealthy.
+	CheckHealth(ctx context.Context) error
+}
+
+// WorkloadMonitor exposes the state of running workloads.
+type WorkloadMonitor interface {
+	HealthChecker
+
+	RunnersState() []*WorkloadRunnerState
+}
+
+// WorkloadRunnerState is a snapshot of a running workload.
+type WorkloadRunnerState struct {
+	Workload           *Workload
+	Status             WorkloadStatus
+	StartTime          time.Time
+	LastCandleTickTime time.Time
+	LastActionTime     time.Time
+	// Stalled is set if any of the runner loops hasn't made progress
+	// for a long time.
+	Stalled bool
+}
+
+// RunnersState returns states of all running workloads, sorted by
+// workload IDs.
+func (wc *WorkloadController) RunnersState() []*WorkloadRunnerState {
+	wc.runnersMutex.RLock()
+	runners := wc.runners
+	wc.runnersMutex.RUnlock()
+
+	states := make([]*WorkloadRunnerState, 0)
+	for _, workloadRunner := range runners {
+		states = append(states, workloadRunner.state())
+	}
+
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Workload.ID.String() < states[j].Workload.ID.String()
+	})
+
+	return states
+}
+
+// CheckHealth reports the controller as unhealthy if its loop or any of
+// the running workloads is stalled. The controller is unhealthy as well
+// if states of the running workloads can't be taken before the context
+// is done.
+func (wc *WorkloadController) CheckHealth(ctx context.Context) error {
+	wc.lastRefreshMutex.RLock()
+	lastRefreshTime := wc.lastRefreshTime
+	wc.lastRefreshMutex.RUnlock()
+
+	sinceLastRefresh := wc.clock.Now().Sub(lastRefreshTime)
+	if sinceLastRefresh > workloadControllerLoopTick+workloadStallTimeout {
+		return fmt.Errorf(
+			"workloads not refreshed since [%v]",
+			lastRefreshTime.Format(time.RFC3339),
+		)
+	}
+
+	statesChan := make(chan []*WorkloadRunnerState, 1)
+	go func() {
+		statesChan <- wc.RunnersState()
+	}()
+
+	var states []*WorkloadRunnerState
+	select {
+	case states = <-statesChan:
+	case <-ctx.Done():
+		return fmt.Errorf(
+			"could not get workloads state: [%v]",
+			ctx.Err(),
+		)
+	}
+
+	stalled := make([]string, 0)
+	for _, state := range states {
+		if state.Stalled {
+			stalled = append(stalled, state.Workload.ID.String())
+		}
+	}
+
+	if len(stalled) > 0 {
+		return fmt.Errorf(
+			"stalled workloads: [%v]",
+			strings.Join(stalled, ", "),
+		)
+	}
+
+	return nil
+}
+
+func (wr *WorkloadRunner) state() *WorkloadRunnerState {
+	wr.statusMutex.RLock()
+	status := wr.status
+	wr.statusMutex.RUnlock()
+
+	lastCandleTickTime := wr.marketData.LastTickTime()
+
+	wr.activityMutex.RLock()
+	defer wr.activityMutex.RUnlock()
+
+	// Runners get the whole stall timeout to receive their first tick,
+	// even if the shared feed stopped ticking before they subscribed.
+	if lastCandleTickTime.Before(wr.startTime) {
+		lastCandleTickTime = wr.startTime
+	}
+
+	now := wr.clock.Now()
+
+	return &WorkloadRunnerState{
+		Workload:           wr.workload,
+		Status:             status,
+		StartTime:          wr.startTime,
+		LastCandleTickTime: lastCandleTickTime,
+		LastActionTime:     wr.lastActionTime,
+		Stalled: now.Sub(lastCandleTickTime) > workloadStallTimeout ||
+			now.Sub(wr.lastActionTime) > workloadStallTimeout,
+	}
+}
@@ -0,0 +1,168 @@
+package indicator
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+)
+
+// SMA is the simple moving average of the given number of the most recent
+// values.
+type SMA struct {
+	source Source
+	period trading.Decimal
+	values *window
+	sum    trading.Decimal
+	ready  bool
+	value  trading.Decimal
+}
+
+// NewSMA creates the average of values taken from candles by the source.
+// It panics if the period is not positive.
+func NewSMA(period int, source Source) *SMA {
+	validatePeriod("SMA", period)
+
+	return &SMA{
+		source: source,
+		period: trading.NewDecimal(int64(period), 0),
+		values: newWindow(period),
+	}
+}
+
+func (sma *SMA) Append(candle *trading.Candle) {
+	sma.Update(sma.source(candle))
+}
+
+// Update updates the average with the next value. It allows averaging
+// values which are not taken from candles, e.g. values of other indicators.
+func (sma *SMA) Update(value trading.Decimal) {
+	sma.sum = sma.sum.Add(value)
+
+	if replaced, full := sma.values.push(value); full {
+		sma.sum = sma.sum.Sub(replaced)
+	}
+
+	sma.ready = sma.values.full
+	if sma.ready {
+		sma.value = sma.sum.Div(sma.period)
+	}
+}
+
+func (sma *SMA) Ready() bool {
+	return sma.ready
+}
+
+func (sma *SMA) Value() trading.Decimal {
+	return sma.value
+}
+
+// EMA is the exponential moving average whose weights decrease by the
+// factor of 2/(period+1). The first value is the simple moving average of
+// the first period values.
+type EMA struct {
+	source Source
+	factor trading.Decimal
+	seed   *SMA
+	ready  bool
+	value  trading.Decimal
+}
+
+// NewEMA creates the average of values taken from candles by the source.
+// It panics if the period is not positive.
+func NewEMA(period int, source Source) *EMA {
+	validatePeriod("EMA", period)
+
+	return &EMA{
+		source: source,
+		factor: two.Div(trading.NewDecimal(int64(period)+1, 0)),
+		seed:   NewSMA(period, nil),
+	}
+}
+
+func (ema *EMA) Append(candle *trading.Candle) {
+	ema.Update(ema.source(candle))
+}
+
+// Update updates the average with the next value. It allows averaging
+// values which are not taken from candles, e.g. values of other indicators.
+func (ema *EMA) Update(value trading.Decimal) {
+	if !ema.ready {
+		ema.seed.Update(value)
+
+		ema.ready = ema.seed.Ready()
+		ema.value = ema.seed.Value()
+		return
+	}
+
+	ema.value = round(ema.value.Add(value.Sub(ema.value).Mul(ema.factor)))
+}
+
+func (ema *EMA) Ready() bool {
+	return ema.ready
+}
+
+func (ema *EMA) Value() trading.Decimal {
+	return ema.value
+}
+
+// WMA is the moving average of the given number of the most recent values,
+// weighted linearly from 1 for the oldest value to the period for the most
+// recent one.
+type WMA struct {
+	source      Source
+	period      trading.Decimal
+	weightsSum  trading.Decimal
+	values      *window
+	count       int64
+	sum         trading.Decimal
+	weightedSum trading.Decimal
+	ready       bool
+	value       trading.Decimal
+}
+
+// NewWMA creates the average of values taken from candles by the source.
+// It panics if the period is not positive.
+func NewWMA(period int, source Source) *WMA {
+	validatePeriod("WMA", period)
+
+	return &WMA{
+		source:     source,
+		period:     trading.NewDecimal(int64(period), 0),
+		weightsSum: trading.NewDecimal(int64(period*(period+1)/2), 0),
+		values:     newWindow(period),
+	}
+}
+
+func (wma *WMA) Append(candle *trading.Candle) {
+	wma.Update(wma.source(candle))
+}
+
+// Update updates the average with the next value. It allows averaging
+// values which are not taken from candles, e.g. values of other indicators.
+func (wma *WMA) Update(value trading.Decimal) {
+	replaced, full := wma.values.push(value)
+
+	if full {
+		// Weights of all values kept in the window drop by one.
+		wma.weightedSum = wma.weightedSum.Sub(wma.sum).
+			Add(value.Mul(wma.period))
+		wma.sum = wma.sum.Add(value).Sub(replaced)
+	} else {
+		wma.count++
+		wma.weightedSum = wma.weightedSum.Add(
+			value.Mul(trading.NewDecimal(wma.count, 0)),
+		)
+		wma.sum = wma.sum.Add(value)
+	}
+
+	wma.ready = wma.values.full
+	if wma.ready {
+		wma.value = wma.weightedSum.Div(wma.weightsSum)
+	}
+}
+
+func (wma *WMA) Ready() bool {
+	return wma.ready
+}
+
+func (wma *WMA) Value() trading.Decimal {
+	return wma.value
+}
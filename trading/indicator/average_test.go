@@ -0,0 +1,42 @@
+package indicator
+
+import (
+	"testing"
+)
+
+func TestSMA(t *testing.T) {
+	assertValues(
+		t,
+		"22.22100000 22.20900000 22.22900000 22.25900000 22.30300000 "+
+			"22.42100000 22.61300000 22.76500000 22.90500000 23.07600000 "+
+			"23.21000000 23.37700000 23.52500000 23.65200000 23.71000000 "+
+			"23.68400000 23.61200000 23.50500000 23.43200000 23.27700000 "+
+			"23.13100000",
+		Values(NewSMA(10, ClosePrice), closeCandles),
+	)
+}
+
+func TestEMA(t *testing.T) {
+	assertValues(
+		t,
+		"22.22100000 22.20809091 22.24116529 22.26640796 22.32887924 "+
+			"22.51635574 22.79520015 22.96880013 23.12538192 23.27531248 "+
+			"23.33980112 23.42711001 23.50763546 23.53351992 23.47106176 "+
+			"23.40359598 23.39021489 23.26108491 23.23179675 23.08056097 "+
+			"22.91500443",
+		Values(NewEMA(10, ClosePrice), closeCandles),
+	)
+}
+
+func TestWMA(t *testing.T) {
+	assertValues(
+		t,
+		"22.16466667 22.14866667 22.17533333 22.26600000 22.27000000 "+
+			"22.28600000 22.24800000 22.28866667 22.31533333 22.42200000 "+
+			"22.75400000 23.24466667 23.50866667 23.70866667 23.85200000 "+
+			"23.79933333 23.79200000 23.81666667 23.76000000 23.56200000 "+
+			"23.38466667 23.31933333 23.07000000 23.04000000 22.81333333 "+
+			"22.56266667",
+		Values(NewWMA(5, ClosePrice), closeCandles),
+	)
+}
@@ -0,0 +1,163 @@
+// Package indicator implements technical indicators computed directly from
+// candles using decimals. Indicators are updated incrementally as candles
+// are appended, without recomputing values of former candles. Values which
+// depend on all former ones are kept at trading.DecimalDivisionPrecision
+// decimal places so their precision doesn't grow with each candle.
+package indicator
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+)
+
+var (
+	two     = trading.NewDecimal(2, 0)
+	three   = trading.NewDecimal(3, 0)
+	fifty   = trading.NewDecimal(50, 0)
+	hundred = trading.NewDecimal(100, 0)
+)
+
+// Indicator computes values of a candle series, one per appended candle.
+type Indicator interface {
+	// Append updates the indicator with the next candle of the series.
+	Append(candle *trading.Candle)
+
+	// Ready tells whether enough candles have been appended for the value
+	// to be computed.
+	Ready() bool
+
+	// Value returns the value for the last appended candle. It's zero
+	// until the indicator is ready.
+	Value() trading.Decimal
+}
+
+// Source determines the candle value an indicator is computed from.
+type Source func(candle *trading.Candle) trading.Decimal
+
+func OpenPrice(candle *trading.Candle) trading.Decimal {
+	return candle.OpenPrice
+}
+
+func ClosePrice(candle *trading.Candle) trading.Decimal {
+	return candle.ClosePrice
+}
+
+func MaxPrice(candle *trading.Candle) trading.Decimal {
+	return candle.MaxPrice
+}
+
+func MinPrice(candle *trading.Candle) trading.Decimal {
+	return candle.MinPrice
+}
+
+func Volume(candle *trading.Candle) trading.Decimal {
+	return candle.Volume
+}
+
+// TypicalPrice is the average of the max, min and close prices.
+func TypicalPrice(candle *trading.Candle) trading.Decimal {
+	return candle.MaxPrice.Add(candle.MinPrice).Add(candle.ClosePrice).
+		Div(three)
+}
+
+// Values appends the candles to the indicator and returns its values for
+// candles appended once it's been ready. The values are aligned with the
+// end of the candles so the last value belongs to the last candle.
+func Values(
+	indicator Indicator,
+	candles []*trading.Candle,
+) []trading.Decimal {
+	values := make([]trading.Decimal, 0, len(candles))
+
+	for _, candle := range candles {
+		indicator.Append(candle)
+
+		if indicator.Ready() {
+			values = append(values, indicator.Value())
+		}
+	}
+
+	return values
+}
+
+// round rounds the value of a recursive indicator, computed from its former
+// value, to the precision kept by division. Exact multiplications would
+// make the precision, and the cost of further updates, grow with each
+// appended candle. Wilder's smoothing of RSI and ATR ends with a division
+// so it's rounded already.
+func round(value trading.Decimal) trading.Decimal {
+	return value.Round(trading.DecimalDivisionPrecision)
+}
+
+func validatePeriod(name string, period int) {
+	if period < 1 {
+		panic(fmt.Sprintf("%v period must be positive: [%v]", name, period))
+	}
+}
+
+// window keeps a fixed number of the most recent values.
+type window struct {
+	values []trading.Decimal
+	next   int
+	full   bool
+}
+
+func newWindow(size int) *window {
+	return &window{values: make([]trading.Decimal, size)}
+}
+
+// push adds the value and returns the one it replaced, if the window was
+// already full.
+func (w *window) push(value trading.Decimal) (trading.Decimal, bool) {
+	replaced, full := w.values[w.next], w.full
+
+	w.values[w.next] = value
+
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+
+	return replaced, full
+}
+
+// extremes tracks the maximum or minimum of a fixed number of the most
+// recent values. Values which can no longer become the extreme are dropped
+// so each value is compared a constant number of times on average.
+type extremes struct {
+	size    int
+	cmp     int
+	count   int
+	indexes []int
+	values  []trading.Decimal
+}
+
+func newMaxima(size int) *extremes {
+	return &extremes{size: size, cmp: 1}
+}
+
+func newMinima(size int) *extremes {
+	return &extremes{size: size, cmp: -1}
+}
+
+func (e *extremes) push(value trading.Decimal) {
+	for len(e.values) > 0 &&
+		value.Cmp(e.values[len(e.values)-1]) != -e.cmp {
+		e.values = e.values[:len(e.values)-1]
+		e.indexes = e.indexes[:len(e.indexes)-1]
+	}
+
+	e.values = append(e.values, value)
+	e.indexes = append(e.indexes, e.count)
+	e.count++
+
+	if e.indexes[0] <= e.count-1-e.size {
+		e.values = e.values[1:]
+		e.indexes = e.indexes[1:]
+	}
+}
+
+func (e *extremes) value() trading.Decimal {
+	return e.values[0]
+}
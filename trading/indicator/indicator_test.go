@@ -0,0 +1,238 @@
+package indicator
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Expected values of all indicators were computed independently, by
+// applying textbook definitions to whole windows of exact decimals, and
+// rounded to 8 decimal places.
+
+// closeCandles are candles of StockCharts' moving averages example.
+var closeCandles = newCloseCandles(
+	"22.27 22.19 22.08 22.17 22.18 22.13 22.23 22.43 22.24 22.29 " +
+		"22.15 22.39 22.38 22.61 23.36 24.05 23.75 23.83 23.95 23.63 " +
+		"23.82 23.87 23.65 23.19 23.10 23.33 22.68 23.10 22.40 22.17",
+)
+
+// rsiCandles are candles of StockCharts' RSI example. Published values
+// differ in the second decimal place as the example rounds intermediate
+// averages.
+var rsiCandles = newCloseCandles(
+	"44.34 44.09 44.15 43.61 44.33 44.83 45.10 45.42 45.84 46.08 " +
+		"45.89 46.03 45.61 46.28 46.28 46.00 46.03 46.41 46.22 45.64 " +
+		"46.21 46.25 45.71 46.45 45.78 45.35 44.03 44.18 44.22 44.57 " +
+		"43.42 42.66 43.13",
+)
+
+// ohlcvCandles cover gaps between candles, which affect true ranges, and
+// repeated close prices, which affect the on-balance volume.
+var ohlcvCandles = newOHLCVCandles(
+	"48.70 48.72 47.79 48.16 1200",
+	"48.16 48.90 48.14 48.61 1530",
+	"48.61 48.87 48.39 48.75 980",
+	"48.75 48.99 48.37 48.63 1110",
+	"48.63 48.78 47.64 47.70 2040",
+	"47.70 48.39 47.12 47.23 1870",
+	"47.23 48.66 47.18 48.30 1690",
+	"48.30 48.48 47.61 47.80 1220",
+	"47.80 47.92 47.39 47.62 1050",
+	"47.62 48.29 46.96 48.20 1760",
+	"48.20 48.53 47.94 48.34 1340",
+	"48.34 49.12 48.20 49.06 2210",
+	"49.06 49.20 48.81 48.81 1480",
+	"48.81 49.00 48.16 48.81 1290",
+	"48.81 48.90 47.70 47.80 1910",
+	"47.80 48.16 47.33 48.16 1420",
+)
+
+func TestValues(t *testing.T) {
+	sma := NewSMA(3, ClosePrice)
+
+	if sma.Ready() || !sma.Value().IsZero() {
+		t.Fatalf("indicator should not be ready before candles are appended")
+	}
+
+	// Values of the candles appended before the indicator was ready
+	// are skipped.
+	assertValues(
+		t,
+		"22.18000000 22.14666667 22.14333333",
+		Values(sma, closeCandles[:5]),
+	)
+
+	// Values of the appended candles follow the former ones.
+	assertValues(t, "22.16000000", Values(sma, closeCandles[5:6]))
+}
+
+func TestValidatePeriod(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("non-positive period should not be accepted")
+		}
+	}()
+
+	NewEMA(0, ClosePrice)
+}
+
+func TestPrecision(t *testing.T) {
+	// Prices with many decimal places make exact recursive updates grow
+	// by a few digits per candle.
+	candles := make([]*trading.Candle, 720)
+	for index := range candles {
+		price := trading.NewDecimal(int64(2000000+index*7919%100003), -4).
+			Div(trading.NewDecimal(7, 0))
+
+		candles[index] = newCandle(
+			index,
+			price.String(),
+			price.Add(trading.NewDecimal(1, 0)).String(),
+			price.Sub(trading.NewDecimal(1, 0)).String(),
+			price.String(),
+			"1",
+		)
+	}
+
+	tests := map[string]struct {
+		indicator  Indicator
+		additional []func(indicator Indicator) trading.Decimal
+	}{
+		"EMA": {
+			indicator: NewEMA(14, ClosePrice),
+		},
+		"RSI": {
+			indicator: NewRSI(14, ClosePrice),
+		},
+		"ATR": {
+			indicator: NewATR(14),
+		},
+		"MACD": {
+			indicator: NewMACD(12, 26, 9, ClosePrice),
+			additional: []func(indicator Indicator) trading.Decimal{
+				func(indicator Indicator) trading.Decimal {
+					return indicator.(*MACD).Signal()
+				},
+				func(indicator Indicator) trading.Decimal {
+					return indicator.(*MACD).Histogram()
+				},
+			},
+		},
+		"STOCHASTIC": {
+			indicator: NewStochastic(14, 3),
+			additional: []func(indicator Indicator) trading.Decimal{
+				func(indicator Indicator) trading.Decimal {
+					return indicator.(*Stochastic).D()
+				},
+			},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			getters := append(
+				[]func(indicator Indicator) trading.Decimal{
+					Indicator.Value,
+				},
+				test.additional...,
+			)
+
+			for _, candle := range candles {
+				test.indicator.Append(candle)
+
+				for index, getter := range getters {
+					_, exponent := getter(test.indicator).BigInt()
+
+					if exponent < -trading.DecimalDivisionPrecision {
+						t.Fatalf(
+							"unexpected exponent of value [%v]\n"+
+								"expected: [>= %v]\n"+
+								"actual:   [%v]",
+							index,
+							-trading.DecimalDivisionPrecision,
+							exponent,
+						)
+					}
+				}
+			}
+		})
+	}
+}
+
+func newCloseCandles(closes string) []*trading.Candle {
+	fields := strings.Fields(closes)
+	candles := make([]*trading.Candle, len(fields))
+
+	for index, field := range fields {
+		candles[index] = newCandle(index, field, field, field, field, "0")
+	}
+
+	return candles
+}
+
+func newOHLCVCandles(rows ...string) []*trading.Candle {
+	candles := make([]*trading.Candle, len(rows))
+
+	for index, row := range rows {
+		fields := strings.Fields(row)
+		candles[index] = newCandle(
+			index,
+			fields[0],
+			fields[1],
+			fields[2],
+			fields[3],
+			fields[4],
+		)
+	}
+
+	return candles
+}
+
+func newCandle(
+	index int,
+	openPrice, maxPrice, minPrice, closePrice, volume string,
+) *trading.Candle {
+	openTime := time.Date(2021, 6, 11, 0, 0, 0, 0, time.UTC).
+		AddDate(0, 0, index)
+
+	return &trading.Candle{
+		OpenTime:   openTime,
+		CloseTime:  openTime.Add(24*time.Hour - time.Millisecond),
+		OpenPrice:  trading.MustParseDecimal(openPrice),
+		ClosePrice: trading.MustParseDecimal(closePrice),
+		MaxPrice:   trading.MustParseDecimal(maxPrice),
+		MinPrice:   trading.MustParseDecimal(minPrice),
+		Volume:     trading.MustParseDecimal(volume),
+	}
+}
+
+// assertValues compares values rounded to 8 decimal places with the
+// expected ones separated by whitespaces.
+func assertValues(t *testing.T, expected string, values []trading.Decimal) {
+	expectedValues := strings.Fields(expected)
+
+	if len(expectedValues) != len(values) {
+		t.Fatalf(
+			"unexpected values count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			len(expectedValues),
+			len(values),
+		)
+	}
+
+	for index, value := range values {
+		if value.StringFixed(8) != expectedValues[index] {
+			t.Errorf(
+				"unexpected value [%v]\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				index,
+				expectedValues[index],
+				value.StringFixed(8),
+			)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package indicator
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+)
+
+// RSI is the relative strength index using Wilder's smoothing. Its first
+// value is computed once the given number of price changes is known.
+// If prices haven't changed at all, the index is 50.
+type RSI struct {
+	source      Source
+	period      int
+	started     bool
+	previous    trading.Decimal
+	changes     int
+	averageGain trading.Decimal
+	averageLoss trading.Decimal
+	ready       bool
+	value       trading.Decimal
+}
+
+// NewRSI creates the index of values taken from candles by the source.
+// It panics if the period is not positive.
+func NewRSI(period int, source Source) *RSI {
+	validatePeriod("RSI", period)
+
+	return &RSI{
+		source: source,
+		period: period,
+	}
+}
+
+func (rsi *RSI) Append(candle *trading.Candle) {
+	value := rsi.source(candle)
+
+	// The first value is only needed to compute the first change.
+	if !rsi.started {
+		rsi.started = true
+		rsi.previous = value
+		return
+	}
+
+	change := value.Sub(rsi.previous)
+	rsi.previous = value
+
+	var gain, loss trading.Decimal
+	if change.Sign() > 0 {
+		gain = change
+	} else {
+		loss = change.Neg()
+	}
+
+	period := trading.NewDecimal(int64(rsi.period), 0)
+
+	if !rsi.ready {
+		rsi.changes++
+		// Averages hold sums of changes until the period is complete.
+		rsi.averageGain = rsi.averageGain.Add(gain)
+		rsi.averageLoss = rsi.averageLoss.Add(loss)
+
+		if rsi.changes < rsi.period {
+			return
+		}
+
+		rsi.averageGain = rsi.averageGain.Div(period)
+		rsi.averageLoss = rsi.averageLoss.Div(period)
+		rsi.ready = true
+	} else {
+		previousWeight := trading.NewDecimal(int64(rsi.period)-1, 0)
+
+		rsi.averageGain = rsi.averageGain.Mul(previousWeight).Add(gain).
+			Div(period)
+		rsi.averageLoss = rsi.averageLoss.Mul(previousWeight).Add(loss).
+			Div(period)
+	}
+
+	switch {
+	case rsi.averageLoss.IsZero() && rsi.averageGain.IsZero():
+		rsi.value = fifty
+	case rsi.averageLoss.IsZero():
+		rsi.value = hundred
+	default:
+		relativeStrength := rsi.averageGain.Div(rsi.averageLoss)
+		rsi.value = hundred.Sub(
+			hundred.Div(relativeStrength.Add(trading.NewDecimal(1, 0))),
+		)
+	}
+}
+
+func (rsi *RSI) Ready() bool {
+	return rsi.ready
+}
+
+func (rsi *RSI) Value() trading.Decimal {
+	return rsi.value
+}
+
+// MACD is the moving average convergence divergence. Its value is the
+// difference between the fast and slow exponential moving averages. The
+// signal line is the exponential moving average of that difference.
+type MACD struct {
+	source    Source
+	fast      *EMA
+	slow      *EMA
+	signal    *EMA
+	ready     bool
+	value     trading.Decimal
+	histogram trading.Decimal
+}
+
+// NewMACD creates the indicator of values taken from candles by the
+// source, e.g. NewMACD(12, 26, 9, ClosePrice). It panics if any of the
+// periods is not positive.
+func NewMACD(fastPeriod, slowPeriod, signalPeriod int, source Source) *MACD {
+	return &MACD{
+		source: source,
+		fast:   NewEMA(fastPeriod, nil),
+		slow:   NewEMA(slowPeriod, nil),
+		signal: NewEMA(signalPeriod, nil),
+	}
+}
+
+func (macd *MACD) Append(candle *trading.Candle) {
+	value := macd.source(candle)
+
+	macd.fast.Update(value)
+	macd.slow.Update(value)
+
+	if !macd.fast.Ready() || !macd.slow.Ready() {
+		return
+	}
+
+	difference := macd.fast.Value().Sub(macd.slow.Value())
+
+	macd.signal.Update(difference)
+
+	macd.ready = macd.signal.Ready()
+	if macd.ready {
+		macd.value = difference
+		macd.histogram = difference.Sub(macd.signal.Value())
+	}
+}
+
+// Ready tells whether the signal line, computed last, is known.
+func (macd *MACD) Ready() bool {
+	return macd.ready
+}
+
+func (macd *MACD) Value() trading.Decimal {
+	return macd.value
+}
+
+// Signal returns the signal line for the last appended candle.
+func (macd *MACD) Signal() trading.Decimal {
+	return macd.signal.Value()
+}
+
+// Histogram returns the difference between the value and the signal line
+// for the last appended candle.
+func (macd *MACD) Histogram() trading.Decimal {
+	return macd.histogram
+}
+
+// Stochastic is the stochastic oscillator. Its value, %K, locates the close
+// price within the price range of the given number of the most recent
+// candles, from 0 at the min price to 100 at the max one. If the range is
+// empty, %K is 50. %D is the simple moving average of %K.
+type Stochastic struct {
+	period int
+	count  int
+	maxima *extremes
+	minima *extremes
+	d      *SMA
+	ready  bool
+	value  trading.Decimal
+}
+
+// NewStochastic creates the oscillator whose %D averages the given number
+// of %K values, e.g. NewStochastic(14, 3). It panics if any of the
+// periods is not positive.
+func NewStochastic(period, smoothingPeriod int) *Stochastic {
+	validatePeriod("stochastic", period)
+
+	return &Stochastic{
+		period: period,
+		maxima: newMaxima(period),
+		minima: newMinima(period),
+		d:      NewSMA(smoothingPeriod, nil),
+	}
+}
+
+func (s *Stochastic) Append(candle *trading.Candle) {
+	s.maxima.push(candle.MaxPrice)
+	s.minima.push(candle.MinPrice)
+
+	if s.count < s.period {
+		s.count++
+	}
+
+	if s.count < s.period {
+		return
+	}
+
+	minPrice := s.minima.value()
+	priceRange := s.maxima.value().Sub(minPrice)
+
+	k := fifty
+	if !priceRange.IsZero() {
+		k = hundred.Mul(candle.ClosePrice.Sub(minPrice)).Div(priceRange)
+	}
+
+	s.d.Update(k)
+
+	s.ready = s.d.Ready()
+	if s.ready {
+		s.value = k
+	}
+}
+
+// Ready tells whether %D, computed last, is known.
+func (s *Stochastic) Ready() bool {
+	return s.ready
+}
+
+// Value returns %K for the last appended candle.
+func (s *Stochastic) Value() trading.Decimal {
+	return s.value
+}
+
+// D returns %D for the last appended candle.
+func (s *Stochastic) D() trading.Decimal {
+	return s.d.Value()
+}
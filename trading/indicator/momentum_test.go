@@ -0,0 +1,114 @@
+package indicator
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"testing"
+)
+
+func TestRSI(t *testing.T) {
+	assertValues(
+		t,
+		"70.46413502 66.24961855 66.48094183 69.34685316 66.29471266 "+
+			"57.91502067 62.88071831 63.20878872 56.01158479 62.33992931 "+
+			"54.67097138 50.38681520 40.01942379 41.49263540 41.90242968 "+
+			"45.49949724 37.32277831 33.09048257 37.78877198",
+		Values(NewRSI(14, ClosePrice), rsiCandles),
+	)
+}
+
+func TestRSI_Extremes(t *testing.T) {
+	tests := map[string]struct {
+		closes   string
+		expected string
+	}{
+		"only gains":  {"1 2 3 4", "100.00000000 100.00000000"},
+		"only losses": {"4 3 2 1", "0.00000000 0.00000000"},
+		"flat":        {"2 2 2 2", "50.00000000 50.00000000"},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assertValues(
+				t,
+				test.expected,
+				Values(NewRSI(2, ClosePrice), newCloseCandles(test.closes)),
+			)
+		})
+	}
+}
+
+func TestMACD(t *testing.T) {
+	macd := NewMACD(5, 10, 4, ClosePrice)
+
+	values := collectValues(macd, closeCandles, macd.Signal, macd.Histogram)
+
+	assertValues(
+		t,
+		"0.04867937 0.08451231 0.21257196 0.37408498 0.39405663 "+
+			"0.39318925 0.38706830 0.31178607 0.28061478 0.25418107 "+
+			"0.19102443 0.07530115 -0.00602071 -0.01516472 -0.11771813 "+
+			"-0.10288556 -0.19462018 -0.26771057",
+		values[0],
+	)
+	assertValues(
+		t,
+		"0.03960511 0.05756799 0.11956958 0.22137574 0.29044810 "+
+			"0.33154456 0.35375405 0.33696686 0.31442603 0.29032804 "+
+			"0.25060660 0.18048442 0.10588236 0.05746353 -0.01260913 "+
+			"-0.04871970 -0.10707989 -0.17133216",
+		values[1],
+	)
+	assertValues(
+		t,
+		"0.00907426 0.02694432 0.09300238 0.15270924 0.10360854 "+
+			"0.06164469 0.03331425 -0.02518079 -0.03381125 -0.03614698 "+
+			"-0.05958217 -0.10518327 -0.11190308 -0.07262825 -0.10510900 "+
+			"-0.05416586 -0.08754029 -0.09637841",
+		values[2],
+	)
+}
+
+func TestStochastic(t *testing.T) {
+	stochastic := NewStochastic(5, 3)
+
+	values := collectValues(stochastic, ohlcvCandles, stochastic.D)
+
+	assertValues(
+		t,
+		"63.10160428 36.36363636 30.12048193 72.94117647 81.17647059 "+
+			"97.22222222 82.58928571 82.58928571 6.66666667 44.38502674",
+		values[0],
+	)
+	assertValues(
+		t,
+		"24.47613389 35.11586453 43.19524086 46.47509825 61.41270966 "+
+			"83.77995643 86.99599284 87.46693122 57.28174603 44.54699304",
+		values[1],
+	)
+}
+
+// collectValues appends the candles to the indicator and collects its
+// values, followed by the given additional ones, for candles appended once
+// it's been ready.
+func collectValues(
+	indicator Indicator,
+	candles []*trading.Candle,
+	additional ...func() trading.Decimal,
+) [][]trading.Decimal {
+	values := make([][]trading.Decimal, len(additional)+1)
+
+	for _, candle := range candles {
+		indicator.Append(candle)
+
+		if !indicator.Ready() {
+			continue
+		}
+
+		values[0] = append(values[0], indicator.Value())
+		for index, value := range additional {
+			values[index+1] = append(values[index+1], value())
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,155 @@
+package indicator
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+)
+
+// ATR is the average true range using Wilder's smoothing. The true range
+// of a candle spans its max and min prices along with the close price of
+// the previous candle. The first value is the simple average of the true
+// ranges of the first period candles.
+type ATR struct {
+	period        int
+	count         int
+	sum           trading.Decimal
+	previousClose trading.Decimal
+	ready         bool
+	value         trading.Decimal
+}
+
+// NewATR creates the average of the given number of true ranges. It panics
+// if the period is not positive.
+func NewATR(period int) *ATR {
+	validatePeriod("ATR", period)
+
+	return &ATR{period: period}
+}
+
+func (atr *ATR) Append(candle *trading.Candle) {
+	trueRange := candle.MaxPrice.Sub(candle.MinPrice)
+
+	if atr.count > 0 {
+		for _, distance := range []trading.Decimal{
+			candle.MaxPrice.Sub(atr.previousClose).Abs(),
+			candle.MinPrice.Sub(atr.previousClose).Abs(),
+		} {
+			if distance.Cmp(trueRange) > 0 {
+				trueRange = distance
+			}
+		}
+	}
+
+	atr.previousClose = candle.ClosePrice
+
+	period := trading.NewDecimal(int64(atr.period), 0)
+
+	if !atr.ready {
+		atr.count++
+		atr.sum = atr.sum.Add(trueRange)
+
+		if atr.count == atr.period {
+			atr.value = atr.sum.Div(period)
+			atr.ready = true
+		}
+
+		return
+	}
+
+	atr.value = atr.value.Mul(trading.NewDecimal(int64(atr.period)-1, 0)).
+		Add(trueRange).
+		Div(period)
+}
+
+func (atr *ATR) Ready() bool {
+	return atr.ready
+}
+
+func (atr *ATR) Value() trading.Decimal {
+	return atr.value
+}
+
+// Bollinger are the Bollinger Bands. The middle band is the simple moving
+// average of the given number of the most recent values. The upper and
+// lower bands are shifted from it by a multiple of the population standard
+// deviation of those values.
+type Bollinger struct {
+	source     Source
+	period     trading.Decimal
+	multiplier trading.Decimal
+	values     *window
+	sum        trading.Decimal
+	squaresSum trading.Decimal
+	ready      bool
+	value      trading.Decimal
+	deviation  trading.Decimal
+}
+
+// NewBollinger creates bands of values taken from candles by the source,
+// e.g. NewBollinger(20, trading.NewDecimal(2, 0), ClosePrice). It panics if
+// the period is not positive.
+func NewBollinger(
+	period int,
+	multiplier trading.Decimal,
+	source Source,
+) *Bollinger {
+	validatePeriod("Bollinger", period)
+
+	return &Bollinger{
+		source:     source,
+		period:     trading.NewDecimal(int64(period), 0),
+		multiplier: multiplier,
+		values:     newWindow(period),
+	}
+}
+
+func (b *Bollinger) Append(candle *trading.Candle) {
+	value := b.source(candle)
+
+	b.sum = b.sum.Add(value)
+	b.squaresSum = b.squaresSum.Add(value.Mul(value))
+
+	if replaced, full := b.values.push(value); full {
+		b.sum = b.sum.Sub(replaced)
+		b.squaresSum = b.squaresSum.Sub(replaced.Mul(replaced))
+	}
+
+	b.ready = b.values.full
+	if !b.ready {
+		return
+	}
+
+	b.value = b.sum.Div(b.period)
+
+	// Sums are exact so the variance is negative only due to rounding
+	// of the mean.
+	variance := b.squaresSum.Div(b.period).Sub(b.value.Mul(b.value))
+	if variance.Sign() < 0 {
+		variance = trading.Decimal{}
+	}
+
+	b.deviation = variance.Sqrt()
+}
+
+func (b *Bollinger) Ready() bool {
+	return b.ready
+}
+
+// Value returns the middle band for the last appended candle.
+func (b *Bollinger) Value() trading.Decimal {
+	return b.value
+}
+
+// Upper returns the upper band for the last appended candle.
+func (b *Bollinger) Upper() trading.Decimal {
+	return b.value.Add(b.multiplier.Mul(b.deviation))
+}
+
+// Lower returns the lower band for the last appended candle.
+func (b *Bollinger) Lower() trading.Decimal {
+	return b.value.Sub(b.multiplier.Mul(b.deviation))
+}
+
+// Deviation returns the standard deviation for the last appended candle.
+func (b *Bollinger) Deviation() trading.Decimal {
+	return b.deviation
+}
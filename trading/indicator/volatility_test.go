@@ -0,0 +1,56 @@
+package indicator
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"testing"
+)
+
+func TestATR(t *testing.T) {
+	assertValues(
+		t,
+		"0.78600000 0.88280000 1.00224000 0.97579200 0.88663360 "+
+			"0.97530688 0.89824550 0.90259640 0.80007712 0.80806170 "+
+			"0.88644936 0.87515949",
+		Values(NewATR(5), ohlcvCandles),
+	)
+}
+
+func TestBollinger(t *testing.T) {
+	bollinger := NewBollinger(10, trading.NewDecimal(2, 0), ClosePrice)
+
+	values := collectValues(
+		bollinger,
+		ohlcvCandles,
+		bollinger.Upper,
+		bollinger.Lower,
+	)
+
+	assertValues(
+		t,
+		"48.10000000 48.11800000 48.16300000 48.16900000 48.18700000 "+
+			"48.19700000 48.29000000",
+		values[0],
+	)
+	assertValues(
+		t,
+		"49.04961045 49.07824164 49.24562828 49.26515510 49.31819583 "+
+			"49.31245686 49.20441785",
+		values[1],
+	)
+	assertValues(
+		t,
+		"47.15038955 47.15775836 47.08037172 47.07284490 47.05580417 "+
+			"47.08154314 47.37558215",
+		values[2],
+	)
+}
+
+func TestBollinger_Flat(t *testing.T) {
+	bollinger := NewBollinger(3, trading.NewDecimal(2, 0), ClosePrice)
+
+	Values(bollinger, newCloseCandles("0.1 0.1 0.1"))
+
+	if !bollinger.Deviation().IsZero() {
+		t.Errorf("unexpected deviation: [%v]", bollinger.Deviation())
+	}
+}
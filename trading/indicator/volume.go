@@ -0,0 +1,113 @@
+package indicator
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+)
+
+// VWAP is the volume weighted average of typical prices. It's anchored at
+// the first appended candle unless the period is given, in which case it
+// averages the given number of the most recent candles. If there was no
+// volume at all, the typical price of the last candle is used.
+type VWAP struct {
+	period          int
+	priceVolumes    *window
+	volumes         *window
+	priceVolumesSum trading.Decimal
+	volumesSum      trading.Decimal
+	ready           bool
+	value           trading.Decimal
+}
+
+// NewVWAP creates the average of the given number of the most recent
+// candles, or all of them if the period is zero. It panics if the period
+// is negative.
+func NewVWAP(period int) *VWAP {
+	vwap := &VWAP{period: period}
+
+	if period != 0 {
+		validatePeriod("VWAP", period)
+
+		vwap.priceVolumes = newWindow(period)
+		vwap.volumes = newWindow(period)
+	}
+
+	return vwap
+}
+
+func (vwap *VWAP) Append(candle *trading.Candle) {
+	price := TypicalPrice(candle)
+	priceVolume := price.Mul(candle.Volume)
+
+	vwap.priceVolumesSum = vwap.priceVolumesSum.Add(priceVolume)
+	vwap.volumesSum = vwap.volumesSum.Add(candle.Volume)
+
+	if vwap.period == 0 {
+		vwap.ready = true
+	} else {
+		replaced, full := vwap.priceVolumes.push(priceVolume)
+		if full {
+			vwap.priceVolumesSum = vwap.priceVolumesSum.Sub(replaced)
+		}
+
+		replaced, full = vwap.volumes.push(candle.Volume)
+		if full {
+			vwap.volumesSum = vwap.volumesSum.Sub(replaced)
+		}
+
+		vwap.ready = vwap.volumes.full
+	}
+
+	if !vwap.ready {
+		return
+	}
+
+	if vwap.volumesSum.IsZero() {
+		vwap.value = price
+		return
+	}
+
+	vwap.value = vwap.priceVolumesSum.Div(vwap.volumesSum)
+}
+
+func (vwap *VWAP) Ready() bool {
+	return vwap.ready
+}
+
+func (vwap *VWAP) Value() trading.Decimal {
+	return vwap.value
+}
+
+// OBV is the on-balance volume. The volume of each candle is added if its
+// close price is higher than the previous one and subtracted if it's lower.
+// The value for the first appended candle is zero.
+type OBV struct {
+	previousClose trading.Decimal
+	ready         bool
+	value         trading.Decimal
+}
+
+func NewOBV() *OBV {
+	return &OBV{}
+}
+
+func (obv *OBV) Append(candle *trading.Candle) {
+	if obv.ready {
+		switch candle.ClosePrice.Cmp(obv.previousClose) {
+		case 1:
+			obv.value = obv.value.Add(candle.Volume)
+		case -1:
+			obv.value = obv.value.Sub(candle.Volume)
+		}
+	}
+
+	obv.previousClose = candle.ClosePrice
+	obv.ready = true
+}
+
+func (obv *OBV) Ready() bool {
+	return obv.ready
+}
+
+func (obv *OBV) Value() trading.Decimal {
+	return obv.value
+}
@@ -0,0 +1,56 @@
+package indicator
+
+import (
+	"testing"
+)
+
+func TestVWAP(t *testing.T) {
+	tests := map[string]struct {
+		period   int
+		expected string
+	}{
+		"anchored": {
+			period: 0,
+			expected: "48.22333333 48.40641026 48.47603774 48.51917012 " +
+				"48.37667638 48.20602520 48.18017914 48.15745132 " +
+				"48.11491200 48.07858593 48.09483006 48.18059074 " +
+				"48.23828713 48.26427219 48.25324515 48.23144952",
+		},
+		"rolling": {
+			period: 5,
+			expected: "48.37667638 48.20326693 48.09986563 48.00840269 " +
+				"47.86732317 47.80916557 47.95733239 48.18115655 " +
+				"48.35830357 48.49884901 48.55990684 48.49104292",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assertValues(
+				t,
+				test.expected,
+				Values(NewVWAP(test.period), ohlcvCandles),
+			)
+		})
+	}
+}
+
+func TestVWAP_NoVolume(t *testing.T) {
+	// Typical prices are equal to close prices of these candles.
+	assertValues(
+		t,
+		"1.00000000 2.00000000",
+		Values(NewVWAP(0), newCloseCandles("1 2")),
+	)
+}
+
+func TestOBV(t *testing.T) {
+	assertValues(
+		t,
+		"0.00000000 1530.00000000 2510.00000000 1400.00000000 "+
+			"-640.00000000 -2510.00000000 -820.00000000 -2040.00000000 "+
+			"-3090.00000000 -1330.00000000 10.00000000 2220.00000000 "+
+			"740.00000000 740.00000000 -1170.00000000 250.00000000",
+		Values(NewOBV(), ohlcvCandles),
+	)
+}
@@ -0,0 +1,65 @@
+package inmem
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
+)
+
+type AccountRepository struct {
+	accountsMutex sync.RWMutex
+	accounts      map[string]*trading.Account
+	// Accounts are listed in the order of creation.
+	accountIDs []string
+}
+
+func NewAccountRepository() *AccountRepository {
+	return &AccountRepository{
+		accounts: make(map[string]*trading.Account),
+	}
+}
+
+func (ar *AccountRepository) CreateAccount(account *trading.Account) error {
+	ar.accountsMutex.Lock()
+	defer ar.accountsMutex.Unlock()
+
+	if _, exists := ar.accounts[account.ID.String()]; exists {
+		return fmt.Errorf("account [%v] already exists", account.ID)
+	}
+
+	stored := *account
+	ar.accounts[account.ID.String()] = &stored
+	ar.accountIDs = append(ar.accountIDs, account.ID.String())
+
+	return nil
+}
+
+func (ar *AccountRepository) Account(
+	accountID trading.ID,
+) (*trading.Account, error) {
+	ar.accountsMutex.RLock()
+	defer ar.accountsMutex.RUnlock()
+
+	stored, exists := ar.accounts[accountID.String()]
+	if !exists {
+		return nil, trading.ErrNotFound
+	}
+
+	account := *stored
+
+	return &account, nil
+}
+
+func (ar *AccountRepository) Accounts() ([]*trading.Account, error) {
+	ar.accountsMutex.RLock()
+	defer ar.accountsMutex.RUnlock()
+
+	accounts := make([]*trading.Account, 0)
+
+	for _, accountID := range ar.accountIDs {
+		account := *ar.accounts[accountID]
+		accounts = append(accounts, &account)
+	}
+
+	return accounts, nil
+}
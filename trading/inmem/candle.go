@@ -1,71 +1,171 @@
 package inmem
 
 import (
+	"fmt"
 	"github.com/lukasz-zimnoch/dexly/trading"
 	"sync"
 )
 
 type CandleRepository struct {
 	candlesMutex sync.RWMutex
-	candles      map[string][]*trading.Candle
+	candles      map[trading.CandleKey][]*trading.Candle
 
 	windowSize int
 }
 
 func NewCandleRepository(windowSize int) *CandleRepository {
 	return &CandleRepository{
-		candles:    make(map[string][]*trading.Candle),
+		candles:    make(map[trading.CandleKey][]*trading.Candle),
 		windowSize: windowSize,
 	}
 }
 
+// SaveCandles stores candles ordered by their open times. Candles which
+// are already stored get updated. Once the window size is exceeded, the
+// oldest candles are removed.
 func (cr *CandleRepository) SaveCandles(
-	key string,
+	key trading.CandleKey,
 	candles ...*trading.Candle,
-) {
+) error {
 	cr.candlesMutex.Lock()
 	defer cr.candlesMutex.Unlock()
 
 	for _, candle := range candles {
-		var lastCandle *trading.Candle
-		if len(cr.candles[key]) > 0 {
-			lastCandle = cr.candles[key][len(cr.candles[key])-1]
+		stored := cr.candles[key]
+
+		// Candles usually come in order so the search starts from the end.
+		index := len(stored)
+		for index > 0 && stored[index-1].OpenTime.After(candle.OpenTime) {
+			index--
+		}
+
+		if index > 0 && stored[index-1].Equal(candle) {
+			existingCandle := stored[index-1]
+			existingCandle.OpenPrice = candle.OpenPrice
+			existingCandle.ClosePrice = candle.ClosePrice
+			existingCandle.MaxPrice = candle.MaxPrice
+			existingCandle.MinPrice = candle.MinPrice
+			existingCandle.Volume = candle.Volume
+			existingCandle.TradeCount = candle.TradeCount
+			continue
 		}
 
-		if lastCandle != nil && lastCandle.Equal(candle) {
-			lastCandle.OpenPrice = candle.OpenPrice
-			lastCandle.ClosePrice = candle.ClosePrice
-			lastCandle.MaxPrice = candle.MaxPrice
-			lastCandle.MinPrice = candle.MinPrice
-			lastCandle.Volume = candle.Volume
-			lastCandle.TradeCount = candle.TradeCount
-		} else {
-			cr.candles[key] = append(cr.candles[key], candle)
-
-			// remove oldest candle if window size has been exceeded
-			if len(cr.candles[key]) > cr.windowSize {
-				index := 0
-				copy(cr.candles[key][index:], cr.candles[key][index+1:])
-				cr.candles[key][len(cr.candles[key])-1] = nil
-				cr.candles[key] = cr.candles[key][:len(cr.candles[key])-1]
-			}
+		stored = append(stored, nil)
+		copy(stored[index+1:], stored[index:])
+		stored[index] = candle
+
+		// remove oldest candle if window size has been exceeded
+		if len(stored) > cr.windowSize {
+			copy(stored[0:], stored[1:])
+			stored[len(stored)-1] = nil
+			stored = stored[:len(stored)-1]
 		}
+
+		cr.candles[key] = stored
 	}
+
+	return nil
 }
 
-func (cr *CandleRepository) Candles(key string) []*trading.Candle {
+func (cr *CandleRepository) Candles(
+	key trading.CandleKey,
+) ([]*trading.Candle, error) {
 	cr.candlesMutex.RLock()
 	defer cr.candlesMutex.RUnlock()
 
 	snapshot := make([]*trading.Candle, len(cr.candles[key]))
 	copy(snapshot, cr.candles[key])
 
-	return snapshot
+	return snapshot, nil
 }
 
-func (cr *CandleRepository) DeleteCandles(key string) {
-	cr.candlesMutex.RLock()
-	defer cr.candlesMutex.RUnlock()
+func (cr *CandleRepository) DeleteCandles(key trading.CandleKey) error {
+	cr.candlesMutex.Lock()
+	defer cr.candlesMutex.Unlock()
 
 	delete(cr.candles, key)
+
+	return nil
+}
+
+// CachedCandleRepository keeps windows of candles in memory and reads
+// through to the durable store the first time a series is accessed, e.g.
+// after a restart. Saved candles are written to the store before they
+// get cached.
+type CachedCandleRepository struct {
+	store  trading.CandleStore
+	window *CandleRepository
+
+	loadedMutex sync.Mutex
+	loaded      map[trading.CandleKey]bool
+}
+
+func NewCachedCandleRepository(
+	store trading.CandleStore,
+	windowSize int,
+) *CachedCandleRepository {
+	return &CachedCandleRepository{
+		store:  store,
+		window: NewCandleRepository(windowSize),
+		loaded: make(map[trading.CandleKey]bool),
+	}
+}
+
+func (ccr *CachedCandleRepository) SaveCandles(
+	key trading.CandleKey,
+	candles ...*trading.Candle,
+) error {
+	if err := ccr.load(key); err != nil {
+		return err
+	}
+
+	if err := ccr.store.SaveCandles(key, candles...); err != nil {
+		return fmt.Errorf("could not store candles of [%v]: [%v]", key, err)
+	}
+
+	return ccr.window.SaveCandles(key, candles...)
+}
+
+func (ccr *CachedCandleRepository) Candles(
+	key trading.CandleKey,
+) ([]*trading.Candle, error) {
+	if err := ccr.load(key); err != nil {
+		return nil, err
+	}
+
+	return ccr.window.Candles(key)
+}
+
+// DeleteCandles drops the cached window. Stored candles are retained.
+func (ccr *CachedCandleRepository) DeleteCandles(
+	key trading.CandleKey,
+) error {
+	ccr.loadedMutex.Lock()
+	defer ccr.loadedMutex.Unlock()
+
+	delete(ccr.loaded, key)
+
+	return ccr.window.DeleteCandles(key)
+}
+
+func (ccr *CachedCandleRepository) load(key trading.CandleKey) error {
+	ccr.loadedMutex.Lock()
+	defer ccr.loadedMutex.Unlock()
+
+	if ccr.loaded[key] {
+		return nil
+	}
+
+	candles, err := ccr.store.LatestCandles(key, ccr.window.windowSize)
+	if err != nil {
+		return fmt.Errorf("could not load candles of [%v]: [%v]", key, err)
+	}
+
+	if err := ccr.window.SaveCandles(key, candles...); err != nil {
+		return err
+	}
+
+	ccr.loaded[key] = true
+
+	return nil
 }
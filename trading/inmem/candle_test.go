@@ -1,11 +1,18 @@
 package inmem
 
 import (
+	"fmt"
 	"github.com/lukasz-zimnoch/dexly/trading"
 	"testing"
 	"time"
 )
 
+var testCandleKey = trading.CandleKey{
+	Exchange: "BINANCE",
+	Pair:     trading.Pair{Base: "BTC", Quote: "USDT"},
+	Interval: trading.CandleInterval1m,
+}
+
 func TestCandleRepository_SaveCandles(t *testing.T) {
 	windowSize := 5
 	repository := NewCandleRepository(windowSize)
@@ -23,9 +30,9 @@ func TestCandleRepository_SaveCandles(t *testing.T) {
 		candle(t, "2021-06-11T15:07:00Z", "2021-06-11T15:07:59Z"),
 	}
 
-	repository.SaveCandles("key", candles...)
+	saveCandles(t, repository, candles...)
 
-	actualCandles := repository.Candles("key")
+	actualCandles := windowCandles(t, repository)
 
 	if len(actualCandles) != windowSize {
 		t.Errorf(
@@ -64,6 +71,61 @@ func TestCandleRepository_SaveCandles(t *testing.T) {
 	)
 }
 
+func TestCandleRepository_SaveCandlesOutOfOrder(t *testing.T) {
+	windowSize := 4
+	repository := NewCandleRepository(windowSize)
+
+	saveCandles(
+		t,
+		repository,
+		candle(t, "2021-06-11T15:01:00Z", "2021-06-11T15:01:59Z"),
+		candle(t, "2021-06-11T15:04:00Z", "2021-06-11T15:04:59Z"),
+	)
+
+	// Backfilled candles fill the gap.
+	saveCandles(
+		t,
+		repository,
+		candle(t, "2021-06-11T15:02:00Z", "2021-06-11T15:02:59Z"),
+		candle(t, "2021-06-11T15:03:00Z", "2021-06-11T15:03:59Z"),
+		candle(t, "2021-06-11T15:04:00Z", "2021-06-11T15:04:59Z"),
+	)
+
+	// Candles older than the window are dropped right away.
+	saveCandles(
+		t,
+		repository,
+		candle(t, "2021-06-11T15:00:00Z", "2021-06-11T15:00:59Z"),
+	)
+
+	actualCandles := windowCandles(t, repository)
+
+	if len(actualCandles) != windowSize {
+		t.Fatalf(
+			"unexpected candles count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			windowSize,
+			len(actualCandles),
+		)
+	}
+
+	for index, openTime := range []string{
+		"2021-06-11T15:01:00Z",
+		"2021-06-11T15:02:00Z",
+		"2021-06-11T15:03:00Z",
+		"2021-06-11T15:04:00Z",
+	} {
+		expectedCloseTime := openTime[:len(openTime)-3] + "59Z"
+
+		assertCandlesEqual(
+			t,
+			candle(t, openTime, expectedCloseTime),
+			actualCandles[index],
+		)
+	}
+}
+
 func TestCandleRepository_DeleteCandles(t *testing.T) {
 	windowSize := 5
 	repository := NewCandleRepository(windowSize)
@@ -73,12 +135,14 @@ func TestCandleRepository_DeleteCandles(t *testing.T) {
 		candle(t, "2021-06-11T15:01:00Z", "2021-06-11T15:01:59Z"),
 	}
 
-	repository.SaveCandles("key", candles...)
+	saveCandles(t, repository, candles...)
 
-	repository.DeleteCandles("key")
+	if err := repository.DeleteCandles(testCandleKey); err != nil {
+		t.Fatal(err)
+	}
 
 	expectedCandlesCount := 0
-	actualCandlesCount := len(repository.Candles("key"))
+	actualCandlesCount := len(windowCandles(t, repository))
 
 	if actualCandlesCount != expectedCandlesCount {
 		t.Errorf(
@@ -91,6 +155,112 @@ func TestCandleRepository_DeleteCandles(t *testing.T) {
 	}
 }
 
+func TestCachedCandleRepository(t *testing.T) {
+	store := &testCandleStore{
+		candles: []*trading.Candle{
+			candle(t, "2021-06-11T15:00:00Z", "2021-06-11T15:00:59Z"),
+			candle(t, "2021-06-11T15:01:00Z", "2021-06-11T15:01:59Z"),
+			candle(t, "2021-06-11T15:02:00Z", "2021-06-11T15:02:59Z"),
+		},
+	}
+
+	windowSize := 2
+	repository := NewCachedCandleRepository(store, windowSize)
+
+	// The window is read through from the store.
+	assertCandlesCount(t, windowSize, windowCandles(t, repository))
+
+	saveCandles(
+		t,
+		repository,
+		candle(t, "2021-06-11T15:03:00Z", "2021-06-11T15:03:59Z"),
+	)
+
+	actualCandles := windowCandles(t, repository)
+	assertCandlesCount(t, windowSize, actualCandles)
+	assertCandlesEqual(
+		t,
+		candle(t, "2021-06-11T15:03:00Z", "2021-06-11T15:03:59Z"),
+		actualCandles[1],
+	)
+
+	// Saved candles are written through to the store.
+	assertCandlesCount(t, 4, store.candles)
+
+	if err := repository.DeleteCandles(testCandleKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stored candles outlive the window.
+	assertCandlesCount(t, 4, store.candles)
+	assertCandlesCount(t, windowSize, windowCandles(t, repository))
+
+	if store.loadsCount != 2 {
+		t.Errorf(
+			"unexpected loads count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			2,
+			store.loadsCount,
+		)
+	}
+}
+
+func TestCachedCandleRepository_StoreError(t *testing.T) {
+	store := &testCandleStore{err: fmt.Errorf("unavailable")}
+	repository := NewCachedCandleRepository(store, 5)
+
+	if _, err := repository.Candles(testCandleKey); err == nil {
+		t.Errorf("load error should be returned")
+	}
+
+	err := repository.SaveCandles(
+		testCandleKey,
+		candle(t, "2021-06-11T15:00:00Z", "2021-06-11T15:00:59Z"),
+	)
+	if err == nil {
+		t.Errorf("store error should be returned")
+	}
+}
+
+func saveCandles(
+	t *testing.T,
+	repository trading.CandleRepository,
+	candles ...*trading.Candle,
+) {
+	if err := repository.SaveCandles(testCandleKey, candles...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func windowCandles(
+	t *testing.T,
+	repository trading.CandleRepository,
+) []*trading.Candle {
+	candles, err := repository.Candles(testCandleKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return candles
+}
+
+func assertCandlesCount(
+	t *testing.T,
+	expected int,
+	actual []*trading.Candle,
+) {
+	if len(actual) != expected {
+		t.Fatalf(
+			"unexpected candles count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expected,
+			len(actual),
+		)
+	}
+}
+
 func assertCandlesEqual(
 	t *testing.T,
 	expected *trading.Candle,
@@ -122,3 +292,60 @@ func parseTime(t *testing.T, value string) time.Time {
 
 	return time
 }
+
+// testCandleStore keeps candles of a single series.
+type testCandleStore struct {
+	candles    []*trading.Candle
+	loadsCount int
+	err        error
+}
+
+func (tcs *testCandleStore) SaveCandles(
+	_ trading.CandleKey,
+	candles ...*trading.Candle,
+) error {
+	if tcs.err != nil {
+		return tcs.err
+	}
+
+	tcs.candles = append(tcs.candles, candles...)
+	return nil
+}
+
+func (tcs *testCandleStore) CandlesRange(
+	_ trading.CandleKey,
+	start, end time.Time,
+) ([]*trading.Candle, error) {
+	candles := make([]*trading.Candle, 0)
+	for _, candle := range tcs.candles {
+		if !candle.OpenTime.Before(start) && !candle.OpenTime.After(end) {
+			candles = append(candles, candle)
+		}
+	}
+
+	return candles, tcs.err
+}
+
+func (tcs *testCandleStore) LatestCandles(
+	_ trading.CandleKey,
+	count int,
+) ([]*trading.Candle, error) {
+	tcs.loadsCount++
+
+	if tcs.err != nil {
+		return nil, tcs.err
+	}
+
+	if len(tcs.candles) <= count {
+		return tcs.candles, nil
+	}
+
+	return tcs.candles[len(tcs.candles)-count:], nil
+}
+
+func (tcs *testCandleStore) DeleteCandlesBefore(
+	_ trading.CandleInterval,
+	_ time.Time,
+) (int64, error) {
+	return 0, tcs.err
+}
@@ -0,0 +1,91 @@
+package inmem
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
+)
+
+type OrderRepository struct {
+	ordersMutex sync.RWMutex
+	orders      map[string]*orderEntry
+}
+
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{
+		orders: make(map[string]*orderEntry),
+	}
+}
+
+func (or *OrderRepository) CreateOrder(order *trading.Order) error {
+	or.ordersMutex.Lock()
+	defer or.ordersMutex.Unlock()
+
+	if _, exists := or.orders[order.ID.String()]; exists {
+		return fmt.Errorf("order [%v] already exists", order.ID)
+	}
+
+	for _, entry := range or.orders {
+		if entry.positionID == order.Position.ID.String() &&
+			entry.order.Side == order.Side {
+			return fmt.Errorf(
+				"position [%v] already has a [%v] order",
+				order.Position.ID,
+				order.Side,
+			)
+		}
+	}
+
+	or.orders[order.ID.String()] = newOrderEntry(order)
+
+	return nil
+}
+
+func (or *OrderRepository) UpdateOrder(order *trading.Order) error {
+	or.ordersMutex.Lock()
+	defer or.ordersMutex.Unlock()
+
+	entry, exists := or.orders[order.ID.String()]
+	if !exists {
+		return fmt.Errorf("order [%v] does not exist", order.ID)
+	}
+
+	entry.order.Executed = order.Executed
+
+	return nil
+}
+
+// positionOrders returns copies of all orders belonging to the given position.
+// The Position field of returned orders is not set.
+func (or *OrderRepository) positionOrders(positionID string) []*trading.Order {
+	or.ordersMutex.RLock()
+	defer or.ordersMutex.RUnlock()
+
+	orders := make([]*trading.Order, 0)
+
+	for _, entry := range or.orders {
+		if entry.positionID == positionID {
+			order := entry.order
+			orders = append(orders, &order)
+		}
+	}
+
+	return orders
+}
+
+type orderEntry struct {
+	positionID string
+	order      trading.Order
+}
+
+func newOrderEntry(order *trading.Order) *orderEntry {
+	entry := &orderEntry{
+		positionID: order.Position.ID.String(),
+		order:      *order,
+	}
+
+	// Position should be set by the reader.
+	entry.order.Position = nil
+
+	return entry
+}
@@ -0,0 +1,141 @@
+package inmem
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
+)
+
+type PositionRepository struct {
+	orderRepository *OrderRepository
+
+	positionsMutex sync.RWMutex
+	positions      map[string]*trading.Position
+}
+
+func NewPositionRepository(
+	orderRepository *OrderRepository,
+) *PositionRepository {
+	return &PositionRepository{
+		orderRepository: orderRepository,
+		positions:       make(map[string]*trading.Position),
+	}
+}
+
+func (pr *PositionRepository) CreatePosition(position *trading.Position) error {
+	pr.positionsMutex.Lock()
+	defer pr.positionsMutex.Unlock()
+
+	if _, exists := pr.positions[position.ID.String()]; exists {
+		return fmt.Errorf("position [%v] already exists", position.ID)
+	}
+
+	stored := *position
+	stored.Orders = nil // Orders are kept by the order repository.
+
+	pr.positions[position.ID.String()] = &stored
+
+	return nil
+}
+
+func (pr *PositionRepository) UpdatePosition(position *trading.Position) error {
+	pr.positionsMutex.Lock()
+	defer pr.positionsMutex.Unlock()
+
+	stored, exists := pr.positions[position.ID.String()]
+	if !exists {
+		return fmt.Errorf("position [%v] does not exist", position.ID)
+	}
+
+	stored.Status = position.Status
+
+	return nil
+}
+
+func (pr *PositionRepository) RequestPositionExit(positionID trading.ID) error {
+	pr.positionsMutex.Lock()
+	defer pr.positionsMutex.Unlock()
+
+	stored, exists := pr.positions[positionID.String()]
+	if !exists || stored.Status != trading.StatusOpen {
+		return trading.ErrNotFound
+	}
+
+	stored.ExitRequested = true
+
+	return nil
+}
+
+func (pr *PositionRepository) Position(
+	positionID trading.ID,
+) (*trading.Position, error) {
+	pr.positionsMutex.RLock()
+	defer pr.positionsMutex.RUnlock()
+
+	stored, exists := pr.positions[positionID.String()]
+	if !exists {
+		return nil, trading.ErrNotFound
+	}
+
+	return pr.withOrders(stored), nil
+}
+
+func (pr *PositionRepository) Positions(
+	filter trading.PositionFilter,
+) ([]*trading.Position, error) {
+	pr.positionsMutex.RLock()
+	defer pr.positionsMutex.RUnlock()
+
+	positions := make([]*trading.Position, 0)
+
+	for _, stored := range pr.positions {
+		if !matchesFilter(stored, filter) {
+			continue
+		}
+
+		positions = append(positions, pr.withOrders(stored))
+	}
+
+	return positions, nil
+}
+
+func (pr *PositionRepository) PositionsCount(
+	filter trading.PositionFilter,
+) (int, error) {
+	pr.positionsMutex.RLock()
+	defer pr.positionsMutex.RUnlock()
+
+	count := 0
+
+	for _, stored := range pr.positions {
+		if matchesFilter(stored, filter) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// withOrders returns a copy of the stored position with its orders attached.
+func (pr *PositionRepository) withOrders(
+	stored *trading.Position,
+) *trading.Position {
+	position := *stored
+	position.Orders = pr.orderRepository.positionOrders(
+		position.ID.String(),
+	)
+
+	for _, order := range position.Orders {
+		order.Position = &position
+	}
+
+	return &position
+}
+
+func matchesFilter(
+	position *trading.Position,
+	filter trading.PositionFilter,
+) bool {
+	return position.WorkloadID.String() == filter.WorkloadID.String() &&
+		position.Status == filter.Status
+}
@@ -0,0 +1,122 @@
+package inmem
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
+)
+
+type WorkloadRepository struct {
+	accountRepository *AccountRepository
+
+	workloadsMutex sync.RWMutex
+	workloads      map[string]*workloadEntry
+	// Workloads are listed in the order of creation.
+	workloadIDs []string
+}
+
+func NewWorkloadRepository(
+	accountRepository *AccountRepository,
+) *WorkloadRepository {
+	return &WorkloadRepository{
+		accountRepository: accountRepository,
+		workloads:         make(map[string]*workloadEntry),
+	}
+}
+
+func (wr *WorkloadRepository) CreateWorkload(workload *trading.Workload) error {
+	wr.workloadsMutex.Lock()
+	defer wr.workloadsMutex.Unlock()
+
+	if _, exists := wr.workloads[workload.ID.String()]; exists {
+		return fmt.Errorf("workload [%v] already exists", workload.ID)
+	}
+
+	wr.workloads[workload.ID.String()] = newWorkloadEntry(workload)
+	wr.workloadIDs = append(wr.workloadIDs, workload.ID.String())
+
+	return nil
+}
+
+func (wr *WorkloadRepository) UpdateWorkload(workload *trading.Workload) error {
+	wr.workloadsMutex.Lock()
+	defer wr.workloadsMutex.Unlock()
+
+	entry, exists := wr.workloads[workload.ID.String()]
+	if !exists {
+		return fmt.Errorf("workload [%v] does not exist", workload.ID)
+	}
+
+	entry.workload.Status = workload.Status
+
+	return nil
+}
+
+func (wr *WorkloadRepository) Workload(
+	workloadID trading.ID,
+) (*trading.Workload, error) {
+	wr.workloadsMutex.RLock()
+	defer wr.workloadsMutex.RUnlock()
+
+	entry, exists := wr.workloads[workloadID.String()]
+	if !exists {
+		return nil, trading.ErrNotFound
+	}
+
+	return wr.withAccount(entry)
+}
+
+func (wr *WorkloadRepository) Workloads() ([]*trading.Workload, error) {
+	wr.workloadsMutex.RLock()
+	defer wr.workloadsMutex.RUnlock()
+
+	workloads := make([]*trading.Workload, 0)
+
+	for _, workloadID := range wr.workloadIDs {
+		workload, err := wr.withAccount(wr.workloads[workloadID])
+		if err != nil {
+			return nil, err
+		}
+
+		workloads = append(workloads, workload)
+	}
+
+	return workloads, nil
+}
+
+// withAccount returns a copy of the stored workload with its account
+// attached.
+func (wr *WorkloadRepository) withAccount(
+	entry *workloadEntry,
+) (*trading.Workload, error) {
+	account, err := wr.accountRepository.Account(entry.accountID)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not get account of workload [%v]: [%v]",
+			entry.workload.ID,
+			err,
+		)
+	}
+
+	workload := entry.workload
+	workload.Account = account
+
+	return &workload, nil
+}
+
+type workloadEntry struct {
+	accountID trading.ID
+	workload  trading.Workload
+}
+
+func newWorkloadEntry(workload *trading.Workload) *workloadEntry {
+	entry := &workloadEntry{
+		accountID: workload.Account.ID,
+		workload:  *workload,
+	}
+
+	// Account should be set by the reader.
+	entry.workload.Account = nil
+
+	return entry
+}
@@ -0,0 +1,98 @@
+package kraken
+
+import (
+	"context"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/url"
+)
+
+func (es *ExchangeService) AccountBalances(
+	ctx context.Context,
+) (trading.Balances, error) {
+	var result map[string]string
+
+	err := es.client.private(ctx, "/0/private/Balance", url.Values{}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(trading.Balances)
+
+	for code, value := range result {
+		asset, ok := parseAssetCode(code)
+		if !ok {
+			continue
+		}
+
+		amount, err := trading.ParseDecimal(value)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse balance for asset [%v]: [%v]",
+				code,
+				err,
+			)
+		}
+
+		if amount.IsZero() {
+			continue
+		}
+
+		balances[asset] = amount
+	}
+
+	return balances, nil
+}
+
+func (es *ExchangeService) AccountTakerCommission(
+	ctx context.Context,
+) (trading.Decimal, error) {
+	var result struct {
+		Fees map[string]struct {
+			Fee string `json:"fee"`
+		} `json:"fees"`
+	}
+
+	err := es.client.private(
+		ctx,
+		"/0/private/TradeVolume",
+		url.Values{"pair": {pairName(es.workload.Pair)}},
+		&result,
+	)
+	if err != nil {
+		return trading.Decimal{}, err
+	}
+
+	fee, ok := result.Fees[es.pairKey]
+	if !ok {
+		return trading.Decimal{}, fmt.Errorf(
+			"could not find fee of [%v]",
+			es.pairKey,
+		)
+	}
+
+	takerCommission, err := trading.ParseDecimal(fee.Fee)
+	if err != nil {
+		return trading.Decimal{}, fmt.Errorf("could not parse fee: [%v]", err)
+	}
+
+	// The fee is expressed in percents.
+	return takerCommission.Div(trading.NewDecimal(100, 0)), nil
+}
+
+// AccountShortSellingAllowed always returns false as the service operates
+// on spot accounts only.
+func (es *ExchangeService) AccountShortSellingAllowed(
+	_ context.Context,
+) (bool, error) {
+	return false, nil
+}
+
+// AccountUpdates returns a channel which never reports errors as the
+// service doesn't subscribe account updates and always polls the
+// exchange instead.
+func (es *ExchangeService) AccountUpdates(
+	_ context.Context,
+) <-chan error {
+	return make(chan error)
+}
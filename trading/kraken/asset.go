@@ -0,0 +1,53 @@
+package kraken
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"strings"
+)
+
+// assetCodes holds assets which Kraken names differently in the REST API.
+var assetCodes = map[trading.Asset]string{
+	"BTC":  "XBT",
+	"DOGE": "XDG",
+}
+
+func assetCode(asset trading.Asset) string {
+	if code, exists := assetCodes[asset]; exists {
+		return code
+	}
+
+	return string(asset)
+}
+
+// parseAssetCode converts codes used in balances to assets. Legacy codes
+// are prefixed with X (crypto assets) or Z (fiat assets), e.g. XXBT or
+// ZUSD. Codes with suffixes denote balances which cannot be traded, like
+// staked ones, so they are not parsed.
+func parseAssetCode(code string) (trading.Asset, bool) {
+	if strings.Contains(code, ".") {
+		return "", false
+	}
+
+	if len(code) == 4 && (code[0] == 'X' || code[0] == 'Z') {
+		code = code[1:]
+	}
+
+	for asset, assetCode := range assetCodes {
+		if assetCode == code {
+			return asset, true
+		}
+	}
+
+	return trading.Asset(code), true
+}
+
+// pairName returns the name of the pair used in REST API requests.
+func pairName(pair trading.Pair) string {
+	return assetCode(pair.Base) + assetCode(pair.Quote)
+}
+
+// pairSymbol returns the symbol of the pair used by the websocket API
+// which, unlike the REST API, uses common asset codes.
+func pairSymbol(pair trading.Pair) string {
+	return string(pair.Base) + "/" + string(pair.Quote)
+}
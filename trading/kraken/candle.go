@@ -0,0 +1,335 @@
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// intervalMinutes returns the duration of the interval expressed in
+// minutes as Kraken expects.
+func intervalMinutes(interval trading.CandleInterval) int {
+	return int(interval.Duration() / time.Minute)
+}
+
+// Candles streams candles page by page. Kraken serves only the most
+// recent candles, up to 720 of them, so the beginning of older ranges is
+// not available.
+func (es *ExchangeService) Candles(
+	ctx context.Context,
+	interval trading.CandleInterval,
+	start,
+	end time.Time,
+) (<-chan *trading.Candle, <-chan error) {
+	return trading.StreamCandles(
+		ctx,
+		interval,
+		start,
+		end,
+		func(
+			ctx context.Context,
+			start, end time.Time,
+		) ([]*trading.Candle, error) {
+			return es.candlesPage(ctx, interval, start, end)
+		},
+	)
+}
+
+func (es *ExchangeService) candlesPage(
+	ctx context.Context,
+	interval trading.CandleInterval,
+	start,
+	end time.Time,
+) ([]*trading.Candle, error) {
+	var result map[string]interface{}
+
+	err := es.client.public(
+		ctx,
+		"/0/public/OHLC",
+		url.Values{
+			"pair":     {pairName(es.workload.Pair)},
+			"interval": {strconv.Itoa(intervalMinutes(interval))},
+			// Kraken returns candles opened after the given time.
+			"since": {strconv.FormatInt(start.Unix()-1, 10)},
+		},
+		&result,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, ok := result[es.pairKey].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not find candles of [%v]", es.pairKey)
+	}
+
+	candles := make([]*trading.Candle, 0)
+
+	for _, entry := range entries {
+		candle, err := parseCandleEntry(entry, interval)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse candle: [%v]", err)
+		}
+
+		// The end of the range can't be passed to Kraken.
+		if candle.OpenTime.Before(start) || candle.OpenTime.After(end) {
+			continue
+		}
+
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+// parseCandleEntry parses an entry in the form of [time, open, high, low,
+// close, vwap, volume, count].
+func parseCandleEntry(
+	entry interface{},
+	interval trading.CandleInterval,
+) (*trading.Candle, error) {
+	fields, ok := entry.([]interface{})
+	if !ok || len(fields) < 8 {
+		return nil, fmt.Errorf("invalid entry: [%v]", entry)
+	}
+
+	values := make([]string, len(fields))
+	for index, field := range fields {
+		values[index] = fmt.Sprint(field)
+	}
+
+	openTime, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse time: [%v]", err)
+	}
+
+	tradeCount, err := strconv.ParseUint(values[7], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse count: [%v]", err)
+	}
+
+	return parseCandle(
+		time.Unix(openTime, 0),
+		interval,
+		values[1],
+		values[4],
+		values[2],
+		values[3],
+		values[6],
+		tradeCount,
+	)
+}
+
+func (es *ExchangeService) CandlesTicker(
+	ctx context.Context,
+	interval trading.CandleInterval,
+) (<-chan *trading.CandleTick, <-chan error) {
+	tickChannel := make(chan *trading.CandleTick)
+	errorChannel := make(chan error)
+
+	go func() {
+		err := es.serveCandlesStream(ctx, interval, tickChannel)
+		if err != nil {
+			select {
+			case errorChannel <- err:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return tickChannel, errorChannel
+}
+
+type ohlcData struct {
+	Symbol        string      `json:"symbol"`
+	Open          json.Number `json:"open"`
+	High          json.Number `json:"high"`
+	Low           json.Number `json:"low"`
+	Close         json.Number `json:"close"`
+	Volume        json.Number `json:"volume"`
+	Trades        uint64      `json:"trades"`
+	IntervalBegin string      `json:"interval_begin"`
+	Timestamp     string      `json:"timestamp"`
+}
+
+// serveCandlesStream subscribes candles using the websocket API and
+// sends ticks until the stream fails or the context is done. See
+// https://docs.kraken.com/websockets-v2/#ohlc
+func (es *ExchangeService) serveCandlesStream(
+	ctx context.Context,
+	interval trading.CandleInterval,
+	tickChannel chan<- *trading.CandleTick,
+) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(
+		ctx,
+		es.websocketURL,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("could not connect candles stream: [%v]", err)
+	}
+
+	// Closing the connection unblocks the pending read.
+	doneChannel := make(chan struct{})
+	defer close(doneChannel)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-doneChannel:
+		}
+		_ = conn.Close()
+	}()
+
+	symbol := pairSymbol(es.workload.Pair)
+
+	err = conn.WriteJSON(map[string]interface{}{
+		"method": "subscribe",
+		"params": map[string]interface{}{
+			"channel":  "ohlc",
+			"symbol":   []string{symbol},
+			"interval": intervalMinutes(interval),
+			// Past candles are fetched using the REST API.
+			"snapshot": false,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not subscribe candles: [%v]", err)
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("candles stream failed: [%v]", err)
+		}
+
+		var message struct {
+			Method  string     `json:"method"`
+			Success bool       `json:"success"`
+			Error   string     `json:"error"`
+			Channel string     `json:"channel"`
+			Type    string     `json:"type"`
+			Data    []ohlcData `json:"data"`
+		}
+
+		if err := json.Unmarshal(data, &message); err != nil {
+			return fmt.Errorf("could not unmarshal message: [%v]", err)
+		}
+
+		if message.Method == "subscribe" && !message.Success {
+			return fmt.Errorf(
+				"could not subscribe candles: [%v]",
+				message.Error,
+			)
+		}
+
+		// Candles are pushed only once a trade happens so heartbeats,
+		// sent every second, report the stream is alive.
+		if message.Channel == "heartbeat" {
+			select {
+			case tickChannel <- &trading.CandleTick{TickTime: time.Now()}:
+			case <-ctx.Done():
+				return nil
+			}
+
+			continue
+		}
+
+		// Other messages, like status updates, are not relevant.
+		if message.Channel != "ohlc" || message.Type != "update" {
+			continue
+		}
+
+		for _, ohlc := range message.Data {
+			if ohlc.Symbol != symbol {
+				continue
+			}
+
+			tick, err := parseCandleTick(ohlc, interval)
+			if err != nil {
+				return fmt.Errorf("could not parse candle tick: [%v]", err)
+			}
+
+			select {
+			case tickChannel <- tick:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+func parseCandleTick(
+	ohlc ohlcData,
+	interval trading.CandleInterval,
+) (*trading.CandleTick, error) {
+	openTime, err := time.Parse(time.RFC3339Nano, ohlc.IntervalBegin)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse interval begin: [%v]", err)
+	}
+
+	tickTime, err := time.Parse(time.RFC3339Nano, ohlc.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse timestamp: [%v]", err)
+	}
+
+	candle, err := parseCandle(
+		openTime,
+		interval,
+		ohlc.Open.String(),
+		ohlc.Close.String(),
+		ohlc.High.String(),
+		ohlc.Low.String(),
+		ohlc.Volume.String(),
+		ohlc.Trades,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trading.CandleTick{
+		Candle:   candle,
+		TickTime: tickTime,
+	}, nil
+}
+
+func parseCandle(
+	openTime time.Time,
+	interval trading.CandleInterval,
+	openPrice, closePrice, maxPrice, minPrice, volume string,
+	tradeCount uint64,
+) (*trading.Candle, error) {
+	values := make([]trading.Decimal, 5)
+
+	for index, value := range []string{
+		openPrice, closePrice, maxPrice, minPrice, volume,
+	} {
+		decimal, err := trading.ParseDecimal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		values[index] = decimal
+	}
+
+	// Kraken doesn't send close times so they are set the same way as
+	// other exchanges do, i.e. to the last millisecond of the candle.
+	return &trading.Candle{
+		OpenTime:   openTime,
+		CloseTime:  openTime.Add(interval.Duration() - time.Millisecond),
+		OpenPrice:  values[0],
+		ClosePrice: values[1],
+		MaxPrice:   values[2],
+		MinPrice:   values[3],
+		Volume:     values[4],
+		TradeCount: uint(tradeCount),
+	}, nil
+}
@@ -0,0 +1,36 @@
+package kraken
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"strings"
+)
+
+// Errors returned when the API key is invalid, lacks permissions or the
+// request signature doesn't match the secret key. See
+// https://docs.kraken.com/rest/#section/General-Usage/Error-details
+var authErrors = map[string]bool{
+	"EAPI:Invalid key":           true,
+	"EAPI:Invalid signature":     true,
+	"EGeneral:Permission denied": true,
+}
+
+// apiError holds error messages returned by Kraken.
+type apiError struct {
+	messages []string
+}
+
+func (ae *apiError) Error() string {
+	return strings.Join(ae.messages, ", ")
+}
+
+// classifyError marks authentication errors as fatal as retrying requests
+// using rejected credentials makes no sense.
+func classifyError(err *apiError) error {
+	for _, message := range err.messages {
+		if authErrors[message] {
+			return trading.NewFatalError(err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,217 @@
+package kraken
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiBaseURL     = "https://api.kraken.com"
+	websocketURL   = "wss://ws.kraken.com/v2"
+	requestTimeout = 1 * time.Minute
+)
+
+// ExchangeService is an exchange service of Kraken spot accounts.
+type ExchangeService struct {
+	client       *client
+	websocketURL string
+	tradingRules *trading.TradingRules
+	workload     *trading.Workload
+	// pairKey is the name Kraken uses for the pair in responses. It may
+	// differ from the name used in requests.
+	pairKey string
+}
+
+func NewExchangeService(
+	ctx context.Context,
+	workload *trading.Workload,
+) (*ExchangeService, error) {
+	return newExchangeService(ctx, workload, apiBaseURL, websocketURL)
+}
+
+func newExchangeService(
+	ctx context.Context,
+	workload *trading.Workload,
+	baseURL string,
+	websocketURL string,
+) (*ExchangeService, error) {
+	secretKey, err := base64.StdEncoding.DecodeString(
+		workload.Account.ExchangeSecretKey,
+	)
+	if err != nil {
+		return nil, trading.NewFatalError(
+			fmt.Errorf("could not decode secret key: [%v]", err),
+		)
+	}
+
+	client := &client{
+		baseURL:    baseURL,
+		apiKey:     workload.Account.ExchangeApiKey,
+		secretKey:  secretKey,
+		httpClient: &http.Client{},
+	}
+
+	pairKey, tradingRules, err := fetchTradingRules(ctx, client, workload.Pair)
+	if err != nil {
+		return nil, fmt.Errorf("could not get trading rules: [%v]", err)
+	}
+
+	return &ExchangeService{
+		client:       client,
+		websocketURL: websocketURL,
+		tradingRules: tradingRules,
+		workload:     workload,
+		pairKey:      pairKey,
+	}, nil
+}
+
+func (es *ExchangeService) Workload() *trading.Workload {
+	return es.workload
+}
+
+// client calls the Kraken REST API. See
+// https://docs.kraken.com/rest/#section/General-Usage
+type client struct {
+	baseURL    string
+	apiKey     string
+	secretKey  []byte
+	httpClient *http.Client
+}
+
+// public calls a public endpoint and decodes its result.
+func (c *client) public(
+	ctx context.Context,
+	path string,
+	params url.Values,
+	result interface{},
+) error {
+	requestCtx, cancelRequestCtx := context.WithTimeout(ctx, requestTimeout)
+	defer cancelRequestCtx()
+
+	request, err := http.NewRequestWithContext(
+		requestCtx,
+		http.MethodGet,
+		c.baseURL+path+"?"+params.Encode(),
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	return c.do(request, result)
+}
+
+// private calls a private endpoint signing the request with the secret
+// key and decodes its result.
+func (c *client) private(
+	ctx context.Context,
+	path string,
+	params url.Values,
+	result interface{},
+) error {
+	requestCtx, cancelRequestCtx := context.WithTimeout(ctx, requestTimeout)
+	defer cancelRequestCtx()
+
+	nonce := nextNonce()
+	params.Set("nonce", nonce)
+	body := params.Encode()
+
+	request, err := http.NewRequestWithContext(
+		requestCtx,
+		http.MethodPost,
+		c.baseURL+path,
+		strings.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("API-Key", c.apiKey)
+	request.Header.Set("API-Sign", c.sign(path, nonce, body))
+
+	return c.do(request, result)
+}
+
+// sign computes HMAC-SHA512 of the path and SHA256 of the nonce and
+// the request body.
+func (c *client) sign(path, nonce, body string) string {
+	bodyHash := sha256.Sum256([]byte(nonce + body))
+
+	mac := hmac.New(sha512.New, c.secretKey)
+	mac.Write(append([]byte(path), bodyHash[:]...))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *client) do(request *http.Request, result interface{}) error {
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response: [%v]", err)
+	}
+
+	var envelope struct {
+		Error  []string        `json:"error"`
+		Result json.RawMessage `json:"result"`
+	}
+
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf(
+			"could not unmarshal response with status [%v]: [%v]",
+			response.StatusCode,
+			err,
+		)
+	}
+
+	if len(envelope.Error) > 0 {
+		return classifyError(&apiError{envelope.Error})
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(envelope.Result)))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(result); err != nil {
+		return fmt.Errorf("could not unmarshal result: [%v]", err)
+	}
+
+	return nil
+}
+
+var (
+	nonceMutex sync.Mutex
+	lastNonce  int64
+)
+
+// nextNonce returns an always increasing nonce as Kraken rejects nonces
+// lower than the previous one used with the same API key.
+func nextNonce() string {
+	nonceMutex.Lock()
+	defer nonceMutex.Unlock()
+
+	nonce := time.Now().UnixNano() / int64(time.Microsecond)
+	if nonce <= lastNonce {
+		nonce = lastNonce + 1
+	}
+	lastNonce = nonce
+
+	return strconv.FormatInt(nonce, 10)
+}
@@ -0,0 +1,445 @@
+package kraken
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/exchangetest"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExchangeService_Conformance(t *testing.T) {
+	exchangetest.Run(t, func(
+		t *testing.T,
+		exchange *exchangetest.Exchange,
+		workload *trading.Workload,
+	) trading.ExchangeService {
+		server := httptest.NewServer(newStandInHandler(t, exchange, workload))
+		t.Cleanup(server.Close)
+
+		service, err := newExchangeService(
+			context.Background(),
+			workload,
+			server.URL,
+			"ws"+strings.TrimPrefix(server.URL, "http")+"/v2",
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return service
+	})
+}
+
+func TestParseAssetCode(t *testing.T) {
+	tests := map[string]struct {
+		code          string
+		expectedAsset trading.Asset
+		expectedOk    bool
+	}{
+		"legacy crypto code": {"XXBT", "BTC", true},
+		"legacy fiat code":   {"ZUSD", "USD", true},
+		"aliased code":       {"XDG", "DOGE", true},
+		"common code":        {"USDT", "USDT", true},
+		"short code":         {"XTZ", "XTZ", true},
+		"staked balance":     {"DOT.S", "", false},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			asset, ok := parseAssetCode(test.code)
+
+			if asset != test.expectedAsset || ok != test.expectedOk {
+				t.Errorf(
+					"unexpected asset\n"+
+						"expected: [%v, %v]\n"+
+						"actual:   [%v, %v]",
+					test.expectedAsset,
+					test.expectedOk,
+					asset,
+					ok,
+				)
+			}
+		})
+	}
+}
+
+// newStandInHandler serves the stand-in exchange using the Kraken API.
+func newStandInHandler(
+	t *testing.T,
+	exchange *exchangetest.Exchange,
+	workload *trading.Workload,
+) http.Handler {
+	pair := pairName(exchange.Pair)
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/0/public/AssetPairs", func(
+		responseWriter http.ResponseWriter,
+		_ *http.Request,
+	) {
+		rules := exchange.TradingRules
+
+		writeResult(t, responseWriter, map[string]interface{}{
+			pair: map[string]interface{}{
+				"altname":       pair,
+				"wsname":        pairSymbol(exchange.Pair),
+				"pair_decimals": decimalPlaces(rules.PriceTick),
+				"lot_decimals":  decimalPlaces(rules.LotStep),
+				"tick_size":     rules.PriceTick.String(),
+				"ordermin":      rules.MinQuantity.String(),
+				"costmin":       rules.MinNotional.String(),
+			},
+		})
+	})
+
+	mux.HandleFunc("/0/public/OHLC", func(
+		responseWriter http.ResponseWriter,
+		request *http.Request,
+	) {
+		query := request.URL.Query()
+
+		interval := strconv.Itoa(intervalMinutes(exchange.CandleInterval))
+		if query.Get("interval") != interval {
+			writeError(t, responseWriter, "EGeneral:Invalid arguments")
+			return
+		}
+
+		since, _ := strconv.ParseInt(query.Get("since"), 10, 64)
+
+		entries := make([][]interface{}, 0)
+
+		for _, candle := range exchange.Candles(
+			time.Unix(since+1, 0),
+			time.Now(),
+		) {
+			entries = append(entries, []interface{}{
+				candle.OpenTime.Unix(),
+				candle.OpenPrice.String(),
+				candle.MaxPrice.String(),
+				candle.MinPrice.String(),
+				candle.ClosePrice.String(),
+				"0",
+				candle.Volume.String(),
+				candle.TradeCount,
+			})
+		}
+
+		writeResult(t, responseWriter, map[string]interface{}{
+			pair:   entries,
+			"last": time.Now().Unix(),
+		})
+	})
+
+	mux.HandleFunc("/0/private/Balance", private(
+		t,
+		workload,
+		func(_ *http.Request) (interface{}, error) {
+			balances := make(map[string]string)
+			for asset, balance := range exchange.Balances() {
+				code := assetCode(asset)
+				// Older assets have legacy codes.
+				if code == "XBT" {
+					code = "XXBT"
+				}
+
+				balances[code] = balance.String()
+			}
+
+			// Balances which can't be traded are skipped.
+			balances["XBT.F"] = "1"
+
+			return balances, nil
+		},
+	))
+
+	mux.HandleFunc("/0/private/TradeVolume", private(
+		t,
+		workload,
+		func(_ *http.Request) (interface{}, error) {
+			fee := exchange.TakerCommission.Mul(trading.NewDecimal(100, 0))
+
+			return map[string]interface{}{
+				"currency": "ZUSD",
+				"volume":   "0",
+				"fees": map[string]interface{}{
+					pair: map[string]string{"fee": fee.String()},
+				},
+			}, nil
+		},
+	))
+
+	mux.HandleFunc("/0/private/AddOrder", private(
+		t,
+		workload,
+		func(request *http.Request) (interface{}, error) {
+			side, err := trading.ParseOrderSide(
+				strings.ToUpper(request.Form.Get("type")),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			price, err := trading.ParseDecimal(request.Form.Get("price"))
+			if err != nil {
+				return nil, err
+			}
+
+			size, err := trading.ParseDecimal(request.Form.Get("volume"))
+			if err != nil {
+				return nil, err
+			}
+
+			order, err := exchange.PlaceOrder(
+				request.Form.Get("cl_ord_id"),
+				side,
+				price,
+				size,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			return map[string]interface{}{
+				"txid": []string{transactionID(order)},
+			}, nil
+		},
+	))
+
+	mux.HandleFunc("/0/private/ClosedOrders", private(
+		t,
+		workload,
+		func(request *http.Request) (interface{}, error) {
+			closed := make(map[string]interface{})
+
+			order, exists := exchange.Order(request.Form.Get("cl_ord_id"))
+			if exists {
+				status, executedVolume := "canceled", "0"
+				if order.Filled {
+					status, executedVolume = "closed", order.Size.String()
+				}
+
+				closed[transactionID(order)] = map[string]string{
+					"cl_ord_id": order.ID,
+					"status":    status,
+					"vol":       order.Size.String(),
+					"vol_exec":  executedVolume,
+				}
+			}
+
+			return map[string]interface{}{
+				"closed": closed,
+				"count":  len(closed),
+			}, nil
+		},
+	))
+
+	mux.HandleFunc("/v2", func(
+		responseWriter http.ResponseWriter,
+		request *http.Request,
+	) {
+		serveStandInStream(t, responseWriter, request, exchange)
+	})
+
+	return mux
+}
+
+// private verifies the signature of the request before handling it.
+func private(
+	t *testing.T,
+	workload *trading.Workload,
+	handle func(request *http.Request) (interface{}, error),
+) http.HandlerFunc {
+	return func(responseWriter http.ResponseWriter, request *http.Request) {
+		if err := request.ParseForm(); err != nil {
+			t.Error(err)
+			return
+		}
+
+		secretKey, err := base64.StdEncoding.DecodeString(
+			workload.Account.ExchangeSecretKey,
+		)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		signer := &client{secretKey: secretKey}
+		signature := signer.sign(
+			request.URL.Path,
+			request.Form.Get("nonce"),
+			request.PostForm.Encode(),
+		)
+
+		if request.Header.Get("API-Key") != workload.Account.ExchangeApiKey ||
+			request.Header.Get("API-Sign") != signature {
+			writeError(t, responseWriter, "EAPI:Invalid signature")
+			return
+		}
+
+		result, err := handle(request)
+		if err != nil {
+			writeError(t, responseWriter, "EOrder:"+err.Error())
+			return
+		}
+
+		writeResult(t, responseWriter, result)
+	}
+}
+
+// serveStandInStream sends candle ticks published by the exchange to
+// the websocket client once it subscribes them.
+func serveStandInStream(
+	t *testing.T,
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	exchange *exchangetest.Exchange,
+) {
+	upgrader := websocket.Upgrader{}
+
+	conn, err := upgrader.Upgrade(responseWriter, request, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	var subscription struct {
+		Method string `json:"method"`
+		Params struct {
+			Channel  string   `json:"channel"`
+			Symbol   []string `json:"symbol"`
+			Interval int      `json:"interval"`
+		} `json:"params"`
+	}
+
+	if err := conn.ReadJSON(&subscription); err != nil {
+		t.Error(err)
+		return
+	}
+
+	symbol := pairSymbol(exchange.Pair)
+	interval := intervalMinutes(exchange.CandleInterval)
+
+	if subscription.Method != "subscribe" ||
+		subscription.Params.Channel != "ohlc" ||
+		subscription.Params.Interval != interval ||
+		len(subscription.Params.Symbol) != 1 ||
+		subscription.Params.Symbol[0] != symbol {
+		t.Errorf("unexpected subscription: [%+v]", subscription)
+		return
+	}
+
+	tickChannel, unsubscribe := exchange.SubscribeCandles()
+	defer unsubscribe()
+
+	err = conn.WriteJSON(map[string]interface{}{
+		"method":  "subscribe",
+		"success": true,
+	})
+	if err != nil {
+		return
+	}
+
+	// Kraken sends heartbeats while no trades happen.
+	if err := conn.WriteJSON(map[string]interface{}{
+		"channel": "heartbeat",
+	}); err != nil {
+		return
+	}
+
+	closedChannel := make(chan struct{})
+	go func() {
+		defer close(closedChannel)
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case tick := <-tickChannel:
+			err := conn.WriteJSON(map[string]interface{}{
+				"channel": "ohlc",
+				"type":    "update",
+				"data": []map[string]interface{}{{
+					"symbol":         symbol,
+					"open":           json.Number(tick.OpenPrice.String()),
+					"high":           json.Number(tick.MaxPrice.String()),
+					"low":            json.Number(tick.MinPrice.String()),
+					"close":          json.Number(tick.ClosePrice.String()),
+					"volume":         json.Number(tick.Volume.String()),
+					"trades":         tick.TradeCount,
+					"interval_begin": formatTime(tick.OpenTime),
+					"interval":       interval,
+					"timestamp":      formatTime(tick.TickTime),
+				}},
+			})
+			if err != nil {
+				return
+			}
+		case <-closedChannel:
+			return
+		}
+	}
+}
+
+func transactionID(order *exchangetest.Order) string {
+	return "O" + strings.ToUpper(order.ID[:8])
+}
+
+func decimalPlaces(value trading.Decimal) int {
+	parts := strings.SplitN(value.String(), ".", 2)
+	if len(parts) < 2 {
+		return 0
+	}
+
+	return len(parts[1])
+}
+
+func formatTime(value time.Time) string {
+	return value.UTC().Format(time.RFC3339Nano)
+}
+
+func writeError(
+	t *testing.T,
+	responseWriter http.ResponseWriter,
+	message string,
+) {
+	writeJSON(t, responseWriter, map[string]interface{}{
+		"error": []string{message},
+	})
+}
+
+func writeResult(
+	t *testing.T,
+	responseWriter http.ResponseWriter,
+	result interface{},
+) {
+	writeJSON(t, responseWriter, map[string]interface{}{
+		"error":  []string{},
+		"result": result,
+	})
+}
+
+func writeJSON(
+	t *testing.T,
+	responseWriter http.ResponseWriter,
+	value interface{},
+) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(responseWriter).Encode(value); err != nil {
+		t.Error(fmt.Errorf("could not encode response: [%v]", err))
+	}
+}
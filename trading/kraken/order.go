@@ -0,0 +1,105 @@
+package kraken
+
+import (
+	"context"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/url"
+	"strings"
+)
+
+func (es *ExchangeService) ExecuteOrder(
+	ctx context.Context,
+	order *trading.Order,
+) (bool, error) {
+	var result struct {
+		TransactionIDs []string `json:"txid"`
+	}
+
+	err := es.client.private(
+		ctx,
+		"/0/private/AddOrder",
+		url.Values{
+			"pair":      {pairName(es.workload.Pair)},
+			"type":      {strings.ToLower(order.Side.String())},
+			"ordertype": {"limit"},
+			"price":     {es.tradingRules.RoundPrice(order.Price).String()},
+			"volume":    {es.tradingRules.RoundQuantity(order.Size).String()},
+			// Kraken doesn't support fill or kill (FOK) orders so
+			// immediate or cancel (IOC) ones are used instead.
+			"timeinforce": {"IOC"},
+			"cl_ord_id":   {order.ID.String()},
+		},
+		&result,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	// IOC orders are closed right after they are matched.
+	return es.IsOrderExecuted(ctx, order)
+}
+
+func (es *ExchangeService) IsOrderExecuted(
+	ctx context.Context,
+	order *trading.Order,
+) (bool, error) {
+	var result struct {
+		Closed map[string]struct {
+			Volume         string `json:"vol"`
+			ExecutedVolume string `json:"vol_exec"`
+			ClientOrderID  string `json:"cl_ord_id"`
+		} `json:"closed"`
+	}
+
+	err := es.client.private(
+		ctx,
+		"/0/private/ClosedOrders",
+		url.Values{"cl_ord_id": {order.ID.String()}},
+		&result,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	for transactionID, closedOrder := range result.Closed {
+		if closedOrder.ClientOrderID != order.ID.String() {
+			continue
+		}
+
+		volume, err := trading.ParseDecimal(closedOrder.Volume)
+		if err != nil {
+			return false, fmt.Errorf("could not parse volume: [%v]", err)
+		}
+
+		executedVolume, err := trading.ParseDecimal(closedOrder.ExecutedVolume)
+		if err != nil {
+			return false, fmt.Errorf(
+				"could not parse executed volume: [%v]",
+				err,
+			)
+		}
+
+		if executedVolume.IsZero() {
+			return false, nil
+		}
+
+		// Unlike FOK orders, IOC ones can be filled partially. Such
+		// a position can't be managed automatically.
+		if !executedVolume.Equal(volume) {
+			return false, trading.NewFatalError(fmt.Errorf(
+				"order [%v] (transaction [%v]) filled partially: [%v/%v]",
+				order.ID,
+				transactionID,
+				executedVolume,
+				volume,
+			))
+		}
+
+		return true, nil
+	}
+
+	// Given order doesn't exist so we are returning false to the caller
+	// but it's not an error situation.
+	return false, nil
+}
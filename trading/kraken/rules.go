@@ -0,0 +1,99 @@
+package kraken
+
+import (
+	"context"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/url"
+)
+
+func (es *ExchangeService) TradingRules(
+	_ context.Context,
+) (*trading.TradingRules, error) {
+	// Rules are loaded when the service is created so there is no need
+	// to reach the exchange here.
+	return es.tradingRules, nil
+}
+
+type assetPair struct {
+	PairDecimals int32  `json:"pair_decimals"`
+	LotDecimals  int32  `json:"lot_decimals"`
+	TickSize     string `json:"tick_size"`
+	OrderMin     string `json:"ordermin"`
+	CostMin      string `json:"costmin"`
+}
+
+// fetchTradingRules gets the trading rules of the pair along with the
+// name Kraken uses for the pair in responses.
+func fetchTradingRules(
+	ctx context.Context,
+	client *client,
+	pair trading.Pair,
+) (string, *trading.TradingRules, error) {
+	var assetPairs map[string]assetPair
+
+	err := client.public(
+		ctx,
+		"/0/public/AssetPairs",
+		url.Values{"pair": {pairName(pair)}},
+		&assetPairs,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(assetPairs) != 1 {
+		return "", nil, fmt.Errorf(
+			"could not find info for pair: [%v]",
+			pairName(pair),
+		)
+	}
+
+	for pairKey, assetPair := range assetPairs {
+		tradingRules, err := parseTradingRules(assetPair)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return pairKey, tradingRules, nil
+	}
+
+	panic("unreachable")
+}
+
+func parseTradingRules(assetPair assetPair) (*trading.TradingRules, error) {
+	// Kraken doesn't publish the maximum order quantity.
+	tradingRules := &trading.TradingRules{
+		PriceTick: trading.NewDecimal(1, -assetPair.PairDecimals),
+		LotStep:   trading.NewDecimal(1, -assetPair.LotDecimals),
+	}
+
+	if len(assetPair.TickSize) > 0 {
+		priceTick, err := trading.ParseDecimal(assetPair.TickSize)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse tick size: [%v]", err)
+		}
+
+		tradingRules.PriceTick = priceTick
+	}
+
+	if len(assetPair.OrderMin) > 0 {
+		minQuantity, err := trading.ParseDecimal(assetPair.OrderMin)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse order min: [%v]", err)
+		}
+
+		tradingRules.MinQuantity = minQuantity
+	}
+
+	if len(assetPair.CostMin) > 0 {
+		minNotional, err := trading.ParseDecimal(assetPair.CostMin)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse cost min: [%v]", err)
+		}
+
+		tradingRules.MinNotional = minNotional
+	}
+
+	return tradingRules, nil
+}
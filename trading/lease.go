@@ -0,0 +1,213 @@
+package trading
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// workloadLeaseTTL is the time after which leases which have not been
+	// renewed expire and can be claimed by other controller instances.
+	workloadLeaseTTL = 3 * workloadControllerLoopTick
+	// workloadLeaseSafetyMargin is subtracted from the lease TTL when the
+	// instance determines whether its own leases are still valid, so it
+	// stops trading before other instances can take over.
+	workloadLeaseSafetyMargin = workloadControllerLoopTick
+)
+
+// LeaseRepository coordinates ownership of workloads across multiple
+// controller instances. Lease expiration times must be determined using
+// a single clock, e.g. the database one, to avoid problems caused by
+// clock skew between instances.
+type LeaseRepository interface {
+	// Heartbeat marks the instance alive for the given time and returns
+	// the number of alive instances, including the given one.
+	Heartbeat(instanceID string, ttl time.Duration) (int, error)
+
+	// LeasedWorkloads returns IDs of workloads whose leases are held by
+	// the instance and have not expired yet.
+	LeasedWorkloads(instanceID string) ([]ID, error)
+
+	// AcquireLease renews the lease held by the instance or claims the
+	// lease which is free or expired. It returns false if the lease is
+	// held by another instance.
+	AcquireLease(
+		workloadID ID,
+		instanceID string,
+		ttl time.Duration,
+	) (bool, error)
+
+	ReleaseLease(workloadID ID, instanceID string) error
+
+	// Unregister releases all leases of the instance and removes it
+	// from alive instances.
+	Unregister(instanceID string) error
+}
+
+// WorkloadLeaser claims workloads to be run by the controller instance.
+// Each instance claims a fair share of workloads, i.e. their count divided
+// by the number of alive instances, and releases the surplus once new
+// instances join. Workloads of instances which died are taken over once
+// their leases expire.
+type WorkloadLeaser struct {
+	instanceID      string
+	leaseRepository LeaseRepository
+	clock           Clock
+	logger          Logger
+
+	mutex         sync.Mutex
+	lastLeaseTime time.Time
+}
+
+func NewWorkloadLeaser(
+	instanceID string,
+	leaseRepository LeaseRepository,
+	clock Clock,
+	logger Logger,
+) *WorkloadLeaser {
+	return &WorkloadLeaser{
+		instanceID:      instanceID,
+		leaseRepository: leaseRepository,
+		clock:           clock,
+		logger:          logger.WithField("instanceID", instanceID),
+	}
+}
+
+// LeaseWorkloads renews leases on owned workloads and claims free ones,
+// up to the fair share. Disabled workloads are not leased. It returns IDs
+// of workloads owned by the instance. Leases above the fair share are no
+// longer renewed but they are not released either, as the workloads may
+// still be running. They should be released by ReleaseLease once the
+// workloads are stopped, otherwise they expire.
+func (wl *WorkloadLeaser) LeaseWorkloads(
+	workloads []*Workload,
+) (map[string]bool, error) {
+	leaseTime := wl.clock.Now()
+
+	instances, err := wl.leaseRepository.Heartbeat(
+		wl.instanceID,
+		workloadLeaseTTL,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not send heartbeat: [%v]", err)
+	}
+
+	leasedIDs, err := wl.leaseRepository.LeasedWorkloads(wl.instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get leased workloads: [%v]", err)
+	}
+
+	leased := make(map[string]bool)
+	for _, leasedID := range leasedIDs {
+		leased[leasedID.String()] = true
+	}
+
+	candidates := make([]*Workload, 0)
+	for _, workload := range workloads {
+		if workload.Status != WorkloadDisabled {
+			candidates = append(candidates, workload)
+		}
+	}
+
+	if instances < 1 {
+		instances = 1
+	}
+	fairShare := (len(candidates) + instances - 1) / instances
+
+	// Workloads which are already leased go first so they are not
+	// unnecessarily moved between instances.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iLeased := leased[candidates[i].ID.String()]
+		jLeased := leased[candidates[j].ID.String()]
+
+		if iLeased != jLeased {
+			return iLeased
+		}
+
+		return candidates[i].ID.String() < candidates[j].ID.String()
+	})
+
+	owned := make(map[string]bool)
+
+	for _, workload := range candidates {
+		workloadID := workload.ID.String()
+
+		if len(owned) >= fairShare {
+			continue
+		}
+
+		acquired, err := wl.leaseRepository.AcquireLease(
+			workload.ID,
+			wl.instanceID,
+			workloadLeaseTTL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not acquire workload [%v] lease: [%v]",
+				workloadID,
+				err,
+			)
+		}
+
+		if acquired {
+			if !leased[workloadID] {
+				wl.logger.Infof("claimed workload [%v] lease", workloadID)
+			}
+
+			owned[workloadID] = true
+		}
+	}
+
+	wl.mutex.Lock()
+	wl.lastLeaseTime = leaseTime
+	wl.mutex.Unlock()
+
+	return owned, nil
+}
+
+// LeasesExpired tells whether leases obtained during the last successful
+// call of LeaseWorkloads could have been taken over by other instances.
+func (wl *WorkloadLeaser) LeasesExpired() bool {
+	wl.mutex.Lock()
+	defer wl.mutex.Unlock()
+
+	validUntil := wl.lastLeaseTime.Add(
+		workloadLeaseTTL - workloadLeaseSafetyMargin,
+	)
+
+	return !wl.clock.Now().Before(validUntil)
+}
+
+// ReleaseLease gives up the lease of the workload, if held by the instance,
+// so other instances can take it over without waiting for the lease to
+// expire. The workload must not be run by the instance anymore.
+func (wl *WorkloadLeaser) ReleaseLease(workloadID ID) error {
+	if err := wl.leaseRepository.ReleaseLease(
+		workloadID,
+		wl.instanceID,
+	); err != nil {
+		return fmt.Errorf(
+			"could not release workload [%v] lease: [%v]",
+			workloadID,
+			err,
+		)
+	}
+
+	wl.logger.Infof("released workload [%v] lease", workloadID)
+
+	return nil
+}
+
+// Release gives up all leases so other instances can take over the
+// workloads without waiting for the leases to expire.
+func (wl *WorkloadLeaser) Release() error {
+	if err := wl.leaseRepository.Unregister(wl.instanceID); err != nil {
+		return fmt.Errorf("could not unregister instance: [%v]", err)
+	}
+
+	wl.logger.Infof("released all workload leases")
+
+	return nil
+}
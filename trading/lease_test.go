@@ -0,0 +1,262 @@
+package trading
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkloadLeaser_LeaseWorkloads(t *testing.T) {
+	clock := NewVirtualClock(time.Time{})
+	leaseRepository := newTestLeaseRepository(clock)
+
+	workloads := []*Workload{
+		{ID: testID("workload-1"), Status: WorkloadActive},
+		{ID: testID("workload-2"), Status: WorkloadPaused},
+		{ID: testID("workload-3"), Status: WorkloadActive},
+		{ID: testID("workload-4"), Status: WorkloadActive},
+		{ID: testID("disabled"), Status: WorkloadDisabled},
+	}
+
+	leaserA := NewWorkloadLeaser("a", leaseRepository, clock, &testLogger{})
+	leaserB := NewWorkloadLeaser("b", leaseRepository, clock, &testLogger{})
+
+	// The first instance claims all workloads except the disabled one.
+	assertOwnedWorkloads(
+		t,
+		leaserA,
+		workloads,
+		"workload-1", "workload-2", "workload-3", "workload-4",
+	)
+
+	// The second instance joins but all leases are taken.
+	assertOwnedWorkloads(t, leaserB, workloads)
+
+	// The first instance gives up the surplus...
+	assertOwnedWorkloads(t, leaserA, workloads, "workload-1", "workload-2")
+
+	// ...which can't be claimed until it's stopped and released.
+	assertOwnedWorkloads(t, leaserB, workloads)
+
+	for _, workloadID := range []string{"workload-3", "workload-4"} {
+		if err := leaserA.ReleaseLease(testID(workloadID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertOwnedWorkloads(t, leaserB, workloads, "workload-3", "workload-4")
+
+	// The first instance dies so its heartbeat and leases expire.
+	clock.Set(clock.Now().Add(workloadLeaseTTL))
+
+	assertOwnedWorkloads(
+		t,
+		leaserB,
+		workloads,
+		"workload-1", "workload-2", "workload-3", "workload-4",
+	)
+
+	// The second instance shuts down gracefully.
+	if err := leaserB.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertOwnedWorkloads(
+		t,
+		leaserA,
+		workloads,
+		"workload-1", "workload-2", "workload-3", "workload-4",
+	)
+}
+
+func TestWorkloadLeaser_LeasesExpired(t *testing.T) {
+	clock := NewVirtualClock(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))
+	leaseRepository := newTestLeaseRepository(clock)
+	leaser := NewWorkloadLeaser("a", leaseRepository, clock, &testLogger{})
+
+	if !leaser.LeasesExpired() {
+		t.Errorf("leases should be expired before they are acquired")
+	}
+
+	if _, err := leaser.LeaseWorkloads([]*Workload{
+		{ID: testID("workload"), Status: WorkloadActive},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if leaser.LeasesExpired() {
+		t.Errorf("leases should not be expired right after acquiring")
+	}
+
+	// Renewal fails but other instances can't take over yet.
+	clock.Set(clock.Now().Add(workloadLeaseTTL - workloadLeaseSafetyMargin))
+
+	if !leaser.LeasesExpired() {
+		t.Errorf("leases should be expired before their actual expiry")
+	}
+}
+
+func assertOwnedWorkloads(
+	t *testing.T,
+	leaser *WorkloadLeaser,
+	workloads []*Workload,
+	expectedIDs ...string,
+) {
+	owned, err := leaser.LeaseWorkloads(workloads)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualIDs := make([]string, 0)
+	for workloadID := range owned {
+		actualIDs = append(actualIDs, workloadID)
+	}
+	sort.Strings(actualIDs)
+
+	expected := strings.Join(expectedIDs, ", ")
+	actual := strings.Join(actualIDs, ", ")
+
+	if expected != actual {
+		t.Errorf(
+			"unexpected workloads owned by instance [%v]\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			leaser.instanceID,
+			expected,
+			actual,
+		)
+	}
+}
+
+type testLease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// testLeaseRepository keeps leases in memory and determines their
+// expiration using the given clock.
+type testLeaseRepository struct {
+	clock Clock
+
+	mutex     sync.Mutex
+	instances map[string]time.Time
+	leases    map[string]*testLease
+}
+
+func newTestLeaseRepository(clock Clock) *testLeaseRepository {
+	return &testLeaseRepository{
+		clock:     clock,
+		instances: make(map[string]time.Time),
+		leases:    make(map[string]*testLease),
+	}
+}
+
+func (tlr *testLeaseRepository) Heartbeat(
+	instanceID string,
+	ttl time.Duration,
+) (int, error) {
+	tlr.mutex.Lock()
+	defer tlr.mutex.Unlock()
+
+	now := tlr.clock.Now()
+	tlr.instances[instanceID] = now.Add(ttl)
+
+	alive := 0
+	for _, expiresAt := range tlr.instances {
+		if expiresAt.After(now) {
+			alive++
+		}
+	}
+
+	return alive, nil
+}
+
+func (tlr *testLeaseRepository) LeasedWorkloads(
+	instanceID string,
+) ([]ID, error) {
+	tlr.mutex.Lock()
+	defer tlr.mutex.Unlock()
+
+	leased := make([]ID, 0)
+
+	for workloadID, lease := range tlr.leases {
+		if lease.owner == instanceID &&
+			lease.expiresAt.After(tlr.clock.Now()) {
+			leased = append(leased, testID(workloadID))
+		}
+	}
+
+	return leased, nil
+}
+
+func (tlr *testLeaseRepository) AcquireLease(
+	workloadID ID,
+	instanceID string,
+	ttl time.Duration,
+) (bool, error) {
+	tlr.mutex.Lock()
+	defer tlr.mutex.Unlock()
+
+	now := tlr.clock.Now()
+
+	lease, exists := tlr.leases[workloadID.String()]
+	if exists && lease.owner != instanceID && lease.expiresAt.After(now) {
+		return false, nil
+	}
+
+	tlr.leases[workloadID.String()] = &testLease{
+		owner:     instanceID,
+		expiresAt: now.Add(ttl),
+	}
+
+	return true, nil
+}
+
+func (tlr *testLeaseRepository) ReleaseLease(
+	workloadID ID,
+	instanceID string,
+) error {
+	tlr.mutex.Lock()
+	defer tlr.mutex.Unlock()
+
+	lease, exists := tlr.leases[workloadID.String()]
+	if !exists || lease.owner != instanceID {
+		return fmt.Errorf("lease not held by instance")
+	}
+
+	delete(tlr.leases, workloadID.String())
+
+	return nil
+}
+
+func (tlr *testLeaseRepository) Unregister(instanceID string) error {
+	tlr.mutex.Lock()
+	defer tlr.mutex.Unlock()
+
+	for workloadID, lease := range tlr.leases {
+		if lease.owner == instanceID {
+			delete(tlr.leases, workloadID)
+		}
+	}
+
+	delete(tlr.instances, instanceID)
+
+	return nil
+}
+
+// leaseOwner returns the instance holding the unexpired lease of the
+// workload, if any.
+func (tlr *testLeaseRepository) leaseOwner(workloadID string) string {
+	tlr.mutex.Lock()
+	defer tlr.mutex.Unlock()
+
+	lease, exists := tlr.leases[workloadID]
+	if !exists || !lease.expiresAt.After(tlr.clock.Now()) {
+		return ""
+	}
+
+	return lease.owner
+}
@@ -0,0 +1,749 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	candleTickerIdleTimeout = 10 * time.Second
+
+	// The candle ticker is reconnected with an exponential backoff. The
+	// feed, along with its subscribed runners, fails if the outage exceeds
+	// the maximum one which is shorter than the stall timeout, so runners
+	// get restarted before they are reported as stalled.
+	candleTickerReconnectDelay    = 1 * time.Second
+	candleTickerMaxReconnectDelay = 30 * time.Second
+	candleTickerMaxOutage         = 2 * time.Minute
+)
+
+type MarketData interface {
+	Candles() ([]*Candle, error)
+
+	Evaluate(signalGenerator SignalGenerator) (*Signal, bool, error)
+
+	// UpToDate is false while candles are missing, e.g. due to a candle
+	// ticker outage.
+	UpToDate() bool
+
+	LastTickTime() time.Time
+
+	// Candles are no longer updated once ErrChan reports a failure.
+	ErrChan() <-chan error
+}
+
+// MarketFollower follows the market state of a candle series, e.g. a
+// simulated exchange service which fills orders at the most recent price.
+type MarketFollower interface {
+	Advance(candle *Candle)
+}
+
+// MarketDataHub runs a single feed per candle series, no matter how many
+// workloads trade on it. The feed keeps one candle window, large enough
+// for all subscribed workloads, and is stopped once its last subscription
+// is closed.
+type MarketDataHub struct {
+	ctx                     context.Context
+	candleRepositoryFactory CandleRepositoryFactory
+	clock                   Clock
+	metrics                 Metrics
+	logger                  Logger
+
+	feedsMutex sync.Mutex
+	feeds      map[CandleKey]*marketDataFeed
+}
+
+func NewMarketDataHub(
+	ctx context.Context,
+	candleRepositoryFactory CandleRepositoryFactory,
+	clock Clock,
+	metrics Metrics,
+	logger Logger,
+) *MarketDataHub {
+	return &MarketDataHub{
+		ctx:                     ctx,
+		candleRepositoryFactory: candleRepositoryFactory,
+		clock:                   clock,
+		metrics:                 metrics,
+		logger:                  logger,
+		feeds:                   make(map[CandleKey]*marketDataFeed),
+	}
+}
+
+// Subscribe uses the exchange service to run the feed of the series unless
+// it's already running. The optional follower is advanced to the most
+// recent candle of the feed, no matter which workload's exchange service
+// the feed uses.
+func (mdh *MarketDataHub) Subscribe(
+	workload *Workload,
+	exchangeService ExchangeCandleService,
+	follower MarketFollower,
+) *MarketDataSubscription {
+	mdh.feedsMutex.Lock()
+	defer mdh.feedsMutex.Unlock()
+
+	key := workload.CandleKey()
+
+	feed, exists := mdh.feeds[key]
+
+	switch {
+	case !exists:
+		feed = mdh.runFeed(
+			key,
+			workload.CandleWindowSize,
+			exchangeService,
+			nil,
+		)
+	case feed.windowSize < workload.CandleWindowSize:
+		// The window can't be extended in place as the older candles
+		// would have to be fetched before the ones already kept. The feed
+		// is replaced by a larger one instead.
+		replacement := mdh.runFeed(
+			key,
+			workload.CandleWindowSize,
+			exchangeService,
+			feed,
+		)
+
+		for subscription := range feed.subscriptions {
+			subscription.feed = replacement
+			replacement.subscriptions[subscription] = true
+		}
+
+		feed.stop()
+		feed = replacement
+	}
+
+	subscription := &MarketDataSubscription{
+		hub:      mdh,
+		workload: workload,
+		follower: follower,
+		feed:     feed,
+		errChan:  make(chan error, 1),
+	}
+
+	feed.subscriptions[subscription] = true
+
+	// Further candles are pushed by the feed, which takes the feeds mutex
+	// to get its followers, so none of them is missed.
+	if lastCandle := feed.latestCandle(); follower != nil &&
+		lastCandle != nil {
+		follower.Advance(lastCandle)
+	}
+
+	return subscription
+}
+
+// runFeed starts a feed of the series and makes it the current one.
+// If the feed replaces another one, followers are not moved back to the
+// older candles the replacement fetches. Must be called with the feeds
+// mutex held.
+func (mdh *MarketDataHub) runFeed(
+	key CandleKey,
+	windowSize int,
+	exchangeService ExchangeCandleService,
+	replaced *marketDataFeed,
+) *marketDataFeed {
+	feed := newMarketDataFeed(
+		mdh,
+		key,
+		windowSize,
+		exchangeService,
+		mdh.candleRepositoryFactory(windowSize),
+	)
+
+	if replaced != nil {
+		feed.lastCandle = replaced.latestCandle()
+	}
+
+	mdh.feeds[key] = feed
+
+	mdh.logger.Infof(
+		"starting market data feed of [%v] with window size [%v]",
+		key,
+		windowSize,
+	)
+
+	ctx, cancelCtx := context.WithCancel(mdh.ctx)
+	feed.cancelCtx = cancelCtx
+
+	go func() {
+		// Errors caused by stopping the feed are not failures.
+		if err := feed.run(ctx); err != nil && ctx.Err() == nil {
+			mdh.feedFailed(feed, err)
+		}
+	}()
+
+	return feed
+}
+
+// feedFailed reports the failure to subscriptions of the feed. The next
+// subscription of the series starts a new feed.
+func (mdh *MarketDataHub) feedFailed(feed *marketDataFeed, err error) {
+	mdh.feedsMutex.Lock()
+	defer mdh.feedsMutex.Unlock()
+
+	// Subscriptions of replaced feeds have been moved to the replacement.
+	if mdh.feeds[feed.key] != feed {
+		return
+	}
+
+	delete(mdh.feeds, feed.key)
+
+	for subscription := range feed.subscriptions {
+		select {
+		case subscription.errChan <- err:
+		default:
+		}
+	}
+}
+
+func (mdh *MarketDataHub) subscribedWorkloads(
+	feed *marketDataFeed,
+) []*Workload {
+	mdh.feedsMutex.Lock()
+	defer mdh.feedsMutex.Unlock()
+
+	workloads := make([]*Workload, 0, len(feed.subscriptions))
+	for subscription := range feed.subscriptions {
+		workloads = append(workloads, subscription.workload)
+	}
+
+	return workloads
+}
+
+func (mdh *MarketDataHub) subscribedFollowers(
+	feed *marketDataFeed,
+) []MarketFollower {
+	mdh.feedsMutex.Lock()
+	defer mdh.feedsMutex.Unlock()
+
+	followers := make([]MarketFollower, 0, len(feed.subscriptions))
+	for subscription := range feed.subscriptions {
+		if subscription.follower != nil {
+			followers = append(followers, subscription.follower)
+		}
+	}
+
+	return followers
+}
+
+type MarketDataSubscription struct {
+	hub      *MarketDataHub
+	workload *Workload
+	follower MarketFollower
+	// feed is guarded by the feeds mutex of the hub as the feed can be
+	// replaced by a larger one.
+	feed    *marketDataFeed
+	errChan chan error
+}
+
+func (mds *MarketDataSubscription) currentFeed() *marketDataFeed {
+	mds.hub.feedsMutex.Lock()
+	defer mds.hub.feedsMutex.Unlock()
+
+	return mds.feed
+}
+
+func (mds *MarketDataSubscription) Candles() ([]*Candle, error) {
+	return mds.currentFeed().candles(mds.workload.CandleWindowSize)
+}
+
+// Evaluate shares the result with all workloads evaluating the same
+// generator against a window of the same size, until candles change.
+func (mds *MarketDataSubscription) Evaluate(
+	signalGenerator SignalGenerator,
+) (*Signal, bool, error) {
+	return mds.currentFeed().evaluate(
+		signalGenerator,
+		mds.workload.CandleWindowSize,
+	)
+}
+
+func (mds *MarketDataSubscription) UpToDate() bool {
+	return mds.currentFeed().upToDate()
+}
+
+func (mds *MarketDataSubscription) LastTickTime() time.Time {
+	return mds.currentFeed().lastTick()
+}
+
+func (mds *MarketDataSubscription) ErrChan() <-chan error {
+	return mds.errChan
+}
+
+func (mds *MarketDataSubscription) Close() {
+	mds.hub.feedsMutex.Lock()
+	defer mds.hub.feedsMutex.Unlock()
+
+	feed := mds.feed
+
+	delete(feed.subscriptions, mds)
+
+	if len(feed.subscriptions) > 0 {
+		return
+	}
+
+	if mds.hub.feeds[feed.key] == feed {
+		delete(mds.hub.feeds, feed.key)
+	}
+
+	feed.stop()
+}
+
+// marketDataFeed fails only if no candle tick has been received for
+// a long time.
+type marketDataFeed struct {
+	hub              *MarketDataHub
+	key              CandleKey
+	windowSize       int
+	exchangeService  ExchangeCandleService
+	candleRepository CandleRepository
+	logger           Logger
+	cancelCtx        context.CancelFunc
+
+	// subscriptions are guarded by the feeds mutex of the hub.
+	subscriptions map[*MarketDataSubscription]bool
+
+	stateMutex   sync.RWMutex
+	lastTickTime time.Time
+	// outdated is set until the window is filled and while some candles
+	// are missing, e.g. due to a candle ticker outage.
+	outdated bool
+	// version changes each time candles are saved so evaluations made
+	// against former candles are not reused.
+	version    uint64
+	lastCandle *Candle
+
+	evaluationsMutex sync.Mutex
+	evaluations      map[marketDataEvaluationKey]*marketDataEvaluation
+	// sessions keep indicators of signal generators between evaluations.
+	// They are guarded by the evaluations mutex.
+	sessions map[marketDataEvaluationKey]SignalGenerator
+}
+
+type marketDataEvaluationKey struct {
+	signalGenerator SignalGenerator
+	windowSize      int
+}
+
+type marketDataEvaluation struct {
+	version uint64
+	signal  *Signal
+	exists  bool
+}
+
+func newMarketDataFeed(
+	hub *MarketDataHub,
+	key CandleKey,
+	windowSize int,
+	exchangeService ExchangeCandleService,
+	candleRepository CandleRepository,
+) *marketDataFeed {
+	return &marketDataFeed{
+		hub:              hub,
+		key:              key,
+		windowSize:       windowSize,
+		exchangeService:  exchangeService,
+		candleRepository: candleRepository,
+		logger:           hub.logger.WithField("candles", key.String()),
+		subscriptions:    make(map[*MarketDataSubscription]bool),
+		lastTickTime:     hub.clock.Now(),
+		outdated:         true,
+		evaluations: make(
+			map[marketDataEvaluationKey]*marketDataEvaluation,
+		),
+		sessions: make(map[marketDataEvaluationKey]SignalGenerator),
+	}
+}
+
+func (mdf *marketDataFeed) stop() {
+	if mdf.cancelCtx != nil {
+		mdf.cancelCtx()
+	}
+}
+
+func (mdf *marketDataFeed) run(ctx context.Context) error {
+	defer func() {
+		if err := mdf.candleRepository.DeleteCandles(mdf.key); err != nil {
+			mdf.logger.Errorf("could not delete candles: [%v]", err)
+		}
+	}()
+
+	end := mdf.hub.clock.Now()
+	start := end.Add(
+		-1 * time.Duration(mdf.windowSize) * mdf.key.Interval.Duration(),
+	)
+
+	// Candles kept by a durable store don't have to be fetched again.
+	// The last stored one is fetched anyway as it may be incomplete.
+	storedCandles, err := mdf.candleRepository.Candles(mdf.key)
+	if err != nil {
+		return fmt.Errorf("failed to get stored candles: [%w]", err)
+	}
+
+	if len(storedCandles) > 0 {
+		lastStoredCandle := storedCandles[len(storedCandles)-1]
+		if lastStoredCandle.OpenTime.After(start) {
+			start = lastStoredCandle.OpenTime
+		}
+	}
+
+	count, err := mdf.fetchCandles(ctx, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to get candles: [%w]", err)
+	}
+
+	mdf.logger.Debugf("fetched [%v] historical candles", count)
+
+	mdf.setOutdated(false)
+
+	reconnectDelay := candleTickerReconnectDelay
+
+	for {
+		tickerStartTime := mdf.hub.clock.Now()
+
+		err := mdf.runCandlesTicker(ctx)
+		if err == nil {
+			return nil
+		}
+
+		// Candles are outdated until the ticker gets reconnected and
+		// the missing ones are backfilled.
+		mdf.setOutdated(true)
+
+		lastTickTime := mdf.lastTick()
+
+		outage := mdf.hub.clock.Now().Sub(lastTickTime)
+		if outage > candleTickerMaxOutage {
+			return fmt.Errorf(
+				"no candle ticks received for [%v]: [%w]",
+				outage,
+				err,
+			)
+		}
+
+		if lastTickTime.After(tickerStartTime) {
+			reconnectDelay = candleTickerReconnectDelay
+		}
+
+		mdf.logger.Warningf(
+			"candle ticker failed; reconnecting in [%v]: [%v]",
+			reconnectDelay,
+			err,
+		)
+
+		select {
+		case <-time.After(reconnectDelay):
+		case <-ctx.Done():
+			return nil
+		}
+
+		reconnectDelay *= 2
+		if reconnectDelay > candleTickerMaxReconnectDelay {
+			reconnectDelay = candleTickerMaxReconnectDelay
+		}
+	}
+}
+
+// runCandlesTicker returns nil once the context is done.
+func (mdf *marketDataFeed) runCandlesTicker(ctx context.Context) error {
+	// Cancelling the context stops the ticker of the exchange service.
+	tickerCtx, cancelTickerCtx := context.WithCancel(ctx)
+	defer cancelTickerCtx()
+
+	tickerIdleTimer := time.NewTimer(candleTickerIdleTimeout)
+	defer tickerIdleTimer.Stop()
+
+	tickerChan, tickerErrChan := mdf.exchangeService.CandlesTicker(
+		tickerCtx,
+		mdf.key.Interval,
+	)
+
+	for {
+		select {
+		case tick := <-tickerChan:
+			// Heartbeats only keep the ticker of a quiet pair alive.
+			if !tick.IsHeartbeat() {
+				mdf.logger.Debugf("received candle tick [%v]", tick)
+				for _, workload := range mdf.hub.subscribedWorkloads(mdf) {
+					mdf.hub.metrics.CandleTickReceived(workload)
+				}
+
+				if err := mdf.saveCandleTick(ctx, tick); err != nil {
+					return err
+				}
+			}
+
+			mdf.stateMutex.Lock()
+			mdf.lastTickTime = mdf.hub.clock.Now()
+			mdf.stateMutex.Unlock()
+
+			if !tickerIdleTimer.Stop() {
+				<-tickerIdleTimer.C
+			}
+			tickerIdleTimer.Reset(candleTickerIdleTimeout)
+		case <-tickerIdleTimer.C:
+			for _, workload := range mdf.hub.subscribedWorkloads(mdf) {
+				mdf.hub.metrics.TickerIdleTimeout(workload)
+			}
+			return fmt.Errorf("ticker idle timeout expired")
+		case err := <-tickerErrChan:
+			return fmt.Errorf("ticker error: [%w]", err)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// saveCandleTick saves the candle of the tick. Candles missing between
+// the last saved candle and the received one, e.g. due to a ticker outage,
+// are backfilled first.
+func (mdf *marketDataFeed) saveCandleTick(
+	ctx context.Context,
+	tick *CandleTick,
+) error {
+	candles, err := mdf.candleRepository.Candles(mdf.key)
+	if err != nil {
+		return fmt.Errorf("could not get candles: [%w]", err)
+	}
+
+	if len(candles) > 0 {
+		lastCandle := candles[len(candles)-1]
+		start := lastCandle.OpenTime.Add(mdf.key.Interval.Duration())
+
+		if tick.OpenTime.After(start) {
+			mdf.setOutdated(true)
+
+			// Bounds are inclusive so the tick's candle is excluded.
+			count, err := mdf.fetchCandles(
+				ctx,
+				start,
+				tick.OpenTime.Add(-1*time.Millisecond),
+			)
+			if err != nil {
+				return fmt.Errorf(
+					"could not backfill candles since [%v]: [%w]",
+					start,
+					err,
+				)
+			}
+
+			mdf.logger.Infof(
+				"backfilled [%v] candles missing since [%v]",
+				count,
+				start,
+			)
+		}
+	}
+
+	if err := mdf.saveCandles(tick.Candle); err != nil {
+		return err
+	}
+
+	mdf.setOutdated(false)
+
+	return nil
+}
+
+// fetchCandles saves candles as they are streamed so long ranges don't have
+// to fit in memory.
+func (mdf *marketDataFeed) fetchCandles(
+	ctx context.Context,
+	start, end time.Time,
+) (int, error) {
+	ctx, cancelCtx := context.WithCancel(ctx)
+	defer cancelCtx()
+
+	candleChannel, errorChannel := mdf.exchangeService.Candles(
+		ctx,
+		mdf.key.Interval,
+		start,
+		end,
+	)
+
+	count := 0
+	var lastCandle *Candle
+	for candle := range candleChannel {
+		if lastCandle != nil {
+			expectedOpenTime := lastCandle.OpenTime.Add(
+				mdf.key.Interval.Duration(),
+			)
+			if candle.OpenTime.After(expectedOpenTime) {
+				mdf.logger.Warningf(
+					"exchange has no candles between [%v] and [%v]",
+					expectedOpenTime,
+					candle.OpenTime,
+				)
+			}
+		}
+
+		if err := mdf.saveCandles(candle); err != nil {
+			return count, err
+		}
+
+		lastCandle = candle
+		count++
+	}
+
+	return count, <-errorChannel
+}
+
+// saveCandles doesn't move followers back to older candles, e.g. the ones
+// backfilled or fetched by a replacement feed.
+func (mdf *marketDataFeed) saveCandles(candles ...*Candle) error {
+	err := mdf.candleRepository.SaveCandles(mdf.key, candles...)
+	if err != nil {
+		return fmt.Errorf("could not save candle: [%w]", err)
+	}
+
+	mdf.stateMutex.Lock()
+	mdf.version++
+	advanced := false
+	for _, candle := range candles {
+		if mdf.lastCandle == nil ||
+			!candle.OpenTime.Before(mdf.lastCandle.OpenTime) {
+			mdf.lastCandle = candle
+			advanced = true
+		}
+	}
+	lastCandle := mdf.lastCandle
+	mdf.stateMutex.Unlock()
+
+	if advanced {
+		for _, follower := range mdf.hub.subscribedFollowers(mdf) {
+			follower.Advance(lastCandle)
+		}
+	}
+
+	return nil
+}
+
+func (mdf *marketDataFeed) candles(windowSize int) ([]*Candle, error) {
+	candles, err := mdf.candleRepository.Candles(mdf.key)
+	if err != nil {
+		return nil, fmt.Errorf("could not get candles: [%w]", err)
+	}
+
+	if len(candles) > windowSize {
+		candles = candles[len(candles)-windowSize:]
+	}
+
+	return candles, nil
+}
+
+func (mdf *marketDataFeed) evaluate(
+	signalGenerator SignalGenerator,
+	windowSize int,
+) (*Signal, bool, error) {
+	mdf.evaluationsMutex.Lock()
+	defer mdf.evaluationsMutex.Unlock()
+
+	// The version is taken before candles so the evaluation is never
+	// reused for candles newer than the ones it was made against.
+	mdf.stateMutex.RLock()
+	version := mdf.version
+	mdf.stateMutex.RUnlock()
+
+	key := marketDataEvaluationKey{signalGenerator, windowSize}
+
+	if evaluation, exists := mdf.evaluations[key]; exists &&
+		evaluation.version == version {
+		return evaluation.signal, evaluation.exists, nil
+	}
+
+	candles, err := mdf.candles(windowSize)
+	if err != nil {
+		return nil, false, err
+	}
+
+	session, exists := mdf.sessions[key]
+	if !exists {
+		session = newSignalSession(signalGenerator)
+		mdf.sessions[key] = session
+	}
+
+	signal, exists := session.Evaluate(candles)
+
+	mdf.evaluations[key] = &marketDataEvaluation{version, signal, exists}
+
+	return signal, exists, nil
+}
+
+func (mdf *marketDataFeed) setOutdated(outdated bool) {
+	mdf.stateMutex.Lock()
+	defer mdf.stateMutex.Unlock()
+
+	mdf.outdated = outdated
+}
+
+func (mdf *marketDataFeed) upToDate() bool {
+	mdf.stateMutex.RLock()
+	defer mdf.stateMutex.RUnlock()
+
+	return !mdf.outdated
+}
+
+func (mdf *marketDataFeed) latestCandle() *Candle {
+	mdf.stateMutex.RLock()
+	defer mdf.stateMutex.RUnlock()
+
+	return mdf.lastCandle
+}
+
+func (mdf *marketDataFeed) lastTick() time.Time {
+	mdf.stateMutex.RLock()
+	defer mdf.stateMutex.RUnlock()
+
+	return mdf.lastTickTime
+}
+
+// repositoryMarketData serves market data straight from a candle
+// repository which is kept up to date by the caller, e.g. the backtest.
+type repositoryMarketData struct {
+	candleRepository CandleRepository
+	candleKey        CandleKey
+	// sessions keep indicators of signal generators between evaluations.
+	sessions map[SignalGenerator]SignalGenerator
+}
+
+func (rmd *repositoryMarketData) Candles() ([]*Candle, error) {
+	return rmd.candleRepository.Candles(rmd.candleKey)
+}
+
+func (rmd *repositoryMarketData) Evaluate(
+	signalGenerator SignalGenerator,
+) (*Signal, bool, error) {
+	candles, err := rmd.Candles()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if rmd.sessions == nil {
+		rmd.sessions = make(map[SignalGenerator]SignalGenerator)
+	}
+
+	session, exists := rmd.sessions[signalGenerator]
+	if !exists {
+		session = newSignalSession(signalGenerator)
+		rmd.sessions[signalGenerator] = session
+	}
+
+	signal, exists := session.Evaluate(candles)
+
+	return signal, exists, nil
+}
+
+func (rmd *repositoryMarketData) UpToDate() bool {
+	return true
+}
+
+func (rmd *repositoryMarketData) LastTickTime() time.Time {
+	return time.Time{}
+}
+
+func (rmd *repositoryMarketData) ErrChan() <-chan error {
+	return nil
+}
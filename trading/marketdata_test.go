@@ -0,0 +1,469 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMarketDataHub_Subscribe(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	startTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	hub := newTestMarketDataHub(ctx)
+	hub.clock = NewVirtualClock(startTime)
+
+	candleService := &testCandleService{
+		candles: newTestCandles(startTime, CandleInterval1m, -3, -2, -1),
+	}
+
+	newWorkload := func(id string, base Asset) *Workload {
+		return &Workload{
+			ID:               testID(id),
+			Account:          &Account{Exchange: "BINANCE"},
+			Pair:             Pair{Base: base, Quote: "USDT"},
+			CandleInterval:   CandleInterval1m,
+			CandleWindowSize: 2,
+		}
+	}
+
+	first := hub.Subscribe(newWorkload("first", "BTC"), candleService, nil)
+	second := hub.Subscribe(newWorkload("second", "BTC"), candleService, nil)
+	other := hub.Subscribe(newWorkload("other", "ETH"), candleService, nil)
+
+	assertFeedsCount(t, hub, 2)
+	assertUpToDate(t, first, second, other)
+
+	if candleService.tickersCount() != 2 {
+		t.Errorf(
+			"unexpected tickers count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			2,
+			candleService.tickersCount(),
+		)
+	}
+
+	candles, err := first.Candles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertCandleTimes(
+		t,
+		newTestCandles(startTime, CandleInterval1m, -2, -1),
+		candles,
+	)
+
+	// Identical generators are evaluated once per candles update.
+	signalGenerator := &testSignalGenerator{}
+
+	for _, subscription := range []*MarketDataSubscription{first, second} {
+		_, exists, err := subscription.Evaluate(signalGenerator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !exists {
+			t.Errorf("signal should exist")
+		}
+	}
+
+	assertEvaluations(t, signalGenerator, 1)
+
+	candleService.tick(newTestCandles(startTime, CandleInterval1m, 0)[0])
+
+	waitFor(t, func() bool {
+		candles, err := second.Candles()
+		return err == nil && len(candles) == 2 &&
+			candles[1].OpenTime.Equal(startTime)
+	})
+
+	for _, subscription := range []*MarketDataSubscription{first, second} {
+		if _, _, err := subscription.Evaluate(signalGenerator); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertEvaluations(t, signalGenerator, 2)
+
+	// The feed is stopped once its last subscription is closed.
+	first.Close()
+	assertFeedsCount(t, hub, 2)
+
+	second.Close()
+	other.Close()
+	assertFeedsCount(t, hub, 0)
+}
+
+func TestMarketDataHub_LargerWindow(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	startTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	hub := newTestMarketDataHub(ctx)
+	hub.clock = NewVirtualClock(startTime)
+
+	candleService := &testCandleService{
+		candles: newTestCandles(startTime, CandleInterval1m, -3, -2, -1),
+	}
+
+	newWorkload := func(id string, windowSize int) *Workload {
+		return &Workload{
+			ID:               testID(id),
+			Account:          &Account{},
+			CandleInterval:   CandleInterval1m,
+			CandleWindowSize: windowSize,
+		}
+	}
+
+	small := hub.Subscribe(newWorkload("small", 1), candleService, nil)
+	large := hub.Subscribe(newWorkload("large", 3), candleService, nil)
+
+	assertFeedsCount(t, hub, 1)
+	assertUpToDate(t, small, large)
+
+	if small.currentFeed() != large.currentFeed() {
+		t.Fatalf("subscriptions should share the replacement feed")
+	}
+
+	for subscription, expected := range map[*MarketDataSubscription]int{
+		small: 1,
+		large: 3,
+	} {
+		candles, err := subscription.Candles()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(candles) != expected {
+			t.Errorf(
+				"unexpected candles count\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				expected,
+				len(candles),
+			)
+		}
+	}
+}
+
+func TestMarketDataHub_FeedFailed(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	hub := newTestMarketDataHub(ctx)
+
+	candleService := &testCandleService{
+		candlesErr: fmt.Errorf("service unavailable"),
+	}
+
+	workload := &Workload{
+		ID:               testID("workload"),
+		Account:          &Account{},
+		CandleInterval:   CandleInterval1m,
+		CandleWindowSize: 10,
+	}
+
+	subscription := hub.Subscribe(workload, candleService, nil)
+	defer subscription.Close()
+
+	select {
+	case err := <-subscription.ErrChan():
+		if err == nil {
+			t.Errorf("failure should be reported")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("failure has not been reported")
+	}
+
+	// The next subscription starts a new feed.
+	assertFeedsCount(t, hub, 0)
+}
+
+func TestMarketDataHub_Heartbeat(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	startTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+	clock := NewVirtualClock(startTime)
+
+	hub := newTestMarketDataHub(ctx)
+	hub.clock = clock
+
+	candleService := &testCandleService{
+		candles: newTestCandles(startTime, CandleInterval1m, -3, -2, -1),
+	}
+
+	subscription := hub.Subscribe(
+		&Workload{
+			ID:               testID("workload"),
+			Account:          &Account{},
+			CandleInterval:   CandleInterval1m,
+			CandleWindowSize: 2,
+		},
+		candleService,
+		nil,
+	)
+	defer subscription.Close()
+
+	assertUpToDate(t, subscription)
+
+	// Heartbeats of a quiet pair keep the ticker alive but hold
+	// no candle to save.
+	clock.Set(startTime.Add(30 * time.Second))
+	candleService.heartbeat()
+
+	waitFor(t, func() bool {
+		return subscription.LastTickTime().Equal(clock.Now())
+	})
+
+	candles, err := subscription.Candles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertCandleTimes(
+		t,
+		newTestCandles(startTime, CandleInterval1m, -2, -1),
+		candles,
+	)
+}
+
+func TestMarketDataFeed_SaveCandleTick(t *testing.T) {
+	startTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		candlesErr              error
+		expectedErr             bool
+		expectedCandles         int
+		expectedCandlesUpToDate bool
+	}{
+		"missing candles backfilled": {
+			expectedErr:             false,
+			expectedCandles:         5,
+			expectedCandlesUpToDate: true,
+		},
+		"backfill failed": {
+			candlesErr:              fmt.Errorf("service unavailable"),
+			expectedErr:             true,
+			expectedCandles:         1,
+			expectedCandlesUpToDate: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			candleRepository := &testCandleRepository{
+				candles: newTestCandles(startTime, CandleInterval15m, 0),
+			}
+
+			feed := newMarketDataFeed(
+				newTestMarketDataHub(context.Background()),
+				CandleKey{Interval: CandleInterval15m},
+				5,
+				&testCandleService{
+					candles: newTestCandles(
+						startTime,
+						CandleInterval15m,
+						0, 1, 2, 3, 4,
+					),
+					candlesErr: test.candlesErr,
+				},
+				candleRepository,
+			)
+			feed.setOutdated(false)
+
+			err := feed.saveCandleTick(
+				context.Background(),
+				&CandleTick{
+					Candle: newTestCandles(startTime, CandleInterval15m, 4)[0],
+				},
+			)
+			if test.expectedErr != (err != nil) {
+				t.Errorf("unexpected error: [%v]", err)
+			}
+
+			// The tick is not saved if the backfill fails. It's saved once
+			// the ticker gets reconnected.
+			candles, _ := candleRepository.Candles(feed.key)
+
+			if len(candles) != test.expectedCandles {
+				t.Fatalf(
+					"unexpected candles count\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedCandles,
+					len(candles),
+				)
+			}
+
+			for index, candle := range candles {
+				expected := newTestCandles(startTime, CandleInterval15m, index)
+				if !candle.Equal(expected[0]) {
+					t.Errorf("unexpected candle [%v]", candle)
+				}
+			}
+
+			if feed.upToDate() != test.expectedCandlesUpToDate {
+				t.Errorf(
+					"unexpected candles state\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedCandlesUpToDate,
+					feed.upToDate(),
+				)
+			}
+		})
+	}
+}
+
+func newTestMarketDataHub(ctx context.Context) *MarketDataHub {
+	return NewMarketDataHub(
+		ctx,
+		func(int) CandleRepository {
+			return &testCandleRepository{}
+		},
+		NewVirtualClock(time.Time{}),
+		&NoopMetrics{},
+		&testLogger{},
+	)
+}
+
+// newTestCandles creates candles of the given interval, opened the given
+// number of intervals after the start time.
+func newTestCandles(
+	startTime time.Time,
+	interval CandleInterval,
+	indexes ...int,
+) []*Candle {
+	candles := make([]*Candle, len(indexes))
+
+	for i, index := range indexes {
+		openTime := startTime.Add(time.Duration(index) * interval.Duration())
+
+		candles[i] = &Candle{
+			OpenTime:   openTime,
+			CloseTime:  openTime.Add(interval.Duration() - time.Millisecond),
+			ClosePrice: NewDecimal(100, 0),
+		}
+	}
+
+	return candles
+}
+
+func assertFeedsCount(t *testing.T, hub *MarketDataHub, expected int) {
+	hub.feedsMutex.Lock()
+	defer hub.feedsMutex.Unlock()
+
+	if len(hub.feeds) != expected {
+		t.Fatalf(
+			"unexpected feeds count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expected,
+			len(hub.feeds),
+		)
+	}
+}
+
+func assertUpToDate(t *testing.T, subscriptions ...*MarketDataSubscription) {
+	for _, subscription := range subscriptions {
+		waitFor(t, subscription.UpToDate)
+	}
+}
+
+func assertEvaluations(
+	t *testing.T,
+	signalGenerator *testSignalGenerator,
+	expected int,
+) {
+	signalGenerator.mutex.Lock()
+	defer signalGenerator.mutex.Unlock()
+
+	if signalGenerator.evaluations != expected {
+		t.Errorf(
+			"unexpected signal generator evaluations\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expected,
+			signalGenerator.evaluations,
+		)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// testCandleService serves the given candles and pushes ticks to all
+// running tickers.
+type testCandleService struct {
+	candles    []*Candle
+	candlesErr error
+
+	mutex   sync.Mutex
+	tickers []chan *CandleTick
+}
+
+func (tcs *testCandleService) Candles(
+	ctx context.Context,
+	interval CandleInterval,
+	start, end time.Time,
+) (<-chan *Candle, <-chan error) {
+	return (&testExchangeService{
+		candles:    tcs.candles,
+		candlesErr: tcs.candlesErr,
+	}).Candles(ctx, interval, start, end)
+}
+
+func (tcs *testCandleService) CandlesTicker(
+	_ context.Context,
+	_ CandleInterval,
+) (<-chan *CandleTick, <-chan error) {
+	tcs.mutex.Lock()
+	defer tcs.mutex.Unlock()
+
+	tickerChan := make(chan *CandleTick, 1)
+	tcs.tickers = append(tcs.tickers, tickerChan)
+
+	return tickerChan, make(chan error)
+}
+
+func (tcs *testCandleService) tickersCount() int {
+	tcs.mutex.Lock()
+	defer tcs.mutex.Unlock()
+
+	return len(tcs.tickers)
+}
+
+func (tcs *testCandleService) tick(candle *Candle) {
+	tcs.mutex.Lock()
+	defer tcs.mutex.Unlock()
+
+	for _, tickerChan := range tcs.tickers {
+		tickerChan <- &CandleTick{Candle: candle}
+	}
+}
+
+func (tcs *testCandleService) heartbeat() {
+	tcs.mutex.Lock()
+	defer tcs.mutex.Unlock()
+
+	for _, tickerChan := range tcs.tickers {
+		tickerChan <- &CandleTick{}
+	}
+}
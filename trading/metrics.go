@@ -0,0 +1,203 @@
+package trading
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Metrics implementations must be safe for concurrent use.
+type Metrics interface {
+	RunningWorkloads(count int)
+
+	// WorkloadStopped allows to forget per-workload metrics.
+	WorkloadStopped(workload *Workload)
+
+	CandleTickReceived(workload *Workload)
+
+	TickerIdleTimeout(workload *Workload)
+
+	SignalGenerated(workload *Workload, signal *Signal)
+
+	// The reason is a short and constant description, see DropReason.
+	SignalDropped(workload *Workload, reason string)
+
+	OrderSent(workload *Workload, order *Order)
+
+	OrderFilled(workload *Workload, order *Order)
+
+	OrderRejected(workload *Workload, order *Order)
+
+	ExchangeRequest(
+		workload *Workload,
+		operation string,
+		duration time.Duration,
+		err error,
+	)
+
+	OpenPositions(workload *Workload, count int)
+
+	RealizedProfit(workload *Workload, profit Decimal)
+}
+
+// DropReason trims details from the reason returned by PositionOpener
+// so it can be used as a metric label with a bounded set of values.
+func DropReason(reason string) string {
+	return strings.SplitN(reason, ":", 2)[0]
+}
+
+type NoopMetrics struct{}
+
+func (nm *NoopMetrics) RunningWorkloads(_ int) {}
+
+func (nm *NoopMetrics) WorkloadStopped(_ *Workload) {}
+
+func (nm *NoopMetrics) CandleTickReceived(_ *Workload) {}
+
+func (nm *NoopMetrics) TickerIdleTimeout(_ *Workload) {}
+
+func (nm *NoopMetrics) SignalGenerated(_ *Workload, _ *Signal) {}
+
+func (nm *NoopMetrics) SignalDropped(_ *Workload, _ string) {}
+
+func (nm *NoopMetrics) OrderSent(_ *Workload, _ *Order) {}
+
+func (nm *NoopMetrics) OrderFilled(_ *Workload, _ *Order) {}
+
+func (nm *NoopMetrics) OrderRejected(_ *Workload, _ *Order) {}
+
+func (nm *NoopMetrics) ExchangeRequest(
+	_ *Workload,
+	_ string,
+	_ time.Duration,
+	_ error,
+) {
+}
+
+func (nm *NoopMetrics) OpenPositions(_ *Workload, _ int) {}
+
+func (nm *NoopMetrics) RealizedProfit(_ *Workload, _ Decimal) {}
+
+type instrumentedExchangeService struct {
+	ExchangeService
+
+	metrics Metrics
+	clock   Clock
+}
+
+func (ies *instrumentedExchangeService) observe(
+	operation string,
+	start time.Time,
+	err error,
+) {
+	ies.metrics.ExchangeRequest(
+		ies.Workload(),
+		operation,
+		ies.clock.Now().Sub(start),
+		err,
+	)
+}
+
+// Candles observes the whole stream, i.e. the observed duration covers all
+// pages of the time range.
+func (ies *instrumentedExchangeService) Candles(
+	ctx context.Context,
+	interval CandleInterval,
+	start, end time.Time,
+) (<-chan *Candle, <-chan error) {
+	begin := ies.clock.Now()
+	sourceCandleChannel, sourceErrorChannel := ies.ExchangeService.Candles(
+		ctx,
+		interval,
+		start,
+		end,
+	)
+
+	candleChannel := make(chan *Candle)
+	errorChannel := make(chan error, 1)
+
+	go func() {
+		defer close(errorChannel)
+		defer close(candleChannel)
+
+		// The source stream stops on its own once the context is done
+		// so it is drained without forwarding.
+		for candle := range sourceCandleChannel {
+			select {
+			case candleChannel <- candle:
+			case <-ctx.Done():
+			}
+		}
+
+		err := <-sourceErrorChannel
+		ies.observe("candles", begin, err)
+
+		if err != nil {
+			errorChannel <- err
+		}
+	}()
+
+	return candleChannel, errorChannel
+}
+
+func (ies *instrumentedExchangeService) AccountTakerCommission(
+	ctx context.Context,
+) (Decimal, error) {
+	begin := ies.clock.Now()
+	commission, err := ies.ExchangeService.AccountTakerCommission(ctx)
+	ies.observe("account_taker_commission", begin, err)
+
+	return commission, err
+}
+
+func (ies *instrumentedExchangeService) AccountBalances(
+	ctx context.Context,
+) (Balances, error) {
+	begin := ies.clock.Now()
+	balances, err := ies.ExchangeService.AccountBalances(ctx)
+	ies.observe("account_balances", begin, err)
+
+	return balances, err
+}
+
+func (ies *instrumentedExchangeService) AccountShortSellingAllowed(
+	ctx context.Context,
+) (bool, error) {
+	begin := ies.clock.Now()
+	allowed, err := ies.ExchangeService.AccountShortSellingAllowed(ctx)
+	ies.observe("account_short_selling_allowed", begin, err)
+
+	return allowed, err
+}
+
+func (ies *instrumentedExchangeService) TradingRules(
+	ctx context.Context,
+) (*TradingRules, error) {
+	begin := ies.clock.Now()
+	tradingRules, err := ies.ExchangeService.TradingRules(ctx)
+	ies.observe("trading_rules", begin, err)
+
+	return tradingRules, err
+}
+
+func (ies *instrumentedExchangeService) ExecuteOrder(
+	ctx context.Context,
+	order *Order,
+) (bool, error) {
+	begin := ies.clock.Now()
+	executed, err := ies.ExchangeService.ExecuteOrder(ctx, order)
+	ies.observe("execute_order", begin, err)
+
+	return executed, err
+}
+
+func (ies *instrumentedExchangeService) IsOrderExecuted(
+	ctx context.Context,
+	order *Order,
+) (bool, error) {
+	begin := ies.clock.Now()
+	executed, err := ies.ExchangeService.IsOrderExecuted(ctx, order)
+	ies.observe("is_order_executed", begin, err)
+
+	return executed, err
+}
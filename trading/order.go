@@ -2,7 +2,6 @@ package trading
 
 import (
 	"fmt"
-	"math/big"
 	"time"
 )
 
@@ -45,15 +44,17 @@ type Order struct {
 	ID       ID
 	Position *Position
 	Side     OrderSide
-	Price    *big.Float
-	Size     *big.Float
+	Price    Decimal
+	Size     Decimal
 	Time     time.Time
 	Executed bool
 }
 
 type OrderFactory struct {
+	tradingRules    *TradingRules
 	orderRepository OrderRepository
 	idService       IDService
+	clock           Clock
 }
 
 func (of *OrderFactory) CreateEntryOrder(
@@ -63,9 +64,9 @@ func (of *OrderFactory) CreateEntryOrder(
 		ID:       of.idService.NewID(),
 		Position: position,
 		Side:     position.Type.EntryOrderSide(),
-		Price:    position.EntryPrice,
-		Size:     position.Size,
-		Time:     time.Now(),
+		Price:    of.tradingRules.RoundPrice(position.EntryPrice),
+		Size:     of.tradingRules.RoundQuantity(position.Size),
+		Time:     of.clock.Now(),
 		Executed: false,
 	}
 
@@ -78,15 +79,15 @@ func (of *OrderFactory) CreateEntryOrder(
 
 func (of *OrderFactory) CreateExitOrder(
 	position *Position,
-	price *big.Float,
+	price Decimal,
 ) (*Order, error) {
 	order := &Order{
 		ID:       of.idService.NewID(),
 		Position: position,
 		Side:     position.Type.ExitOrderSide(),
-		Price:    price,
-		Size:     position.Size,
-		Time:     time.Now(),
+		Price:    of.tradingRules.RoundPrice(price),
+		Size:     of.tradingRules.RoundQuantity(position.Size),
+		Time:     of.clock.Now(),
 		Executed: false,
 	}
 
@@ -2,8 +2,6 @@ package trading
 
 import (
 	"fmt"
-	"math"
-	"math/big"
 	"sort"
 	"time"
 )
@@ -98,6 +96,12 @@ type PositionRepository interface {
 
 	UpdatePosition(position *Position) error
 
+	// RequestPositionExit marks the open position as one which should be
+	// exited at the current market price as soon as possible.
+	RequestPositionExit(positionID ID) error
+
+	Position(positionID ID) (*Position, error)
+
 	Positions(filter PositionFilter) ([]*Position, error)
 
 	PositionsCount(filter PositionFilter) (int, error)
@@ -108,12 +112,15 @@ type Position struct {
 	WorkloadID      ID
 	Type            PositionType
 	Status          PositionStatus
-	EntryPrice      *big.Float
-	Size            *big.Float
-	TakeProfitPrice *big.Float
-	StopLossPrice   *big.Float
+	EntryPrice      Decimal
+	Size            Decimal
+	TakeProfitPrice Decimal
+	StopLossPrice   Decimal
 	Time            time.Time
-	Orders          []*Order
+	// ExitRequested is set when the position should be exited regardless
+	// of its take profit and stop loss prices, e.g. by an operator.
+	ExitRequested bool
+	Orders        []*Order
 }
 
 func (p *Position) OrdersBreakdown() (*Order, *Order, error) {
@@ -157,19 +164,36 @@ func (p *Position) OrdersBreakdown() (*Order, *Order, error) {
 	}
 }
 
+// ShouldExit tells whether the given market price hit the take profit or
+// the stop loss price of the position.
+func (p *Position) ShouldExit(currentPrice Decimal) bool {
+	switch p.Type {
+	case TypeLong:
+		return currentPrice.Cmp(p.StopLossPrice) <= 0 ||
+			currentPrice.Cmp(p.TakeProfitPrice) >= 0
+	case TypeShort:
+		return currentPrice.Cmp(p.StopLossPrice) >= 0 ||
+			currentPrice.Cmp(p.TakeProfitPrice) <= 0
+	default:
+		panic("unknown position type")
+	}
+}
+
 type PositionOpener struct {
 	workload           *Workload
 	walletItem         *AccountWalletItem
+	tradingRules       *TradingRules
 	positionRepository PositionRepository
 	idService          IDService
 	eventService       EventService
+	clock              Clock
 }
 
 func (po *PositionOpener) OpenPosition(
 	signal *Signal,
 ) (*Position, string, error) {
-	if signal.Type != TypeLong {
-		return nil, "only LONG signals are currently supported", nil
+	if signal.Type == TypeShort && !po.walletItem.ShortSellingAllowed {
+		return nil, "SHORT positions are not allowed on spot accounts", nil
 	}
 
 	openPositionsCount, err := po.positionRepository.PositionsCount(
@@ -189,47 +213,64 @@ func (po *PositionOpener) OpenPosition(
 		return nil, "open position limit violated", nil
 	}
 
+	// Otherwise the position would be closed at a loss right away.
+	if err := signal.ValidateTargets(); err != nil {
+		return nil, err.Error(), nil
+	}
+
 	accountBalance := po.walletItem.Balance
-	accountRisk := new(big.Float).Mul(accountBalance, po.walletItem.RiskFactor)
-	tradeRisk := new(big.Float).Sub(signal.EntryTarget, signal.StopLossTarget)
-	positionSize := new(big.Float).Quo(accountRisk, tradeRisk)
+	accountRisk := accountBalance.Mul(po.walletItem.RiskFactor)
+	// For LONG positions the stop loss is placed below the entry,
+	// for SHORT positions above it.
+	tradeRisk := signal.EntryTarget.Sub(signal.StopLossTarget)
+	if signal.Type == TypeShort {
+		tradeRisk = tradeRisk.Neg()
+	}
+
+	positionSize := accountRisk.Div(tradeRisk)
 
-	maxPositionSize := new(big.Float).Quo(accountBalance, signal.EntryTarget)
+	maxPositionSize := accountBalance.Div(signal.EntryTarget)
 	if positionSize.Cmp(maxPositionSize) == 1 {
 		positionSize = maxPositionSize
 	}
 
-	if positionSize.Cmp(big.NewFloat(0)) == 0 {
-		return nil, "insufficient funds", nil
-	}
-
-	takeProfitPrice := new(big.Float).Mul(
-		signal.TakeProfitTarget,
-		new(big.Float).Add(big.NewFloat(1), po.walletItem.TakerCommission),
-	)
-
-	stopLossPrice := new(big.Float).Mul(
-		signal.StopLossTarget,
-		new(big.Float).Sub(big.NewFloat(1), po.walletItem.TakerCommission),
-	)
+	// Move both targets away from the entry to cover the commission.
+	one := NewDecimal(1, 0)
+	commissionUp := one.Add(po.walletItem.TakerCommission)
+	commissionDown := one.Sub(po.walletItem.TakerCommission)
 
-	// TODO: Read precision from exchange info.
-	roundToPrecision := func(value *big.Float) *big.Float {
-		float, _ := value.Float64()
-		precisionPower := math.Pow(10, float64(4))
-		return big.NewFloat(math.Round(float*precisionPower) / precisionPower)
+	takeProfitFactor, stopLossFactor := commissionUp, commissionDown
+	if signal.Type == TypeShort {
+		takeProfitFactor, stopLossFactor = commissionDown, commissionUp
 	}
 
+	takeProfitPrice := signal.TakeProfitTarget.Mul(takeProfitFactor)
+	stopLossPrice := signal.StopLossTarget.Mul(stopLossFactor)
+
+	// Prices are rounded to the nearest tick while the size is always
+	// rounded down so the position never exceeds the available funds.
 	position := &Position{
 		ID:              po.idService.NewID(),
 		WorkloadID:      po.workload.ID,
 		Type:            signal.Type,
 		Status:          StatusOpen,
-		EntryPrice:      roundToPrecision(signal.EntryTarget),
-		Size:            roundToPrecision(positionSize),
-		TakeProfitPrice: roundToPrecision(takeProfitPrice),
-		StopLossPrice:   roundToPrecision(stopLossPrice),
-		Time:            time.Now(),
+		EntryPrice:      po.tradingRules.RoundPrice(signal.EntryTarget),
+		Size:            po.tradingRules.RoundQuantity(positionSize),
+		TakeProfitPrice: po.tradingRules.RoundPrice(takeProfitPrice),
+		StopLossPrice:   po.tradingRules.RoundPrice(stopLossPrice),
+		Time:            po.clock.Now(),
+	}
+
+	if position.Size.IsZero() {
+		return nil, "insufficient funds", nil
+	}
+
+	// Orders violating the exchange rules would be rejected anyway.
+	if err := po.tradingRules.Validate(
+		position.EntryPrice,
+		position.Size,
+	); err != nil {
+		return nil, fmt.Sprintf("exchange rules violated: [%v]", err), nil
 	}
 
 	err = po.positionRepository.CreatePosition(position)
@@ -0,0 +1,319 @@
+package trading
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPositionOpener_OpenPosition(t *testing.T) {
+	tests := map[string]struct {
+		signal              *Signal
+		shortSellingAllowed bool
+		tradingRules        *TradingRules
+
+		expectedDropReason      string
+		expectedSize            string
+		expectedTakeProfitPrice string
+		expectedStopLossPrice   string
+	}{
+		"long": {
+			signal: &Signal{
+				Type:             TypeLong,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(110, 0),
+				StopLossTarget:   NewDecimal(95, 0),
+			},
+			expectedSize:            "2",
+			expectedTakeProfitPrice: "110.11",
+			expectedStopLossPrice:   "94.905",
+		},
+		"short": {
+			signal: &Signal{
+				Type:             TypeShort,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(90, 0),
+				StopLossTarget:   NewDecimal(105, 0),
+			},
+			shortSellingAllowed:     true,
+			expectedSize:            "2",
+			expectedTakeProfitPrice: "89.91",
+			expectedStopLossPrice:   "105.105",
+		},
+		"short on spot account": {
+			signal: &Signal{
+				Type:             TypeShort,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(90, 0),
+				StopLossTarget:   NewDecimal(105, 0),
+			},
+			shortSellingAllowed: false,
+			expectedDropReason:  "SHORT positions are not allowed on spot accounts",
+		},
+		"short with stop loss below entry": {
+			signal: &Signal{
+				Type:             TypeShort,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(90, 0),
+				StopLossTarget:   NewDecimal(95, 0),
+			},
+			shortSellingAllowed: true,
+			expectedDropReason:  "stop loss target on the wrong side of entry target",
+		},
+		"long with take profit below entry": {
+			signal: &Signal{
+				Type:             TypeLong,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(99, 0),
+				StopLossTarget:   NewDecimal(95, 0),
+			},
+			expectedDropReason: "take profit target on the wrong side of " +
+				"entry target",
+		},
+		"prices rounded to price tick": {
+			signal: &Signal{
+				Type:             TypeLong,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(110, 0),
+				StopLossTarget:   NewDecimal(95, 0),
+			},
+			tradingRules:            &TradingRules{PriceTick: NewDecimal(1, -2)},
+			expectedSize:            "2",
+			expectedTakeProfitPrice: "110.11",
+			expectedStopLossPrice:   "94.91",
+		},
+		"size rounded down to lot step": {
+			signal: &Signal{
+				Type:             TypeLong,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(110, 0),
+				StopLossTarget:   NewDecimal(97, 0),
+			},
+			tradingRules:            &TradingRules{LotStep: NewDecimal(1, -2)},
+			expectedSize:            "3.33",
+			expectedTakeProfitPrice: "110.11",
+			expectedStopLossPrice:   "96.903",
+		},
+		"size capped at max quantity": {
+			signal: &Signal{
+				Type:             TypeLong,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(110, 0),
+				StopLossTarget:   NewDecimal(95, 0),
+			},
+			tradingRules:            &TradingRules{MaxQuantity: MustParseDecimal("1.5")},
+			expectedSize:            "1.5",
+			expectedTakeProfitPrice: "110.11",
+			expectedStopLossPrice:   "94.905",
+		},
+		"size below min quantity": {
+			signal: &Signal{
+				Type:             TypeLong,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(110, 0),
+				StopLossTarget:   NewDecimal(95, 0),
+			},
+			tradingRules: &TradingRules{MinQuantity: NewDecimal(5, 0)},
+			expectedDropReason: "exchange rules violated: " +
+				"[quantity [2] is below minimum quantity [5]]",
+		},
+		"notional below min notional": {
+			signal: &Signal{
+				Type:             TypeLong,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(110, 0),
+				StopLossTarget:   NewDecimal(95, 0),
+			},
+			tradingRules: &TradingRules{MinNotional: NewDecimal(500, 0)},
+			expectedDropReason: "exchange rules violated: " +
+				"[notional [200] is below minimum notional [500]]",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			account := &Account{
+				RiskFactor:         NewDecimal(1, -2),
+				OpenPositionsLimit: 1,
+			}
+
+			tradingRules := test.tradingRules
+			if tradingRules == nil {
+				tradingRules = &TradingRules{
+					PriceTick:   NewDecimal(1, -3),
+					LotStep:     NewDecimal(1, -4),
+					MinNotional: NewDecimal(10, 0),
+				}
+			}
+
+			positionOpener := &PositionOpener{
+				workload: &Workload{
+					ID:      testID("workload"),
+					Account: account,
+				},
+				walletItem: &AccountWalletItem{
+					Account:             account,
+					Balance:             NewDecimal(1000, 0),
+					TakerCommission:     NewDecimal(1, -3),
+					ShortSellingAllowed: test.shortSellingAllowed,
+				},
+				tradingRules:       tradingRules,
+				positionRepository: &testPositionRepository{},
+				idService:          &testIDService{},
+				eventService:       &testEventService{},
+				clock:              NewVirtualClock(time.Time{}),
+			}
+
+			position, dropReason, err := positionOpener.OpenPosition(
+				test.signal,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if dropReason != test.expectedDropReason {
+				t.Fatalf(
+					"unexpected drop reason\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedDropReason,
+					dropReason,
+				)
+			}
+
+			if len(test.expectedDropReason) > 0 {
+				return
+			}
+
+			assertDecimal(t, "size", test.expectedSize, position.Size)
+			assertDecimal(
+				t,
+				"take profit price",
+				test.expectedTakeProfitPrice,
+				position.TakeProfitPrice,
+			)
+			assertDecimal(
+				t,
+				"stop loss price",
+				test.expectedStopLossPrice,
+				position.StopLossPrice,
+			)
+		})
+	}
+}
+
+func TestPosition_ShouldExit(t *testing.T) {
+	tests := map[string]struct {
+		positionType PositionType
+		price        int64
+		expected     bool
+	}{
+		"long below stop loss":    {TypeLong, 94, true},
+		"long between targets":    {TypeLong, 100, false},
+		"long above take profit":  {TypeLong, 111, true},
+		"short above stop loss":   {TypeShort, 106, true},
+		"short between targets":   {TypeShort, 100, false},
+		"short below take profit": {TypeShort, 89, true},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			position := &Position{
+				Type:            test.positionType,
+				TakeProfitPrice: NewDecimal(110, 0),
+				StopLossPrice:   NewDecimal(95, 0),
+			}
+
+			if test.positionType == TypeShort {
+				position.TakeProfitPrice = NewDecimal(90, 0)
+				position.StopLossPrice = NewDecimal(105, 0)
+			}
+
+			actual := position.ShouldExit(NewDecimal(test.price, 0))
+
+			if actual != test.expected {
+				t.Errorf(
+					"unexpected result\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expected,
+					actual,
+				)
+			}
+		})
+	}
+}
+
+func assertDecimal(t *testing.T, name, expected string, actual Decimal) {
+	if !MustParseDecimal(expected).Equal(actual) {
+		t.Errorf(
+			"unexpected %v\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			name,
+			expected,
+			actual,
+		)
+	}
+}
+
+type testID string
+
+func (ti testID) String() string {
+	return string(ti)
+}
+
+type testIDService struct{}
+
+func (tis *testIDService) NewID() ID {
+	return testID("id")
+}
+
+func (tis *testIDService) NewIDFromString(id string) (ID, error) {
+	return testID(id), nil
+}
+
+type testEventService struct {
+	mutex  sync.Mutex
+	events []*Event
+}
+
+func (tes *testEventService) Publish(event *Event) {
+	tes.mutex.Lock()
+	defer tes.mutex.Unlock()
+
+	tes.events = append(tes.events, event)
+}
+
+type testPositionRepository struct {
+	positions []*Position
+}
+
+func (tpr *testPositionRepository) CreatePosition(position *Position) error {
+	tpr.positions = append(tpr.positions, position)
+	return nil
+}
+
+func (tpr *testPositionRepository) UpdatePosition(_ *Position) error {
+	return nil
+}
+
+func (tpr *testPositionRepository) Positions(
+	_ PositionFilter,
+) ([]*Position, error) {
+	return tpr.positions, nil
+}
+
+func (tpr *testPositionRepository) PositionsCount(
+	_ PositionFilter,
+) (int, error) {
+	return len(tpr.positions), nil
+}
+
+func (tpr *testPositionRepository) RequestPositionExit(_ ID) error {
+	return nil
+}
+
+func (tpr *testPositionRepository) Position(_ ID) (*Position, error) {
+	return nil, ErrNotFound
+}
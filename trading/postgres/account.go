@@ -1,21 +1,26 @@
 package postgres
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/jackc/pgtype"
 	"github.com/lukasz-zimnoch/dexly/trading"
 )
 
+// AccountRepository stores exchange credentials encrypted using
+// the secret cipher.
 type AccountRepository struct {
-	client    *Client
-	idService trading.IDService
+	client       *Client
+	idService    trading.IDService
+	secretCipher trading.SecretCipher
 }
 
 func NewAccountRepository(
 	client *Client,
 	idService trading.IDService,
+	secretCipher trading.SecretCipher,
 ) *AccountRepository {
-	return &AccountRepository{client, idService}
+	return &AccountRepository{client, idService, secretCipher}
 }
 
 func (ar *AccountRepository) CreateAccount(account *trading.Account) error {
@@ -25,7 +30,7 @@ func (ar *AccountRepository) CreateAccount(account *trading.Account) error {
     	VALUES (:id, :email, :exchange, :exchange_api_key, :exchange_secret_key, 
     	        :risk_factor, :open_position_limit)`
 
-	accountRow, err := new(accountRow).wrap(account)
+	accountRow, err := new(accountRow).wrap(account, ar.secretCipher)
 	if err != nil {
 		return fmt.Errorf(
 			"could not convert account [%v] to pg row: [%v]",
@@ -59,10 +64,170 @@ func (ar *AccountRepository) Account(
 		accountID.String(),
 	)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, trading.ErrNotFound
+		}
+
 		return nil, fmt.Errorf("could not execute query: [%v]", err)
 	}
 
-	return accountRow.unwrap(ar.idService)
+	return accountRow.unwrap(ar.idService, ar.secretCipher)
+}
+
+func (ar *AccountRepository) Accounts() ([]*trading.Account, error) {
+	var accountRows []accountRow
+
+	query := `SELECT * FROM account ORDER BY email ASC`
+
+	err := ar.client.instance().Select(&accountRows, query)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute query: [%v]", err)
+	}
+
+	accounts := make([]*trading.Account, 0)
+
+	for _, accountRow := range accountRows {
+		account, err := accountRow.unwrap(ar.idService, ar.secretCipher)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not convert account [%v] from pg row: [%v]",
+				accountRow.ID,
+				err,
+			)
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// ReencryptCredentials encrypts credentials of all accounts using the
+// primary master key. Credentials stored in plain text as well as those
+// encrypted using other master keys or an older format are re-encrypted,
+// all others are left intact. It returns the number of updated accounts.
+func (ar *AccountRepository) ReencryptCredentials() (int, error) {
+	return ar.updateCredentials(ar.secretCipher.Reencrypt)
+}
+
+// EncryptPlaintextCredentials encrypts credentials stored in plain text
+// before encryption has been introduced. Already encrypted credentials
+// are left intact. It returns the number of updated accounts.
+func (ar *AccountRepository) EncryptPlaintextCredentials() (int, error) {
+	return ar.updateCredentials(
+		func(value, associatedData string) (string, bool, error) {
+			if ar.secretCipher.IsEncrypted(value) {
+				return value, false, nil
+			}
+
+			ciphertext, err := ar.secretCipher.Encrypt(value, associatedData)
+			if err != nil {
+				return "", false, err
+			}
+
+			return ciphertext, true, nil
+		},
+	)
+}
+
+func (ar *AccountRepository) updateCredentials(
+	reencrypt func(value, associatedData string) (string, bool, error),
+) (int, error) {
+	transaction, err := ar.client.instance().Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("could not begin transaction: [%v]", err)
+	}
+
+	var credentialsRows []accountCredentialsRow
+
+	err = transaction.Select(
+		&credentialsRows,
+		`SELECT id, exchange_api_key, exchange_secret_key 
+		FROM account FOR UPDATE`,
+	)
+	if err != nil {
+		_ = transaction.Rollback()
+		return 0, fmt.Errorf("could not execute query: [%v]", err)
+	}
+
+	updated := 0
+
+	for _, credentialsRow := range credentialsRows {
+		apiKey, apiKeyChanged, err := reencrypt(
+			credentialsRow.ExchangeApiKey,
+			credentialAssociatedData(credentialsRow.ID, apiKeyColumn),
+		)
+		if err != nil {
+			_ = transaction.Rollback()
+			return 0, fmt.Errorf(
+				"could not re-encrypt api key of account [%v]: [%v]",
+				credentialsRow.ID,
+				err,
+			)
+		}
+
+		secretKey, secretKeyChanged, err := reencrypt(
+			credentialsRow.ExchangeSecretKey,
+			credentialAssociatedData(credentialsRow.ID, secretKeyColumn),
+		)
+		if err != nil {
+			_ = transaction.Rollback()
+			return 0, fmt.Errorf(
+				"could not re-encrypt secret key of account [%v]: [%v]",
+				credentialsRow.ID,
+				err,
+			)
+		}
+
+		if !apiKeyChanged && !secretKeyChanged {
+			continue
+		}
+
+		_, err = transaction.NamedExec(
+			`UPDATE account SET 
+				exchange_api_key = :exchange_api_key, 
+				exchange_secret_key = :exchange_secret_key 
+			WHERE id = :id`,
+			&accountCredentialsRow{
+				ID:                credentialsRow.ID,
+				ExchangeApiKey:    apiKey,
+				ExchangeSecretKey: secretKey,
+			},
+		)
+		if err != nil {
+			_ = transaction.Rollback()
+			return 0, fmt.Errorf(
+				"could not execute command for account [%v]: [%v]",
+				credentialsRow.ID,
+				err,
+			)
+		}
+
+		updated++
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return 0, fmt.Errorf("could not commit transaction: [%v]", err)
+	}
+
+	return updated, nil
+}
+
+const (
+	apiKeyColumn    = "exchange_api_key"
+	secretKeyColumn = "exchange_secret_key"
+)
+
+// credentialAssociatedData binds encrypted credentials to the account
+// and the column they are stored in.
+func credentialAssociatedData(accountID, column string) string {
+	return "account:" + accountID + ":" + column
+}
+
+type accountCredentialsRow struct {
+	ID                string
+	ExchangeApiKey    string `db:"exchange_api_key"`
+	ExchangeSecretKey string `db:"exchange_secret_key"`
 }
 
 type accountRow struct {
@@ -75,17 +240,36 @@ type accountRow struct {
 	OpenPositionLimit int            `db:"open_position_limit"`
 }
 
-func (ar *accountRow) wrap(account *trading.Account) (*accountRow, error) {
-	riskFactor, err := floatToNumeric(account.RiskFactor)
+func (ar *accountRow) wrap(
+	account *trading.Account,
+	secretCipher trading.SecretCipher,
+) (*accountRow, error) {
+	riskFactor, err := decimalToNumeric(account.RiskFactor)
 	if err != nil {
 		return nil, err
 	}
 
+	apiKey, err := secretCipher.Encrypt(
+		account.ExchangeApiKey,
+		credentialAssociatedData(account.ID.String(), apiKeyColumn),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt api key: [%v]", err)
+	}
+
+	secretKey, err := secretCipher.Encrypt(
+		account.ExchangeSecretKey,
+		credentialAssociatedData(account.ID.String(), secretKeyColumn),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt secret key: [%v]", err)
+	}
+
 	ar.ID = account.ID.String()
 	ar.Email = account.Email
 	ar.Exchange = account.Exchange
-	ar.ExchangeApiKey = account.ExchangeApiKey
-	ar.ExchangeSecretKey = account.ExchangeSecretKey
+	ar.ExchangeApiKey = apiKey
+	ar.ExchangeSecretKey = secretKey
 	ar.RiskFactor = riskFactor
 	ar.OpenPositionLimit = account.OpenPositionsLimit
 
@@ -94,23 +278,40 @@ func (ar *accountRow) wrap(account *trading.Account) (*accountRow, error) {
 
 func (ar *accountRow) unwrap(
 	idService trading.IDService,
+	secretCipher trading.SecretCipher,
 ) (*trading.Account, error) {
 	ID, err := idService.NewIDFromString(ar.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	riskFactor, err := numericToFloat(ar.RiskFactor)
+	riskFactor, err := numericToDecimal(ar.RiskFactor)
 	if err != nil {
 		return nil, err
 	}
 
+	apiKey, err := secretCipher.Decrypt(
+		ar.ExchangeApiKey,
+		credentialAssociatedData(ar.ID, apiKeyColumn),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt api key: [%v]", err)
+	}
+
+	secretKey, err := secretCipher.Decrypt(
+		ar.ExchangeSecretKey,
+		credentialAssociatedData(ar.ID, secretKeyColumn),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt secret key: [%v]", err)
+	}
+
 	return &trading.Account{
 		ID:                 ID,
 		Email:              ar.Email,
 		Exchange:           ar.Exchange,
-		ExchangeApiKey:     ar.ExchangeApiKey,
-		ExchangeSecretKey:  ar.ExchangeSecretKey,
+		ExchangeApiKey:     apiKey,
+		ExchangeSecretKey:  secretKey,
 		RiskFactor:         riskFactor,
 		OpenPositionsLimit: ar.OpenPositionLimit,
 	}, nil
@@ -0,0 +1,322 @@
+package postgres
+
+import (
+	"fmt"
+	"github.com/jackc/pgtype"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"strings"
+	"time"
+)
+
+// candlesBatchSize limits the number of candles upserted by a single
+// command to stay within the limit of command parameters.
+const candlesBatchSize = 1000
+
+// CandleRepository durably keeps candles of all series. Candles are keyed
+// by the series and their open times so the table can be converted into
+// a TimescaleDB hypertable partitioned by open times.
+type CandleRepository struct {
+	client *Client
+}
+
+func NewCandleRepository(client *Client) *CandleRepository {
+	return &CandleRepository{client}
+}
+
+func (cr *CandleRepository) SaveCandles(
+	key trading.CandleKey,
+	candles ...*trading.Candle,
+) error {
+	for start := 0; start < len(candles); start += candlesBatchSize {
+		end := start + candlesBatchSize
+		if end > len(candles) {
+			end = len(candles)
+		}
+
+		query, args, err := upsertCandlesCommand(key, candles[start:end])
+		if err != nil {
+			return fmt.Errorf(
+				"could not prepare command for candles of [%v]: [%v]",
+				key,
+				err,
+			)
+		}
+
+		if _, err := cr.client.instance().Exec(query, args...); err != nil {
+			return fmt.Errorf(
+				"could not execute command for candles of [%v]: [%v]",
+				key,
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// upsertCandlesCommand builds a single command inserting all candles or
+// updating the stored ones. The key is passed once and shared by all
+// rows. Candles of the same open time are collapsed into the last one as
+// a row can't be updated twice by the same command.
+func upsertCandlesCommand(
+	key trading.CandleKey,
+	candles []*trading.Candle,
+) (string, []interface{}, error) {
+	unique := make([]*trading.Candle, 0, len(candles))
+	indexes := make(map[int64]int)
+
+	for _, candle := range candles {
+		openTime := candle.OpenTime.UnixNano()
+
+		if index, exists := indexes[openTime]; exists {
+			unique[index] = candle
+			continue
+		}
+
+		indexes[openTime] = len(unique)
+		unique = append(unique, candle)
+	}
+
+	args := []interface{}{
+		key.Exchange,
+		string(key.Pair.Base),
+		string(key.Pair.Quote),
+		key.Interval.String(),
+	}
+
+	values := make([]string, len(unique))
+
+	for index, candle := range unique {
+		row, err := new(candleRow).wrap(candle)
+		if err != nil {
+			return "", nil, fmt.Errorf(
+				"could not convert candle [%v] to pg row: [%v]",
+				candle,
+				err,
+			)
+		}
+
+		rowArgs := []interface{}{
+			row.OpenTime,
+			row.CloseTime,
+			row.OpenPrice,
+			row.ClosePrice,
+			row.MaxPrice,
+			row.MinPrice,
+			row.Volume,
+			row.TradeCount,
+		}
+
+		placeholders := make([]string, len(rowArgs))
+		for i := range rowArgs {
+			placeholders[i] = fmt.Sprintf("$%v", len(args)+i+1)
+		}
+
+		values[index] = fmt.Sprintf(
+			"($1, $2, $3, $4, %v)",
+			strings.Join(placeholders, ", "),
+		)
+		args = append(args, rowArgs...)
+	}
+
+	query := `INSERT INTO
+		candle (exchange, base_asset, quote_asset, candle_interval,
+		        open_time, close_time, open_price, close_price,
+		        max_price, min_price, volume, trade_count)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (exchange, base_asset, quote_asset, candle_interval,
+		             open_time) DO UPDATE
+		SET close_time = EXCLUDED.close_time,
+		    open_price = EXCLUDED.open_price,
+		    close_price = EXCLUDED.close_price,
+		    max_price = EXCLUDED.max_price,
+		    min_price = EXCLUDED.min_price,
+		    volume = EXCLUDED.volume,
+		    trade_count = EXCLUDED.trade_count`
+
+	return query, args, nil
+}
+
+func (cr *CandleRepository) CandlesRange(
+	key trading.CandleKey,
+	start, end time.Time,
+) ([]*trading.Candle, error) {
+	return cr.selectCandles(
+		key,
+		`SELECT `+candleColumns+` FROM candle
+		WHERE `+candleKeyCondition+`
+		AND open_time BETWEEN $5 AND $6
+		ORDER BY open_time`,
+		start.UTC(),
+		end.UTC(),
+	)
+}
+
+func (cr *CandleRepository) LatestCandles(
+	key trading.CandleKey,
+	count int,
+) ([]*trading.Candle, error) {
+	return cr.selectCandles(
+		key,
+		`SELECT * FROM (
+			SELECT `+candleColumns+` FROM candle
+			WHERE `+candleKeyCondition+`
+			ORDER BY open_time DESC
+			LIMIT $5
+		) latest ORDER BY open_time`,
+		count,
+	)
+}
+
+func (cr *CandleRepository) DeleteCandlesBefore(
+	interval trading.CandleInterval,
+	before time.Time,
+) (int64, error) {
+	query := `DELETE FROM candle
+		WHERE candle_interval = $1 AND open_time < $2`
+
+	result, err := cr.client.instance().Exec(
+		query,
+		interval.String(),
+		before.UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"could not execute command for [%v] candles: [%v]",
+			interval,
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf(
+			"could not get affected rows for [%v] candles: [%v]",
+			interval,
+			err,
+		)
+	}
+
+	return rowsAffected, nil
+}
+
+const (
+	candleColumns = `open_time, close_time, open_price, close_price,
+		max_price, min_price, volume, trade_count`
+
+	candleKeyCondition = `exchange = $1 AND base_asset = $2
+		AND quote_asset = $3 AND candle_interval = $4`
+)
+
+func (cr *CandleRepository) selectCandles(
+	key trading.CandleKey,
+	query string,
+	args ...interface{},
+) ([]*trading.Candle, error) {
+	var candleRows []*candleRow
+
+	err := cr.client.instance().Select(
+		&candleRows,
+		query,
+		append(
+			[]interface{}{
+				key.Exchange,
+				string(key.Pair.Base),
+				string(key.Pair.Quote),
+				key.Interval.String(),
+			},
+			args...,
+		)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not execute query for candles of [%v]: [%v]",
+			key,
+			err,
+		)
+	}
+
+	candles := make([]*trading.Candle, len(candleRows))
+
+	for index, row := range candleRows {
+		candle, err := row.unwrap()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not convert candle [%v] from pg row: [%v]",
+				row.OpenTime,
+				err,
+			)
+		}
+
+		candles[index] = candle
+	}
+
+	return candles, nil
+}
+
+type candleRow struct {
+	OpenTime   time.Time      `db:"open_time"`
+	CloseTime  time.Time      `db:"close_time"`
+	OpenPrice  pgtype.Numeric `db:"open_price"`
+	ClosePrice pgtype.Numeric `db:"close_price"`
+	MaxPrice   pgtype.Numeric `db:"max_price"`
+	MinPrice   pgtype.Numeric `db:"min_price"`
+	Volume     pgtype.Numeric
+	TradeCount int64 `db:"trade_count"`
+}
+
+func (cr *candleRow) wrap(candle *trading.Candle) (*candleRow, error) {
+	cr.OpenTime = candle.OpenTime.UTC()
+	cr.CloseTime = candle.CloseTime.UTC()
+	cr.TradeCount = int64(candle.TradeCount)
+
+	for _, field := range []struct {
+		target *pgtype.Numeric
+		value  trading.Decimal
+	}{
+		{&cr.OpenPrice, candle.OpenPrice},
+		{&cr.ClosePrice, candle.ClosePrice},
+		{&cr.MaxPrice, candle.MaxPrice},
+		{&cr.MinPrice, candle.MinPrice},
+		{&cr.Volume, candle.Volume},
+	} {
+		numeric, err := decimalToNumeric(field.value)
+		if err != nil {
+			return nil, err
+		}
+
+		*field.target = numeric
+	}
+
+	return cr, nil
+}
+
+func (cr *candleRow) unwrap() (*trading.Candle, error) {
+	values := make([]trading.Decimal, 5)
+
+	for index, numeric := range []pgtype.Numeric{
+		cr.OpenPrice,
+		cr.ClosePrice,
+		cr.MaxPrice,
+		cr.MinPrice,
+		cr.Volume,
+	} {
+		value, err := numericToDecimal(numeric)
+		if err != nil {
+			return nil, err
+		}
+
+		values[index] = value
+	}
+
+	return &trading.Candle{
+		OpenTime:   cr.OpenTime,
+		CloseTime:  cr.CloseTime,
+		OpenPrice:  values[0],
+		ClosePrice: values[1],
+		MaxPrice:   values[2],
+		MinPrice:   values[3],
+		Volume:     values[4],
+		TradeCount: uint(cr.TradeCount),
+	}, nil
+}
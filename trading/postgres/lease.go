@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"time"
+)
+
+// LeaseRepository keeps workload leases and heartbeats of controller
+// instances. Expiration times are computed using the database clock.
+type LeaseRepository struct {
+	client    *Client
+	idService trading.IDService
+}
+
+func NewLeaseRepository(
+	client *Client,
+	idService trading.IDService,
+) *LeaseRepository {
+	return &LeaseRepository{client, idService}
+}
+
+func (lr *LeaseRepository) Heartbeat(
+	instanceID string,
+	ttl time.Duration,
+) (int, error) {
+	query := `INSERT INTO
+		controller_instance (id, expires_at)
+		VALUES ($1, now() + $2 * INTERVAL '1 second')
+		ON CONFLICT (id) DO UPDATE SET expires_at = EXCLUDED.expires_at`
+
+	_, err := lr.client.instance().Exec(query, instanceID, ttl.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf(
+			"could not execute command for instance [%v]: [%v]",
+			instanceID,
+			err,
+		)
+	}
+
+	// Instances which died long time ago are of no use anymore.
+	_, err = lr.client.instance().Exec(
+		`DELETE FROM controller_instance
+		WHERE expires_at < now() - INTERVAL '1 day'`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"could not delete expired instances: [%v]",
+			err,
+		)
+	}
+
+	var instances int
+
+	err = lr.client.instance().Get(
+		&instances,
+		`SELECT count(*) FROM controller_instance WHERE expires_at > now()`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("could not count alive instances: [%v]", err)
+	}
+
+	return instances, nil
+}
+
+func (lr *LeaseRepository) LeasedWorkloads(
+	instanceID string,
+) ([]trading.ID, error) {
+	var workloadIDs []string
+
+	query := `SELECT workload_id FROM workload_lease
+		WHERE owner = $1 AND expires_at > now()`
+
+	err := lr.client.instance().Select(&workloadIDs, query, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not execute query for instance [%v]: [%v]",
+			instanceID,
+			err,
+		)
+	}
+
+	leased := make([]trading.ID, 0)
+
+	for _, workloadID := range workloadIDs {
+		id, err := lr.idService.NewIDFromString(workloadID)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse workload ID [%v]: [%v]",
+				workloadID,
+				err,
+			)
+		}
+
+		leased = append(leased, id)
+	}
+
+	return leased, nil
+}
+
+func (lr *LeaseRepository) AcquireLease(
+	workloadID trading.ID,
+	instanceID string,
+	ttl time.Duration,
+) (bool, error) {
+	// The lease is updated only if it's already held by the instance
+	// or has expired.
+	query := `INSERT INTO
+		workload_lease (workload_id, owner, expires_at)
+		VALUES ($1, $2, now() + $3 * INTERVAL '1 second')
+		ON CONFLICT (workload_id) DO UPDATE
+		SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE workload_lease.owner = EXCLUDED.owner
+		OR workload_lease.expires_at <= now()`
+
+	result, err := lr.client.instance().Exec(
+		query,
+		workloadID.String(),
+		instanceID,
+		ttl.Seconds(),
+	)
+	if err != nil {
+		return false, fmt.Errorf(
+			"could not execute command for workload [%v]: [%v]",
+			workloadID,
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf(
+			"could not get affected rows for workload [%v]: [%v]",
+			workloadID,
+			err,
+		)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (lr *LeaseRepository) ReleaseLease(
+	workloadID trading.ID,
+	instanceID string,
+) error {
+	query := `DELETE FROM workload_lease WHERE workload_id = $1 AND owner = $2`
+
+	_, err := lr.client.instance().Exec(query, workloadID.String(), instanceID)
+	if err != nil {
+		return fmt.Errorf(
+			"could not execute command for workload [%v]: [%v]",
+			workloadID,
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (lr *LeaseRepository) Unregister(instanceID string) error {
+	transaction, err := lr.client.instance().Beginx()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: [%v]", err)
+	}
+
+	_, err = transaction.Exec(
+		`DELETE FROM workload_lease WHERE owner = $1`,
+		instanceID,
+	)
+	if err != nil {
+		_ = transaction.Rollback()
+		return fmt.Errorf(
+			"could not delete leases of instance [%v]: [%v]",
+			instanceID,
+			err,
+		)
+	}
+
+	_, err = transaction.Exec(
+		`DELETE FROM controller_instance WHERE id = $1`,
+		instanceID,
+	)
+	if err != nil {
+		_ = transaction.Rollback()
+		return fmt.Errorf(
+			"could not delete instance [%v]: [%v]",
+			instanceID,
+			err,
+		)
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: [%v]", err)
+	}
+
+	return nil
+}
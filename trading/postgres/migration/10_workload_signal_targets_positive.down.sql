@@ -0,0 +1,3 @@
+ALTER TABLE workload
+    DROP CONSTRAINT IF EXISTS workload_reward_risk_ratio_positive,
+    DROP CONSTRAINT IF EXISTS workload_stop_loss_atr_multiplier_positive;
\ No newline at end of file
@@ -0,0 +1,10 @@
+UPDATE workload SET stop_loss_atr_multiplier = 2
+    WHERE stop_loss_atr_multiplier <= 0;
+UPDATE workload SET reward_risk_ratio = 2
+    WHERE reward_risk_ratio <= 0;
+
+ALTER TABLE workload
+    ADD CONSTRAINT workload_stop_loss_atr_multiplier_positive
+        CHECK (stop_loss_atr_multiplier > 0),
+    ADD CONSTRAINT workload_reward_risk_ratio_positive
+        CHECK (reward_risk_ratio > 0);
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS paper_balance;
+
+ALTER TABLE workload DROP COLUMN IF EXISTS mode;
+
+DROP TYPE IF EXISTS workload_mode;
\ No newline at end of file
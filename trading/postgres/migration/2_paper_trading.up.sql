@@ -0,0 +1,10 @@
+CREATE TYPE workload_mode AS ENUM ('LIVE', 'PAPER');
+
+ALTER TABLE workload ADD COLUMN mode workload_mode NOT NULL DEFAULT 'LIVE';
+
+CREATE TABLE paper_balance (
+    account_id UUID REFERENCES account NOT NULL,
+    asset VARCHAR NOT NULL,
+    balance NUMERIC NOT NULL,
+    PRIMARY KEY(account_id, asset)
+);
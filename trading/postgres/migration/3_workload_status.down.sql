@@ -0,0 +1,3 @@
+ALTER TABLE workload DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS workload_status;
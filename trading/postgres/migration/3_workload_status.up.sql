@@ -0,0 +1,3 @@
+CREATE TYPE workload_status AS ENUM ('ACTIVE', 'PAUSED', 'DISABLED');
+
+ALTER TABLE workload ADD COLUMN status workload_status NOT NULL DEFAULT 'ACTIVE';
@@ -0,0 +1 @@
+ALTER TABLE position DROP COLUMN IF EXISTS exit_requested;
@@ -0,0 +1 @@
+ALTER TABLE position ADD COLUMN exit_requested BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS workload_lease;
+
+DROP TABLE IF EXISTS controller_instance;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE controller_instance (
+    id VARCHAR PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE workload_lease (
+    workload_id UUID PRIMARY KEY REFERENCES workload ON DELETE CASCADE,
+    owner VARCHAR NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX workload_lease_owner_idx ON workload_lease (owner);
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE workload
+    DROP COLUMN IF EXISTS candle_window_size,
+    DROP COLUMN IF EXISTS candle_interval;
+
+DROP TYPE IF EXISTS candle_interval;
\ No newline at end of file
@@ -0,0 +1,5 @@
+CREATE TYPE candle_interval AS ENUM ('1m', '5m', '15m', '30m', '1h', '4h', '1d');
+
+ALTER TABLE workload
+    ADD COLUMN candle_interval candle_interval NOT NULL DEFAULT '1m',
+    ADD COLUMN candle_window_size INT NOT NULL DEFAULT 720;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS candle;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE candle (
+    exchange VARCHAR NOT NULL,
+    base_asset VARCHAR NOT NULL,
+    quote_asset VARCHAR NOT NULL,
+    candle_interval candle_interval NOT NULL,
+    open_time TIMESTAMPTZ NOT NULL,
+    close_time TIMESTAMPTZ NOT NULL,
+    open_price NUMERIC NOT NULL,
+    close_price NUMERIC NOT NULL,
+    max_price NUMERIC NOT NULL,
+    min_price NUMERIC NOT NULL,
+    volume NUMERIC NOT NULL,
+    trade_count BIGINT NOT NULL,
+    PRIMARY KEY(exchange, base_asset, quote_asset, candle_interval, open_time)
+);
+
+CREATE INDEX candle_interval_open_time_idx ON candle (candle_interval, open_time);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE workload
+    DROP COLUMN IF EXISTS reward_risk_ratio,
+    DROP COLUMN IF EXISTS stop_loss_atr_multiplier;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE workload
+    ADD COLUMN stop_loss_atr_multiplier NUMERIC NOT NULL DEFAULT 2,
+    ADD COLUMN reward_risk_ratio NUMERIC NOT NULL DEFAULT 2;
\ No newline at end of file
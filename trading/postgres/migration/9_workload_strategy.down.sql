@@ -0,0 +1,3 @@
+ALTER TABLE workload
+    DROP COLUMN IF EXISTS strategy_version,
+    DROP COLUMN IF EXISTS strategy_name;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE workload
+    ADD COLUMN strategy_name VARCHAR NOT NULL DEFAULT 'ema',
+    ADD COLUMN strategy_version INTEGER NOT NULL DEFAULT 1;
\ No newline at end of file
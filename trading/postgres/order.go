@@ -80,12 +80,12 @@ type orderRow struct {
 }
 
 func (or *orderRow) wrap(order *trading.Order) (*orderRow, error) {
-	price, err := floatToNumeric(order.Price)
+	price, err := decimalToNumeric(order.Price)
 	if err != nil {
 		return nil, err
 	}
 
-	size, err := floatToNumeric(order.Size)
+	size, err := decimalToNumeric(order.Size)
 	if err != nil {
 		return nil, err
 	}
@@ -114,12 +114,12 @@ func (or *orderRow) unwrap(
 		return nil, err
 	}
 
-	price, err := numericToFloat(or.Price)
+	price, err := numericToDecimal(or.Price)
 	if err != nil {
 		return nil, err
 	}
 
-	size, err := numericToFloat(or.Size)
+	size, err := numericToDecimal(or.Size)
 	if err != nil {
 		return nil, err
 	}
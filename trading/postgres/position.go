@@ -3,6 +3,7 @@ package postgres
 import (
 	"fmt"
 	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
 	"github.com/lukasz-zimnoch/dexly/trading"
 	"time"
 )
@@ -71,41 +72,86 @@ func (pr *PositionRepository) UpdatePosition(position *trading.Position) error {
 	return nil
 }
 
+func (pr *PositionRepository) RequestPositionExit(
+	positionID trading.ID,
+) error {
+	query := `UPDATE position SET exit_requested = TRUE 
+		WHERE id = $1 AND status = $2`
+
+	result, err := pr.client.instance().Exec(
+		query,
+		positionID.String(),
+		trading.StatusOpen.String(),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"could not execute command for position [%v]: [%v]",
+			positionID,
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"could not get affected rows for position [%v]: [%v]",
+			positionID,
+			err,
+		)
+	}
+
+	if rowsAffected == 0 {
+		return trading.ErrNotFound
+	}
+
+	return nil
+}
+
+func (pr *PositionRepository) Position(
+	positionID trading.ID,
+) (*trading.Position, error) {
+	var positionRows []positionRow
+
+	query := `SELECT * FROM position WHERE id = $1`
+
+	err := pr.client.instance().Select(
+		&positionRows,
+		query,
+		positionID.String(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not execute query for position [%v]: [%v]",
+			positionID,
+			err,
+		)
+	}
+
+	if len(positionRows) == 0 {
+		return nil, trading.ErrNotFound
+	}
+
+	positions, err := pr.unwrapWithOrders(positionRows)
+	if err != nil {
+		return nil, err
+	}
+
+	return positions[0], nil
+}
+
 func (pr *PositionRepository) Positions(
 	filter trading.PositionFilter,
 ) ([]*trading.Position, error) {
-	var selectResult []struct {
-		positionRow `db:"position"`
-		orderRow    `db:"order"`
-	}
+	var positionRows []positionRow
 
-	query :=
-		`SELECT 
-       		p.id "position.id",
-       		p.workload_id "position.workload_id",
-       		p.type "position.type",
-       		p.status "position.status",
-       		p.entry_price "position.entry_price",
-       		p.size "position.size",
-       		p.take_profit_price "position.take_profit_price",
-       		p.stop_loss_price "position.stop_loss_price",
-       		p.time "position.time",
-    		o.id "order.id", 
-       		o.position_id "order.position_id", 
-       		o.side "order.side", 
-       		o.price "order.price", 
-       		o.size "order.size",
-       		o.time "order.time",
-       		o.executed "order.executed"
-		FROM position p
-		LEFT JOIN position_order o ON o.position_id = p.id
-		WHERE p.workload_id = $1 AND p.status = $2
-		ORDER BY o.time ASC`
+	query := `SELECT * FROM position 
+		WHERE workload_id = $1 AND status = $2
+		ORDER BY time ASC`
 
 	err := pr.client.instance().Select(
-		&selectResult,
+		&positionRows,
 		query,
-		filter.WorkloadID,
+		filter.WorkloadID.String(),
 		filter.Status.String(),
 	)
 	if err != nil {
@@ -116,41 +162,78 @@ func (pr *PositionRepository) Positions(
 		)
 	}
 
+	return pr.unwrapWithOrders(positionRows)
+}
+
+// unwrapWithOrders converts the given rows to positions and attaches
+// their orders. Orders are fetched with a separate query as positions
+// without orders must be supported too.
+func (pr *PositionRepository) unwrapWithOrders(
+	positionRows []positionRow,
+) ([]*trading.Position, error) {
+	positions := make([]*trading.Position, 0)
+
+	if len(positionRows) == 0 {
+		return positions, nil
+	}
+
 	positionsByID := make(map[string]*trading.Position)
+	positionIDs := make([]string, 0)
 
-	for _, result := range selectResult {
-		order, err := result.orderRow.unwrap(pr.idService)
+	for _, positionRow := range positionRows {
+		position, err := positionRow.unwrap(pr.idService)
 		if err != nil {
 			return nil, fmt.Errorf(
-				"could not convert order [%v] from pg row: [%v]",
-				result.orderRow.ID,
+				"could not convert position [%v] from pg row: [%v]",
+				positionRow.ID,
 				err,
 			)
 		}
 
-		position, exists := positionsByID[result.positionRow.ID]
-		if !exists {
-			position, err = result.positionRow.unwrap(pr.idService)
-			if err != nil {
-				return nil, fmt.Errorf(
-					"could not convert position [%v] from pg row: [%v]",
-					result.positionRow.ID,
-					err,
-				)
-			}
-
-			positionsByID[result.positionRow.ID] = position
+		positionsByID[positionRow.ID] = position
+		positionIDs = append(positionIDs, positionRow.ID)
+		positions = append(positions, position)
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT * FROM position_order 
+		WHERE position_id IN (?) 
+		ORDER BY time ASC`,
+		positionIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not build orders query: [%v]", err)
+	}
+
+	var orderRows []orderRow
+
+	err = pr.client.instance().Select(
+		&orderRows,
+		pr.client.instance().Rebind(query),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not execute query for orders: [%v]",
+			err,
+		)
+	}
+
+	for _, orderRow := range orderRows {
+		order, err := orderRow.unwrap(pr.idService)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not convert order [%v] from pg row: [%v]",
+				orderRow.ID,
+				err,
+			)
 		}
 
+		position := positionsByID[orderRow.PositionID]
 		order.Position = position
 		position.Orders = append(position.Orders, order)
 	}
 
-	positions := make([]*trading.Position, 0)
-	for _, position := range positionsByID {
-		positions = append(positions, position)
-	}
-
 	return positions, nil
 }
 
@@ -191,27 +274,28 @@ type positionRow struct {
 	Pair            string
 	Exchange        string
 	Time            time.Time
+	ExitRequested   bool `db:"exit_requested"`
 }
 
 func (pr *positionRow) wrap(
 	position *trading.Position,
 ) (*positionRow, error) {
-	entryPrice, err := floatToNumeric(position.EntryPrice)
+	entryPrice, err := decimalToNumeric(position.EntryPrice)
 	if err != nil {
 		return nil, err
 	}
 
-	size, err := floatToNumeric(position.Size)
+	size, err := decimalToNumeric(position.Size)
 	if err != nil {
 		return nil, err
 	}
 
-	takeProfitPrice, err := floatToNumeric(position.TakeProfitPrice)
+	takeProfitPrice, err := decimalToNumeric(position.TakeProfitPrice)
 	if err != nil {
 		return nil, err
 	}
 
-	stopLossPrice, err := floatToNumeric(position.StopLossPrice)
+	stopLossPrice, err := decimalToNumeric(position.StopLossPrice)
 	if err != nil {
 		return nil, err
 	}
@@ -225,6 +309,7 @@ func (pr *positionRow) wrap(
 	pr.TakeProfitPrice = takeProfitPrice
 	pr.StopLossPrice = stopLossPrice
 	pr.Time = position.Time
+	pr.ExitRequested = position.ExitRequested
 
 	return pr, nil
 }
@@ -252,22 +337,22 @@ func (pr *positionRow) unwrap(
 		return nil, err
 	}
 
-	entryPrice, err := numericToFloat(pr.EntryPrice)
+	entryPrice, err := numericToDecimal(pr.EntryPrice)
 	if err != nil {
 		return nil, err
 	}
 
-	size, err := numericToFloat(pr.Size)
+	size, err := numericToDecimal(pr.Size)
 	if err != nil {
 		return nil, err
 	}
 
-	takeProfitPrice, err := numericToFloat(pr.TakeProfitPrice)
+	takeProfitPrice, err := numericToDecimal(pr.TakeProfitPrice)
 	if err != nil {
 		return nil, err
 	}
 
-	stopLossPrice, err := numericToFloat(pr.StopLossPrice)
+	stopLossPrice, err := numericToDecimal(pr.StopLossPrice)
 	if err != nil {
 		return nil, err
 	}
@@ -282,5 +367,6 @@ func (pr *positionRow) unwrap(
 		TakeProfitPrice: takeProfitPrice,
 		StopLossPrice:   stopLossPrice,
 		Time:            pr.Time,
+		ExitRequested:   pr.ExitRequested,
 	}, nil
 }
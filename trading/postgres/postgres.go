@@ -11,7 +11,6 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/lukasz-zimnoch/dexly/trading"
 	"github.com/sirupsen/logrus"
-	"math/big"
 	"sync"
 	"time"
 )
@@ -67,8 +66,7 @@ func (c *Client) monitorDatabaseMode(ctx context.Context, config *Config) {
 	for {
 		select {
 		case <-ticker.C:
-			var isReadonly bool
-			err := c.database.Get(&isReadonly, "SELECT pg_is_in_recovery()")
+			isReadonly, err := c.isReadonly(ctx)
 			if err != nil {
 				logrus.Errorf(
 					"could not determine database mode: [%v]",
@@ -106,6 +104,34 @@ func (c *Client) monitorDatabaseMode(ctx context.Context, config *Config) {
 	}
 }
 
+func (c *Client) isReadonly(ctx context.Context) (bool, error) {
+	var isReadonly bool
+
+	err := c.instance().GetContext(
+		ctx,
+		&isReadonly,
+		"SELECT pg_is_in_recovery()",
+	)
+
+	return isReadonly, err
+}
+
+// CheckHealth reports the database as unhealthy if it can't be queried
+// or is in read-only mode, e.g. after a failover which has not been
+// handled yet.
+func (c *Client) CheckHealth(ctx context.Context) error {
+	isReadonly, err := c.isReadonly(ctx)
+	if err != nil {
+		return fmt.Errorf("could not determine database mode: [%v]", err)
+	}
+
+	if isReadonly {
+		return fmt.Errorf("database is in read-only mode")
+	}
+
+	return nil
+}
+
 func (c *Client) instance() *sqlx.DB {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
@@ -123,21 +149,11 @@ func RunMigration(
 
 	logger.Infof("starting postgres migration")
 
-	migrationsDir := "file://" + config.MigrationDir
-
-	databaseAddress := fmt.Sprintf(
-		"postgres://%s:%s@%s/%s?sslmode=%s",
-		config.User,
-		config.Password,
-		config.Address,
-		config.Name,
-		config.SSLMode,
-	)
-
-	migration, err := migrate.New(migrationsDir, databaseAddress)
+	migration, err := newMigration(config)
 	if err != nil {
 		return err
 	}
+	defer migration.Close()
 
 	err = migration.Up()
 	if err != nil {
@@ -154,23 +170,98 @@ func RunMigration(
 	return nil
 }
 
-func floatToNumeric(value *big.Float) (pgtype.Numeric, error) {
-	var result pgtype.Numeric
-	valueFloat, _ := value.Float64()
+// RollbackMigration reverts the given number of the most recently
+// applied migrations.
+func RollbackMigration(
+	logger trading.Logger,
+	config *Config,
+	steps int,
+) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps count must be positive")
+	}
+
+	if len(config.MigrationDir) == 0 {
+		return fmt.Errorf("migration directory is not set")
+	}
+
+	logger.Infof("rolling back [%v] postgres migration(s)", steps)
 
-	if err := result.Set(valueFloat); err != nil {
-		return pgtype.Numeric{}, err
+	migration, err := newMigration(config)
+	if err != nil {
+		return err
 	}
+	defer migration.Close()
 
-	return result, nil
+	err = migration.Steps(-steps)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("postgres migration rolled back successfully")
+
+	return nil
 }
 
-func numericToFloat(value pgtype.Numeric) (*big.Float, error) {
-	var result float64
+// MigrationVersion returns the currently applied migration version and
+// tells whether the last migration failed leaving the schema dirty.
+func MigrationVersion(config *Config) (uint, bool, error) {
+	migration, err := newMigration(config)
+	if err != nil {
+		return 0, false, err
+	}
+	defer migration.Close()
 
-	if err := value.AssignTo(&result); err != nil {
-		return nil, err
+	version, dirty, err := migration.Version()
+	if err != nil {
+		if err == migrate.ErrNilVersion {
+			return 0, false, nil
+		}
+
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+func newMigration(config *Config) (*migrate.Migrate, error) {
+	migrationsDir := "file://" + config.MigrationDir
+
+	databaseAddress := fmt.Sprintf(
+		"postgres://%s:%s@%s/%s?sslmode=%s",
+		config.User,
+		config.Password,
+		config.Address,
+		config.Name,
+		config.SSLMode,
+	)
+
+	return migrate.New(migrationsDir, databaseAddress)
+}
+
+// decimalToNumeric converts the decimal to the PostgreSQL NUMERIC type.
+// The conversion is lossless as both types keep an arbitrary precision
+// integer coefficient and a base-10 exponent.
+func decimalToNumeric(value trading.Decimal) (pgtype.Numeric, error) {
+	coefficient, exponent := value.BigInt()
+
+	return pgtype.Numeric{
+		Int:    coefficient,
+		Exp:    exponent,
+		Status: pgtype.Present,
+	}, nil
+}
+
+// numericToDecimal converts the PostgreSQL NUMERIC type to the decimal.
+// The conversion is lossless. NULL and NaN values are rejected.
+func numericToDecimal(value pgtype.Numeric) (trading.Decimal, error) {
+	if value.Status != pgtype.Present {
+		return trading.Decimal{}, fmt.Errorf("numeric value is not present")
+	}
+
+	if value.NaN {
+		return trading.Decimal{}, fmt.Errorf("numeric value is NaN")
 	}
 
-	return big.NewFloat(result), nil
+	return trading.NewDecimalFromBigInt(value.Int, value.Exp), nil
 }
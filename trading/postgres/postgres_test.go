@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"bytes"
+	"github.com/jackc/pgtype"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/envelope"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecimalNumericRoundTrip(t *testing.T) {
+	values := []string{
+		"0",
+		"1",
+		"-1",
+		"0.01",
+		"35234.12",
+		"0.000000000000000001",
+		"123456789012345678901234567890.123456789012345678901234567890",
+		"-0.1234",
+	}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			expected := trading.MustParseDecimal(value)
+
+			numeric, err := decimalToNumeric(expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Pass through the binary encoding used on the wire to make sure
+			// the database sees exactly the same value.
+			encoded, err := numeric.EncodeBinary(nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded pgtype.Numeric
+			if err := decoded.DecodeBinary(nil, encoded); err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := numericToDecimal(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !expected.Equal(actual) {
+				t.Errorf(
+					"unexpected decimal\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					expected,
+					actual,
+				)
+			}
+		})
+	}
+}
+
+func TestNumericToDecimal_Invalid(t *testing.T) {
+	tests := map[string]pgtype.Numeric{
+		"null": {Status: pgtype.Null},
+		"nan":  {Status: pgtype.Present, NaN: true},
+	}
+
+	for testName, numeric := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if _, err := numericToDecimal(numeric); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestAccountRowEncryptsCredentials(t *testing.T) {
+	keyProvider, err := envelope.NewLocalKeyProvider("", &trading.MasterKey{
+		ID:       "test",
+		Material: bytes.Repeat([]byte{1}, envelope.KeySize),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secretCipher := envelope.NewCipher(keyProvider)
+	idService := &uuid.IDService{}
+
+	account := &trading.Account{
+		ID:                 idService.NewID(),
+		Email:              "test@example.com",
+		Exchange:           "BINANCE",
+		ExchangeApiKey:     "api-key",
+		ExchangeSecretKey:  "secret-key",
+		RiskFactor:         trading.MustParseDecimal("0.01"),
+		OpenPositionsLimit: 1,
+	}
+
+	row, err := new(accountRow).wrap(account, secretCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(row.ExchangeApiKey, account.ExchangeApiKey) ||
+		strings.Contains(row.ExchangeSecretKey, account.ExchangeSecretKey) {
+		t.Fatalf("account row contains plain text credentials")
+	}
+
+	unwrapped, err := row.unwrap(idService, secretCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if unwrapped.ExchangeApiKey != account.ExchangeApiKey ||
+		unwrapped.ExchangeSecretKey != account.ExchangeSecretKey {
+		t.Errorf(
+			"unexpected credentials\n"+
+				"expected: [%v %v]\n"+
+				"actual:   [%v %v]",
+			account.ExchangeApiKey,
+			account.ExchangeSecretKey,
+			unwrapped.ExchangeApiKey,
+			unwrapped.ExchangeSecretKey,
+		)
+	}
+}
+
+func TestUpsertCandlesCommand(t *testing.T) {
+	key := trading.CandleKey{
+		Exchange: "BINANCE",
+		Pair:     trading.Pair{Base: "BTC", Quote: "USDT"},
+		Interval: trading.CandleInterval1m,
+	}
+
+	openTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	newCandle := func(minute int, closePrice string) *trading.Candle {
+		candleOpenTime := openTime.Add(time.Duration(minute) * time.Minute)
+
+		return &trading.Candle{
+			OpenTime:   candleOpenTime,
+			CloseTime:  candleOpenTime.Add(time.Minute - time.Millisecond),
+			ClosePrice: trading.MustParseDecimal(closePrice),
+		}
+	}
+
+	query, args, err := upsertCandlesCommand(key, []*trading.Candle{
+		newCandle(0, "35000"),
+		newCandle(1, "35010"),
+		newCandle(0, "35005"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Key parameters are shared by two unique rows of 8 parameters.
+	expectedArgsCount := 4 + 2*8
+	if len(args) != expectedArgsCount {
+		t.Fatalf(
+			"unexpected args count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expectedArgsCount,
+			len(args),
+		)
+	}
+
+	for _, placeholder := range []string{
+		"($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+		"($1, $2, $3, $4, $13, $14, $15, $16, $17, $18, $19, $20)",
+	} {
+		if !strings.Contains(query, placeholder) {
+			t.Errorf("query should contain row [%v]", placeholder)
+		}
+	}
+
+	// The last candle of the same open time wins.
+	closePrice, err := numericToDecimal(args[7].(pgtype.Numeric))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !closePrice.Equal(trading.MustParseDecimal("35005")) {
+		t.Errorf(
+			"unexpected close price\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			"35005",
+			closePrice,
+		)
+	}
+}
+
+func TestCandleRowRoundTrip(t *testing.T) {
+	openTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	candle := &trading.Candle{
+		OpenTime:   openTime,
+		CloseTime:  openTime.Add(time.Minute - time.Millisecond),
+		OpenPrice:  trading.MustParseDecimal("35000.5"),
+		ClosePrice: trading.MustParseDecimal("35010.25"),
+		MaxPrice:   trading.MustParseDecimal("35020"),
+		MinPrice:   trading.MustParseDecimal("34990.75"),
+		Volume:     trading.MustParseDecimal("12.34567"),
+		TradeCount: 42,
+	}
+
+	row, err := new(candleRow).wrap(candle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unwrapped, err := row.unwrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !unwrapped.Equal(candle) ||
+		!unwrapped.OpenPrice.Equal(candle.OpenPrice) ||
+		!unwrapped.ClosePrice.Equal(candle.ClosePrice) ||
+		!unwrapped.MaxPrice.Equal(candle.MaxPrice) ||
+		!unwrapped.MinPrice.Equal(candle.MinPrice) ||
+		!unwrapped.Volume.Equal(candle.Volume) ||
+		unwrapped.TradeCount != candle.TradeCount {
+		t.Errorf(
+			"unexpected candle\n"+
+				"expected: [%+v]\n"+
+				"actual:   [%+v]",
+			*candle,
+			*unwrapped,
+		)
+	}
+}
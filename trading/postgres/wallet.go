@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"fmt"
+	"github.com/jackc/pgtype"
+	"github.com/lukasz-zimnoch/dexly/trading"
+)
+
+// PaperWalletRepository persists balances of simulated wallets used by
+// paper trading accounts.
+type PaperWalletRepository struct {
+	client *Client
+}
+
+func NewPaperWalletRepository(client *Client) *PaperWalletRepository {
+	return &PaperWalletRepository{client}
+}
+
+func (pwr *PaperWalletRepository) Balances(
+	accountID trading.ID,
+) (trading.Balances, error) {
+	var balanceRows []paperBalanceRow
+
+	query := `SELECT * FROM paper_balance WHERE account_id = $1`
+
+	err := pwr.client.instance().Select(
+		&balanceRows,
+		query,
+		accountID.String(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not execute query for account [%v]: [%v]",
+			accountID,
+			err,
+		)
+	}
+
+	balances := make(trading.Balances)
+
+	for _, balanceRow := range balanceRows {
+		balance, err := numericToDecimal(balanceRow.Balance)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not convert balance of asset [%v] from pg row: [%v]",
+				balanceRow.Asset,
+				err,
+			)
+		}
+
+		balances[trading.Asset(balanceRow.Asset)] = balance
+	}
+
+	return balances, nil
+}
+
+func (pwr *PaperWalletRepository) SaveBalances(
+	accountID trading.ID,
+	balances trading.Balances,
+) error {
+	query := `INSERT INTO
+    	paper_balance (account_id, asset, balance)
+    	VALUES (:account_id, :asset, :balance)
+    	ON CONFLICT (account_id, asset) DO UPDATE SET balance = :balance`
+
+	transaction, err := pwr.client.instance().Beginx()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: [%v]", err)
+	}
+
+	for asset, balance := range balances {
+		balanceNumeric, err := decimalToNumeric(balance)
+		if err != nil {
+			_ = transaction.Rollback()
+			return fmt.Errorf(
+				"could not convert balance of asset [%v] to pg row: [%v]",
+				asset,
+				err,
+			)
+		}
+
+		_, err = transaction.NamedExec(query, &paperBalanceRow{
+			AccountID: accountID.String(),
+			Asset:     string(asset),
+			Balance:   balanceNumeric,
+		})
+		if err != nil {
+			_ = transaction.Rollback()
+			return fmt.Errorf(
+				"could not execute command for account [%v]: [%v]",
+				accountID,
+				err,
+			)
+		}
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: [%v]", err)
+	}
+
+	return nil
+}
+
+type paperBalanceRow struct {
+	AccountID string `db:"account_id"`
+	Asset     string
+	Balance   pgtype.Numeric
+}
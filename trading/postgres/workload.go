@@ -2,25 +2,36 @@ package postgres
 
 import (
 	"fmt"
+	"github.com/jackc/pgtype"
 	"github.com/lukasz-zimnoch/dexly/trading"
 )
 
 type WorkloadRepository struct {
-	client    *Client
-	idService trading.IDService
+	client       *Client
+	idService    trading.IDService
+	secretCipher trading.SecretCipher
+	logger       trading.Logger
 }
 
 func NewWorkloadRepository(
 	client *Client,
 	idService trading.IDService,
+	secretCipher trading.SecretCipher,
+	logger trading.Logger,
 ) *WorkloadRepository {
-	return &WorkloadRepository{client, idService}
+	return &WorkloadRepository{client, idService, secretCipher, logger}
 }
 
 func (wr *WorkloadRepository) CreateWorkload(workload *trading.Workload) error {
 	query := `INSERT INTO 
-    	workload (id, account_id, base_asset, quote_asset) 
-    	VALUES (:id, :account_id, :base_asset, :quote_asset)`
+    	workload (id, account_id, base_asset, quote_asset, mode, status, 
+    	          candle_interval, candle_window_size, 
+    	          stop_loss_atr_multiplier, reward_risk_ratio, 
+    	          strategy_name, strategy_version) 
+    	VALUES (:id, :account_id, :base_asset, :quote_asset, :mode, :status, 
+    	        :candle_interval, :candle_window_size, 
+    	        :stop_loss_atr_multiplier, :reward_risk_ratio, 
+    	        :strategy_name, :strategy_version)`
 
 	workloadRow, err := new(workloadRow).wrap(workload)
 	if err != nil {
@@ -43,7 +54,60 @@ func (wr *WorkloadRepository) CreateWorkload(workload *trading.Workload) error {
 	return nil
 }
 
+func (wr *WorkloadRepository) UpdateWorkload(workload *trading.Workload) error {
+	query := `UPDATE workload SET status = :status WHERE id = :id`
+
+	workloadRow, err := new(workloadRow).wrap(workload)
+	if err != nil {
+		return fmt.Errorf(
+			"could not convert workload [%v] to pg row: [%v]",
+			workload.ID,
+			err,
+		)
+	}
+
+	_, err = wr.client.instance().NamedExec(query, workloadRow)
+	if err != nil {
+		return fmt.Errorf(
+			"could not execute command for workload [%v]: [%v]",
+			workload.ID,
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (wr *WorkloadRepository) Workload(
+	workloadID trading.ID,
+) (*trading.Workload, error) {
+	workloads, err := wr.selectWorkloads(
+		false,
+		`WHERE w.id = $1`,
+		workloadID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workloads) == 0 {
+		return nil, trading.ErrNotFound
+	}
+
+	return workloads[0], nil
+}
+
 func (wr *WorkloadRepository) Workloads() ([]*trading.Workload, error) {
+	// Workloads of other accounts can still run if credentials of one
+	// account can't be decrypted.
+	return wr.selectWorkloads(true, "")
+}
+
+func (wr *WorkloadRepository) selectWorkloads(
+	skipInvalidAccounts bool,
+	condition string,
+	args ...interface{},
+) ([]*trading.Workload, error) {
 	var selectResult []struct {
 		workloadRow `db:"workload"`
 		accountRow  `db:"account"`
@@ -55,6 +119,14 @@ func (wr *WorkloadRepository) Workloads() ([]*trading.Workload, error) {
        		w.account_id "workload.account_id",
        		w.base_asset "workload.base_asset",
        		w.quote_asset "workload.quote_asset",
+       		w.mode "workload.mode",
+       		w.status "workload.status",
+       		w.candle_interval "workload.candle_interval",
+       		w.candle_window_size "workload.candle_window_size",
+       		w.stop_loss_atr_multiplier "workload.stop_loss_atr_multiplier",
+       		w.reward_risk_ratio "workload.reward_risk_ratio",
+       		w.strategy_name "workload.strategy_name",
+       		w.strategy_version "workload.strategy_version",
        		a.id "account.id",
        		a.email "account.email",
        		a.exchange "account.exchange",
@@ -63,11 +135,12 @@ func (wr *WorkloadRepository) Workloads() ([]*trading.Workload, error) {
        		a.risk_factor "account.risk_factor",
        		a.open_position_limit "account.open_position_limit"
 		FROM workload w
-		JOIN account a ON a.id = w.account_id`
+		JOIN account a ON a.id = w.account_id ` + condition
 
 	err := wr.client.instance().Select(
 		&selectResult,
 		query,
+		args...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("could not execute query: [%v]", err)
@@ -76,7 +149,20 @@ func (wr *WorkloadRepository) Workloads() ([]*trading.Workload, error) {
 	workloads := make([]*trading.Workload, 0)
 
 	for _, result := range selectResult {
-		account, err := result.accountRow.unwrap(wr.idService)
+		account, err := result.accountRow.unwrap(
+			wr.idService,
+			wr.secretCipher,
+		)
+		if err != nil && skipInvalidAccounts {
+			wr.logger.Errorf(
+				"skipping workload [%v] as its account [%v] "+
+					"could not be converted from pg row: [%v]",
+				result.workloadRow.ID,
+				result.accountRow.ID,
+				err,
+			)
+			continue
+		}
 		if err != nil {
 			return nil, fmt.Errorf(
 				"could not convert account [%v] from pg row: [%v]",
@@ -102,10 +188,18 @@ func (wr *WorkloadRepository) Workloads() ([]*trading.Workload, error) {
 }
 
 type workloadRow struct {
-	ID         string
-	AccountID  string `db:"account_id"`
-	BaseAsset  string `db:"base_asset"`
-	QuoteAsset string `db:"quote_asset"`
+	ID                    string
+	AccountID             string `db:"account_id"`
+	BaseAsset             string `db:"base_asset"`
+	QuoteAsset            string `db:"quote_asset"`
+	Mode                  string
+	Status                string
+	CandleInterval        string         `db:"candle_interval"`
+	CandleWindowSize      int            `db:"candle_window_size"`
+	StopLossATRMultiplier pgtype.Numeric `db:"stop_loss_atr_multiplier"`
+	RewardRiskRatio       pgtype.Numeric `db:"reward_risk_ratio"`
+	StrategyName          string         `db:"strategy_name"`
+	StrategyVersion       int            `db:"strategy_version"`
 }
 
 func (wr *workloadRow) wrap(workload *trading.Workload) (*workloadRow, error) {
@@ -113,6 +207,27 @@ func (wr *workloadRow) wrap(workload *trading.Workload) (*workloadRow, error) {
 	wr.AccountID = workload.Account.ID.String()
 	wr.BaseAsset = string(workload.Pair.Base)
 	wr.QuoteAsset = string(workload.Pair.Quote)
+	wr.Mode = workload.Mode.String()
+	wr.Status = workload.Status.String()
+	wr.CandleInterval = workload.CandleInterval.String()
+	wr.CandleWindowSize = workload.CandleWindowSize
+
+	stopLossATRMultiplier, err := decimalToNumeric(
+		workload.StopLossATRMultiplier,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rewardRiskRatio, err := decimalToNumeric(workload.RewardRiskRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	wr.StopLossATRMultiplier = stopLossATRMultiplier
+	wr.RewardRiskRatio = rewardRiskRatio
+	wr.StrategyName = workload.Strategy.Name
+	wr.StrategyVersion = workload.Strategy.Version
 
 	return wr, nil
 }
@@ -125,14 +240,49 @@ func (wr *workloadRow) unwrap(
 		return nil, err
 	}
 
+	mode, err := trading.ParseWorkloadMode(wr.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := trading.ParseWorkloadStatus(wr.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	candleInterval, err := trading.ParseCandleInterval(wr.CandleInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	stopLossATRMultiplier, err := numericToDecimal(wr.StopLossATRMultiplier)
+	if err != nil {
+		return nil, err
+	}
+
+	rewardRiskRatio, err := numericToDecimal(wr.RewardRiskRatio)
+	if err != nil {
+		return nil, err
+	}
+
 	pair := trading.Pair{
 		Base:  trading.Asset(wr.BaseAsset),
 		Quote: trading.Asset(wr.QuoteAsset),
 	}
 
 	return &trading.Workload{
-		ID:      ID,
-		Account: nil, // Account should be set outside.
-		Pair:    pair,
+		ID:                    ID,
+		Account:               nil, // Account should be set outside.
+		Pair:                  pair,
+		Mode:                  mode,
+		Status:                status,
+		CandleInterval:        candleInterval,
+		CandleWindowSize:      wr.CandleWindowSize,
+		StopLossATRMultiplier: stopLossATRMultiplier,
+		RewardRiskRatio:       rewardRiskRatio,
+		Strategy: trading.StrategyRef{
+			Name:    wr.StrategyName,
+			Version: wr.StrategyVersion,
+		},
 	}, nil
 }
@@ -0,0 +1,236 @@
+package prometheus
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const namespace = "dexly"
+
+// Metrics exposes trading metrics in the Prometheus format. Per-workload
+// metrics are labeled with the workload ID and the traded pair.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	runningWorkloads      prometheus.Gauge
+	candleTicks           *prometheus.CounterVec
+	tickerIdleTimeouts    *prometheus.CounterVec
+	signalsGenerated      *prometheus.CounterVec
+	signalsDropped        *prometheus.CounterVec
+	ordersSent            *prometheus.CounterVec
+	ordersFilled          *prometheus.CounterVec
+	ordersRejected        *prometheus.CounterVec
+	exchangeRequests      *prometheus.HistogramVec
+	exchangeRequestErrors *prometheus.CounterVec
+	openPositions         *prometheus.GaugeVec
+	realizedProfitAndLoss *prometheus.GaugeVec
+}
+
+func NewMetrics() *Metrics {
+	workloadLabels := []string{"workload", "pair"}
+	orderLabels := []string{"workload", "pair", "side"}
+	exchangeLabels := []string{"exchange", "operation"}
+
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		runningWorkloads: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "running_workloads",
+			Help:      "Number of currently running workloads.",
+		}),
+		candleTicks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "candle_ticks_total",
+			Help:      "Number of candle ticks received from the exchange.",
+		}, workloadLabels),
+		tickerIdleTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ticker_idle_timeouts_total",
+			Help:      "Number of candle ticker idle timeouts.",
+		}, workloadLabels),
+		signalsGenerated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "signals_generated_total",
+			Help:      "Number of signals emitted by the signal generator.",
+		}, []string{"workload", "pair", "type"}),
+		signalsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "signals_dropped_total",
+			Help:      "Number of signals which didn't result in a position.",
+		}, []string{"workload", "pair", "reason"}),
+		ordersSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_sent_total",
+			Help:      "Number of orders sent to the exchange.",
+		}, orderLabels),
+		ordersFilled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_filled_total",
+			Help:      "Number of orders filled by the exchange.",
+		}, orderLabels),
+		ordersRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_rejected_total",
+			Help:      "Number of orders failed or cancelled by the exchange.",
+		}, orderLabels),
+		exchangeRequests: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "exchange_request_duration_seconds",
+				Help:      "Latency of requests to the exchange.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			exchangeLabels,
+		),
+		exchangeRequestErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "exchange_request_errors_total",
+				Help:      "Number of failed requests to the exchange.",
+			},
+			exchangeLabels,
+		),
+		openPositions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "open_positions",
+			Help:      "Number of open positions.",
+		}, workloadLabels),
+		realizedProfitAndLoss: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "realized_pnl",
+			Help:      "Realized gross profit and loss in the quote asset.",
+		}, []string{"workload", "pair", "asset"}),
+	}
+
+	metrics.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		metrics.runningWorkloads,
+		metrics.candleTicks,
+		metrics.tickerIdleTimeouts,
+		metrics.signalsGenerated,
+		metrics.signalsDropped,
+		metrics.ordersSent,
+		metrics.ordersFilled,
+		metrics.ordersRejected,
+		metrics.exchangeRequests,
+		metrics.exchangeRequestErrors,
+		metrics.openPositions,
+		metrics.realizedProfitAndLoss,
+	)
+
+	return metrics
+}
+
+// Handler serves the collected metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) RunningWorkloads(count int) {
+	m.runningWorkloads.Set(float64(count))
+}
+
+func (m *Metrics) WorkloadStopped(workload *trading.Workload) {
+	m.openPositions.DeleteLabelValues(workloadLabelValues(workload)...)
+}
+
+func (m *Metrics) CandleTickReceived(workload *trading.Workload) {
+	m.candleTicks.WithLabelValues(workloadLabelValues(workload)...).Inc()
+}
+
+func (m *Metrics) TickerIdleTimeout(workload *trading.Workload) {
+	m.tickerIdleTimeouts.WithLabelValues(
+		workloadLabelValues(workload)...,
+	).Inc()
+}
+
+func (m *Metrics) SignalGenerated(
+	workload *trading.Workload,
+	signal *trading.Signal,
+) {
+	m.signalsGenerated.WithLabelValues(
+		append(workloadLabelValues(workload), signal.Type.String())...,
+	).Inc()
+}
+
+func (m *Metrics) SignalDropped(workload *trading.Workload, reason string) {
+	m.signalsDropped.WithLabelValues(
+		append(workloadLabelValues(workload), reason)...,
+	).Inc()
+}
+
+func (m *Metrics) OrderSent(workload *trading.Workload, order *trading.Order) {
+	m.ordersSent.WithLabelValues(orderLabelValues(workload, order)...).Inc()
+}
+
+func (m *Metrics) OrderFilled(
+	workload *trading.Workload,
+	order *trading.Order,
+) {
+	m.ordersFilled.WithLabelValues(orderLabelValues(workload, order)...).Inc()
+}
+
+func (m *Metrics) OrderRejected(
+	workload *trading.Workload,
+	order *trading.Order,
+) {
+	m.ordersRejected.WithLabelValues(
+		orderLabelValues(workload, order)...,
+	).Inc()
+}
+
+func (m *Metrics) ExchangeRequest(
+	workload *trading.Workload,
+	operation string,
+	duration time.Duration,
+	err error,
+) {
+	exchange := ""
+	if workload.Account != nil {
+		exchange = workload.Account.Exchange
+	}
+
+	m.exchangeRequests.WithLabelValues(exchange, operation).Observe(
+		duration.Seconds(),
+	)
+
+	if err != nil {
+		m.exchangeRequestErrors.WithLabelValues(exchange, operation).Inc()
+	}
+}
+
+func (m *Metrics) OpenPositions(workload *trading.Workload, count int) {
+	m.openPositions.WithLabelValues(
+		workloadLabelValues(workload)...,
+	).Set(float64(count))
+}
+
+func (m *Metrics) RealizedProfit(
+	workload *trading.Workload,
+	profit trading.Decimal,
+) {
+	m.realizedProfitAndLoss.WithLabelValues(
+		append(
+			workloadLabelValues(workload),
+			string(workload.Pair.Quote),
+		)...,
+	).Add(profit.Float64())
+}
+
+func workloadLabelValues(workload *trading.Workload) []string {
+	return []string{
+		workload.ID.String(),
+		string(workload.Pair.Symbol()),
+	}
+}
+
+func orderLabelValues(
+	workload *trading.Workload,
+	order *trading.Order,
+) []string {
+	return append(workloadLabelValues(workload), order.Side.String())
+}
@@ -4,16 +4,26 @@ import (
 	"cloud.google.com/go/pubsub"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
 )
 
 type EventService struct {
 	client *Client
 	logger trading.Logger
+
+	statusMutex sync.RWMutex
+	// lastPublishErr holds the result of the most recent publish.
+	lastPublishErr error
+
+	// pendingPublishes tracks events whose publish results are not
+	// known yet.
+	pendingPublishes sync.WaitGroup
 }
 
 func NewEventService(client *Client, logger trading.Logger) *EventService {
-	return &EventService{client, logger}
+	return &EventService{client: client, logger: logger}
 }
 
 func (es *EventService) Publish(event *trading.Event) {
@@ -53,8 +63,17 @@ func (es *EventService) publishOnTopic(
 		Data: messageData,
 	})
 
+	es.pendingPublishes.Add(1)
+
 	go func() {
+		defer es.pendingPublishes.Done()
+
 		id, err := result.Get(ctx)
+
+		es.statusMutex.Lock()
+		es.lastPublishErr = err
+		es.statusMutex.Unlock()
+
 		if err != nil {
 			topicLogger.Errorf(
 				"could not publish trading event: [%v]",
@@ -67,6 +86,44 @@ func (es *EventService) publishOnTopic(
 	}()
 }
 
+// Flush sends all buffered events and waits until they are published.
+// Events still pending once the context is done may be lost.
+func (es *EventService) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+
+	go func() {
+		es.client.notificationsTopic.Flush()
+		es.pendingPublishes.Wait()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf(
+			"pending events not published: [%w]",
+			ctx.Err(),
+		)
+	}
+}
+
+// CheckHealth reports the publisher as unhealthy if the most recent
+// event could not be published.
+func (es *EventService) CheckHealth(_ context.Context) error {
+	es.statusMutex.RLock()
+	defer es.statusMutex.RUnlock()
+
+	if es.lastPublishErr != nil {
+		return fmt.Errorf(
+			"could not publish last event: [%v]",
+			es.lastPublishErr,
+		)
+	}
+
+	return nil
+}
+
 type notificationEvent struct {
 	Email   string
 	Payload string
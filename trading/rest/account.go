@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/http"
+	"strings"
+)
+
+func (s *Server) listAccounts(
+	responseWriter http.ResponseWriter,
+	_ *http.Request,
+	_ []string,
+) {
+	accounts, err := s.accountRepository.Accounts()
+	if err != nil {
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not get accounts: [%v]", err),
+		)
+		return
+	}
+
+	response := &accountListV1{Accounts: make([]*accountV1, 0)}
+	for _, account := range accounts {
+		response.Accounts = append(response.Accounts, newAccountV1(account))
+	}
+
+	s.writeJSON(responseWriter, http.StatusOK, response)
+}
+
+func (s *Server) createAccount(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	_ []string,
+) {
+	var createRequest createAccountRequestV1
+	if err := s.readJSON(request, &createRequest); err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := validateCreateAccountRequest(&createRequest); err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account := &trading.Account{
+		ID:                 s.idService.NewID(),
+		Email:              createRequest.Email,
+		Exchange:           createRequest.Exchange,
+		ExchangeApiKey:     createRequest.ExchangeApiKey,
+		ExchangeSecretKey:  createRequest.ExchangeSecretKey,
+		RiskFactor:         createRequest.RiskFactor,
+		OpenPositionsLimit: createRequest.OpenPositionsLimit,
+	}
+
+	if err := s.accountRepository.CreateAccount(account); err != nil {
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not create account: [%v]", err),
+		)
+		return
+	}
+
+	s.writeJSON(responseWriter, http.StatusCreated, newAccountV1(account))
+}
+
+func validateCreateAccountRequest(request *createAccountRequestV1) error {
+	if !strings.Contains(request.Email, "@") {
+		return fmt.Errorf("invalid email [%v]", request.Email)
+	}
+
+	if len(request.Exchange) == 0 {
+		return fmt.Errorf("exchange must be set")
+	}
+
+	one := trading.NewDecimal(1, 0)
+	if request.RiskFactor.Sign() <= 0 || request.RiskFactor.Cmp(one) > 0 {
+		return fmt.Errorf(
+			"risk factor [%v] must be within (0, 1]",
+			request.RiskFactor,
+		)
+	}
+
+	if request.OpenPositionsLimit <= 0 {
+		return fmt.Errorf(
+			"open positions limit [%v] must be positive",
+			request.OpenPositionsLimit,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/http"
+	"sort"
+)
+
+// listPositions lists positions of the workload along with their orders.
+// Positions can be filtered using the `status` query parameter, all of
+// them are returned otherwise.
+func (s *Server) listPositions(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	params []string,
+) {
+	workload, ok := s.getWorkload(responseWriter, params[0])
+	if !ok {
+		return
+	}
+
+	statuses := []trading.PositionStatus{
+		trading.StatusOpen,
+		trading.StatusClosed,
+	}
+
+	if statusParam := request.URL.Query().Get("status"); len(statusParam) > 0 {
+		status, err := trading.ParsePositionStatus(statusParam)
+		if err != nil {
+			s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		statuses = []trading.PositionStatus{status}
+	}
+
+	positions := make([]*trading.Position, 0)
+
+	for _, status := range statuses {
+		statusPositions, err := s.positionRepository.Positions(
+			trading.PositionFilter{
+				WorkloadID: workload.ID,
+				Status:     status,
+			},
+		)
+		if err != nil {
+			s.writeInternalError(
+				responseWriter,
+				fmt.Errorf("could not get positions: [%v]", err),
+			)
+			return
+		}
+
+		positions = append(positions, statusPositions...)
+	}
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].Time.Before(positions[j].Time)
+	})
+
+	response := &positionListV1{Positions: make([]*positionV1, 0)}
+	for _, position := range positions {
+		response.Positions = append(
+			response.Positions,
+			newPositionV1(position),
+		)
+	}
+
+	s.writeJSON(responseWriter, http.StatusOK, response)
+}
+
+// closePosition requests the position to be exited at the current market
+// price. The exit is performed asynchronously by the workload runner so
+// the request is only accepted here.
+func (s *Server) closePosition(
+	responseWriter http.ResponseWriter,
+	_ *http.Request,
+	params []string,
+) {
+	positionID, err := s.parseID(params[0])
+	if err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	position, err := s.positionRepository.Position(positionID)
+	if err != nil {
+		if err == trading.ErrNotFound {
+			s.writeError(
+				responseWriter,
+				http.StatusNotFound,
+				fmt.Sprintf("position [%v] does not exist", positionID),
+			)
+			return
+		}
+
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not get position: [%v]", err),
+		)
+		return
+	}
+
+	positionClosedErr := fmt.Sprintf(
+		"position [%v] is already closed",
+		positionID,
+	)
+
+	if position.Status != trading.StatusOpen {
+		s.writeError(responseWriter, http.StatusConflict, positionClosedErr)
+		return
+	}
+
+	err = s.positionRepository.RequestPositionExit(positionID)
+	if err != nil {
+		if err == trading.ErrNotFound {
+			// The position has been closed in the meantime.
+			s.writeError(
+				responseWriter,
+				http.StatusConflict,
+				positionClosedErr,
+			)
+			return
+		}
+
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not request position exit: [%v]", err),
+		)
+		return
+	}
+
+	position.ExitRequested = true
+
+	s.writeJSON(responseWriter, http.StatusAccepted, newPositionV1(position))
+}
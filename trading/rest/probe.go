@@ -0,0 +1,214 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	checkTimeout = 5 * time.Second
+
+	statusOK        = "ok"
+	statusUnhealthy = "unhealthy"
+
+	workloadsCheck = "workloads"
+)
+
+// ProbeServer exposes Kubernetes probes and the status page. Unlike the
+// administration API, it doesn't require authentication so it must not
+// expose any sensitive data.
+//
+//   - /healthz reports whether the workloads are making progress and
+//     fails if the process should be restarted,
+//   - /readyz additionally checks the dependencies, like the database,
+//     without which the service can't work properly,
+//   - /status lists running workloads along with results of all checks.
+type ProbeServer struct {
+	workloadMonitor trading.WorkloadMonitor
+	dependencies    map[string]trading.HealthChecker
+
+	mux *http.ServeMux
+
+	logger trading.Logger
+}
+
+func NewProbeServer(
+	workloadMonitor trading.WorkloadMonitor,
+	dependencies map[string]trading.HealthChecker,
+	logger trading.Logger,
+) *ProbeServer {
+	server := &ProbeServer{
+		workloadMonitor: workloadMonitor,
+		dependencies:    dependencies,
+		mux:             http.NewServeMux(),
+		logger:          logger,
+	}
+
+	server.mux.HandleFunc("/healthz", server.healthz)
+	server.mux.HandleFunc("/readyz", server.readyz)
+	server.mux.HandleFunc("/status", server.status)
+
+	return server
+}
+
+func (ps *ProbeServer) ServeHTTP(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) {
+	if request.Method != http.MethodGet {
+		ps.writeJSON(
+			responseWriter,
+			http.StatusMethodNotAllowed,
+			&errorV1{Error: "method not allowed"},
+		)
+		return
+	}
+
+	ps.mux.ServeHTTP(responseWriter, request)
+}
+
+func (ps *ProbeServer) healthz(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) {
+	checks := ps.runChecks(request.Context(), false)
+	ps.writeJSON(responseWriter, checks.httpStatus(), checks)
+}
+
+func (ps *ProbeServer) readyz(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) {
+	checks := ps.runChecks(request.Context(), true)
+	ps.writeJSON(responseWriter, checks.httpStatus(), checks)
+}
+
+func (ps *ProbeServer) status(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) {
+	workloads := make([]*workloadStateV1, 0)
+	for _, state := range ps.workloadMonitor.RunnersState() {
+		workloads = append(workloads, newWorkloadStateV1(state))
+	}
+
+	ps.writeJSON(responseWriter, http.StatusOK, &statusV1{
+		Checks:    ps.runChecks(request.Context(), true),
+		Workloads: workloads,
+	})
+}
+
+// runChecks runs the workloads check and, optionally, checks of all
+// dependencies.
+func (ps *ProbeServer) runChecks(
+	ctx context.Context,
+	includeDependencies bool,
+) *checksV1 {
+	ctx, cancelCtx := context.WithTimeout(ctx, checkTimeout)
+	defer cancelCtx()
+
+	checkers := map[string]trading.HealthChecker{
+		workloadsCheck: ps.workloadMonitor,
+	}
+
+	if includeDependencies {
+		for name, checker := range ps.dependencies {
+			checkers[name] = checker
+		}
+	}
+
+	names := make([]string, 0)
+	for name := range checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checks := &checksV1{
+		Status: statusOK,
+		Checks: make([]*checkV1, 0),
+	}
+
+	for _, name := range names {
+		check := &checkV1{Name: name, Status: statusOK}
+
+		if err := checkers[name].CheckHealth(ctx); err != nil {
+			ps.logger.Warningf("health check [%v] failed: [%v]", name, err)
+
+			check.Status = statusUnhealthy
+			check.Error = err.Error()
+			checks.Status = statusUnhealthy
+		}
+
+		checks.Checks = append(checks.Checks, check)
+	}
+
+	return checks
+}
+
+func (ps *ProbeServer) writeJSON(
+	responseWriter http.ResponseWriter,
+	status int,
+	value interface{},
+) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.Header().Set("Cache-Control", "no-store")
+	responseWriter.WriteHeader(status)
+
+	if err := json.NewEncoder(responseWriter).Encode(value); err != nil {
+		ps.logger.Errorf("could not write response: [%v]", err)
+	}
+}
+
+type checkV1 struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type checksV1 struct {
+	Status string     `json:"status"`
+	Checks []*checkV1 `json:"checks"`
+}
+
+func (c *checksV1) httpStatus() int {
+	if c.Status != statusOK {
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusOK
+}
+
+type workloadStateV1 struct {
+	ID                 string    `json:"id"`
+	BaseAsset          string    `json:"baseAsset"`
+	QuoteAsset         string    `json:"quoteAsset"`
+	Mode               string    `json:"mode"`
+	Status             string    `json:"status"`
+	StartTime          time.Time `json:"startTime"`
+	LastCandleTickTime time.Time `json:"lastCandleTickTime"`
+	LastActionTime     time.Time `json:"lastActionTime"`
+	Stalled            bool      `json:"stalled"`
+}
+
+func newWorkloadStateV1(state *trading.WorkloadRunnerState) *workloadStateV1 {
+	return &workloadStateV1{
+		ID:                 state.Workload.ID.String(),
+		BaseAsset:          string(state.Workload.Pair.Base),
+		QuoteAsset:         string(state.Workload.Pair.Quote),
+		Mode:               state.Workload.Mode.String(),
+		Status:             state.Status.String(),
+		StartTime:          state.StartTime,
+		LastCandleTickTime: state.LastCandleTickTime,
+		LastActionTime:     state.LastActionTime,
+		Stalled:            state.Stalled,
+	}
+}
+
+type statusV1 struct {
+	Checks    *checksV1          `json:"checks"`
+	Workloads []*workloadStateV1 `json:"workloads"`
+}
@@ -0,0 +1,167 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/logrus"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbeServer_Checks(t *testing.T) {
+	tests := map[string]struct {
+		workloadsErr      error
+		databaseErr       error
+		expectedHealthz   int
+		expectedReadyz    int
+		expectedUnhealthy string
+	}{
+		"healthy": {
+			expectedHealthz: http.StatusOK,
+			expectedReadyz:  http.StatusOK,
+		},
+		"database unavailable": {
+			databaseErr:       fmt.Errorf("connection refused"),
+			expectedHealthz:   http.StatusOK,
+			expectedReadyz:    http.StatusServiceUnavailable,
+			expectedUnhealthy: "postgres",
+		},
+		"workloads stalled": {
+			workloadsErr:      fmt.Errorf("stalled workloads"),
+			expectedHealthz:   http.StatusServiceUnavailable,
+			expectedReadyz:    http.StatusServiceUnavailable,
+			expectedUnhealthy: "workloads",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			server := NewProbeServer(
+				&testWorkloadMonitor{err: test.workloadsErr},
+				map[string]trading.HealthChecker{
+					"postgres": &testHealthChecker{err: test.databaseErr},
+				},
+				logrus.ConfigureStandardLogger("text", "panic"),
+			)
+
+			recorder := doProbe(server, http.MethodGet, "/healthz")
+			assertStatus(t, test.expectedHealthz, recorder)
+
+			recorder = doProbe(server, http.MethodGet, "/readyz")
+			assertStatus(t, test.expectedReadyz, recorder)
+
+			var checks checksV1
+			decodeBody(t, recorder, &checks)
+
+			if len(checks.Checks) != 2 {
+				t.Fatalf("unexpected checks: [%v]", checks.Checks)
+			}
+
+			for _, check := range checks.Checks {
+				expectedStatus := statusOK
+				if check.Name == test.expectedUnhealthy {
+					expectedStatus = statusUnhealthy
+				}
+
+				if check.Status != expectedStatus {
+					t.Errorf(
+						"unexpected status of check [%v]\n"+
+							"expected: [%v]\n"+
+							"actual:   [%v]",
+						check.Name,
+						expectedStatus,
+						check.Status,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestProbeServer_Status(t *testing.T) {
+	idService := &uuid.IDService{}
+	startTime := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	workload := &trading.Workload{
+		ID:      idService.NewID(),
+		Account: &trading.Account{},
+		Pair:    trading.Pair{Base: "BTC", Quote: "USDT"},
+		Mode:    trading.ModePaper,
+		Status:  trading.WorkloadActive,
+	}
+
+	server := NewProbeServer(
+		&testWorkloadMonitor{
+			states: []*trading.WorkloadRunnerState{{
+				Workload:           workload,
+				Status:             trading.WorkloadPaused,
+				StartTime:          startTime,
+				LastCandleTickTime: startTime.Add(time.Minute),
+				LastActionTime:     startTime.Add(2 * time.Minute),
+			}},
+		},
+		map[string]trading.HealthChecker{},
+		logrus.ConfigureStandardLogger("text", "panic"),
+	)
+
+	recorder := doProbe(server, http.MethodGet, "/status")
+	assertStatus(t, http.StatusOK, recorder)
+
+	var status statusV1
+	decodeBody(t, recorder, &status)
+
+	if len(status.Workloads) != 1 {
+		t.Fatalf("unexpected workloads: [%v]", status.Workloads)
+	}
+
+	state := status.Workloads[0]
+
+	if state.ID != workload.ID.String() ||
+		state.Status != "PAUSED" ||
+		state.Mode != "PAPER" ||
+		!state.LastActionTime.Equal(startTime.Add(2*time.Minute)) {
+		t.Errorf("unexpected workload state: [%+v]", state)
+	}
+
+	if status.Checks.Status != statusOK {
+		t.Errorf("unexpected checks status: [%v]", status.Checks.Status)
+	}
+
+	recorder = doProbe(server, http.MethodPost, "/status")
+	assertStatus(t, http.StatusMethodNotAllowed, recorder)
+}
+
+func doProbe(
+	server *ProbeServer,
+	method string,
+	path string,
+) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
+
+type testHealthChecker struct {
+	err error
+}
+
+func (thc *testHealthChecker) CheckHealth(_ context.Context) error {
+	return thc.err
+}
+
+type testWorkloadMonitor struct {
+	err    error
+	states []*trading.WorkloadRunnerState
+}
+
+func (twm *testWorkloadMonitor) CheckHealth(_ context.Context) error {
+	return twm.err
+}
+
+func (twm *testWorkloadMonitor) RunnersState() []*trading.WorkloadRunnerState {
+	return twm.states
+}
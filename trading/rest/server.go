@@ -0,0 +1,244 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/http"
+	"strings"
+)
+
+const (
+	// apiVersion is the first path segment of all endpoints. Schemas of
+	// requests and responses are bound to the version, see v1.go.
+	apiVersion = "v1"
+
+	maxRequestBodySize = 1 << 20
+)
+
+// Server exposes the administration API allowing to manage accounts,
+// workloads and positions. All requests must be authenticated using
+// the `Authorization: Bearer <token>` header.
+type Server struct {
+	accountRepository  trading.AccountRepository
+	workloadRepository trading.WorkloadRepository
+	positionRepository trading.PositionRepository
+	// strategyRegistry validates strategies referenced by new workloads.
+	strategyRegistry trading.StrategyRegistry
+	idService        trading.IDService
+
+	authToken string
+	routes    []*route
+
+	logger trading.Logger
+}
+
+func NewServer(
+	accountRepository trading.AccountRepository,
+	workloadRepository trading.WorkloadRepository,
+	positionRepository trading.PositionRepository,
+	strategyRegistry trading.StrategyRegistry,
+	idService trading.IDService,
+	authToken string,
+	logger trading.Logger,
+) *Server {
+	server := &Server{
+		accountRepository:  accountRepository,
+		workloadRepository: workloadRepository,
+		positionRepository: positionRepository,
+		strategyRegistry:   strategyRegistry,
+		idService:          idService,
+		authToken:          authToken,
+		logger:             logger,
+	}
+
+	server.routes = []*route{
+		newRoute(http.MethodGet, "accounts", server.listAccounts),
+		newRoute(http.MethodPost, "accounts", server.createAccount),
+		newRoute(http.MethodGet, "workloads", server.listWorkloads),
+		newRoute(http.MethodPost, "workloads", server.createWorkload),
+		newRoute(http.MethodPatch, "workloads/*", server.updateWorkload),
+		newRoute(
+			http.MethodGet,
+			"workloads/*/positions",
+			server.listPositions,
+		),
+		newRoute(
+			http.MethodPost,
+			"positions/*/close",
+			server.closePosition,
+		),
+	}
+
+	return server
+}
+
+func (s *Server) ServeHTTP(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+) {
+	if !s.authenticate(request) {
+		responseWriter.Header().Set("WWW-Authenticate", "Bearer")
+		s.writeError(responseWriter, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	segments := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] != apiVersion {
+		s.writeError(responseWriter, http.StatusNotFound, "not found")
+		return
+	}
+
+	pathMatched := false
+
+	for _, route := range s.routes {
+		params, ok := route.match(segments[1:])
+		if !ok {
+			continue
+		}
+
+		pathMatched = true
+
+		if route.method != request.Method {
+			continue
+		}
+
+		request.Body = http.MaxBytesReader(
+			responseWriter,
+			request.Body,
+			maxRequestBodySize,
+		)
+
+		route.handler(responseWriter, request, params)
+		return
+	}
+
+	if pathMatched {
+		s.writeError(
+			responseWriter,
+			http.StatusMethodNotAllowed,
+			"method not allowed",
+		)
+		return
+	}
+
+	s.writeError(responseWriter, http.StatusNotFound, "not found")
+}
+
+func (s *Server) authenticate(request *http.Request) bool {
+	if len(s.authToken) == 0 {
+		return false
+	}
+
+	header := request.Header.Get("Authorization")
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.authToken)) == 1
+}
+
+func (s *Server) parseID(value string) (trading.ID, error) {
+	id, err := s.idService.NewIDFromString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id [%v]", value)
+	}
+
+	return id, nil
+}
+
+func (s *Server) readJSON(
+	request *http.Request,
+	value interface{},
+) error {
+	decoder := json.NewDecoder(request.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("invalid request body: [%v]", err)
+	}
+
+	return nil
+}
+
+func (s *Server) writeJSON(
+	responseWriter http.ResponseWriter,
+	status int,
+	value interface{},
+) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(status)
+
+	if err := json.NewEncoder(responseWriter).Encode(value); err != nil {
+		s.logger.Errorf("could not write response: [%v]", err)
+	}
+}
+
+func (s *Server) writeError(
+	responseWriter http.ResponseWriter,
+	status int,
+	message string,
+) {
+	s.writeJSON(responseWriter, status, &errorV1{Error: message})
+}
+
+// writeInternalError logs the actual error and hides its details from
+// the client.
+func (s *Server) writeInternalError(
+	responseWriter http.ResponseWriter,
+	err error,
+) {
+	s.logger.Errorf("could not handle request: [%v]", err)
+
+	s.writeError(
+		responseWriter,
+		http.StatusInternalServerError,
+		"internal error",
+	)
+}
+
+type handlerFunc func(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	params []string,
+)
+
+// route matches request paths against a pattern whose `*` segments
+// match any value. Values of those segments are passed to the handler
+// as params.
+type route struct {
+	method  string
+	pattern []string
+	handler handlerFunc
+}
+
+func newRoute(method, pattern string, handler handlerFunc) *route {
+	return &route{
+		method:  method,
+		pattern: strings.Split(pattern, "/"),
+		handler: handler,
+	}
+}
+
+func (r *route) match(segments []string) ([]string, bool) {
+	if len(segments) != len(r.pattern) {
+		return nil, false
+	}
+
+	params := make([]string, 0)
+
+	for i, segment := range segments {
+		if r.pattern[i] == "*" {
+			params = append(params, segment)
+			continue
+		}
+
+		if r.pattern[i] != segment {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
@@ -0,0 +1,832 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/inmem"
+	"github.com/lukasz-zimnoch/dexly/trading/logrus"
+	"github.com/lukasz-zimnoch/dexly/trading/strategy"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAuthToken = "secret-token"
+
+type testFixture struct {
+	server             *Server
+	idService          trading.IDService
+	accountRepository  *inmem.AccountRepository
+	workloadRepository *inmem.WorkloadRepository
+	positionRepository *inmem.PositionRepository
+	orderRepository    *inmem.OrderRepository
+}
+
+func newTestFixture() *testFixture {
+	idService := &uuid.IDService{}
+	accountRepository := inmem.NewAccountRepository()
+	workloadRepository := inmem.NewWorkloadRepository(accountRepository)
+	orderRepository := inmem.NewOrderRepository()
+	positionRepository := inmem.NewPositionRepository(orderRepository)
+	logger := logrus.ConfigureStandardLogger("text", "panic")
+
+	return &testFixture{
+		server: NewServer(
+			accountRepository,
+			workloadRepository,
+			positionRepository,
+			strategy.NewRegistry(logger),
+			idService,
+			testAuthToken,
+			logger,
+		),
+		idService:          idService,
+		accountRepository:  accountRepository,
+		workloadRepository: workloadRepository,
+		positionRepository: positionRepository,
+		orderRepository:    orderRepository,
+	}
+}
+
+func (tf *testFixture) do(
+	method string,
+	path string,
+	body string,
+) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer "+testAuthToken)
+
+	recorder := httptest.NewRecorder()
+	tf.server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (tf *testFixture) createAccount(t *testing.T) *trading.Account {
+	account := &trading.Account{
+		ID:                 tf.idService.NewID(),
+		Email:              "trader@example.com",
+		Exchange:           "BINANCE",
+		ExchangeApiKey:     "api-key",
+		ExchangeSecretKey:  "secret-key",
+		RiskFactor:         trading.NewDecimal(1, -2),
+		OpenPositionsLimit: 1,
+	}
+
+	if err := tf.accountRepository.CreateAccount(account); err != nil {
+		t.Fatal(err)
+	}
+
+	return account
+}
+
+func (tf *testFixture) createWorkload(t *testing.T) *trading.Workload {
+	workload := &trading.Workload{
+		ID:      tf.idService.NewID(),
+		Account: tf.createAccount(t),
+		Pair:    trading.Pair{Base: "BTC", Quote: "USDT"},
+		Mode:    trading.ModeLive,
+		Status:  trading.WorkloadActive,
+	}
+
+	if err := tf.workloadRepository.CreateWorkload(workload); err != nil {
+		t.Fatal(err)
+	}
+
+	return workload
+}
+
+func (tf *testFixture) createPosition(
+	t *testing.T,
+	workload *trading.Workload,
+	status trading.PositionStatus,
+	positionTime time.Time,
+) *trading.Position {
+	position := &trading.Position{
+		ID:              tf.idService.NewID(),
+		WorkloadID:      workload.ID,
+		Type:            trading.TypeLong,
+		Status:          status,
+		EntryPrice:      trading.NewDecimal(100, 0),
+		Size:            trading.MustParseDecimal("0.5"),
+		TakeProfitPrice: trading.NewDecimal(110, 0),
+		StopLossPrice:   trading.NewDecimal(95, 0),
+		Time:            positionTime,
+	}
+
+	if err := tf.positionRepository.CreatePosition(position); err != nil {
+		t.Fatal(err)
+	}
+
+	order := &trading.Order{
+		ID:       tf.idService.NewID(),
+		Position: position,
+		Side:     trading.SideBuy,
+		Price:    position.EntryPrice,
+		Size:     position.Size,
+		Time:     positionTime,
+		Executed: true,
+	}
+
+	if err := tf.orderRepository.CreateOrder(order); err != nil {
+		t.Fatal(err)
+	}
+
+	return position
+}
+
+func TestServer_Authentication(t *testing.T) {
+	fixture := newTestFixture()
+
+	tests := map[string]struct {
+		header         string
+		expectedStatus int
+	}{
+		"missing header": {"", http.StatusUnauthorized},
+		"wrong scheme":   {"Basic " + testAuthToken, http.StatusUnauthorized},
+		"wrong token":    {"Bearer other-token", http.StatusUnauthorized},
+		"valid token":    {"Bearer " + testAuthToken, http.StatusOK},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/v1/accounts", nil)
+			if len(test.header) > 0 {
+				request.Header.Set("Authorization", test.header)
+			}
+
+			recorder := httptest.NewRecorder()
+			fixture.server.ServeHTTP(recorder, request)
+
+			assertStatus(t, test.expectedStatus, recorder)
+		})
+	}
+}
+
+func TestServer_NoTokenConfigured(t *testing.T) {
+	fixture := newTestFixture()
+	fixture.server.authToken = ""
+
+	request := httptest.NewRequest(http.MethodGet, "/v1/accounts", nil)
+	request.Header.Set("Authorization", "Bearer ")
+
+	recorder := httptest.NewRecorder()
+	fixture.server.ServeHTTP(recorder, request)
+
+	assertStatus(t, http.StatusUnauthorized, recorder)
+}
+
+func TestServer_Routing(t *testing.T) {
+	fixture := newTestFixture()
+
+	tests := map[string]struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		"unknown version":    {http.MethodGet, "/v2/accounts", http.StatusNotFound},
+		"unknown resource":   {http.MethodGet, "/v1/orders", http.StatusNotFound},
+		"method not allowed": {http.MethodDelete, "/v1/accounts", http.StatusMethodNotAllowed},
+		"trailing slash":     {http.MethodGet, "/v1/accounts/", http.StatusOK},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			recorder := fixture.do(test.method, test.path, "")
+
+			assertStatus(t, test.expectedStatus, recorder)
+		})
+	}
+}
+
+func TestServer_CreateAccount(t *testing.T) {
+	fixture := newTestFixture()
+
+	recorder := fixture.do(
+		http.MethodPost,
+		"/v1/accounts",
+		`{
+			"email": "trader@example.com",
+			"exchange": "BINANCE",
+			"exchangeApiKey": "api-key",
+			"exchangeSecretKey": "secret-key",
+			"riskFactor": "0.01",
+			"openPositionsLimit": 2
+		}`,
+	)
+
+	assertStatus(t, http.StatusCreated, recorder)
+
+	if strings.Contains(recorder.Body.String(), "secret-key") {
+		t.Errorf("response exposes exchange credentials")
+	}
+
+	var created accountV1
+	decodeBody(t, recorder, &created)
+
+	accounts, err := fixture.accountRepository.Accounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(accounts) != 1 {
+		t.Fatalf("unexpected accounts count: [%v]", len(accounts))
+	}
+
+	account := accounts[0]
+
+	if account.ID.String() != created.ID {
+		t.Errorf(
+			"unexpected account ID\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			created.ID,
+			account.ID,
+		)
+	}
+
+	if account.ExchangeSecretKey != "secret-key" ||
+		account.ExchangeApiKey != "api-key" {
+		t.Errorf("exchange credentials were not stored")
+	}
+
+	if !account.RiskFactor.Equal(trading.NewDecimal(1, -2)) {
+		t.Errorf("unexpected risk factor: [%v]", account.RiskFactor)
+	}
+
+	if account.OpenPositionsLimit != 2 {
+		t.Errorf(
+			"unexpected open positions limit: [%v]",
+			account.OpenPositionsLimit,
+		)
+	}
+}
+
+func TestServer_CreateAccount_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed json": `{"email": `,
+		"unknown field":  `{"email": "trader@example.com", "unknown": 1}`,
+		"invalid email": `{"email": "trader", "exchange": "BINANCE", ` +
+			`"riskFactor": "0.01", "openPositionsLimit": 1}`,
+		"missing exchange": `{"email": "trader@example.com", ` +
+			`"riskFactor": "0.01", "openPositionsLimit": 1}`,
+		"risk factor above one": `{"email": "trader@example.com", ` +
+			`"exchange": "BINANCE", "riskFactor": "1.5", ` +
+			`"openPositionsLimit": 1}`,
+		"risk factor as number": `{"email": "trader@example.com", ` +
+			`"exchange": "BINANCE", "riskFactor": 0.01, ` +
+			`"openPositionsLimit": 1}`,
+		"zero positions limit": `{"email": "trader@example.com", ` +
+			`"exchange": "BINANCE", "riskFactor": "0.01", ` +
+			`"openPositionsLimit": 0}`,
+	}
+
+	for testName, body := range tests {
+		t.Run(testName, func(t *testing.T) {
+			fixture := newTestFixture()
+
+			recorder := fixture.do(http.MethodPost, "/v1/accounts", body)
+
+			assertStatus(t, http.StatusBadRequest, recorder)
+
+			accounts, err := fixture.accountRepository.Accounts()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(accounts) != 0 {
+				t.Errorf("invalid account has been created")
+			}
+		})
+	}
+}
+
+func TestServer_ListAccounts(t *testing.T) {
+	fixture := newTestFixture()
+	account := fixture.createAccount(t)
+
+	recorder := fixture.do(http.MethodGet, "/v1/accounts", "")
+
+	assertStatus(t, http.StatusOK, recorder)
+
+	var list accountListV1
+	decodeBody(t, recorder, &list)
+
+	if len(list.Accounts) != 1 || list.Accounts[0].ID != account.ID.String() {
+		t.Errorf("unexpected accounts: [%+v]", list.Accounts)
+	}
+}
+
+func TestServer_CreateWorkload(t *testing.T) {
+	fixture := newTestFixture()
+	account := fixture.createAccount(t)
+
+	recorder := fixture.do(
+		http.MethodPost,
+		"/v1/workloads",
+		`{
+			"accountId": "`+account.ID.String()+`",
+			"baseAsset": "ETH",
+			"quoteAsset": "USDT",
+			"mode": "PAPER",
+			"status": "PAUSED",
+			"candleInterval": "15m",
+			"candleWindowSize": 96,
+			"stopLossAtrMultiplier": "1.5",
+			"rewardRiskRatio": "3",
+			"strategy": "ema",
+			"strategyVersion": 1
+		}`,
+	)
+
+	assertStatus(t, http.StatusCreated, recorder)
+
+	var created workloadV1
+	decodeBody(t, recorder, &created)
+
+	assertDecimal(
+		t,
+		"stop loss ATR multiplier",
+		trading.NewDecimal(15, -1),
+		created.StopLossATRMultiplier,
+	)
+	assertDecimal(
+		t,
+		"reward risk ratio",
+		trading.NewDecimal(3, 0),
+		created.RewardRiskRatio,
+	)
+
+	// Decimals are compared above as they don't support the equality
+	// operator.
+	created.StopLossATRMultiplier = trading.Decimal{}
+	created.RewardRiskRatio = trading.Decimal{}
+
+	expected := workloadV1{
+		ID:               created.ID,
+		AccountID:        account.ID.String(),
+		BaseAsset:        "ETH",
+		QuoteAsset:       "USDT",
+		Mode:             "PAPER",
+		Status:           "PAUSED",
+		CandleInterval:   "15m",
+		CandleWindowSize: 96,
+		Strategy:         "ema",
+		StrategyVersion:  1,
+	}
+
+	if created != expected {
+		t.Errorf(
+			"unexpected workload\n"+
+				"expected: [%+v]\n"+
+				"actual:   [%+v]",
+			expected,
+			created,
+		)
+	}
+
+	workloads, err := fixture.workloadRepository.Workloads()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(workloads) != 1 || workloads[0].ID.String() != created.ID {
+		t.Fatalf("workload has not been stored")
+	}
+
+	if workloads[0].Status != trading.WorkloadPaused {
+		t.Errorf("unexpected workload status: [%v]", workloads[0].Status)
+	}
+}
+
+func TestServer_CreateWorkload_DefaultStatus(t *testing.T) {
+	fixture := newTestFixture()
+	account := fixture.createAccount(t)
+
+	recorder := fixture.do(
+		http.MethodPost,
+		"/v1/workloads",
+		`{
+			"accountId": "`+account.ID.String()+`",
+			"baseAsset": "BTC",
+			"quoteAsset": "USDT",
+			"mode": "LIVE"
+		}`,
+	)
+
+	assertStatus(t, http.StatusCreated, recorder)
+
+	var created workloadV1
+	decodeBody(t, recorder, &created)
+
+	if created.Status != "ACTIVE" {
+		t.Errorf("unexpected workload status: [%v]", created.Status)
+	}
+
+	assertDecimal(
+		t,
+		"stop loss ATR multiplier",
+		trading.DefaultStopLossATRMultiplier,
+		created.StopLossATRMultiplier,
+	)
+	assertDecimal(
+		t,
+		"reward risk ratio",
+		trading.DefaultRewardRiskRatio,
+		created.RewardRiskRatio,
+	)
+
+	if created.Strategy != trading.DefaultStrategy.Name ||
+		created.StrategyVersion != trading.DefaultStrategy.Version {
+		t.Errorf(
+			"unexpected workload strategy: [%v@%v]",
+			created.Strategy,
+			created.StrategyVersion,
+		)
+	}
+}
+
+func TestServer_CreateWorkload_Invalid(t *testing.T) {
+	fixture := newTestFixture()
+	account := fixture.createAccount(t)
+	accountID := account.ID.String()
+
+	tests := map[string]string{
+		"invalid account id": `{"accountId": "abc", "baseAsset": "BTC", ` +
+			`"quoteAsset": "USDT", "mode": "LIVE"}`,
+		"unknown account": `{"accountId": "` + fixture.idService.NewID().String() +
+			`", "baseAsset": "BTC", "quoteAsset": "USDT", "mode": "LIVE"}`,
+		"missing asset": `{"accountId": "` + accountID + `", ` +
+			`"baseAsset": "BTC", "mode": "LIVE"}`,
+		"unknown mode": `{"accountId": "` + accountID + `", ` +
+			`"baseAsset": "BTC", "quoteAsset": "USDT", "mode": "DEMO"}`,
+		"unknown status": `{"accountId": "` + accountID + `", ` +
+			`"baseAsset": "BTC", "quoteAsset": "USDT", "mode": "LIVE", ` +
+			`"status": "STOPPED"}`,
+		"negative stop loss multiplier": `{"accountId": "` + accountID +
+			`", "baseAsset": "BTC", "quoteAsset": "USDT", "mode": "LIVE", ` +
+			`"stopLossAtrMultiplier": "-1"}`,
+		"negative reward risk ratio": `{"accountId": "` + accountID + `", ` +
+			`"baseAsset": "BTC", "quoteAsset": "USDT", "mode": "LIVE", ` +
+			`"rewardRiskRatio": "-2"}`,
+		"zero stop loss multiplier": `{"accountId": "` + accountID + `", ` +
+			`"baseAsset": "BTC", "quoteAsset": "USDT", "mode": "LIVE", ` +
+			`"stopLossAtrMultiplier": "0"}`,
+		"zero reward risk ratio": `{"accountId": "` + accountID + `", ` +
+			`"baseAsset": "BTC", "quoteAsset": "USDT", "mode": "LIVE", ` +
+			`"rewardRiskRatio": "0"}`,
+		"unknown strategy": `{"accountId": "` + accountID + `", ` +
+			`"baseAsset": "BTC", "quoteAsset": "USDT", "mode": "LIVE", ` +
+			`"strategy": "ema", "strategyVersion": 2}`,
+		"missing strategy name": `{"accountId": "` + accountID + `", ` +
+			`"baseAsset": "BTC", "quoteAsset": "USDT", "mode": "LIVE", ` +
+			`"strategyVersion": 1}`,
+	}
+
+	for testName, body := range tests {
+		t.Run(testName, func(t *testing.T) {
+			recorder := fixture.do(http.MethodPost, "/v1/workloads", body)
+
+			assertStatus(t, http.StatusBadRequest, recorder)
+		})
+	}
+
+	workloads, err := fixture.workloadRepository.Workloads()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(workloads) != 0 {
+		t.Errorf("invalid workload has been created")
+	}
+}
+
+func TestServer_ListWorkloads(t *testing.T) {
+	fixture := newTestFixture()
+	workload := fixture.createWorkload(t)
+
+	recorder := fixture.do(http.MethodGet, "/v1/workloads", "")
+
+	assertStatus(t, http.StatusOK, recorder)
+
+	var list workloadListV1
+	decodeBody(t, recorder, &list)
+
+	if len(list.Workloads) != 1 ||
+		list.Workloads[0].ID != workload.ID.String() ||
+		list.Workloads[0].AccountID != workload.Account.ID.String() {
+		t.Errorf("unexpected workloads: [%+v]", list.Workloads)
+	}
+}
+
+func TestServer_UpdateWorkload(t *testing.T) {
+	fixture := newTestFixture()
+	workload := fixture.createWorkload(t)
+
+	tests := map[string]struct {
+		path           string
+		body           string
+		expectedStatus int
+		expectedResult trading.WorkloadStatus
+	}{
+		"disable": {
+			path:           "/v1/workloads/" + workload.ID.String(),
+			body:           `{"status": "DISABLED"}`,
+			expectedStatus: http.StatusOK,
+			expectedResult: trading.WorkloadDisabled,
+		},
+		"unknown status": {
+			path:           "/v1/workloads/" + workload.ID.String(),
+			body:           `{"status": "STOPPED"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedResult: trading.WorkloadActive,
+		},
+		"unknown workload": {
+			path:           "/v1/workloads/" + fixture.idService.NewID().String(),
+			body:           `{"status": "DISABLED"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedResult: trading.WorkloadActive,
+		},
+		"invalid workload id": {
+			path:           "/v1/workloads/abc",
+			body:           `{"status": "DISABLED"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedResult: trading.WorkloadActive,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			workload.Status = trading.WorkloadActive
+			err := fixture.workloadRepository.UpdateWorkload(workload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := fixture.do(http.MethodPatch, test.path, test.body)
+
+			assertStatus(t, test.expectedStatus, recorder)
+
+			stored, err := fixture.workloadRepository.Workload(workload.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stored.Status != test.expectedResult {
+				t.Errorf(
+					"unexpected workload status\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedResult,
+					stored.Status,
+				)
+			}
+		})
+	}
+}
+
+func TestServer_ListPositions(t *testing.T) {
+	fixture := newTestFixture()
+	workload := fixture.createWorkload(t)
+
+	now := time.Date(2021, 6, 11, 0, 0, 0, 0, time.UTC)
+	closedPosition := fixture.createPosition(
+		t,
+		workload,
+		trading.StatusClosed,
+		now,
+	)
+	openPosition := fixture.createPosition(
+		t,
+		workload,
+		trading.StatusOpen,
+		now.Add(time.Hour),
+	)
+
+	tests := map[string]struct {
+		query          string
+		expectedStatus int
+		expectedIDs    []string
+	}{
+		"all": {
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expectedIDs: []string{
+				closedPosition.ID.String(),
+				openPosition.ID.String(),
+			},
+		},
+		"open": {
+			query:          "?status=OPEN",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{openPosition.ID.String()},
+		},
+		"closed": {
+			query:          "?status=CLOSED",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{closedPosition.ID.String()},
+		},
+		"unknown status": {
+			query:          "?status=PENDING",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			recorder := fixture.do(
+				http.MethodGet,
+				"/v1/workloads/"+workload.ID.String()+"/positions"+test.query,
+				"",
+			)
+
+			assertStatus(t, test.expectedStatus, recorder)
+
+			if test.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var list positionListV1
+			decodeBody(t, recorder, &list)
+
+			actualIDs := make([]string, 0)
+			for _, position := range list.Positions {
+				actualIDs = append(actualIDs, position.ID)
+
+				if len(position.Orders) != 1 {
+					t.Errorf(
+						"unexpected orders count of position [%v]: [%v]",
+						position.ID,
+						len(position.Orders),
+					)
+				}
+			}
+
+			if strings.Join(actualIDs, ",") !=
+				strings.Join(test.expectedIDs, ",") {
+				t.Errorf(
+					"unexpected positions\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedIDs,
+					actualIDs,
+				)
+			}
+		})
+	}
+}
+
+func TestServer_ListPositions_Encoding(t *testing.T) {
+	fixture := newTestFixture()
+	workload := fixture.createWorkload(t)
+	fixture.createPosition(
+		t,
+		workload,
+		trading.StatusOpen,
+		time.Date(2021, 6, 11, 0, 0, 0, 0, time.UTC),
+	)
+
+	recorder := fixture.do(
+		http.MethodGet,
+		"/v1/workloads/"+workload.ID.String()+"/positions",
+		"",
+	)
+
+	assertStatus(t, http.StatusOK, recorder)
+
+	// Decimals must be encoded as strings to retain their precision.
+	for _, expected := range []string{
+		`"entryPrice":"100"`,
+		`"size":"0.5"`,
+		`"time":"2021-06-11T00:00:00Z"`,
+		`"side":"BUY"`,
+	} {
+		if !bytes.Contains(recorder.Body.Bytes(), []byte(expected)) {
+			t.Errorf(
+				"response does not contain [%v]: [%v]",
+				expected,
+				recorder.Body.String(),
+			)
+		}
+	}
+}
+
+func TestServer_ClosePosition(t *testing.T) {
+	fixture := newTestFixture()
+	workload := fixture.createWorkload(t)
+
+	openPosition := fixture.createPosition(
+		t,
+		workload,
+		trading.StatusOpen,
+		time.Now(),
+	)
+	closedPosition := fixture.createPosition(
+		t,
+		workload,
+		trading.StatusClosed,
+		time.Now(),
+	)
+
+	tests := map[string]struct {
+		positionID     string
+		expectedStatus int
+	}{
+		"open position": {
+			positionID:     openPosition.ID.String(),
+			expectedStatus: http.StatusAccepted,
+		},
+		"closed position": {
+			positionID:     closedPosition.ID.String(),
+			expectedStatus: http.StatusConflict,
+		},
+		"unknown position": {
+			positionID:     fixture.idService.NewID().String(),
+			expectedStatus: http.StatusNotFound,
+		},
+		"invalid position id": {
+			positionID:     "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			recorder := fixture.do(
+				http.MethodPost,
+				"/v1/positions/"+test.positionID+"/close",
+				"",
+			)
+
+			assertStatus(t, test.expectedStatus, recorder)
+		})
+	}
+
+	stored, err := fixture.positionRepository.Position(openPosition.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !stored.ExitRequested {
+		t.Errorf("exit of the open position has not been requested")
+	}
+
+	stored, err = fixture.positionRepository.Position(closedPosition.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.ExitRequested {
+		t.Errorf("exit of the closed position has been requested")
+	}
+}
+
+func assertStatus(
+	t *testing.T,
+	expected int,
+	recorder *httptest.ResponseRecorder,
+) {
+	if recorder.Code != expected {
+		t.Errorf(
+			"unexpected status code\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]\n"+
+				"body:     [%v]",
+			expected,
+			recorder.Code,
+			recorder.Body.String(),
+		)
+	}
+}
+
+func decodeBody(
+	t *testing.T,
+	recorder *httptest.ResponseRecorder,
+	value interface{},
+) {
+	if err := json.Unmarshal(recorder.Body.Bytes(), value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertDecimal(
+	t *testing.T,
+	name string,
+	expected trading.Decimal,
+	actual trading.Decimal,
+) {
+	if !expected.Equal(actual) {
+		t.Errorf(
+			"unexpected %v\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			name,
+			expected,
+			actual,
+		)
+	}
+}
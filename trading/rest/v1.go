@@ -0,0 +1,167 @@
+package rest
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"time"
+)
+
+// Schemas of the v1 API. Existing fields must not be removed nor change
+// their meaning, a new API version must be introduced instead. Decimal
+// values are always encoded as strings to retain their precision.
+
+type errorV1 struct {
+	Error string `json:"error"`
+}
+
+type createAccountRequestV1 struct {
+	Email              string          `json:"email"`
+	Exchange           string          `json:"exchange"`
+	ExchangeApiKey     string          `json:"exchangeApiKey"`
+	ExchangeSecretKey  string          `json:"exchangeSecretKey"`
+	RiskFactor         trading.Decimal `json:"riskFactor"`
+	OpenPositionsLimit int             `json:"openPositionsLimit"`
+}
+
+// accountV1 never exposes the exchange credentials.
+type accountV1 struct {
+	ID                 string          `json:"id"`
+	Email              string          `json:"email"`
+	Exchange           string          `json:"exchange"`
+	RiskFactor         trading.Decimal `json:"riskFactor"`
+	OpenPositionsLimit int             `json:"openPositionsLimit"`
+}
+
+func newAccountV1(account *trading.Account) *accountV1 {
+	return &accountV1{
+		ID:                 account.ID.String(),
+		Email:              account.Email,
+		Exchange:           account.Exchange,
+		RiskFactor:         account.RiskFactor,
+		OpenPositionsLimit: account.OpenPositionsLimit,
+	}
+}
+
+type accountListV1 struct {
+	Accounts []*accountV1 `json:"accounts"`
+}
+
+type createWorkloadRequestV1 struct {
+	AccountID  string `json:"accountId"`
+	BaseAsset  string `json:"baseAsset"`
+	QuoteAsset string `json:"quoteAsset"`
+	Mode       string `json:"mode"`
+	// Status is optional, new workloads are active by default.
+	Status string `json:"status"`
+	// CandleInterval is optional, the default interval is used if empty.
+	CandleInterval string `json:"candleInterval"`
+	// CandleWindowSize is optional, the default size is used if zero.
+	CandleWindowSize int `json:"candleWindowSize"`
+	// StopLossATRMultiplier is optional, the default multiplier is used
+	// if not set.
+	StopLossATRMultiplier *trading.Decimal `json:"stopLossAtrMultiplier"`
+	// RewardRiskRatio is optional, the default ratio is used if not set.
+	RewardRiskRatio *trading.Decimal `json:"rewardRiskRatio"`
+	// Strategy is optional, the default strategy is used if empty.
+	// StrategyVersion is required if the strategy is set.
+	Strategy        string `json:"strategy"`
+	StrategyVersion int    `json:"strategyVersion"`
+}
+
+type updateWorkloadRequestV1 struct {
+	Status string `json:"status"`
+}
+
+type workloadV1 struct {
+	ID                    string          `json:"id"`
+	AccountID             string          `json:"accountId"`
+	BaseAsset             string          `json:"baseAsset"`
+	QuoteAsset            string          `json:"quoteAsset"`
+	Mode                  string          `json:"mode"`
+	Status                string          `json:"status"`
+	CandleInterval        string          `json:"candleInterval"`
+	CandleWindowSize      int             `json:"candleWindowSize"`
+	StopLossATRMultiplier trading.Decimal `json:"stopLossAtrMultiplier"`
+	RewardRiskRatio       trading.Decimal `json:"rewardRiskRatio"`
+	Strategy              string          `json:"strategy"`
+	StrategyVersion       int             `json:"strategyVersion"`
+}
+
+func newWorkloadV1(workload *trading.Workload) *workloadV1 {
+	return &workloadV1{
+		ID:                    workload.ID.String(),
+		AccountID:             workload.Account.ID.String(),
+		BaseAsset:             string(workload.Pair.Base),
+		QuoteAsset:            string(workload.Pair.Quote),
+		Mode:                  workload.Mode.String(),
+		Status:                workload.Status.String(),
+		CandleInterval:        workload.CandleInterval.String(),
+		CandleWindowSize:      workload.CandleWindowSize,
+		StopLossATRMultiplier: workload.StopLossATRMultiplier,
+		RewardRiskRatio:       workload.RewardRiskRatio,
+		Strategy:              workload.Strategy.Name,
+		StrategyVersion:       workload.Strategy.Version,
+	}
+}
+
+type workloadListV1 struct {
+	Workloads []*workloadV1 `json:"workloads"`
+}
+
+type orderV1 struct {
+	ID       string          `json:"id"`
+	Side     string          `json:"side"`
+	Price    trading.Decimal `json:"price"`
+	Size     trading.Decimal `json:"size"`
+	Time     time.Time       `json:"time"`
+	Executed bool            `json:"executed"`
+}
+
+func newOrderV1(order *trading.Order) *orderV1 {
+	return &orderV1{
+		ID:       order.ID.String(),
+		Side:     order.Side.String(),
+		Price:    order.Price,
+		Size:     order.Size,
+		Time:     order.Time,
+		Executed: order.Executed,
+	}
+}
+
+type positionV1 struct {
+	ID              string          `json:"id"`
+	WorkloadID      string          `json:"workloadId"`
+	Type            string          `json:"type"`
+	Status          string          `json:"status"`
+	EntryPrice      trading.Decimal `json:"entryPrice"`
+	Size            trading.Decimal `json:"size"`
+	TakeProfitPrice trading.Decimal `json:"takeProfitPrice"`
+	StopLossPrice   trading.Decimal `json:"stopLossPrice"`
+	Time            time.Time       `json:"time"`
+	ExitRequested   bool            `json:"exitRequested"`
+	Orders          []*orderV1      `json:"orders"`
+}
+
+func newPositionV1(position *trading.Position) *positionV1 {
+	orders := make([]*orderV1, 0)
+	for _, order := range position.Orders {
+		orders = append(orders, newOrderV1(order))
+	}
+
+	return &positionV1{
+		ID:              position.ID.String(),
+		WorkloadID:      position.WorkloadID.String(),
+		Type:            position.Type.String(),
+		Status:          position.Status.String(),
+		EntryPrice:      position.EntryPrice,
+		Size:            position.Size,
+		TakeProfitPrice: position.TakeProfitPrice,
+		StopLossPrice:   position.StopLossPrice,
+		Time:            position.Time,
+		ExitRequested:   position.ExitRequested,
+		Orders:          orders,
+	}
+}
+
+type positionListV1 struct {
+	Positions []*positionV1 `json:"positions"`
+}
@@ -0,0 +1,257 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"net/http"
+)
+
+func (s *Server) listWorkloads(
+	responseWriter http.ResponseWriter,
+	_ *http.Request,
+	_ []string,
+) {
+	workloads, err := s.workloadRepository.Workloads()
+	if err != nil {
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not get workloads: [%v]", err),
+		)
+		return
+	}
+
+	response := &workloadListV1{Workloads: make([]*workloadV1, 0)}
+	for _, workload := range workloads {
+		response.Workloads = append(
+			response.Workloads,
+			newWorkloadV1(workload),
+		)
+	}
+
+	s.writeJSON(responseWriter, http.StatusOK, response)
+}
+
+func (s *Server) createWorkload(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	_ []string,
+) {
+	var createRequest createWorkloadRequestV1
+	if err := s.readJSON(request, &createRequest); err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	accountID, err := s.parseID(createRequest.AccountID)
+	if err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(createRequest.BaseAsset) == 0 || len(createRequest.QuoteAsset) == 0 {
+		s.writeError(
+			responseWriter,
+			http.StatusBadRequest,
+			"base and quote assets must be set",
+		)
+		return
+	}
+
+	mode, err := trading.ParseWorkloadMode(createRequest.Mode)
+	if err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := trading.WorkloadActive
+	if len(createRequest.Status) > 0 {
+		status, err = trading.ParseWorkloadStatus(createRequest.Status)
+		if err != nil {
+			s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	candleInterval := trading.DefaultCandleInterval
+	if len(createRequest.CandleInterval) > 0 {
+		candleInterval, err = trading.ParseCandleInterval(
+			createRequest.CandleInterval,
+		)
+		if err != nil {
+			s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	candleWindowSize := trading.DefaultCandleWindowSize
+	if createRequest.CandleWindowSize != 0 {
+		if createRequest.CandleWindowSize < 0 {
+			s.writeError(
+				responseWriter,
+				http.StatusBadRequest,
+				"candle window size must be positive",
+			)
+			return
+		}
+
+		candleWindowSize = createRequest.CandleWindowSize
+	}
+
+	stopLossATRMultiplier := trading.DefaultStopLossATRMultiplier
+	if createRequest.StopLossATRMultiplier != nil {
+		stopLossATRMultiplier = *createRequest.StopLossATRMultiplier
+	}
+
+	rewardRiskRatio := trading.DefaultRewardRiskRatio
+	if createRequest.RewardRiskRatio != nil {
+		rewardRiskRatio = *createRequest.RewardRiskRatio
+	}
+
+	if err := trading.ValidateATRTargets(
+		stopLossATRMultiplier,
+		rewardRiskRatio,
+	); err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	strategy := trading.DefaultStrategy
+	if len(createRequest.Strategy) > 0 || createRequest.StrategyVersion != 0 {
+		strategy = trading.StrategyRef{
+			Name:    createRequest.Strategy,
+			Version: createRequest.StrategyVersion,
+		}
+
+		_, err := s.strategyRegistry.SignalGenerator(strategy)
+		if err != nil {
+			if err == trading.ErrNotFound {
+				s.writeError(
+					responseWriter,
+					http.StatusBadRequest,
+					fmt.Sprintf("strategy [%v] does not exist", strategy),
+				)
+				return
+			}
+
+			s.writeInternalError(
+				responseWriter,
+				fmt.Errorf("could not get strategy: [%v]", err),
+			)
+			return
+		}
+	}
+
+	account, err := s.accountRepository.Account(accountID)
+	if err != nil {
+		if err == trading.ErrNotFound {
+			s.writeError(
+				responseWriter,
+				http.StatusBadRequest,
+				fmt.Sprintf("account [%v] does not exist", accountID),
+			)
+			return
+		}
+
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not get account: [%v]", err),
+		)
+		return
+	}
+
+	workload := &trading.Workload{
+		ID:      s.idService.NewID(),
+		Account: account,
+		Pair: trading.Pair{
+			Base:  trading.Asset(createRequest.BaseAsset),
+			Quote: trading.Asset(createRequest.QuoteAsset),
+		},
+		Mode:                  mode,
+		Status:                status,
+		CandleInterval:        candleInterval,
+		CandleWindowSize:      candleWindowSize,
+		StopLossATRMultiplier: stopLossATRMultiplier,
+		RewardRiskRatio:       rewardRiskRatio,
+		Strategy:              strategy,
+	}
+
+	if err := s.workloadRepository.CreateWorkload(workload); err != nil {
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not create workload: [%v]", err),
+		)
+		return
+	}
+
+	s.writeJSON(responseWriter, http.StatusCreated, newWorkloadV1(workload))
+}
+
+// updateWorkload allows to change the workload status. The change is
+// picked up by the workload controller without restarting the service.
+func (s *Server) updateWorkload(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	params []string,
+) {
+	workload, ok := s.getWorkload(responseWriter, params[0])
+	if !ok {
+		return
+	}
+
+	var updateRequest updateWorkloadRequestV1
+	if err := s.readJSON(request, &updateRequest); err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status, err := trading.ParseWorkloadStatus(updateRequest.Status)
+	if err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workload.Status = status
+
+	if err := s.workloadRepository.UpdateWorkload(workload); err != nil {
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not update workload: [%v]", err),
+		)
+		return
+	}
+
+	s.writeJSON(responseWriter, http.StatusOK, newWorkloadV1(workload))
+}
+
+// getWorkload fetches the workload with the given ID. If the workload
+// cannot be fetched, an error response is written and false is returned.
+func (s *Server) getWorkload(
+	responseWriter http.ResponseWriter,
+	workloadIDParam string,
+) (*trading.Workload, bool) {
+	workloadID, err := s.parseID(workloadIDParam)
+	if err != nil {
+		s.writeError(responseWriter, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	workload, err := s.workloadRepository.Workload(workloadID)
+	if err != nil {
+		if err == trading.ErrNotFound {
+			s.writeError(
+				responseWriter,
+				http.StatusNotFound,
+				fmt.Sprintf("workload [%v] does not exist", workloadID),
+			)
+			return nil, false
+		}
+
+		s.writeInternalError(
+			responseWriter,
+			fmt.Errorf("could not get workload: [%v]", err),
+		)
+		return nil, false
+	}
+
+	return workload, true
+}
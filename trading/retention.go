@@ -0,0 +1,121 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// candleRetentionLoopTick determines how often stored candles exceeding
+// the retention policy are deleted.
+const candleRetentionLoopTick = 1 * time.Hour
+
+// CandleRetentionPolicy determines how long stored candles of each
+// interval are kept. Candles of intervals missing in the policy are kept
+// forever.
+type CandleRetentionPolicy map[CandleInterval]time.Duration
+
+// ParseCandleRetentionPolicy parses a policy encoded as
+// `<interval>=<duration>` entries separated by commas, e.g.
+// `1m=720h,1h=8760h`.
+func ParseCandleRetentionPolicy(encoded string) (CandleRetentionPolicy, error) {
+	policy := make(CandleRetentionPolicy)
+
+	for _, entry := range strings.Split(encoded, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf(
+				"retention entry must be <interval>=<duration>",
+			)
+		}
+
+		interval, err := ParseCandleInterval(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		retention, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse retention of [%v]: [%v]",
+				interval,
+				err,
+			)
+		}
+
+		if retention <= 0 {
+			return nil, fmt.Errorf(
+				"retention of [%v] must be positive",
+				interval,
+			)
+		}
+
+		policy[interval] = retention
+	}
+
+	return policy, nil
+}
+
+// RunCandleRetention deletes stored candles exceeding the retention policy
+// periodically until the context is done.
+func RunCandleRetention(
+	ctx context.Context,
+	store CandleStore,
+	policy CandleRetentionPolicy,
+	clock Clock,
+	logger Logger,
+) {
+	if len(policy) == 0 {
+		logger.Infof("candle retention policy not set; candles are kept")
+		return
+	}
+
+	ticker := time.NewTicker(candleRetentionLoopTick)
+	defer ticker.Stop()
+
+	for {
+		enforceCandleRetention(store, policy, clock.Now(), logger)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func enforceCandleRetention(
+	store CandleStore,
+	policy CandleRetentionPolicy,
+	now time.Time,
+	logger Logger,
+) {
+	for interval, retention := range policy {
+		deleted, err := store.DeleteCandlesBefore(
+			interval,
+			now.Add(-1*retention),
+		)
+		if err != nil {
+			logger.Errorf(
+				"could not delete [%v] candles exceeding retention: [%v]",
+				interval,
+				err,
+			)
+			continue
+		}
+
+		if deleted > 0 {
+			logger.Infof(
+				"deleted [%v] [%v] candles exceeding retention",
+				deleted,
+				interval,
+			)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package trading
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseCandleRetentionPolicy(t *testing.T) {
+	tests := map[string]struct {
+		encoded        string
+		expectedPolicy CandleRetentionPolicy
+		expectedErr    bool
+	}{
+		"empty": {
+			encoded:        "",
+			expectedPolicy: CandleRetentionPolicy{},
+		},
+		"multiple intervals": {
+			encoded: "1m=720h, 1h=8760h",
+			expectedPolicy: CandleRetentionPolicy{
+				CandleInterval1m: 720 * time.Hour,
+				CandleInterval1h: 8760 * time.Hour,
+			},
+		},
+		"missing duration": {
+			encoded:     "1m",
+			expectedErr: true,
+		},
+		"unknown interval": {
+			encoded:     "2m=720h",
+			expectedErr: true,
+		},
+		"invalid duration": {
+			encoded:     "1m=30d",
+			expectedErr: true,
+		},
+		"non-positive duration": {
+			encoded:     "1m=0s",
+			expectedErr: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			policy, err := ParseCandleRetentionPolicy(test.encoded)
+
+			if test.expectedErr != (err != nil) {
+				t.Fatalf(
+					"unexpected error\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedErr,
+					err,
+				)
+			}
+
+			if fmt.Sprint(policy) != fmt.Sprint(test.expectedPolicy) {
+				t.Errorf(
+					"unexpected policy\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedPolicy,
+					policy,
+				)
+			}
+		})
+	}
+}
+
+func TestEnforceCandleRetention(t *testing.T) {
+	now := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+
+	store := &testCandleStore{
+		deleteErrs: map[CandleInterval]error{
+			CandleInterval5m: fmt.Errorf("unavailable"),
+		},
+	}
+
+	enforceCandleRetention(
+		store,
+		CandleRetentionPolicy{
+			CandleInterval1m: 24 * time.Hour,
+			CandleInterval5m: 48 * time.Hour,
+			CandleInterval1h: 720 * time.Hour,
+		},
+		now,
+		&testLogger{},
+	)
+
+	// Failure of one interval doesn't stop the others.
+	expected := map[CandleInterval]time.Time{
+		CandleInterval1m: now.Add(-24 * time.Hour),
+		CandleInterval1h: now.Add(-720 * time.Hour),
+	}
+
+	if fmt.Sprint(store.deletedBefore) != fmt.Sprint(expected) {
+		t.Errorf(
+			"unexpected deletions\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expected,
+			store.deletedBefore,
+		)
+	}
+}
+
+type testCandleStore struct {
+	deleteErrs    map[CandleInterval]error
+	deletedBefore map[CandleInterval]time.Time
+}
+
+func (tcs *testCandleStore) SaveCandles(_ CandleKey, _ ...*Candle) error {
+	return nil
+}
+
+func (tcs *testCandleStore) CandlesRange(
+	_ CandleKey,
+	_, _ time.Time,
+) ([]*Candle, error) {
+	return []*Candle{}, nil
+}
+
+func (tcs *testCandleStore) LatestCandles(
+	_ CandleKey,
+	_ int,
+) ([]*Candle, error) {
+	return []*Candle{}, nil
+}
+
+func (tcs *testCandleStore) DeleteCandlesBefore(
+	interval CandleInterval,
+	before time.Time,
+) (int64, error) {
+	if err := tcs.deleteErrs[interval]; err != nil {
+		return 0, err
+	}
+
+	if tcs.deletedBefore == nil {
+		tcs.deletedBefore = make(map[CandleInterval]time.Time)
+	}
+
+	tcs.deletedBefore[interval] = before
+
+	return 1, nil
+}
@@ -0,0 +1,111 @@
+package trading
+
+import (
+	"fmt"
+)
+
+// TradingRules describes the constraints the exchange imposes on orders
+// placed for a given pair. A zero value of any field means the exchange
+// does not impose the given constraint.
+type TradingRules struct {
+	// PriceTick is the smallest allowed change of the order price.
+	PriceTick Decimal
+	// LotStep is the smallest allowed change of the order quantity.
+	LotStep Decimal
+	// MinQuantity is the minimum allowed order quantity.
+	MinQuantity Decimal
+	// MaxQuantity is the maximum allowed order quantity.
+	MaxQuantity Decimal
+	// MinNotional is the minimum allowed value of the order, i.e. price
+	// multiplied by quantity, expressed in the quote asset.
+	MinNotional Decimal
+}
+
+// RoundPrice rounds the price to the nearest multiple of the price tick.
+func (tr *TradingRules) RoundPrice(price Decimal) Decimal {
+	if tr.PriceTick.Sign() <= 0 {
+		return price
+	}
+
+	return price.Div(tr.PriceTick).Round(0).Mul(tr.PriceTick)
+}
+
+// RoundQuantity rounds the quantity down to the nearest multiple of the
+// lot step and caps it at the maximum quantity. The quantity is never
+// rounded up so the order never exceeds the available funds.
+func (tr *TradingRules) RoundQuantity(quantity Decimal) Decimal {
+	if tr.MaxQuantity.Sign() > 0 && quantity.Cmp(tr.MaxQuantity) > 0 {
+		quantity = tr.MaxQuantity
+	}
+
+	if tr.LotStep.Sign() <= 0 {
+		return quantity
+	}
+
+	return quantity.Div(tr.LotStep).RoundDown(0).Mul(tr.LotStep)
+}
+
+// Validate checks whether an order with the given price and quantity
+// satisfies the rules. Both values are expected to be already rounded
+// using RoundPrice and RoundQuantity.
+func (tr *TradingRules) Validate(price, quantity Decimal) error {
+	if price.Sign() <= 0 {
+		return fmt.Errorf("price [%v] must be positive", price)
+	}
+
+	if quantity.Sign() <= 0 {
+		return fmt.Errorf("quantity [%v] must be positive", quantity)
+	}
+
+	if !isMultipleOf(price, tr.PriceTick) {
+		return fmt.Errorf(
+			"price [%v] is not a multiple of price tick [%v]",
+			price,
+			tr.PriceTick,
+		)
+	}
+
+	if !isMultipleOf(quantity, tr.LotStep) {
+		return fmt.Errorf(
+			"quantity [%v] is not a multiple of lot step [%v]",
+			quantity,
+			tr.LotStep,
+		)
+	}
+
+	if quantity.Cmp(tr.MinQuantity) < 0 {
+		return fmt.Errorf(
+			"quantity [%v] is below minimum quantity [%v]",
+			quantity,
+			tr.MinQuantity,
+		)
+	}
+
+	if tr.MaxQuantity.Sign() > 0 && quantity.Cmp(tr.MaxQuantity) > 0 {
+		return fmt.Errorf(
+			"quantity [%v] is above maximum quantity [%v]",
+			quantity,
+			tr.MaxQuantity,
+		)
+	}
+
+	if notional := price.Mul(quantity); notional.Cmp(tr.MinNotional) < 0 {
+		return fmt.Errorf(
+			"notional [%v] is below minimum notional [%v]",
+			notional,
+			tr.MinNotional,
+		)
+	}
+
+	return nil
+}
+
+func isMultipleOf(value, step Decimal) bool {
+	if step.Sign() <= 0 {
+		return true
+	}
+
+	quotient := value.Div(step)
+
+	return quotient.Equal(quotient.RoundDown(0))
+}
@@ -0,0 +1,128 @@
+package trading
+
+import (
+	"testing"
+)
+
+func TestTradingRules_Round(t *testing.T) {
+	tradingRules := &TradingRules{
+		PriceTick:   MustParseDecimal("0.05"),
+		LotStep:     MustParseDecimal("0.001"),
+		MaxQuantity: NewDecimal(100, 0),
+	}
+
+	tests := map[string]struct {
+		value    string
+		round    func(Decimal) Decimal
+		expected string
+	}{
+		"price on tick":         {"100.15", tradingRules.RoundPrice, "100.15"},
+		"price rounded down":    {"100.12", tradingRules.RoundPrice, "100.1"},
+		"price rounded up":      {"100.13", tradingRules.RoundPrice, "100.15"},
+		"quantity on step":      {"1.234", tradingRules.RoundQuantity, "1.234"},
+		"quantity rounded down": {"1.2349", tradingRules.RoundQuantity, "1.234"},
+		"quantity capped":       {"150.5", tradingRules.RoundQuantity, "100"},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual := test.round(MustParseDecimal(test.value))
+
+			assertDecimal(t, "value", test.expected, actual)
+		})
+	}
+}
+
+func TestTradingRules_RoundWithoutConstraints(t *testing.T) {
+	tradingRules := &TradingRules{}
+
+	value := MustParseDecimal("1.23456789")
+
+	assertDecimal(t, "price", "1.23456789", tradingRules.RoundPrice(value))
+	assertDecimal(
+		t,
+		"quantity",
+		"1.23456789",
+		tradingRules.RoundQuantity(value),
+	)
+}
+
+func TestTradingRules_Validate(t *testing.T) {
+	tradingRules := &TradingRules{
+		PriceTick:   MustParseDecimal("0.01"),
+		LotStep:     MustParseDecimal("0.001"),
+		MinQuantity: MustParseDecimal("0.01"),
+		MaxQuantity: NewDecimal(100, 0),
+		MinNotional: NewDecimal(10, 0),
+	}
+
+	tests := map[string]struct {
+		price         string
+		quantity      string
+		expectedError string
+	}{
+		"valid": {
+			price:    "100.01",
+			quantity: "0.5",
+		},
+		"zero price": {
+			price:         "0",
+			quantity:      "0.5",
+			expectedError: "price [0] must be positive",
+		},
+		"zero quantity": {
+			price:         "100",
+			quantity:      "0",
+			expectedError: "quantity [0] must be positive",
+		},
+		"price off tick": {
+			price:         "100.001",
+			quantity:      "0.5",
+			expectedError: "price [100.001] is not a multiple of price tick [0.01]",
+		},
+		"quantity off step": {
+			price:         "100",
+			quantity:      "0.5005",
+			expectedError: "quantity [0.5005] is not a multiple of lot step [0.001]",
+		},
+		"quantity below minimum": {
+			price:         "2000",
+			quantity:      "0.005",
+			expectedError: "quantity [0.005] is below minimum quantity [0.01]",
+		},
+		"quantity above maximum": {
+			price:         "100",
+			quantity:      "100.001",
+			expectedError: "quantity [100.001] is above maximum quantity [100]",
+		},
+		"notional below minimum": {
+			price:         "100",
+			quantity:      "0.09",
+			expectedError: "notional [9] is below minimum notional [10]",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := tradingRules.Validate(
+				MustParseDecimal(test.price),
+				MustParseDecimal(test.quantity),
+			)
+
+			actualError := ""
+			if err != nil {
+				actualError = err.Error()
+			}
+
+			if actualError != test.expectedError {
+				t.Errorf(
+					"unexpected error\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedError,
+					actualError,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package trading
+
+// MasterKey is a key encryption key. Sensitive values are encrypted
+// using random data keys and only those data keys are encrypted using
+// the master key. The key material is loaded into the memory of the
+// process using it, so master keys must be protected as carefully as
+// the values they encrypt.
+type MasterKey struct {
+	ID       string
+	Material []byte
+}
+
+// KeyProvider supplies master keys used for envelope encryption.
+type KeyProvider interface {
+	// PrimaryKey returns the master key new values should be encrypted
+	// with.
+	PrimaryKey() (*MasterKey, error)
+
+	// Key returns the master key with the given ID. It returns
+	// ErrNotFound if the key is not known to the provider.
+	Key(keyID string) (*MasterKey, error)
+}
+
+// SecretCipher encrypts sensitive values, like exchange credentials,
+// before they are persisted. Ciphertexts are bound to the associated
+// data, e.g. the owner and the name of the value, and can't be decrypted
+// using any other associated data. This way encrypted values can't be
+// swapped between records or fields.
+type SecretCipher interface {
+	Encrypt(plaintext, associatedData string) (string, error)
+
+	Decrypt(ciphertext, associatedData string) (string, error)
+
+	// IsEncrypted tells whether the value is a ciphertext, as opposed to
+	// a plaintext persisted before encryption has been introduced.
+	IsEncrypted(value string) bool
+
+	// Reencrypt encrypts the value using the current primary master key.
+	// The value may be either a plaintext or a ciphertext produced using
+	// another master key or an older format. The returned flag is false
+	// if the value is already encrypted using the primary key and has
+	// been left intact.
+	Reencrypt(value, associatedData string) (string, bool, error)
+}
@@ -2,26 +2,170 @@ package trading
 
 import (
 	"fmt"
-	"math/big"
 )
 
+// Workloads stop losses at 2 ATRs from the entry and take profits at twice
+// the risked distance unless configured otherwise.
+var (
+	DefaultStopLossATRMultiplier = NewDecimal(2, 0)
+	DefaultRewardRiskRatio       = NewDecimal(2, 0)
+)
+
+// ValidateATRTargets checks the parameters signal targets of a workload
+// are derived from. Both must be positive, otherwise the targets would
+// collapse onto the entry.
+func ValidateATRTargets(stopLossATRMultiplier, rewardRiskRatio Decimal) error {
+	if stopLossATRMultiplier.Sign() <= 0 {
+		return fmt.Errorf("stop loss ATR multiplier must be positive")
+	}
+
+	if rewardRiskRatio.Sign() <= 0 {
+		return fmt.Errorf("reward risk ratio must be positive")
+	}
+
+	return nil
+}
+
 type Signal struct {
 	Type             PositionType
-	EntryTarget      *big.Float
-	TakeProfitTarget *big.Float
-	StopLossTarget   *big.Float
+	EntryTarget      Decimal
+	TakeProfitTarget Decimal
+	StopLossTarget   Decimal
+	// ATR is the average true range the targets are derived from. It's
+	// zero if the signal generator sets targets on its own.
+	ATR Decimal
+}
+
+// NewATRSignal creates a signal whose targets are derived from the ATR
+// using the default multipliers. Workloads adjust them according to their
+// own settings, see WithATRTargets.
+func NewATRSignal(
+	positionType PositionType,
+	entryTarget Decimal,
+	atr Decimal,
+) *Signal {
+	signal := &Signal{
+		Type:        positionType,
+		EntryTarget: entryTarget,
+		ATR:         atr,
+	}
+
+	return signal.WithATRTargets(
+		DefaultStopLossATRMultiplier,
+		DefaultRewardRiskRatio,
+	)
 }
 
 func (s *Signal) String() string {
 	return fmt.Sprintf(
-		"%v, entry %v, tp: %v, sl: %v",
+		"%v, entry %v, tp: %v, sl: %v, atr: %v",
 		s.Type.String(),
-		s.EntryTarget.Text('f', 2),
-		s.TakeProfitTarget.Text('f', 2),
-		s.StopLossTarget.Text('f', 2),
+		s.EntryTarget.StringFixed(2),
+		s.TakeProfitTarget.StringFixed(2),
+		s.StopLossTarget.StringFixed(2),
+		s.ATR.StringFixed(2),
 	)
 }
 
+// ValidateTargets checks the stop loss target is below the entry target
+// and the take profit target above it for LONG signals, and the other
+// way round for SHORT ones.
+func (s *Signal) ValidateTargets() error {
+	risk := s.EntryTarget.Sub(s.StopLossTarget)
+	reward := s.TakeProfitTarget.Sub(s.EntryTarget)
+	if s.Type == TypeShort {
+		risk, reward = risk.Neg(), reward.Neg()
+	}
+
+	if risk.Sign() <= 0 {
+		return fmt.Errorf("stop loss target on the wrong side of entry target")
+	}
+
+	if reward.Sign() <= 0 {
+		return fmt.Errorf(
+			"take profit target on the wrong side of entry target",
+		)
+	}
+
+	return nil
+}
+
+// WithATRTargets returns a copy of the signal whose stop loss is the given
+// multiple of the ATR away from the entry and whose take profit is the
+// reward:risk ratio times further away on the other side. Signals without
+// the ATR are returned unchanged.
+func (s *Signal) WithATRTargets(
+	stopLossATRMultiplier Decimal,
+	rewardRiskRatio Decimal,
+) *Signal {
+	if s.ATR.IsZero() {
+		return s
+	}
+
+	risk := s.ATR.Mul(stopLossATRMultiplier)
+	reward := risk.Mul(rewardRiskRatio)
+
+	targeted := *s
+
+	switch s.Type {
+	case TypeLong:
+		targeted.StopLossTarget = s.EntryTarget.Sub(risk)
+		targeted.TakeProfitTarget = s.EntryTarget.Add(reward)
+	case TypeShort:
+		targeted.StopLossTarget = s.EntryTarget.Add(risk)
+		targeted.TakeProfitTarget = s.EntryTarget.Sub(reward)
+	}
+
+	return &targeted
+}
+
 type SignalGenerator interface {
 	Evaluate(candles []*Candle) (*Signal, bool)
 }
+
+// SessionSignalGenerator is a signal generator whose indicators can be kept
+// between evaluations of the same candle series, so they are updated only
+// with candles appended since the former evaluation instead of being
+// recomputed from the whole window.
+type SessionSignalGenerator interface {
+	SignalGenerator
+
+	// NewSession creates a generator keeping indicators of a single candle
+	// series. It must not be used to evaluate other series and must not be
+	// used concurrently.
+	NewSession() SignalGenerator
+}
+
+// newSignalSession returns a session of the generator, if it supports
+// them, or the generator itself otherwise.
+func newSignalSession(signalGenerator SignalGenerator) SignalGenerator {
+	if sessionGenerator, ok := signalGenerator.(SessionSignalGenerator); ok {
+		return sessionGenerator.NewSession()
+	}
+
+	return signalGenerator
+}
+
+// DefaultStrategy is used by workloads which don't reference any strategy
+// explicitly. It's the built-in EMA strategy.
+var DefaultStrategy = StrategyRef{Name: "ema", Version: 1}
+
+// StrategyRef identifies a version of the strategy signals are generated
+// with. Versions are immutable so workloads keep trading the same way until
+// they are moved to another version.
+type StrategyRef struct {
+	Name    string
+	Version int
+}
+
+func (sr StrategyRef) String() string {
+	return fmt.Sprintf("%v@%v", sr.Name, sr.Version)
+}
+
+// StrategyRegistry provides signal generators of the known strategies.
+type StrategyRegistry interface {
+	// SignalGenerator returns the generator of the given strategy or
+	// ErrNotFound if the strategy is unknown. Workloads referencing the
+	// same strategy share the same generator.
+	SignalGenerator(strategy StrategyRef) (SignalGenerator, error)
+}
@@ -0,0 +1,145 @@
+package trading
+
+import (
+	"testing"
+)
+
+func TestSignal_WithATRTargets(t *testing.T) {
+	tests := map[string]struct {
+		signal             *Signal
+		expectedStopLoss   Decimal
+		expectedTakeProfit Decimal
+	}{
+		"long": {
+			signal: &Signal{
+				Type:        TypeLong,
+				EntryTarget: NewDecimal(100, 0),
+				ATR:         NewDecimal(25, -1),
+			},
+			expectedStopLoss:   NewDecimal(95, 0),
+			expectedTakeProfit: NewDecimal(115, 0),
+		},
+		"short": {
+			signal: &Signal{
+				Type:        TypeShort,
+				EntryTarget: NewDecimal(100, 0),
+				ATR:         NewDecimal(25, -1),
+			},
+			expectedStopLoss:   NewDecimal(105, 0),
+			expectedTakeProfit: NewDecimal(85, 0),
+		},
+		"no atr": {
+			signal: &Signal{
+				Type:             TypeLong,
+				EntryTarget:      NewDecimal(100, 0),
+				TakeProfitTarget: NewDecimal(110, 0),
+				StopLossTarget:   NewDecimal(95, 0),
+			},
+			expectedStopLoss:   NewDecimal(95, 0),
+			expectedTakeProfit: NewDecimal(110, 0),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			original := *test.signal
+
+			signal := test.signal.WithATRTargets(
+				NewDecimal(2, 0),
+				NewDecimal(3, 0),
+			)
+
+			if !signal.StopLossTarget.Equal(test.expectedStopLoss) {
+				t.Errorf(
+					"unexpected stop loss target\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedStopLoss,
+					signal.StopLossTarget,
+				)
+			}
+
+			if !signal.TakeProfitTarget.Equal(test.expectedTakeProfit) {
+				t.Errorf(
+					"unexpected take profit target\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedTakeProfit,
+					signal.TakeProfitTarget,
+				)
+			}
+
+			if !test.signal.StopLossTarget.Equal(original.StopLossTarget) ||
+				!test.signal.TakeProfitTarget.Equal(
+					original.TakeProfitTarget,
+				) {
+				t.Errorf("original signal has been modified")
+			}
+		})
+	}
+}
+
+func TestNewATRSignal(t *testing.T) {
+	signal := NewATRSignal(TypeLong, NewDecimal(100, 0), NewDecimal(25, -1))
+
+	// Default multipliers put the stop loss 2 ATRs below the entry and
+	// the take profit twice as far above it.
+	expected := &Signal{
+		Type:             TypeLong,
+		EntryTarget:      NewDecimal(100, 0),
+		TakeProfitTarget: NewDecimal(110, 0),
+		StopLossTarget:   NewDecimal(95, 0),
+		ATR:              NewDecimal(25, -1),
+	}
+
+	if signal.String() != expected.String() {
+		t.Errorf(
+			"unexpected signal\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expected,
+			signal,
+		)
+	}
+}
+
+func TestValidateATRTargets(t *testing.T) {
+	tests := map[string]struct {
+		stopLossATRMultiplier Decimal
+		rewardRiskRatio       Decimal
+		expectedErr           bool
+	}{
+		"positive": {
+			stopLossATRMultiplier: NewDecimal(15, -1),
+			rewardRiskRatio:       NewDecimal(3, 0),
+			expectedErr:           false,
+		},
+		"zero stop loss multiplier": {
+			stopLossATRMultiplier: NewDecimal(0, 0),
+			rewardRiskRatio:       NewDecimal(3, 0),
+			expectedErr:           true,
+		},
+		"negative stop loss multiplier": {
+			stopLossATRMultiplier: NewDecimal(-1, 0),
+			rewardRiskRatio:       NewDecimal(3, 0),
+			expectedErr:           true,
+		},
+		"zero reward risk ratio": {
+			stopLossATRMultiplier: NewDecimal(2, 0),
+			rewardRiskRatio:       NewDecimal(0, 0),
+			expectedErr:           true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := ValidateATRTargets(
+				test.stopLossATRMultiplier,
+				test.rewardRiskRatio,
+			)
+			if test.expectedErr != (err != nil) {
+				t.Errorf("unexpected error: [%v]", err)
+			}
+		})
+	}
+}
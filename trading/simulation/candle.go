@@ -0,0 +1,79 @@
+package simulation
+
+import (
+	"context"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sort"
+	"time"
+)
+
+// HistoricalCandleService serves candles from a fixed, previously recorded
+// set. Candles closing after the current time of the given clock are never
+// returned so the trading logic cannot look into the future. The set is
+// expected to be recorded using the interval of the workload and is not
+// resampled, the requested interval is used only to detect gaps.
+type HistoricalCandleService struct {
+	candles []*trading.Candle
+	clock   trading.Clock
+}
+
+func NewHistoricalCandleService(
+	candles []*trading.Candle,
+	clock trading.Clock,
+) *HistoricalCandleService {
+	sorted := make([]*trading.Candle, len(candles))
+	copy(sorted, candles)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OpenTime.Before(sorted[j].OpenTime)
+	})
+
+	return &HistoricalCandleService{
+		candles: sorted,
+		clock:   clock,
+	}
+}
+
+func (hcs *HistoricalCandleService) Candles(
+	ctx context.Context,
+	interval trading.CandleInterval,
+	start,
+	end time.Time,
+) (<-chan *trading.Candle, <-chan error) {
+	return trading.StreamCandles(ctx, interval, start, end, hcs.candlesPage)
+}
+
+// candlesPage returns all recorded candles of the time range at once as
+// they are already held in memory.
+func (hcs *HistoricalCandleService) candlesPage(
+	_ context.Context,
+	start,
+	end time.Time,
+) ([]*trading.Candle, error) {
+	now := hcs.clock.Now()
+
+	candles := make([]*trading.Candle, 0)
+
+	for _, candle := range hcs.candles {
+		if candle.OpenTime.Before(start) || candle.OpenTime.After(end) {
+			continue
+		}
+
+		if candle.CloseTime.After(now) {
+			continue
+		}
+
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+// CandlesTicker returns channels that never deliver anything. Historical
+// candles are pushed explicitly by the component driving the clock.
+func (hcs *HistoricalCandleService) CandlesTicker(
+	_ context.Context,
+	_ trading.CandleInterval,
+) (<-chan *trading.CandleTick, <-chan error) {
+	return make(chan *trading.CandleTick), make(chan error)
+}
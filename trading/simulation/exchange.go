@@ -0,0 +1,160 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
+	"time"
+)
+
+// ExchangeService is an exchange service which takes candles from the
+// underlying candle service but fills orders against a simulated wallet.
+// Orders are filled using the close price of the most recent candle the
+// service has been advanced to. The service doesn't follow the candles it
+// streams as they may be fetched on behalf of other workloads, e.g. by
+// the shared market data feed, which advances it instead. Orders violating
+// the trading rules are rejected the same way a real exchange would reject
+// them.
+type ExchangeService struct {
+	workload      *trading.Workload
+	candleService trading.ExchangeCandleService
+	tradingRules  *trading.TradingRules
+	wallet        *Wallet
+
+	mutex          sync.RWMutex
+	lastCandle     *trading.Candle
+	executedOrders map[string]bool
+}
+
+func NewExchangeService(
+	workload *trading.Workload,
+	candleService trading.ExchangeCandleService,
+	tradingRules *trading.TradingRules,
+	wallet *Wallet,
+) *ExchangeService {
+	return &ExchangeService{
+		workload:       workload,
+		candleService:  candleService,
+		tradingRules:   tradingRules,
+		wallet:         wallet,
+		executedOrders: make(map[string]bool),
+	}
+}
+
+func (es *ExchangeService) Workload() *trading.Workload {
+	return es.workload
+}
+
+// Advance makes the given candle the current market state.
+func (es *ExchangeService) Advance(candle *trading.Candle) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	es.lastCandle = candle
+}
+
+func (es *ExchangeService) Candles(
+	ctx context.Context,
+	interval trading.CandleInterval,
+	start,
+	end time.Time,
+) (<-chan *trading.Candle, <-chan error) {
+	return es.candleService.Candles(ctx, interval, start, end)
+}
+
+func (es *ExchangeService) CandlesTicker(
+	ctx context.Context,
+	interval trading.CandleInterval,
+) (<-chan *trading.CandleTick, <-chan error) {
+	return es.candleService.CandlesTicker(ctx, interval)
+}
+
+func (es *ExchangeService) AccountTakerCommission(
+	_ context.Context,
+) (trading.Decimal, error) {
+	return es.wallet.TakerCommission(), nil
+}
+
+func (es *ExchangeService) AccountBalances(
+	_ context.Context,
+) (trading.Balances, error) {
+	return es.wallet.Balances(), nil
+}
+
+func (es *ExchangeService) AccountShortSellingAllowed(
+	_ context.Context,
+) (bool, error) {
+	return es.wallet.ShortSellingAllowed(), nil
+}
+
+// AccountUpdates returns a channel which never reports errors as the
+// simulated wallet is always up to date.
+func (es *ExchangeService) AccountUpdates(
+	_ context.Context,
+) <-chan error {
+	return make(chan error)
+}
+
+func (es *ExchangeService) TradingRules(
+	_ context.Context,
+) (*trading.TradingRules, error) {
+	return es.tradingRules, nil
+}
+
+func (es *ExchangeService) ExecuteOrder(
+	_ context.Context,
+	order *trading.Order,
+) (bool, error) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	if es.executedOrders[order.ID.String()] {
+		return false, fmt.Errorf("order [%v] already executed", order.ID)
+	}
+
+	if es.lastCandle == nil {
+		return false, fmt.Errorf("market price is not known yet")
+	}
+
+	if err := es.tradingRules.Validate(order.Price, order.Size); err != nil {
+		return false, fmt.Errorf("order [%v] rejected: [%v]", order.ID, err)
+	}
+
+	marketPrice := es.lastCandle.ClosePrice
+
+	// Orders behave like fill or kill (FOK) limit orders: they are
+	// filled immediately at the limit price or not filled at all.
+	switch order.Side {
+	case trading.SideBuy:
+		if marketPrice.Cmp(order.Price) > 0 {
+			return false, nil
+		}
+	case trading.SideSell:
+		if marketPrice.Cmp(order.Price) < 0 {
+			return false, nil
+		}
+	}
+
+	settled, err := es.wallet.settle(
+		es.workload.Pair,
+		order.Side,
+		order.Price,
+		order.Size,
+	)
+	if settled {
+		es.executedOrders[order.ID.String()] = true
+	}
+
+	return settled, err
+}
+
+func (es *ExchangeService) IsOrderExecuted(
+	_ context.Context,
+	order *trading.Order,
+) (bool, error) {
+	es.mutex.RLock()
+	defer es.mutex.RUnlock()
+
+	return es.executedOrders[order.ID.String()], nil
+}
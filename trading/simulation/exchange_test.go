@@ -0,0 +1,144 @@
+package simulation_test
+
+import (
+	"context"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/inmem"
+	"github.com/lukasz-zimnoch/dexly/trading/logrus"
+	"github.com/lukasz-zimnoch/dexly/trading/simulation"
+	"github.com/lukasz-zimnoch/dexly/trading/uuid"
+	"testing"
+	"time"
+)
+
+func TestExchangeService_SharedMarketData(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	idService := &uuid.IDService{}
+
+	startTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC)
+	clock := trading.NewVirtualClock(startTime)
+
+	candles := make([]*trading.Candle, 0)
+	for i, price := range []int64{100, 101, 102} {
+		openTime := startTime.Add(time.Duration(i-3) * time.Minute)
+
+		candles = append(candles, &trading.Candle{
+			OpenTime:   openTime,
+			CloseTime:  openTime.Add(time.Minute - time.Millisecond),
+			ClosePrice: trading.NewDecimal(price, 0),
+		})
+	}
+
+	candleService := simulation.NewHistoricalCandleService(candles, clock)
+
+	hub := trading.NewMarketDataHub(
+		ctx,
+		func(windowSize int) trading.CandleRepository {
+			return inmem.NewCandleRepository(windowSize)
+		},
+		clock,
+		&trading.NoopMetrics{},
+		logrus.ConfigureStandardLogger("text", "panic"),
+	)
+
+	// Both workloads trade on the same candle series so the shared feed
+	// runs on the exchange service of only one of them. The larger window
+	// of the second workload makes it replace the feed of the first one.
+	exchangeServices := make([]*simulation.ExchangeService, 0)
+
+	for _, windowSize := range []int{2, 3} {
+		workload := &trading.Workload{
+			ID: idService.NewID(),
+			Account: &trading.Account{
+				ID:       idService.NewID(),
+				Exchange: "PAPER",
+			},
+			Pair:             trading.Pair{Base: "BTC", Quote: "USDT"},
+			CandleInterval:   trading.CandleInterval1m,
+			CandleWindowSize: windowSize,
+			Mode:             trading.ModePaper,
+		}
+
+		exchangeService := simulation.NewExchangeService(
+			workload,
+			candleService,
+			&trading.TradingRules{
+				PriceTick: trading.NewDecimal(1, -2),
+				LotStep:   trading.NewDecimal(1, -5),
+			},
+			simulation.NewWallet(
+				trading.Balances{"USDT": trading.NewDecimal(1000, 0)},
+				trading.NewDecimal(0, 0),
+			),
+		)
+
+		subscription := hub.Subscribe(
+			workload,
+			exchangeService,
+			exchangeService,
+		)
+		defer subscription.Close()
+
+		waitFor(t, subscription.UpToDate)
+
+		exchangeServices = append(exchangeServices, exchangeService)
+	}
+
+	tests := map[string]struct {
+		price          trading.Decimal
+		expectedFilled bool
+	}{
+		"buy at market price": {
+			price:          trading.NewDecimal(102, 0),
+			expectedFilled: true,
+		},
+		"buy below market price": {
+			price:          trading.NewDecimal(101, 0),
+			expectedFilled: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			for index, exchangeService := range exchangeServices {
+				filled, err := exchangeService.ExecuteOrder(
+					ctx,
+					&trading.Order{
+						ID:    idService.NewID(),
+						Side:  trading.SideBuy,
+						Price: test.price,
+						Size:  trading.NewDecimal(1, 0),
+					},
+				)
+				if err != nil {
+					t.Fatalf("exchange service [%v]: [%v]", index, err)
+				}
+
+				if filled != test.expectedFilled {
+					t.Errorf(
+						"unexpected fill of exchange service [%v]\n"+
+							"expected: [%v]\n"+
+							"actual:   [%v]",
+						index,
+						test.expectedFilled,
+						filled,
+					)
+				}
+			}
+		})
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
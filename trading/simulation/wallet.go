@@ -0,0 +1,184 @@
+package simulation
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"sync"
+)
+
+// WalletRepository persists balances of simulated wallets.
+type WalletRepository interface {
+	Balances(accountID trading.ID) (trading.Balances, error)
+
+	SaveBalances(accountID trading.ID, balances trading.Balances) error
+}
+
+// Wallet keeps balances of a simulated account. Taker commission is charged
+// in the quote asset on every filled order. If short selling is allowed,
+// the base asset balance can go below zero, i.e. the asset is borrowed.
+type Wallet struct {
+	mutex               sync.RWMutex
+	balances            trading.Balances
+	takerCommission     trading.Decimal
+	shortSellingAllowed bool
+
+	// Optional; if set, balances are saved after each settled trade.
+	repository WalletRepository
+	accountID  trading.ID
+}
+
+// LoadWallet loads the wallet of the given account from the repository.
+// If the account has no balances yet, the wallet is funded with the given
+// initial balances. All further balance changes are saved back to
+// the repository.
+func LoadWallet(
+	repository WalletRepository,
+	accountID trading.ID,
+	initialBalances trading.Balances,
+	takerCommission trading.Decimal,
+) (*Wallet, error) {
+	balances, err := repository.Balances(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load balances: [%v]", err)
+	}
+
+	if len(balances) == 0 {
+		balances = initialBalances
+
+		if err := repository.SaveBalances(accountID, balances); err != nil {
+			return nil, fmt.Errorf(
+				"could not save initial balances: [%v]",
+				err,
+			)
+		}
+	}
+
+	wallet := NewWallet(balances, takerCommission)
+	wallet.repository = repository
+	wallet.accountID = accountID
+
+	return wallet, nil
+}
+
+func NewWallet(
+	balances trading.Balances,
+	takerCommission trading.Decimal,
+) *Wallet {
+	wallet := &Wallet{
+		balances:        make(trading.Balances),
+		takerCommission: takerCommission,
+	}
+
+	for asset, balance := range balances {
+		wallet.balances[asset] = balance
+	}
+
+	return wallet
+}
+
+func (w *Wallet) Balances() trading.Balances {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	balances := make(trading.Balances)
+
+	for asset, balance := range w.balances {
+		balances[asset] = balance
+	}
+
+	return balances
+}
+
+func (w *Wallet) TakerCommission() trading.Decimal {
+	return w.takerCommission
+}
+
+func (w *Wallet) ShortSellingAllowed() bool {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return w.shortSellingAllowed
+}
+
+func (w *Wallet) SetShortSellingAllowed(allowed bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.shortSellingAllowed = allowed
+}
+
+// Fund sets the balance of the given asset unless the wallet already holds
+// it. It tells whether the asset has been funded.
+func (w *Wallet) Fund(
+	asset trading.Asset,
+	balance trading.Decimal,
+) (bool, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if _, exists := w.balances[asset]; exists {
+		return false, nil
+	}
+
+	w.balances[asset] = balance
+
+	if w.repository != nil {
+		err := w.repository.SaveBalances(w.accountID, w.balances)
+		if err != nil {
+			return true, fmt.Errorf("could not save balances: [%v]", err)
+		}
+	}
+
+	return true, nil
+}
+
+// settle applies the given trade to the wallet balances. It returns false
+// and leaves the balances untouched if the wallet cannot cover the trade.
+func (w *Wallet) settle(
+	pair trading.Pair,
+	side trading.OrderSide,
+	price trading.Decimal,
+	size trading.Decimal,
+) (bool, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	notional := price.Mul(size)
+	commission := notional.Mul(w.takerCommission)
+
+	baseBalance := w.balances.BalanceOf(pair.Base)
+	quoteBalance := w.balances.BalanceOf(pair.Quote)
+
+	switch side {
+	case trading.SideBuy:
+		cost := notional.Add(commission)
+		if quoteBalance.Cmp(cost) < 0 {
+			return false, nil
+		}
+
+		w.balances[pair.Quote] = quoteBalance.Sub(cost)
+		w.balances[pair.Base] = baseBalance.Add(size)
+	case trading.SideSell:
+		if baseBalance.Cmp(size) < 0 && !w.shortSellingAllowed {
+			return false, nil
+		}
+
+		proceeds := notional.Sub(commission)
+
+		w.balances[pair.Base] = baseBalance.Sub(size)
+		w.balances[pair.Quote] = quoteBalance.Add(proceeds)
+	default:
+		return false, nil
+	}
+
+	if w.repository != nil {
+		err := w.repository.SaveBalances(w.accountID, w.balances)
+		if err != nil {
+			// The trade is already settled in memory; report the error
+			// as the persisted balances are stale from now on.
+			return true, fmt.Errorf("could not save balances: [%v]", err)
+		}
+	}
+
+	return true, nil
+}
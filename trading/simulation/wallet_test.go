@@ -0,0 +1,60 @@
+package simulation_test
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/simulation"
+	"testing"
+)
+
+func TestWallet_Fund(t *testing.T) {
+	wallet := simulation.NewWallet(
+		trading.Balances{"USDT": trading.NewDecimal(500, 0)},
+		trading.NewDecimal(1, -3),
+	)
+
+	tests := []struct {
+		asset          trading.Asset
+		expectedFunded bool
+	}{
+		{asset: "USDT", expectedFunded: false},
+		{asset: "BTC", expectedFunded: true},
+		{asset: "BTC", expectedFunded: false},
+	}
+
+	for _, test := range tests {
+		funded, err := wallet.Fund(test.asset, trading.NewDecimal(1000, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if funded != test.expectedFunded {
+			t.Errorf(
+				"unexpected funding of [%v]\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				test.asset,
+				test.expectedFunded,
+				funded,
+			)
+		}
+	}
+
+	expectedBalances := trading.Balances{
+		"USDT": trading.NewDecimal(500, 0),
+		"BTC":  trading.NewDecimal(1000, 0),
+	}
+
+	balances := wallet.Balances()
+	for asset, expectedBalance := range expectedBalances {
+		if balances.BalanceOf(asset).Cmp(expectedBalance) != 0 {
+			t.Errorf(
+				"unexpected balance of [%v]\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				asset,
+				expectedBalance,
+				balances.BalanceOf(asset),
+			)
+		}
+	}
+}
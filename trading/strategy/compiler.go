@@ -0,0 +1,747 @@
+package strategy
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/indicator"
+	"regexp"
+)
+
+var (
+	strategyNamePattern  = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	indicatorNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// entryVariable is the name of the entry target in target formulas.
+const entryVariable = "entry"
+
+var candleVariables = map[string]func(candle *trading.Candle) trading.Decimal{
+	"open":   indicator.OpenPrice,
+	"close":  indicator.ClosePrice,
+	"max":    indicator.MaxPrice,
+	"min":    indicator.MinPrice,
+	"volume": indicator.Volume,
+}
+
+var sources = map[string]indicator.Source{
+	"":        indicator.ClosePrice,
+	"open":    indicator.OpenPrice,
+	"close":   indicator.ClosePrice,
+	"max":     indicator.MaxPrice,
+	"min":     indicator.MinPrice,
+	"volume":  indicator.Volume,
+	"typical": indicator.TypicalPrice,
+}
+
+// SpecSignalGenerator generates signals of a strategy declared by a spec.
+type SpecSignalGenerator struct {
+	strategy   trading.StrategyRef
+	indicators []*compiledIndicator
+	long       *compiledSignal
+	short      *compiledSignal
+	logger     trading.Logger
+}
+
+// Compile validates the spec and turns it into a signal generator.
+func Compile(spec *Spec, logger trading.Logger) (*SpecSignalGenerator, error) {
+	if !strategyNamePattern.MatchString(spec.Name) {
+		return nil, fmt.Errorf("invalid strategy name [%v]", spec.Name)
+	}
+
+	if spec.Version < 1 {
+		return nil, fmt.Errorf("strategy version must be positive")
+	}
+
+	if spec.Long == nil && spec.Short == nil {
+		return nil, fmt.Errorf("long or short signal must be specified")
+	}
+
+	c := &compiler{
+		variables: make(
+			map[string]func(s *session, index int) (trading.Decimal, bool),
+		),
+	}
+
+	for name, value := range candleVariables {
+		value := value
+
+		c.variables[name] = func(s *session, index int) (
+			trading.Decimal,
+			bool,
+		) {
+			return value(s.candles[index]), true
+		}
+	}
+
+	c.variables["price"] = func(s *session, _ int) (trading.Decimal, bool) {
+		return s.candles[len(s.candles)-1].ClosePrice, true
+	}
+
+	for _, indicatorSpec := range spec.Indicators {
+		if err := c.addIndicator(indicatorSpec); err != nil {
+			return nil, fmt.Errorf(
+				"invalid indicator [%v]: [%v]",
+				indicatorSpec.Name,
+				err,
+			)
+		}
+	}
+
+	signalGenerator := &SpecSignalGenerator{
+		strategy: trading.StrategyRef{
+			Name:    spec.Name,
+			Version: spec.Version,
+		},
+		indicators: c.indicators,
+		logger:     logger,
+	}
+
+	if spec.Long != nil {
+		long, err := c.compileSignal(spec.Long)
+		if err != nil {
+			return nil, fmt.Errorf("invalid long signal: [%v]", err)
+		}
+
+		signalGenerator.long = long
+	}
+
+	if spec.Short != nil {
+		short, err := c.compileSignal(spec.Short)
+		if err != nil {
+			return nil, fmt.Errorf("invalid short signal: [%v]", err)
+		}
+
+		signalGenerator.short = short
+	}
+
+	return signalGenerator, nil
+}
+
+// Strategy returns the strategy the generator was compiled from.
+func (ssg *SpecSignalGenerator) Strategy() trading.StrategyRef {
+	return ssg.strategy
+}
+
+// Evaluate evaluates the candles using a new session so the indicators are
+// computed from the whole window.
+func (ssg *SpecSignalGenerator) Evaluate(
+	candles []*trading.Candle,
+) (*trading.Signal, bool) {
+	return ssg.NewSession().Evaluate(candles)
+}
+
+// NewSession creates a generator which keeps the indicators of a candle
+// series between evaluations.
+func (ssg *SpecSignalGenerator) NewSession() trading.SignalGenerator {
+	return &session{signalGenerator: ssg}
+}
+
+// compiledIndicator creates instances of an indicator and extracts its
+// outputs. The first output is the value of the indicator.
+type compiledIndicator struct {
+	create  func() indicator.Indicator
+	outputs []func(instance indicator.Indicator) trading.Decimal
+}
+
+type compiledSignal struct {
+	rule       rule
+	entry      expression
+	stopLoss   expression
+	takeProfit expression
+	// atr is set only if targets are derived from the ATR.
+	atr expression
+}
+
+func (cs *compiledSignal) evaluate(
+	s *session,
+	index int,
+	positionType trading.PositionType,
+) (*trading.Signal, bool) {
+	holds, ok := cs.rule.holds(s, index)
+	if !ok || !holds {
+		return nil, false
+	}
+
+	entry, ok := cs.entry.evaluate(s, index)
+	if !ok {
+		return nil, false
+	}
+
+	s.entry = entry
+
+	if cs.atr != nil {
+		atr, ok := cs.atr.evaluate(s, index)
+		if !ok || atr.Sign() <= 0 {
+			return nil, false
+		}
+
+		return trading.NewATRSignal(positionType, entry, atr), true
+	}
+
+	signal := &trading.Signal{
+		Type:        positionType,
+		EntryTarget: entry,
+	}
+
+	signal.StopLossTarget, ok = cs.stopLoss.evaluate(s, index)
+	if !ok {
+		return nil, false
+	}
+
+	signal.TakeProfitTarget, ok = cs.takeProfit.evaluate(s, index)
+	if !ok {
+		return nil, false
+	}
+
+	if err := signal.ValidateTargets(); err != nil {
+		s.signalGenerator.logger.Debugf(
+			"strategy [%v] dropped signal [%v]: [%v]",
+			s.signalGenerator.strategy,
+			signal,
+			err,
+		)
+		return nil, false
+	}
+
+	return signal, true
+}
+
+// session keeps indicators of a candle series between evaluations. Stable
+// candles are appended to them once, as they appear in evaluated windows,
+// and their values are kept for the stable candles of the current window.
+type session struct {
+	signalGenerator *SpecSignalGenerator
+	series          series
+	instances       []indicator.Indicator
+	// known is the number of stable candles whose values are kept.
+	known int
+
+	candles []*trading.Candle
+	// values are indexed by the indicator, its output and the candle.
+	values [][][]trading.Decimal
+	// ready tells whether the indicator was ready at the given candle.
+	ready [][]bool
+	// entry is the entry target of the signal whose targets are being
+	// evaluated.
+	entry trading.Decimal
+}
+
+func (s *session) Evaluate(
+	candles []*trading.Candle,
+) (*trading.Signal, bool) {
+	// The last candle is not yet stable as its prices change, so rules
+	// are checked against the candle preceding it.
+	if len(candles) < 2 {
+		return nil, false
+	}
+
+	s.update(candles)
+	index := len(candles) - 2
+
+	ssg := s.signalGenerator
+
+	for _, candidate := range []struct {
+		positionType trading.PositionType
+		signal       *compiledSignal
+	}{
+		{trading.TypeLong, ssg.long},
+		{trading.TypeShort, ssg.short},
+	} {
+		if candidate.signal == nil {
+			continue
+		}
+
+		signal, exists := candidate.signal.evaluate(
+			s,
+			index,
+			candidate.positionType,
+		)
+		if exists {
+			ssg.logger.Debugf(
+				"strategy [%v] generated signal [%v]",
+				ssg.strategy,
+				signal,
+			)
+			return signal, true
+		}
+	}
+
+	return nil, false
+}
+
+// update appends stable candles which haven't been appended yet to the
+// indicators. Indicators are reset if values of some stable candles of the
+// window aren't known, e.g. the window has been rebuilt.
+func (s *session) update(candles []*trading.Candle) {
+	s.candles = candles
+
+	stableCandles := candles[:len(candles)-1]
+
+	pending, reset := s.series.advance(stableCandles)
+	if reset || s.known+len(pending) < len(stableCandles) {
+		s.reset()
+		pending = stableCandles
+	}
+
+	for _, candle := range pending {
+		for i, instance := range s.instances {
+			instance.Append(candle)
+
+			ready := instance.Ready()
+			s.ready[i] = append(s.ready[i], ready)
+
+			for j, output := range s.signalGenerator.indicators[i].outputs {
+				var value trading.Decimal
+				if ready {
+					value = output(instance)
+				}
+
+				s.values[i][j] = append(s.values[i][j], value)
+			}
+		}
+	}
+
+	s.known += len(pending)
+
+	// Values of candles which are no longer part of the window are
+	// dropped so they are aligned with the candles.
+	if excess := s.known - len(stableCandles); excess > 0 {
+		for i := range s.instances {
+			s.ready[i] = s.ready[i][excess:]
+
+			for j := range s.values[i] {
+				s.values[i][j] = s.values[i][j][excess:]
+			}
+		}
+
+		s.known = len(stableCandles)
+	}
+}
+
+func (s *session) reset() {
+	indicators := s.signalGenerator.indicators
+
+	s.instances = make([]indicator.Indicator, len(indicators))
+	s.values = make([][][]trading.Decimal, len(indicators))
+	s.ready = make([][]bool, len(indicators))
+	s.known = 0
+
+	for i, compiled := range indicators {
+		s.instances[i] = compiled.create()
+		s.values[i] = make([][]trading.Decimal, len(compiled.outputs))
+		s.ready[i] = make([]bool, 0)
+	}
+}
+
+type compiler struct {
+	indicators []*compiledIndicator
+	variables  map[string]func(s *session, index int) (trading.Decimal, bool)
+}
+
+func (c *compiler) addIndicator(spec *IndicatorSpec) error {
+	if !indicatorNamePattern.MatchString(spec.Name) {
+		return fmt.Errorf("invalid name")
+	}
+
+	if _, exists := c.variables[spec.Name]; exists ||
+		spec.Name == entryVariable {
+		return fmt.Errorf("name already in use")
+	}
+
+	compiled, outputNames, err := compileIndicator(spec)
+	if err != nil {
+		return err
+	}
+
+	indicatorIndex := len(c.indicators)
+	c.indicators = append(c.indicators, compiled)
+
+	for outputIndex, outputName := range outputNames {
+		outputIndex := outputIndex
+
+		name := spec.Name
+		if len(outputName) > 0 {
+			name += "." + outputName
+		}
+
+		c.variables[name] = func(s *session, index int) (
+			trading.Decimal,
+			bool,
+		) {
+			if index >= len(s.ready[indicatorIndex]) ||
+				!s.ready[indicatorIndex][index] {
+				return trading.Decimal{}, false
+			}
+
+			return s.values[indicatorIndex][outputIndex][index], true
+		}
+	}
+
+	return nil
+}
+
+// compileIndicator returns the indicator along with names of its outputs.
+// The value of the indicator has an empty name.
+func compileIndicator(
+	spec *IndicatorSpec,
+) (*compiledIndicator, []string, error) {
+	source, exists := sources[spec.Source]
+	if !exists {
+		return nil, nil, fmt.Errorf("unknown source [%v]", spec.Source)
+	}
+
+	value := func(instance indicator.Indicator) trading.Decimal {
+		return instance.Value()
+	}
+
+	period := spec.Period
+
+	switch spec.Type {
+	case "SMA", "EMA", "WMA", "RSI", "ATR":
+		if err := requirePositive("period", period); err != nil {
+			return nil, nil, err
+		}
+
+		constructors := map[string]func() indicator.Indicator{
+			"SMA": func() indicator.Indicator {
+				return indicator.NewSMA(period, source)
+			},
+			"EMA": func() indicator.Indicator {
+				return indicator.NewEMA(period, source)
+			},
+			"WMA": func() indicator.Indicator {
+				return indicator.NewWMA(period, source)
+			},
+			"RSI": func() indicator.Indicator {
+				return indicator.NewRSI(period, source)
+			},
+			"ATR": func() indicator.Indicator {
+				return indicator.NewATR(period)
+			},
+		}
+
+		return &compiledIndicator{
+			create: constructors[spec.Type],
+			outputs: []func(indicator.Indicator) trading.Decimal{
+				value,
+			},
+		}, []string{""}, nil
+	case "MACD":
+		for _, macdPeriod := range []struct {
+			name  string
+			value int
+		}{
+			{"fast period", spec.FastPeriod},
+			{"slow period", spec.SlowPeriod},
+			{"signal period", spec.SignalPeriod},
+		} {
+			err := requirePositive(macdPeriod.name, macdPeriod.value)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		return &compiledIndicator{
+			create: func() indicator.Indicator {
+				return indicator.NewMACD(
+					spec.FastPeriod,
+					spec.SlowPeriod,
+					spec.SignalPeriod,
+					source,
+				)
+			},
+			outputs: []func(indicator.Indicator) trading.Decimal{
+				value,
+				func(instance indicator.Indicator) trading.Decimal {
+					return instance.(*indicator.MACD).Signal()
+				},
+				func(instance indicator.Indicator) trading.Decimal {
+					return instance.(*indicator.MACD).Histogram()
+				},
+			},
+		}, []string{"", "signal", "histogram"}, nil
+	case "STOCHASTIC":
+		if err := requirePositive("period", period); err != nil {
+			return nil, nil, err
+		}
+
+		err := requirePositive("smoothing period", spec.SmoothingPeriod)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &compiledIndicator{
+			create: func() indicator.Indicator {
+				return indicator.NewStochastic(period, spec.SmoothingPeriod)
+			},
+			outputs: []func(indicator.Indicator) trading.Decimal{
+				value,
+				func(instance indicator.Indicator) trading.Decimal {
+					return instance.(*indicator.Stochastic).D()
+				},
+			},
+		}, []string{"", "d"}, nil
+	case "BOLLINGER":
+		if err := requirePositive("period", period); err != nil {
+			return nil, nil, err
+		}
+
+		multiplier, err := trading.ParseDecimal(spec.Multiplier)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if multiplier.Sign() <= 0 {
+			return nil, nil, fmt.Errorf("multiplier must be positive")
+		}
+
+		return &compiledIndicator{
+			create: func() indicator.Indicator {
+				return indicator.NewBollinger(period, multiplier, source)
+			},
+			outputs: []func(indicator.Indicator) trading.Decimal{
+				value,
+				func(instance indicator.Indicator) trading.Decimal {
+					return instance.(*indicator.Bollinger).Upper()
+				},
+				func(instance indicator.Indicator) trading.Decimal {
+					return instance.(*indicator.Bollinger).Lower()
+				},
+				func(instance indicator.Indicator) trading.Decimal {
+					return instance.(*indicator.Bollinger).Deviation()
+				},
+			},
+		}, []string{"", "upper", "lower", "deviation"}, nil
+	case "VWAP":
+		if period < 0 {
+			return nil, nil, fmt.Errorf("period must not be negative")
+		}
+
+		return &compiledIndicator{
+			create: func() indicator.Indicator {
+				return indicator.NewVWAP(period)
+			},
+			outputs: []func(indicator.Indicator) trading.Decimal{
+				value,
+			},
+		}, []string{""}, nil
+	case "OBV":
+		return &compiledIndicator{
+			create: func() indicator.Indicator {
+				return indicator.NewOBV()
+			},
+			outputs: []func(indicator.Indicator) trading.Decimal{
+				value,
+			},
+		}, []string{""}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown type [%v]", spec.Type)
+	}
+}
+
+func requirePositive(name string, value int) error {
+	if value < 1 {
+		return fmt.Errorf("%v must be positive", name)
+	}
+
+	return nil
+}
+
+func (c *compiler) compileSignal(spec *SignalSpec) (*compiledSignal, error) {
+	if spec.When == nil {
+		return nil, fmt.Errorf("rule must be specified")
+	}
+
+	rule, err := c.compileRule(spec.When)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule: [%v]", err)
+	}
+
+	compiled := &compiledSignal{rule: rule}
+
+	entryFormula := spec.Entry
+	if len(entryFormula) == 0 {
+		entryFormula = "price"
+	}
+
+	compiled.entry, err = c.compileFormula(entryFormula, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry formula: [%v]", err)
+	}
+
+	if len(spec.ATR) > 0 {
+		if len(spec.StopLoss) > 0 || len(spec.TakeProfit) > 0 {
+			return nil, fmt.Errorf(
+				"targets must be given either by ATR or by formulas",
+			)
+		}
+
+		compiled.atr, err = c.compileFormula(spec.ATR, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ATR formula: [%v]", err)
+		}
+
+		return compiled, nil
+	}
+
+	if len(spec.StopLoss) == 0 || len(spec.TakeProfit) == 0 {
+		return nil, fmt.Errorf(
+			"ATR or stop loss and take profit formulas must be specified",
+		)
+	}
+
+	compiled.stopLoss, err = c.compileFormula(spec.StopLoss, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stop loss formula: [%v]", err)
+	}
+
+	compiled.takeProfit, err = c.compileFormula(spec.TakeProfit, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid take profit formula: [%v]", err)
+	}
+
+	return compiled, nil
+}
+
+func (c *compiler) compileRule(spec *RuleSpec) (rule, error) {
+	set := 0
+	for _, isSet := range []bool{
+		spec.CrossUp != nil,
+		spec.CrossDown != nil,
+		spec.Threshold != nil,
+		spec.And != nil,
+		spec.Or != nil,
+		spec.Not != nil,
+		spec.Confirm != nil,
+	} {
+		if isSet {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one condition must be specified")
+	}
+
+	switch {
+	case spec.CrossUp != nil:
+		return c.compileCross(spec.CrossUp, 1)
+	case spec.CrossDown != nil:
+		return c.compileCross(spec.CrossDown, -1)
+	case spec.Threshold != nil:
+		return c.compileThreshold(spec.Threshold)
+	case spec.And != nil:
+		rules, err := c.compileRules(spec.And)
+		if err != nil {
+			return nil, fmt.Errorf("invalid and condition: [%v]", err)
+		}
+
+		return &andRule{rules}, nil
+	case spec.Or != nil:
+		rules, err := c.compileRules(spec.Or)
+		if err != nil {
+			return nil, fmt.Errorf("invalid or condition: [%v]", err)
+		}
+
+		return &orRule{rules}, nil
+	case spec.Not != nil:
+		rule, err := c.compileRule(spec.Not)
+		if err != nil {
+			return nil, fmt.Errorf("invalid not condition: [%v]", err)
+		}
+
+		return &notRule{rule}, nil
+	default:
+		if spec.Confirm.Candles < 1 {
+			return nil, fmt.Errorf("confirmation candles must be positive")
+		}
+
+		if spec.Confirm.Rule == nil {
+			return nil, fmt.Errorf("confirmed rule must be specified")
+		}
+
+		rule, err := c.compileRule(spec.Confirm.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid confirm condition: [%v]", err)
+		}
+
+		return &confirmRule{rule, spec.Confirm.Candles}, nil
+	}
+}
+
+func (c *compiler) compileRules(specs []*RuleSpec) ([]rule, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("rules must be specified")
+	}
+
+	rules := make([]rule, len(specs))
+
+	for i, spec := range specs {
+		rule, err := c.compileRule(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		rules[i] = rule
+	}
+
+	return rules, nil
+}
+
+func (c *compiler) compileCross(spec *CrossSpec, cmp int) (rule, error) {
+	value, err := c.compileFormula(spec.Value, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cross value: [%v]", err)
+	}
+
+	reference, err := c.compileFormula(spec.Reference, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cross reference: [%v]", err)
+	}
+
+	return &crossRule{value, reference, cmp}, nil
+}
+
+func (c *compiler) compileThreshold(spec *ThresholdSpec) (rule, error) {
+	value, err := c.compileFormula(spec.Value, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold value: [%v]", err)
+	}
+
+	if (len(spec.Above) > 0) == (len(spec.Below) > 0) {
+		return nil, fmt.Errorf("exactly one threshold must be specified")
+	}
+
+	formula, cmp := spec.Above, 1
+	if len(spec.Below) > 0 {
+		formula, cmp = spec.Below, -1
+	}
+
+	threshold, err := c.compileFormula(formula, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold: [%v]", err)
+	}
+
+	return &thresholdRule{value, threshold, cmp}, nil
+}
+
+// compileFormula compiles the formula which may refer to the entry target
+// if it's a target formula.
+func (c *compiler) compileFormula(
+	formula string,
+	target bool,
+) (expression, error) {
+	return parseExpression(formula, func(name string) (
+		func(s *session, index int) (trading.Decimal, bool),
+		bool,
+	) {
+		if target && name == entryVariable {
+			return func(s *session, _ int) (trading.Decimal, bool) {
+				return s.entry, true
+			}, true
+		}
+
+		resolve, exists := c.variables[name]
+		return resolve, exists
+	})
+}
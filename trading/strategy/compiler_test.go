@@ -0,0 +1,485 @@
+package strategy
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"testing"
+)
+
+func TestCompile_Invalid(t *testing.T) {
+	tests := map[string]func(spec *Spec){
+		"invalid name": func(spec *Spec) {
+			spec.Name = "ema cross"
+		},
+		"missing version": func(spec *Spec) {
+			spec.Version = 0
+		},
+		"no signals": func(spec *Spec) {
+			spec.Long, spec.Short = nil, nil
+		},
+		"invalid indicator name": func(spec *Spec) {
+			spec.Indicators[0].Name = "ema.fast"
+		},
+		"duplicated indicator name": func(spec *Spec) {
+			spec.Indicators[1].Name = "ema"
+		},
+		"reserved indicator name": func(spec *Spec) {
+			spec.Indicators[0].Name = "close"
+		},
+		"unknown indicator type": func(spec *Spec) {
+			spec.Indicators[0].Type = "KAMA"
+		},
+		"unknown indicator source": func(spec *Spec) {
+			spec.Indicators[0].Source = "median"
+		},
+		"missing indicator period": func(spec *Spec) {
+			spec.Indicators[0].Period = 0
+		},
+		"missing macd period": func(spec *Spec) {
+			spec.Indicators[0] = &IndicatorSpec{
+				Name:       "ema",
+				Type:       "MACD",
+				FastPeriod: 12,
+				SlowPeriod: 26,
+			}
+		},
+		"invalid bollinger multiplier": func(spec *Spec) {
+			spec.Indicators[0] = &IndicatorSpec{
+				Name:       "ema",
+				Type:       "BOLLINGER",
+				Period:     20,
+				Multiplier: "-2",
+			}
+		},
+		"missing rule": func(spec *Spec) {
+			spec.Long.When = nil
+		},
+		"empty rule": func(spec *Spec) {
+			spec.Long.When = &RuleSpec{}
+		},
+		"ambiguous rule": func(spec *Spec) {
+			spec.Long.When.CrossDown = spec.Long.When.CrossUp
+		},
+		"unknown variable": func(spec *Spec) {
+			spec.Long.When.CrossUp.Reference = "sma"
+		},
+		"entry outside targets": func(spec *Spec) {
+			spec.Long.When.CrossUp.Reference = "entry"
+		},
+		"empty and": func(spec *Spec) {
+			spec.Long.When = &RuleSpec{And: []*RuleSpec{}}
+		},
+		"ambiguous threshold": func(spec *Spec) {
+			spec.Long.When = &RuleSpec{
+				Threshold: &ThresholdSpec{
+					Value: "close",
+					Above: "1",
+					Below: "2",
+				},
+			}
+		},
+		"missing confirmation candles": func(spec *Spec) {
+			spec.Long.When = &RuleSpec{
+				Confirm: &ConfirmSpec{Rule: spec.Long.When},
+			}
+		},
+		"invalid entry": func(spec *Spec) {
+			spec.Long.Entry = "price *"
+		},
+		"missing targets": func(spec *Spec) {
+			spec.Long.ATR = ""
+		},
+		"missing take profit": func(spec *Spec) {
+			spec.Long.ATR = ""
+			spec.Long.StopLoss = "entry - atr"
+		},
+		"ambiguous targets": func(spec *Spec) {
+			spec.Long.StopLoss = "entry - atr"
+			spec.Long.TakeProfit = "entry + atr"
+		},
+	}
+
+	for testName, modify := range tests {
+		t.Run(testName, func(t *testing.T) {
+			spec := newTestSpec()
+			modify(spec)
+
+			if _, err := Compile(spec, &testLogger{}); err == nil {
+				t.Errorf("invalid spec has been compiled")
+			}
+		})
+	}
+}
+
+func TestSpecSignalGenerator_EMACross(t *testing.T) {
+	tests := map[string][]int64{
+		"cross up":      {98, 102, 103},
+		"cross down":    {102, 98, 97},
+		"above average": {102, 103, 97},
+	}
+
+	specSignalGenerator, err := Compile(newTestSpec(), &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emaSignalGenerator := NewEMASignalGenerator(&testLogger{})
+
+	for testName, lastCloses := range tests {
+		t.Run(testName, func(t *testing.T) {
+			closes := make([]int64, emaPeriod-1)
+			for i := range closes {
+				closes[i] = 100
+			}
+
+			candles := newCandles(append(closes, lastCloses...)...)
+
+			expected, expectedExists := emaSignalGenerator.Evaluate(candles)
+			actual, actualExists := specSignalGenerator.Evaluate(candles)
+
+			if actualExists != expectedExists {
+				t.Fatalf(
+					"unexpected signal existence\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					expectedExists,
+					actualExists,
+				)
+			}
+
+			if actualExists && actual.String() != expected.String() {
+				t.Errorf(
+					"unexpected signal\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					expected,
+					actual,
+				)
+			}
+		})
+	}
+}
+
+func TestSpecSignalGenerator_Rules(t *testing.T) {
+	above100 := &RuleSpec{
+		Threshold: &ThresholdSpec{Value: "close", Above: "100"},
+	}
+	below90 := &RuleSpec{
+		Threshold: &ThresholdSpec{Value: "close", Below: "90"},
+	}
+
+	tests := map[string]struct {
+		rule *RuleSpec
+		// The last close belongs to the unstable candle which is not
+		// checked by rules.
+		closes         []int64
+		expectedExists bool
+	}{
+		"threshold holds": {
+			rule:           above100,
+			closes:         []int64{95, 101, 95},
+			expectedExists: true,
+		},
+		"threshold doesn't hold": {
+			rule:           above100,
+			closes:         []int64{101, 100, 101},
+			expectedExists: false,
+		},
+		"confirmed": {
+			rule: &RuleSpec{
+				Confirm: &ConfirmSpec{Candles: 2, Rule: above100},
+			},
+			closes:         []int64{101, 102, 95},
+			expectedExists: true,
+		},
+		"not confirmed": {
+			rule: &RuleSpec{
+				Confirm: &ConfirmSpec{Candles: 3, Rule: above100},
+			},
+			closes:         []int64{95, 101, 102, 95},
+			expectedExists: false,
+		},
+		"confirmation exceeding candles": {
+			rule: &RuleSpec{
+				Confirm: &ConfirmSpec{Candles: 3, Rule: above100},
+			},
+			closes:         []int64{101, 102, 95},
+			expectedExists: false,
+		},
+		"and": {
+			rule: &RuleSpec{And: []*RuleSpec{
+				above100,
+				{Not: below90},
+			}},
+			closes:         []int64{101, 95},
+			expectedExists: true,
+		},
+		"or": {
+			rule:           &RuleSpec{Or: []*RuleSpec{above100, below90}},
+			closes:         []int64{89, 95},
+			expectedExists: true,
+		},
+		"not": {
+			rule:           &RuleSpec{Not: above100},
+			closes:         []int64{101, 95},
+			expectedExists: false,
+		},
+		"cross up": {
+			rule: &RuleSpec{
+				CrossUp: &CrossSpec{Value: "close", Reference: "sma"},
+			},
+			closes:         []int64{100, 100, 99, 102, 95},
+			expectedExists: true,
+		},
+		"cross down": {
+			rule: &RuleSpec{
+				CrossDown: &CrossSpec{Value: "close", Reference: "sma"},
+			},
+			closes:         []int64{100, 100, 99, 102, 95},
+			expectedExists: false,
+		},
+		// The average is known for the checked candle but not for the
+		// previous one.
+		"cross before indicator is ready": {
+			rule: &RuleSpec{
+				CrossUp: &CrossSpec{Value: "close", Reference: "sma"},
+			},
+			closes:         []int64{100, 99, 102, 95},
+			expectedExists: false,
+		},
+		// Negation doesn't trigger signals if the rule can't be checked.
+		"negated rule before indicator is ready": {
+			rule: &RuleSpec{Not: &RuleSpec{
+				Threshold: &ThresholdSpec{Value: "close", Above: "sma"},
+			}},
+			closes:         []int64{100, 95},
+			expectedExists: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			signalGenerator, err := Compile(
+				&Spec{
+					Name:    "rules",
+					Version: 1,
+					Indicators: []*IndicatorSpec{
+						{Name: "sma", Type: "SMA", Period: 3},
+					},
+					Long: &SignalSpec{
+						When:       test.rule,
+						StopLoss:   "entry - 1",
+						TakeProfit: "entry + 2",
+					},
+				},
+				&testLogger{},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, exists := signalGenerator.Evaluate(newCandles(test.closes...))
+
+			if exists != test.expectedExists {
+				t.Errorf(
+					"unexpected signal existence\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedExists,
+					exists,
+				)
+			}
+		})
+	}
+}
+
+func TestSpecSignalGenerator_Targets(t *testing.T) {
+	signalGenerator, err := Compile(
+		&Spec{
+			Name:    "targets",
+			Version: 1,
+			Indicators: []*IndicatorSpec{
+				{Name: "bands", Type: "BOLLINGER", Period: 2, Multiplier: "2"},
+			},
+			Short: &SignalSpec{
+				When: &RuleSpec{
+					Threshold: &ThresholdSpec{Value: "close", Above: "100"},
+				},
+				Entry:      "(close + price) / 2",
+				StopLoss:   "bands.upper",
+				TakeProfit: "entry - 2 * bands.deviation",
+			},
+		},
+		&testLogger{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signal, exists := signalGenerator.Evaluate(newCandles(100, 104, 98))
+	if !exists {
+		t.Fatalf("signal has not been generated")
+	}
+
+	// The bands of closes 100 and 104 have the middle at 102 and the
+	// deviation of 2.
+	expected := &trading.Signal{
+		Type:             trading.TypeShort,
+		EntryTarget:      trading.NewDecimal(101, 0),
+		StopLossTarget:   trading.NewDecimal(106, 0),
+		TakeProfitTarget: trading.NewDecimal(97, 0),
+	}
+
+	if signal.String() != expected.String() {
+		t.Errorf(
+			"unexpected signal\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expected,
+			signal,
+		)
+	}
+}
+
+func TestSpecSignalGenerator_MisplacedTargets(t *testing.T) {
+	tests := map[string]struct {
+		stopLoss   string
+		takeProfit string
+	}{
+		"take profit below entry": {
+			stopLoss:   "close * 0.95",
+			takeProfit: "close * 0.99",
+		},
+		"stop loss above entry": {
+			stopLoss:   "close * 1.01",
+			takeProfit: "close * 1.1",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			signalGenerator, err := Compile(
+				&Spec{
+					Name:    "targets",
+					Version: 1,
+					Long: &SignalSpec{
+						When: &RuleSpec{
+							Threshold: &ThresholdSpec{
+								Value: "close",
+								Above: "100",
+							},
+						},
+						StopLoss:   test.stopLoss,
+						TakeProfit: test.takeProfit,
+					},
+				},
+				&testLogger{},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The entry is the price of the unstable candle which is
+			// the same as the close of the checked one.
+			signal, exists := signalGenerator.Evaluate(
+				newCandles(95, 101, 101),
+			)
+			if exists {
+				t.Errorf("unexpected signal: [%v]", signal)
+			}
+		})
+	}
+}
+
+func TestSpecSignalGenerator_NewSession(t *testing.T) {
+	// Rules compare values of several consecutive candles so values of
+	// former candles have to be kept along with the indicators.
+	confirmedCross := func(
+		cross *CrossSpec,
+		threshold *ThresholdSpec,
+	) *RuleSpec {
+		return &RuleSpec{
+			And: []*RuleSpec{
+				{CrossUp: cross},
+				{Confirm: &ConfirmSpec{
+					Candles: 3,
+					Rule:    &RuleSpec{Threshold: threshold},
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		spec    *Spec
+		windows func(candles []*trading.Candle) [][]*trading.Candle
+	}{
+		"growing window": {
+			spec: newTestSpec(),
+			windows: func(candles []*trading.Candle) [][]*trading.Candle {
+				return growingWindows(candles, 60)
+			},
+		},
+		// Values of the simple average don't depend on candles preceding
+		// the window so they are equal to the ones of the whole window.
+		"sliding window": {
+			spec: &Spec{
+				Name:    "sma-cross",
+				Version: 1,
+				Indicators: []*IndicatorSpec{
+					{Name: "sma", Type: "SMA", Period: 10},
+				},
+				Long: &SignalSpec{
+					When: confirmedCross(
+						&CrossSpec{Value: "close", Reference: "sma"},
+						&ThresholdSpec{Value: "sma", Above: "90"},
+					),
+					StopLoss:   "entry - 1",
+					TakeProfit: "entry + 2",
+				},
+			},
+			windows: func(candles []*trading.Candle) [][]*trading.Candle {
+				return slidingWindows(candles, 30)
+			},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			signalGenerator, err := Compile(test.spec, &testLogger{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertSessionEvaluations(
+				t,
+				signalGenerator,
+				signalGenerator.NewSession(),
+				test.windows(newCandles(oscillatingCloses(150)...)),
+			)
+		})
+	}
+}
+
+// newTestSpec returns the spec of the EMA cross strategy which is
+// equivalent to the built-in EMA strategy.
+func newTestSpec() *Spec {
+	return &Spec{
+		Name:    "ema-cross",
+		Version: 1,
+		Indicators: []*IndicatorSpec{
+			{Name: "ema", Type: "EMA", Period: emaPeriod},
+			{Name: "atr", Type: "ATR", Period: atrPeriod},
+		},
+		Long: &SignalSpec{
+			When: &RuleSpec{
+				CrossUp: &CrossSpec{Value: "close", Reference: "ema"},
+			},
+			ATR: "atr",
+		},
+		Short: &SignalSpec{
+			When: &RuleSpec{
+				CrossDown: &CrossSpec{Value: "close", Reference: "ema"},
+			},
+			ATR: "atr",
+		},
+	}
+}
@@ -0,0 +1,165 @@
+package strategy
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"github.com/lukasz-zimnoch/dexly/trading/indicator"
+	"strings"
+)
+
+const (
+	emaPeriod = 50
+	atrPeriod = 14
+)
+
+// EMASignalGenerator emits signals once the close price crosses its
+// exponential moving average or touches it. Targets are placed according
+// to the average true range so they follow the market volatility.
+type EMASignalGenerator struct {
+	logger trading.Logger
+}
+
+func NewEMASignalGenerator(logger trading.Logger) *EMASignalGenerator {
+	return &EMASignalGenerator{logger}
+}
+
+// Evaluate evaluates the candles using a new session so the indicators are
+// computed from the whole window.
+func (esg *EMASignalGenerator) Evaluate(
+	candles []*trading.Candle,
+) (*trading.Signal, bool) {
+	return esg.NewSession().Evaluate(candles)
+}
+
+// NewSession creates a generator which keeps the indicators of a candle
+// series between evaluations.
+func (esg *EMASignalGenerator) NewSession() trading.SignalGenerator {
+	session := &emaSession{logger: esg.logger}
+	session.reset()
+
+	return session
+}
+
+// emaSession appends candles to its indicators once they become stable.
+type emaSession struct {
+	series series
+	ema    *indicator.EMA
+	atr    *indicator.ATR
+	// emas are the averages of the two most recent stable candles, once
+	// the average is ready.
+	emas   []trading.Decimal
+	logger trading.Logger
+}
+
+func (es *emaSession) reset() {
+	es.ema = indicator.NewEMA(emaPeriod, indicator.ClosePrice)
+	es.atr = indicator.NewATR(atrPeriod)
+	es.emas = make([]trading.Decimal, 0, 3)
+}
+
+// TODO: Improve the strategy and make it more accurate.
+func (es *emaSession) Evaluate(
+	candles []*trading.Candle,
+) (*trading.Signal, bool) {
+	// The last candle is not yet stable as its prices change, so the cross
+	// is checked against the two candles preceding it. The ATR is measured
+	// on stable candles only as well.
+	if len(candles) < 3 {
+		return nil, false
+	}
+
+	lastIndex := len(candles) - 1
+
+	pending, reset := es.series.advance(candles[:lastIndex])
+	if reset {
+		es.reset()
+	}
+
+	for _, candle := range pending {
+		es.ema.Append(candle)
+		es.atr.Append(candle)
+
+		if es.ema.Ready() {
+			es.emas = append(es.emas, es.ema.Value())
+			if len(es.emas) > 2 {
+				es.emas = es.emas[1:]
+			}
+		}
+	}
+
+	if len(es.emas) < 2 || !es.atr.Ready() {
+		return nil, false
+	}
+
+	prices := []trading.Decimal{
+		candles[lastIndex-2].ClosePrice,
+		candles[lastIndex-1].ClosePrice,
+	}
+	emas := es.emas
+
+	es.logIndicators(lastIndex-1, prices, emas, es.atr.Value())
+
+	signalType, exists := trading.TypeLong, false
+	switch {
+	case nearCross(prices[1], emas[1], prices[0], emas[0], 1):
+		signalType, exists = trading.TypeLong, true
+	case nearCross(prices[1], emas[1], prices[0], emas[0], -1):
+		signalType, exists = trading.TypeShort, true
+	}
+
+	if !exists {
+		return nil, false
+	}
+
+	return trading.NewATRSignal(
+		signalType,
+		candles[lastIndex].ClosePrice,
+		es.atr.Value(),
+	), true
+}
+
+// nearCross tells whether the value crossed the reference in the direction
+// given by the comparison result, i.e. 1 for crossing up and -1 for crossing
+// down. Touching the reference counts as crossing it.
+func nearCross(
+	value, reference, previousValue, previousReference trading.Decimal,
+	cmp int,
+) bool {
+	current := value.Cmp(reference)
+	previous := previousValue.Cmp(previousReference)
+
+	return (current == 0 || current == cmp) &&
+		(previous == 0 || previous == -cmp)
+}
+
+func (es *emaSession) logIndicators(
+	lastIndex int,
+	prices, emas []trading.Decimal,
+	atr trading.Decimal,
+) {
+	es.logger.Debugf(
+		"price: %v, ema: %v, atr: %v",
+		stringifyValues(lastIndex, prices),
+		stringifyValues(lastIndex, emas),
+		atr.StringFixed(2),
+	)
+}
+
+// stringifyValues lists values starting from the most recent one, which
+// belongs to the given index.
+func stringifyValues(lastIndex int, values []trading.Decimal) string {
+	components := make([]string, 0)
+
+	for i := len(values) - 1; i >= 0; i-- {
+		components = append(
+			components,
+			fmt.Sprintf(
+				"%v=%v",
+				lastIndex-(len(values)-1-i),
+				values[i].StringFixed(2),
+			),
+		)
+	}
+
+	return strings.Join(components, " ")
+}
@@ -0,0 +1,263 @@
+package strategy
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"testing"
+	"time"
+)
+
+func TestEMASignalGenerator_Evaluate(t *testing.T) {
+	tests := map[string]struct {
+		// closes follow the closes making the average equal to 100.
+		closes         []int64
+		expectedExists bool
+		expectedType   trading.PositionType
+		expectedATR    string
+	}{
+		"cross up": {
+			closes:         []int64{98, 102, 103},
+			expectedExists: true,
+			expectedType:   trading.TypeLong,
+			expectedATR:    "2.28061224",
+		},
+		"cross down": {
+			closes:         []int64{102, 98, 97},
+			expectedExists: true,
+			expectedType:   trading.TypeShort,
+			expectedATR:    "2.28061224",
+		},
+		"above average": {
+			closes:         []int64{102, 103, 97},
+			expectedExists: false,
+		},
+		"not enough candles": {
+			closes:         []int64{102},
+			expectedExists: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			closes := make([]int64, emaPeriod-1)
+			for i := range closes {
+				closes[i] = 100
+			}
+
+			signal, exists := NewEMASignalGenerator(&testLogger{}).Evaluate(
+				newCandles(append(closes, test.closes...)...),
+			)
+
+			if exists != test.expectedExists {
+				t.Fatalf(
+					"unexpected signal existence\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedExists,
+					exists,
+				)
+			}
+
+			if !exists {
+				return
+			}
+
+			if signal.Type != test.expectedType {
+				t.Errorf(
+					"unexpected signal type\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedType,
+					signal.Type,
+				)
+			}
+
+			if signal.ATR.StringFixed(8) != test.expectedATR {
+				t.Errorf(
+					"unexpected signal ATR\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedATR,
+					signal.ATR.StringFixed(8),
+				)
+			}
+
+			expectedSignal := signal.WithATRTargets(
+				trading.DefaultStopLossATRMultiplier,
+				trading.DefaultRewardRiskRatio,
+			)
+
+			if !signal.StopLossTarget.Equal(expectedSignal.StopLossTarget) ||
+				!signal.TakeProfitTarget.Equal(
+					expectedSignal.TakeProfitTarget,
+				) {
+				t.Errorf(
+					"unexpected signal targets\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					expectedSignal,
+					signal,
+				)
+			}
+		})
+	}
+}
+
+func TestEMASignalGenerator_NewSession(t *testing.T) {
+	signalGenerator := NewEMASignalGenerator(&testLogger{})
+	candles := newCandles(oscillatingCloses(150)...)
+
+	assertSessionEvaluations(
+		t,
+		signalGenerator,
+		signalGenerator.NewSession(),
+		growingWindows(candles, 100),
+	)
+
+	// Indicators are kept as long as the window is not rebuilt.
+	session := signalGenerator.NewSession().(*emaSession)
+
+	session.Evaluate(candles[:100])
+	ema := session.ema
+	session.Evaluate(candles[:101])
+
+	if session.ema != ema {
+		t.Errorf("indicators should be kept between evaluations")
+	}
+}
+
+// assertSessionEvaluations evaluates the windows by the session and
+// compares its signals with the ones evaluated against whole windows. Then
+// it checks the session gets reset once the last window is rebuilt.
+func assertSessionEvaluations(
+	t *testing.T,
+	signalGenerator trading.SignalGenerator,
+	session trading.SignalGenerator,
+	windows [][]*trading.Candle,
+) {
+	signals := 0
+
+	assertEqual := func(window []*trading.Candle) {
+		expected, expectedExists := signalGenerator.Evaluate(window)
+		actual, exists := session.Evaluate(window)
+
+		if exists != expectedExists ||
+			(exists && actual.String() != expected.String()) {
+			t.Fatalf(
+				"unexpected signal of window ending at [%v]\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				window[len(window)-1].OpenTime,
+				expected,
+				actual,
+			)
+		}
+
+		if exists {
+			signals++
+		}
+	}
+
+	for _, window := range windows {
+		assertEqual(window)
+	}
+
+	if signals == 0 {
+		t.Fatalf("candles should generate signals")
+	}
+
+	// Candles of the rebuilt window are opened a day later.
+	rebuilt := make([]*trading.Candle, 0)
+	for _, candle := range windows[len(windows)-1] {
+		rebuiltCandle := *candle
+		rebuiltCandle.OpenTime = candle.OpenTime.Add(24 * time.Hour)
+		rebuiltCandle.CloseTime = candle.CloseTime.Add(24 * time.Hour)
+		rebuilt = append(rebuilt, &rebuiltCandle)
+	}
+
+	assertEqual(rebuilt)
+}
+
+// growingWindows returns windows of the candles starting with the first
+// one, from the given size up to all candles.
+func growingWindows(
+	candles []*trading.Candle,
+	size int,
+) [][]*trading.Candle {
+	windows := make([][]*trading.Candle, 0)
+
+	for end := size; end <= len(candles); end++ {
+		windows = append(windows, candles[:end])
+	}
+
+	return windows
+}
+
+// slidingWindows returns all windows of the candles of the given size.
+func slidingWindows(
+	candles []*trading.Candle,
+	size int,
+) [][]*trading.Candle {
+	windows := make([][]*trading.Candle, 0)
+
+	for end := size; end <= len(candles); end++ {
+		windows = append(windows, candles[end-size:end])
+	}
+
+	return windows
+}
+
+// oscillatingCloses returns closes moving up and down by 1 around 100.
+func oscillatingCloses(count int) []int64 {
+	closes := make([]int64, count)
+
+	for i := range closes {
+		phase := int64(i % 20)
+		if phase > 10 {
+			phase = 20 - phase
+		}
+
+		closes[i] = 95 + phase
+	}
+
+	return closes
+}
+
+func newCandles(closes ...int64) []*trading.Candle {
+	candles := make([]*trading.Candle, len(closes))
+
+	for index, closePrice := range closes {
+		openTime := time.Date(2021, 6, 11, 15, 0, 0, 0, time.UTC).
+			Add(time.Duration(index) * time.Minute)
+
+		// Each candle spans 1 above and below its close price.
+		candles[index] = &trading.Candle{
+			OpenTime:   openTime,
+			CloseTime:  openTime.Add(time.Minute - time.Millisecond),
+			ClosePrice: trading.NewDecimal(closePrice, 0),
+			MaxPrice:   trading.NewDecimal(closePrice+1, 0),
+			MinPrice:   trading.NewDecimal(closePrice-1, 0),
+		}
+	}
+
+	return candles
+}
+
+type testLogger struct{}
+
+func (tl *testLogger) Debugf(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) Infof(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) Warningf(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) Errorf(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) Fatalf(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) WithField(_ string, _ interface{}) trading.Logger {
+	return tl
+}
+
+func (tl *testLogger) WithFields(_ map[string]interface{}) trading.Logger {
+	return tl
+}
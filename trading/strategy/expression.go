@@ -0,0 +1,260 @@
+package strategy
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"strings"
+	"unicode"
+)
+
+// expression is a formula evaluated against the candle of the given index.
+// It's not evaluable if any of the indicators it uses is not ready yet or
+// it divides by zero.
+type expression interface {
+	evaluate(s *session, index int) (trading.Decimal, bool)
+}
+
+type constant struct {
+	value trading.Decimal
+}
+
+func (c *constant) evaluate(_ *session, _ int) (trading.Decimal, bool) {
+	return c.value, true
+}
+
+// variable is a named value resolved once the expression is compiled,
+// e.g. a candle price or an indicator output.
+type variable struct {
+	resolve func(s *session, index int) (trading.Decimal, bool)
+}
+
+func (v *variable) evaluate(s *session, index int) (trading.Decimal, bool) {
+	return v.resolve(s, index)
+}
+
+type negation struct {
+	operand expression
+}
+
+func (n *negation) evaluate(s *session, index int) (trading.Decimal, bool) {
+	value, ok := n.operand.evaluate(s, index)
+	if !ok {
+		return trading.Decimal{}, false
+	}
+
+	return value.Neg(), true
+}
+
+type operation struct {
+	operator rune
+	left     expression
+	right    expression
+}
+
+func (o *operation) evaluate(s *session, index int) (trading.Decimal, bool) {
+	left, ok := o.left.evaluate(s, index)
+	if !ok {
+		return trading.Decimal{}, false
+	}
+
+	right, ok := o.right.evaluate(s, index)
+	if !ok {
+		return trading.Decimal{}, false
+	}
+
+	switch o.operator {
+	case '+':
+		return left.Add(right), true
+	case '-':
+		return left.Sub(right), true
+	case '*':
+		return left.Mul(right), true
+	default:
+		if right.IsZero() {
+			return trading.Decimal{}, false
+		}
+
+		return left.Div(right), true
+	}
+}
+
+// resolver returns the value of the variable with the given name, or false
+// if there is no such variable.
+type resolver func(
+	name string,
+) (func(s *session, index int) (trading.Decimal, bool), bool)
+
+// parseExpression compiles a formula consisting of decimal numbers,
+// variables, the +, -, * and / operators and parentheses, e.g.
+// `entry - 1.5 * atr`. Variable names may contain a single dot which
+// separates the indicator name from its output, e.g. `macd.signal`.
+func parseExpression(formula string, resolve resolver) (expression, error) {
+	tokens, err := tokenize(formula)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("formula is empty")
+	}
+
+	p := &parser{tokens: tokens, resolve: resolve}
+
+	expression, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected token [%v]", p.peek())
+	}
+
+	return expression, nil
+}
+
+func tokenize(formula string) ([]string, error) {
+	tokens := make([]string, 0)
+	runes := []rune(formula)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("+-*/()", r):
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsDigit(r) || unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) ||
+				unicode.IsLetter(runes[i]) ||
+				runes[i] == '_' ||
+				runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("unexpected character [%c]", r)
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens   []string
+	position int
+	resolve  resolver
+}
+
+func (p *parser) done() bool {
+	return p.position == len(p.tokens)
+}
+
+func (p *parser) peek() string {
+	if p.done() {
+		return ""
+	}
+
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() string {
+	token := p.peek()
+	p.position++
+	return token
+}
+
+func (p *parser) parseSum() (expression, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "+" || p.peek() == "-" {
+		operator := rune(p.next()[0])
+
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &operation{operator, left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseProduct() (expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "*" || p.peek() == "/" {
+		operator := rune(p.next()[0])
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &operation{operator, left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (expression, error) {
+	if p.peek() == "-" {
+		p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &negation{operand}, nil
+	}
+
+	return p.parseOperand()
+}
+
+func (p *parser) parseOperand() (expression, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of formula")
+	}
+
+	token := p.next()
+
+	switch {
+	case token == "(":
+		expression, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+
+		return expression, nil
+	case unicode.IsDigit(rune(token[0])):
+		value, err := trading.ParseDecimal(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number [%v]", token)
+		}
+
+		return &constant{value}, nil
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		resolve, exists := p.resolve(token)
+		if !exists {
+			return nil, fmt.Errorf("unknown variable [%v]", token)
+		}
+
+		return &variable{resolve}, nil
+	default:
+		return nil, fmt.Errorf("unexpected token [%v]", token)
+	}
+}
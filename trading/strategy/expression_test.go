@@ -0,0 +1,132 @@
+package strategy
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := map[string]struct {
+		formula       string
+		expectedValue string
+		expectedOk    bool
+		expectedError bool
+	}{
+		"number": {
+			formula:       "1.5",
+			expectedValue: "1.5",
+			expectedOk:    true,
+		},
+		"variable": {
+			formula:       "macd.signal",
+			expectedValue: "3",
+			expectedOk:    true,
+		},
+		"precedence": {
+			formula:       "entry - 1.5 * atr + 1",
+			expectedValue: "98",
+			expectedOk:    true,
+		},
+		"parentheses": {
+			formula:       "(entry - atr) / (2 - -2)",
+			expectedValue: "24.5",
+			expectedOk:    true,
+		},
+		"not ready variable": {
+			formula:    "entry + pending",
+			expectedOk: false,
+		},
+		"division by zero": {
+			formula:    "entry / (atr - 2)",
+			expectedOk: false,
+		},
+		"empty": {
+			formula:       " ",
+			expectedError: true,
+		},
+		"unknown variable": {
+			formula:       "entry - sma",
+			expectedError: true,
+		},
+		"invalid character": {
+			formula:       "entry % 2",
+			expectedError: true,
+		},
+		"invalid number": {
+			formula:       "2x",
+			expectedError: true,
+		},
+		"missing operand": {
+			formula:       "entry -",
+			expectedError: true,
+		},
+		"missing parenthesis": {
+			formula:       "(entry - atr",
+			expectedError: true,
+		},
+		"trailing token": {
+			formula:       "entry atr",
+			expectedError: true,
+		},
+	}
+
+	variables := map[string]trading.Decimal{
+		"entry":       trading.NewDecimal(100, 0),
+		"atr":         trading.NewDecimal(2, 0),
+		"macd.signal": trading.NewDecimal(3, 0),
+	}
+
+	resolve := func(name string) (
+		func(s *session, index int) (trading.Decimal, bool),
+		bool,
+	) {
+		if name == "pending" {
+			return func(_ *session, _ int) (trading.Decimal, bool) {
+				return trading.Decimal{}, false
+			}, true
+		}
+
+		value, exists := variables[name]
+
+		return func(_ *session, _ int) (trading.Decimal, bool) {
+			return value, true
+		}, exists
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			expression, err := parseExpression(test.formula, resolve)
+			if test.expectedError {
+				if err == nil {
+					t.Errorf("expected error for [%v]", test.formula)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			value, ok := expression.evaluate(nil, 0)
+
+			if ok != test.expectedOk {
+				t.Fatalf(
+					"unexpected evaluability\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedOk,
+					ok,
+				)
+			}
+
+			if ok && value.String() != test.expectedValue {
+				t.Errorf(
+					"unexpected value\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedValue,
+					value,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package strategy
+
+import (
+	"fmt"
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// Registry keeps signal generators of the built-in strategies and the
+// strategies declared by specs. Strategies must be registered before the
+// registry is used by workloads.
+type Registry struct {
+	signalGenerators map[trading.StrategyRef]trading.SignalGenerator
+	logger           trading.Logger
+}
+
+// NewRegistry creates the registry of the built-in strategies. The EMA
+// strategy is registered as the default one.
+func NewRegistry(logger trading.Logger) *Registry {
+	return &Registry{
+		signalGenerators: map[trading.StrategyRef]trading.SignalGenerator{
+			trading.DefaultStrategy: NewEMASignalGenerator(logger),
+		},
+		logger: logger,
+	}
+}
+
+// Register compiles the spec and registers the resulting generator. Each
+// version of a strategy can be registered only once.
+func (r *Registry) Register(spec *Spec) error {
+	signalGenerator, err := Compile(spec, r.logger)
+	if err != nil {
+		return err
+	}
+
+	strategy := signalGenerator.Strategy()
+
+	if _, exists := r.signalGenerators[strategy]; exists {
+		return fmt.Errorf("strategy [%v] already registered", strategy)
+	}
+
+	r.signalGenerators[strategy] = signalGenerator
+
+	return nil
+}
+
+// LoadDir registers specs from all YAML and JSON files in the directory.
+// Nothing is registered if any of the specs is invalid.
+func (r *Registry) LoadDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("could not read directory: [%v]", err)
+	}
+
+	fileNames := make([]string, 0)
+	specs := make([]*Spec, 0)
+
+	for _, file := range files {
+		if file.IsDir() || !specExtensions[filepath.Ext(file.Name())] {
+			continue
+		}
+
+		spec, err := ReadSpec(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return fmt.Errorf(
+				"could not read spec [%v]: [%v]",
+				file.Name(),
+				err,
+			)
+		}
+
+		fileNames = append(fileNames, file.Name())
+		specs = append(specs, spec)
+	}
+
+	registry := &Registry{
+		signalGenerators: make(
+			map[trading.StrategyRef]trading.SignalGenerator,
+		),
+		logger: r.logger,
+	}
+	for strategy, signalGenerator := range r.signalGenerators {
+		registry.signalGenerators[strategy] = signalGenerator
+	}
+
+	for i, spec := range specs {
+		if err := registry.Register(spec); err != nil {
+			return fmt.Errorf(
+				"could not register spec [%v]: [%v]",
+				fileNames[i],
+				err,
+			)
+		}
+	}
+
+	r.signalGenerators = registry.signalGenerators
+
+	return nil
+}
+
+func (r *Registry) SignalGenerator(
+	strategy trading.StrategyRef,
+) (trading.SignalGenerator, error) {
+	signalGenerator, exists := r.signalGenerators[strategy]
+	if !exists {
+		return nil, trading.ErrNotFound
+	}
+
+	return signalGenerator, nil
+}
+
+// Strategies lists the registered strategies ordered by name and version.
+func (r *Registry) Strategies() []trading.StrategyRef {
+	strategies := make([]trading.StrategyRef, 0, len(r.signalGenerators))
+
+	for strategy := range r.signalGenerators {
+		strategies = append(strategies, strategy)
+	}
+
+	sort.Slice(strategies, func(i, j int) bool {
+		if strategies[i].Name != strategies[j].Name {
+			return strategies[i].Name < strategies[j].Name
+		}
+
+		return strategies[i].Version < strategies[j].Version
+	})
+
+	return strategies
+}
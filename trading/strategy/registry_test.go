@@ -0,0 +1,108 @@
+package strategy
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry_LoadDir(t *testing.T) {
+	registry := NewRegistry(&testLogger{})
+
+	if err := registry.LoadDir("testdata"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedStrategies := []trading.StrategyRef{
+		{Name: "ema", Version: 1},
+		{Name: "ema-cross", Version: 1},
+		{Name: "rsi-reversal", Version: 2},
+	}
+
+	if !reflect.DeepEqual(registry.Strategies(), expectedStrategies) {
+		t.Errorf(
+			"unexpected strategies\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			expectedStrategies,
+			registry.Strategies(),
+		)
+	}
+
+	for _, strategy := range expectedStrategies {
+		if _, err := registry.SignalGenerator(strategy); err != nil {
+			t.Errorf("strategy [%v] is not available: [%v]", strategy, err)
+		}
+	}
+
+	_, err := registry.SignalGenerator(
+		trading.StrategyRef{Name: "rsi-reversal", Version: 1},
+	)
+	if err != trading.ErrNotFound {
+		t.Errorf("unexpected error for unknown strategy: [%v]", err)
+	}
+}
+
+func TestRegistry_LoadDir_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"duplicated strategy": "name: ema\nversion: 1\n" +
+			"long: {when: {threshold: {value: close, above: '1'}}, " +
+			"stopLoss: '1', takeProfit: '2'}\n",
+		"unknown field": "name: ema\nversion: 2\nperiod: 50\n",
+		"invalid spec":  "name: ema\nversion: 2\n",
+	}
+
+	for testName, content := range tests {
+		t.Run(testName, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "strategies")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			// The valid spec must not be registered either.
+			err = ioutil.WriteFile(
+				filepath.Join(dir, "a.yml"),
+				[]byte("name: valid\nversion: 1\n"+
+					"long: {when: {threshold: {value: close, above: '1'}}, "+
+					"atr: '1'}\n"),
+				0600,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ioutil.WriteFile(
+				filepath.Join(dir, "b.yaml"),
+				[]byte(content),
+				0600,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			registry := NewRegistry(&testLogger{})
+
+			if err := registry.LoadDir(dir); err == nil {
+				t.Fatalf("invalid spec has been loaded")
+			}
+
+			expectedStrategies := []trading.StrategyRef{
+				trading.DefaultStrategy,
+			}
+
+			if !reflect.DeepEqual(registry.Strategies(), expectedStrategies) {
+				t.Errorf(
+					"unexpected strategies\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					expectedStrategies,
+					registry.Strategies(),
+				)
+			}
+		})
+	}
+}
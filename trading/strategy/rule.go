@@ -0,0 +1,129 @@
+package strategy
+
+// rule is a condition checked against the candle of the given index. The
+// second result tells whether the condition could be checked at all, i.e.
+// the values it compares were already known for that candle. Rules which
+// couldn't be checked never trigger signals, even if negated.
+type rule interface {
+	holds(s *session, index int) (bool, bool)
+}
+
+// crossRule holds if the value crossed the reference in the direction
+// given by the comparison result, i.e. 1 for crossing up and -1 for
+// crossing down. Touching the reference counts as crossing it.
+type crossRule struct {
+	value     expression
+	reference expression
+	cmp       int
+}
+
+func (cr *crossRule) holds(s *session, index int) (bool, bool) {
+	if index < 1 {
+		return false, false
+	}
+
+	current, ok := compare(s, index, cr.value, cr.reference)
+	if !ok {
+		return false, false
+	}
+
+	previous, ok := compare(s, index-1, cr.value, cr.reference)
+	if !ok {
+		return false, false
+	}
+
+	return (current == 0 || current == cr.cmp) &&
+		(previous == 0 || previous == -cr.cmp), true
+}
+
+// thresholdRule holds if the value is strictly above the threshold for the
+// comparison result of 1 or strictly below it for -1.
+type thresholdRule struct {
+	value     expression
+	threshold expression
+	cmp       int
+}
+
+func (tr *thresholdRule) holds(s *session, index int) (bool, bool) {
+	result, ok := compare(s, index, tr.value, tr.threshold)
+	if !ok {
+		return false, false
+	}
+
+	return result == tr.cmp, true
+}
+
+type andRule struct {
+	rules []rule
+}
+
+func (ar *andRule) holds(s *session, index int) (bool, bool) {
+	for _, r := range ar.rules {
+		holds, ok := r.holds(s, index)
+		if !ok || !holds {
+			return false, ok
+		}
+	}
+
+	return true, true
+}
+
+type orRule struct {
+	rules []rule
+}
+
+func (or *orRule) holds(s *session, index int) (bool, bool) {
+	for _, r := range or.rules {
+		holds, ok := r.holds(s, index)
+		if !ok || holds {
+			return holds, ok
+		}
+	}
+
+	return false, true
+}
+
+type notRule struct {
+	rule rule
+}
+
+func (nr *notRule) holds(s *session, index int) (bool, bool) {
+	holds, ok := nr.rule.holds(s, index)
+	return !holds, ok
+}
+
+// confirmRule holds if the rule held for the given number of consecutive
+// candles ending with the checked one.
+type confirmRule struct {
+	rule    rule
+	candles int
+}
+
+func (cr *confirmRule) holds(s *session, index int) (bool, bool) {
+	if index < cr.candles-1 {
+		return false, false
+	}
+
+	for i := index - cr.candles + 1; i <= index; i++ {
+		holds, ok := cr.rule.holds(s, i)
+		if !ok || !holds {
+			return false, ok
+		}
+	}
+
+	return true, true
+}
+
+func compare(s *session, index int, left, right expression) (int, bool) {
+	leftValue, ok := left.evaluate(s, index)
+	if !ok {
+		return 0, false
+	}
+
+	rightValue, ok := right.evaluate(s, index)
+	if !ok {
+		return 0, false
+	}
+
+	return leftValue.Cmp(rightValue), true
+}
@@ -0,0 +1,42 @@
+package strategy
+
+import (
+	"github.com/lukasz-zimnoch/dexly/trading"
+	"time"
+)
+
+// series tracks stable candles of a candle series which have been appended
+// to indicators kept between evaluations, so each evaluation appends only
+// the candles which became stable since the former one.
+type series struct {
+	started      bool
+	lastOpenTime time.Time
+}
+
+// advance returns the stable candles which haven't been appended yet. If
+// the last appended candle is no longer part of the window, e.g. the window
+// has been rebuilt, indicators must be reset and all stable candles are
+// returned along with the reset flag.
+func (s *series) advance(
+	stable []*trading.Candle,
+) ([]*trading.Candle, bool) {
+	if len(stable) == 0 {
+		return stable, false
+	}
+
+	pending, reset := stable, true
+
+	if s.started {
+		for i := len(stable) - 1; i >= 0; i-- {
+			if stable[i].OpenTime.Equal(s.lastOpenTime) {
+				pending, reset = stable[i+1:], false
+				break
+			}
+		}
+	}
+
+	s.started = true
+	s.lastOpenTime = stable[len(stable)-1].OpenTime
+
+	return pending, reset
+}
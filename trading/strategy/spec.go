@@ -0,0 +1,120 @@
+package strategy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// Spec declares a strategy compiled into a signal generator. Specs are
+// written in YAML or JSON, e.g.
+//
+//	name: ema-cross
+//	version: 1
+//	indicators:
+//	  - {name: ema, type: EMA, period: 50}
+//	  - {name: atr, type: ATR, period: 14}
+//	long:
+//	  when:
+//	    crossUp: {value: close, reference: ema}
+//	  atr: atr
+//
+// Rules and formulas are evaluated against the most recent stable candle.
+// Formulas may use its open, close, max, min and volume, the current price,
+// indicator values by name, extra outputs such as `<name>.signal` of MACD,
+// `<name>.d` of STOCHASTIC or `<name>.upper` of BOLLINGER, and the entry
+// in stop loss and take profit formulas.
+type Spec struct {
+	Name       string           `json:"name" yaml:"name"`
+	Version    int              `json:"version" yaml:"version"`
+	Indicators []*IndicatorSpec `json:"indicators" yaml:"indicators"`
+	// Long signals take precedence if both are generated at once.
+	Long  *SignalSpec `json:"long" yaml:"long"`
+	Short *SignalSpec `json:"short" yaml:"short"`
+}
+
+type IndicatorSpec struct {
+	Name   string `json:"name" yaml:"name"`
+	Type   string `json:"type" yaml:"type"`
+	Period int    `json:"period" yaml:"period"`
+	// Source is one of open, close, max, min, volume and typical.
+	Source          string `json:"source" yaml:"source"`
+	FastPeriod      int    `json:"fastPeriod" yaml:"fastPeriod"`
+	SlowPeriod      int    `json:"slowPeriod" yaml:"slowPeriod"`
+	SignalPeriod    int    `json:"signalPeriod" yaml:"signalPeriod"`
+	SmoothingPeriod int    `json:"smoothingPeriod" yaml:"smoothingPeriod"`
+	Multiplier      string `json:"multiplier" yaml:"multiplier"`
+}
+
+// SignalSpec targets are given either by the ATR formula, in which case
+// workload multipliers apply, or by the stop loss and take profit ones.
+type SignalSpec struct {
+	When       *RuleSpec `json:"when" yaml:"when"`
+	Entry      string    `json:"entry" yaml:"entry"`
+	StopLoss   string    `json:"stopLoss" yaml:"stopLoss"`
+	TakeProfit string    `json:"takeProfit" yaml:"takeProfit"`
+	ATR        string    `json:"atr" yaml:"atr"`
+}
+
+// RuleSpec must have exactly one of its fields set.
+type RuleSpec struct {
+	CrossUp   *CrossSpec     `json:"crossUp" yaml:"crossUp"`
+	CrossDown *CrossSpec     `json:"crossDown" yaml:"crossDown"`
+	Threshold *ThresholdSpec `json:"threshold" yaml:"threshold"`
+	And       []*RuleSpec    `json:"and" yaml:"and"`
+	Or        []*RuleSpec    `json:"or" yaml:"or"`
+	Not       *RuleSpec      `json:"not" yaml:"not"`
+	Confirm   *ConfirmSpec   `json:"confirm" yaml:"confirm"`
+}
+
+// CrossSpec counts touching the reference as crossing it.
+type CrossSpec struct {
+	Value     string `json:"value" yaml:"value"`
+	Reference string `json:"reference" yaml:"reference"`
+}
+
+type ThresholdSpec struct {
+	Value string `json:"value" yaml:"value"`
+	Above string `json:"above" yaml:"above"`
+	Below string `json:"below" yaml:"below"`
+}
+
+type ConfirmSpec struct {
+	Candles int       `json:"candles" yaml:"candles"`
+	Rule    *RuleSpec `json:"rule" yaml:"rule"`
+}
+
+var specExtensions = map[string]bool{
+	".yml":  true,
+	".yaml": true,
+	".json": true,
+}
+
+// ReadSpec rejects unknown fields so typos don't go unnoticed.
+func ReadSpec(path string) (*Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{}
+
+	switch filepath.Ext(path) {
+	case ".yml", ".yaml":
+		err = yaml.UnmarshalStrict(data, spec)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(spec)
+	default:
+		return nil, fmt.Errorf("unsupported spec file extension")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode spec: [%v]", err)
+	}
+
+	return spec, nil
+}
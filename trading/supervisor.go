@@ -0,0 +1,134 @@
+package trading
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// workloadStableRunTime is the time after which a running workload is
+// considered recovered and its previous failures are forgotten.
+const workloadStableRunTime = 30 * time.Minute
+
+type WorkloadSupervisorConfig struct {
+	// RestartBackoff doubles with each consecutive failure.
+	RestartBackoff         time.Duration
+	MaxRestartBackoff      time.Duration
+	MaxConsecutiveFailures int
+}
+
+// DefaultWorkloadSupervisorConfig restarts failed workloads after 1, 2,
+// 4 and 8 minutes and disables them on the fifth consecutive failure.
+var DefaultWorkloadSupervisorConfig = WorkloadSupervisorConfig{
+	RestartBackoff:         1 * time.Minute,
+	MaxRestartBackoff:      1 * time.Hour,
+	MaxConsecutiveFailures: 5,
+}
+
+func (wsc *WorkloadSupervisorConfig) Validate() error {
+	if wsc.RestartBackoff <= 0 {
+		return fmt.Errorf("restart backoff must be positive")
+	}
+
+	if wsc.MaxRestartBackoff < wsc.RestartBackoff {
+		return fmt.Errorf(
+			"max restart backoff must not be shorter than restart backoff",
+		)
+	}
+
+	if wsc.MaxConsecutiveFailures <= 0 {
+		return fmt.Errorf("max consecutive failures must be positive")
+	}
+
+	return nil
+}
+
+type workloadFailures struct {
+	count         int
+	nextStartTime time.Time
+}
+
+// workloadSupervisor decides when failed workloads can be restarted.
+// Transient failures delay the next start using an exponential backoff
+// while fatal failures, or too many consecutive transient ones, require
+// the workload to be disabled.
+type workloadSupervisor struct {
+	config WorkloadSupervisorConfig
+
+	mutex    sync.Mutex
+	failures map[string]*workloadFailures
+	clock    Clock
+}
+
+func newWorkloadSupervisor(
+	config WorkloadSupervisorConfig,
+	clock Clock,
+) *workloadSupervisor {
+	return &workloadSupervisor{
+		config:   config,
+		failures: make(map[string]*workloadFailures),
+		clock:    clock,
+	}
+}
+
+func (ws *workloadSupervisor) canStart(workloadID string) bool {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	failures, exists := ws.failures[workloadID]
+	if !exists {
+		return true
+	}
+
+	return !ws.clock.Now().Before(failures.nextStartTime)
+}
+
+// recordFailure returns true if the workload should be disabled.
+// Otherwise, it returns the backoff after which it can be started again.
+func (ws *workloadSupervisor) recordFailure(
+	workloadID string,
+	runTime time.Duration,
+	err error,
+) (bool, time.Duration) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	if IsFatal(err) {
+		delete(ws.failures, workloadID)
+		return true, 0
+	}
+
+	failures, exists := ws.failures[workloadID]
+	if !exists || runTime >= workloadStableRunTime {
+		failures = &workloadFailures{}
+		ws.failures[workloadID] = failures
+	}
+
+	failures.count++
+
+	if failures.count >= ws.config.MaxConsecutiveFailures {
+		delete(ws.failures, workloadID)
+		return true, 0
+	}
+
+	backoff := ws.config.RestartBackoff
+	for i := 1; i < failures.count; i++ {
+		backoff *= 2
+		if backoff >= ws.config.MaxRestartBackoff {
+			backoff = ws.config.MaxRestartBackoff
+			break
+		}
+	}
+
+	failures.nextStartTime = ws.clock.Now().Add(backoff)
+
+	return false, backoff
+}
+
+// reset forgets failures of the workload, e.g. when it gets disabled.
+func (ws *workloadSupervisor) reset(workloadID string) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	delete(ws.failures, workloadID)
+}
@@ -0,0 +1,98 @@
+package trading
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWorkloadSupervisor_RecordFailure(t *testing.T) {
+	clock := NewVirtualClock(time.Time{})
+
+	supervisor := newWorkloadSupervisor(
+		WorkloadSupervisorConfig{
+			RestartBackoff:         10 * time.Second,
+			MaxRestartBackoff:      30 * time.Second,
+			MaxConsecutiveFailures: 4,
+		},
+		clock,
+	)
+
+	expectedBackoffs := []time.Duration{
+		10 * time.Second,
+		20 * time.Second,
+		30 * time.Second,
+	}
+
+	for _, expectedBackoff := range expectedBackoffs {
+		disable, backoff := supervisor.recordFailure(
+			"workload",
+			0,
+			fmt.Errorf("connection reset"),
+		)
+
+		if disable || backoff != expectedBackoff {
+			t.Errorf(
+				"unexpected failure decision\n"+
+					"expected: [%v %v]\n"+
+					"actual:   [%v %v]",
+				false,
+				expectedBackoff,
+				disable,
+				backoff,
+			)
+		}
+	}
+
+	disable, _ := supervisor.recordFailure(
+		"workload",
+		0,
+		fmt.Errorf("connection reset"),
+	)
+	if !disable {
+		t.Errorf("workload has not been disabled after max failures")
+	}
+}
+
+func TestWorkloadSupervisorConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		config      WorkloadSupervisorConfig
+		expectedErr bool
+	}{
+		"default": {
+			config:      DefaultWorkloadSupervisorConfig,
+			expectedErr: false,
+		},
+		"zero restart backoff": {
+			config: WorkloadSupervisorConfig{
+				MaxRestartBackoff:      time.Hour,
+				MaxConsecutiveFailures: 5,
+			},
+			expectedErr: true,
+		},
+		"max restart backoff shorter than restart backoff": {
+			config: WorkloadSupervisorConfig{
+				RestartBackoff:         time.Hour,
+				MaxRestartBackoff:      time.Minute,
+				MaxConsecutiveFailures: 5,
+			},
+			expectedErr: true,
+		},
+		"zero max consecutive failures": {
+			config: WorkloadSupervisorConfig{
+				RestartBackoff:    time.Minute,
+				MaxRestartBackoff: time.Hour,
+			},
+			expectedErr: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.expectedErr != (err != nil) {
+				t.Errorf("unexpected error: [%v]", err)
+			}
+		})
+	}
+}
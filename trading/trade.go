@@ -0,0 +1,54 @@
+package trading
+
+import (
+	"fmt"
+)
+
+// Trade is a position whose entry and exit orders have both been executed.
+type Trade struct {
+	Position   *Position
+	EntryOrder *Order
+	ExitOrder  *Order
+	// Profit is the gross result of the trade, in the quote asset.
+	Profit Decimal
+}
+
+// NewTrade builds a trade out of the given position. False is returned
+// if the position has not been exited, e.g. because it was closed before
+// the entry order execution or is still open.
+func NewTrade(position *Position) (*Trade, bool, error) {
+	entryOrder, exitOrder, err := position.OrdersBreakdown()
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"inconsistent orders state for position [%v]: [%v]",
+			position.ID,
+			err,
+		)
+	}
+
+	if exitOrder == nil || !exitOrder.Executed {
+		return nil, false, nil
+	}
+
+	priceChange := exitOrder.Price.Sub(entryOrder.Price)
+	if position.Type == TypeShort {
+		priceChange = priceChange.Neg()
+	}
+
+	return &Trade{
+		Position:   position,
+		EntryOrder: entryOrder,
+		ExitOrder:  exitOrder,
+		Profit:     priceChange.Mul(entryOrder.Size),
+	}, true, nil
+}
+
+// NetProfit returns the profit of the trade reduced by the commission
+// charged at the given taker rate for both of its orders.
+func (t *Trade) NetProfit(takerCommission Decimal) Decimal {
+	notional := t.EntryOrder.Price.Mul(t.EntryOrder.Size).Add(
+		t.ExitOrder.Price.Mul(t.ExitOrder.Size),
+	)
+
+	return t.Profit.Sub(notional.Mul(takerCommission))
+}
@@ -2,8 +2,8 @@ package trading
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/big"
 	"sort"
 	"sync"
 	"time"
@@ -11,21 +11,116 @@ import (
 
 const (
 	workloadControllerLoopTick = 1 * time.Minute
-	candleTickerIdleTimeout    = 10 * time.Second
 	workloadActionLoopTick     = 5 * time.Second
 	entryOrderValidityTime     = 1 * time.Minute
 	signalGeneratorPauseTime   = 5 * time.Minute
+	workloadStallTimeout       = 3 * time.Minute
 )
 
+const (
+	// Paper accounts trade on Binance market data.
+	PaperExchange           = "PAPER"
+	PaperMarketDataExchange = "BINANCE"
+)
+
+type WorkloadMode int
+
+const (
+	ModeLive WorkloadMode = iota
+	ModePaper
+)
+
+func ParseWorkloadMode(value string) (WorkloadMode, error) {
+	switch value {
+	case "LIVE":
+		return ModeLive, nil
+	case "PAPER":
+		return ModePaper, nil
+	}
+
+	return -1, fmt.Errorf("unknown workload mode: [%v]", value)
+}
+
+func (wm WorkloadMode) String() string {
+	switch wm {
+	case ModeLive:
+		return "LIVE"
+	case ModePaper:
+		return "PAPER"
+	default:
+		panic("unknown workload mode")
+	}
+}
+
+type WorkloadStatus int
+
+const (
+	WorkloadActive WorkloadStatus = iota
+	WorkloadPaused
+	WorkloadDisabled
+)
+
+func ParseWorkloadStatus(value string) (WorkloadStatus, error) {
+	switch value {
+	case "ACTIVE":
+		return WorkloadActive, nil
+	case "PAUSED":
+		return WorkloadPaused, nil
+	case "DISABLED":
+		return WorkloadDisabled, nil
+	}
+
+	return -1, fmt.Errorf("unknown workload status: [%v]", value)
+}
+
+func (ws WorkloadStatus) String() string {
+	switch ws {
+	case WorkloadActive:
+		return "ACTIVE"
+	case WorkloadPaused:
+		return "PAUSED"
+	case WorkloadDisabled:
+		return "DISABLED"
+	default:
+		panic("unknown workload status")
+	}
+}
+
 type Workload struct {
 	ID      ID
 	Account *Account
 	Pair    Pair
+	Mode    WorkloadMode
+	// Paused workloads keep managing their open positions but don't open
+	// new ones.
+	Status                WorkloadStatus
+	CandleInterval        CandleInterval
+	CandleWindowSize      int
+	StopLossATRMultiplier Decimal
+	RewardRiskRatio       Decimal
+	Strategy              StrategyRef
+}
+
+func (w *Workload) CandleKey() CandleKey {
+	exchange := w.Account.Exchange
+	if exchange == PaperExchange {
+		exchange = PaperMarketDataExchange
+	}
+
+	return CandleKey{
+		Exchange: exchange,
+		Pair:     w.Pair,
+		Interval: w.CandleInterval,
+	}
 }
 
 type WorkloadRepository interface {
 	CreateWorkload(workload *Workload) error
 
+	UpdateWorkload(workload *Workload) error
+
+	Workload(workloadID ID) (*Workload, error)
+
 	Workloads() ([]*Workload, error)
 }
 
@@ -33,14 +128,31 @@ type WorkloadController struct {
 	workloadRepository WorkloadRepository
 	idService          IDService
 	exchangeConnector  ExchangeConnector
-	candleRepository   CandleRepository
-	signalGenerator    SignalGenerator
+	marketDataHub      *MarketDataHub
+	strategyRegistry   StrategyRegistry
 	positionRepository PositionRepository
 	orderRepository    OrderRepository
 	eventService       EventService
+	clock              Clock
+	metrics            Metrics
+	supervisor         *workloadSupervisor
+	// If not set, the controller runs all workloads.
+	leaser *WorkloadLeaser
 
 	workloadsMutex sync.Mutex
 	workloads      map[string]*WorkloadRunner
+	// Guarded by the workloads mutex.
+	shuttingDown bool
+
+	// runners are copied from workloads and guarded by their own mutex
+	// so health checks are not blocked by the workloads mutex.
+	runnersMutex sync.RWMutex
+	runners      []*WorkloadRunner
+
+	// lastRefreshTime is guarded by its own mutex so health checks
+	// can detect a deadlock of the workloads mutex.
+	lastRefreshMutex sync.RWMutex
+	lastRefreshTime  time.Time
 
 	logger Logger
 }
@@ -50,23 +162,32 @@ func RunWorkloadController(
 	workloadRepository WorkloadRepository,
 	idService IDService,
 	exchangeConnector ExchangeConnector,
-	candleRepository CandleRepository,
-	signalGenerator SignalGenerator,
+	marketDataHub *MarketDataHub,
+	strategyRegistry StrategyRegistry,
 	positionRepository PositionRepository,
 	orderRepository OrderRepository,
 	eventService EventService,
+	clock Clock,
+	metrics Metrics,
+	supervisorConfig WorkloadSupervisorConfig,
+	leaser *WorkloadLeaser,
 	logger Logger,
 ) *WorkloadController {
 	workerController := &WorkloadController{
 		workloadRepository: workloadRepository,
 		idService:          idService,
 		exchangeConnector:  exchangeConnector,
-		candleRepository:   candleRepository,
-		signalGenerator:    signalGenerator,
+		marketDataHub:      marketDataHub,
+		strategyRegistry:   strategyRegistry,
 		positionRepository: positionRepository,
 		orderRepository:    orderRepository,
 		eventService:       eventService,
+		clock:              clock,
+		metrics:            metrics,
+		supervisor:         newWorkloadSupervisor(supervisorConfig, clock),
+		leaser:             leaser,
 		workloads:          make(map[string]*WorkloadRunner),
+		lastRefreshTime:    clock.Now(),
 		logger:             logger,
 	}
 
@@ -75,79 +196,414 @@ func RunWorkloadController(
 	return workerController
 }
 
-// TODO: Add a possibility to disable the workload.
 func (wc *WorkloadController) loop(ctx context.Context) {
 	ticker := time.NewTicker(workloadControllerLoopTick)
 
 	for {
 		select {
 		case <-ticker.C:
-			workloads, err := wc.workloadRepository.Workloads()
-			if err != nil {
-				wc.logger.Errorf("could not get workloads: [%v]", err)
-				continue
+			wc.refreshWorkloads(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (wc *WorkloadController) refreshWorkloads(ctx context.Context) {
+	workloads, err := wc.workloadRepository.Workloads()
+	if err != nil {
+		err = fmt.Errorf("could not get workloads: [%v]", err)
+	} else {
+		workloads, err = wc.leasedWorkloads(workloads)
+		if err != nil {
+			err = fmt.Errorf("could not lease workloads: [%v]", err)
+		}
+	}
+
+	leasesExpired := false
+
+	if err != nil {
+		wc.logger.Errorf("%v", err)
+
+		// Leases can't be renewed without knowing the workloads either.
+		if wc.leaser == nil || !wc.leaser.LeasesExpired() {
+			return
+		}
+
+		// Other instances could have taken over the workloads.
+		wc.logger.Warningf("workload leases expired; stopping workloads")
+		workloads = make([]*Workload, 0)
+		leasesExpired = true
+	}
+
+	startable := wc.reconcileWorkloads(workloads, leasesExpired)
+
+	// Workloads are started without the workloads mutex held so network
+	// calls made by exchange connectors block neither health checks nor
+	// the shutdown.
+	for _, workload := range startable {
+		wc.startWorkload(ctx, workload)
+	}
+
+	wc.lastRefreshMutex.Lock()
+	wc.lastRefreshTime = wc.clock.Now()
+	wc.lastRefreshMutex.Unlock()
+}
+
+// reconcileWorkloads returns workloads which should be started.
+func (wc *WorkloadController) reconcileWorkloads(
+	workloads []*Workload,
+	leasesExpired bool,
+) []*Workload {
+	wc.workloadsMutex.Lock()
+	defer wc.workloadsMutex.Unlock()
+
+	startable := make([]*Workload, 0)
+
+	if wc.shuttingDown {
+		return startable
+	}
+
+	existingWorkloads := make(map[string]bool)
+
+	for _, workload := range workloads {
+		existingWorkloads[workload.ID.String()] = true
+
+		workloadLogger := wc.logger.WithField(
+			"workloadID",
+			workload.ID.String(),
+		)
+
+		workloadRunner, running := wc.workloads[workload.ID.String()]
+
+		if workload.Status == WorkloadDisabled {
+			if running {
+				workloadLogger.Infof("stopping disabled workload")
+				wc.stopWorkload(workload.ID.String(), workloadRunner)
 			}
 
-			wc.workloadsMutex.Lock()
+			// Re-enabled workloads start with a clean slate.
+			wc.supervisor.reset(workload.ID.String())
+			continue
+		}
 
-			for _, workload := range workloads {
-				if _, running := wc.workloads[workload.ID.String()]; running {
-					continue
-				}
+		if running {
+			workloadRunner.updateStatus(workload.Status)
+			continue
+		}
 
-				workloadLogger := wc.logger.WithField(
-					"workloadID",
-					workload.ID.String(),
-				)
+		if !wc.supervisor.canStart(workload.ID.String()) {
+			workloadLogger.Debugf("workload restart backoff not elapsed yet")
+			continue
+		}
 
-				exchangeService, err := wc.exchangeConnector.Connect(
-					ctx,
-					workload,
-				)
-				if err != nil {
-					workloadLogger.Errorf(
-						"could not connect exchange service: [%v]",
-						err,
-					)
-					continue
-				}
+		startable = append(startable, workload)
+	}
+
+	for workloadID, workloadRunner := range wc.workloads {
+		if !existingWorkloads[workloadID] {
+			wc.logger.
+				WithField("workloadID", workloadID).
+				Infof("stopping removed or no longer leased workload")
+			wc.stopWorkload(workloadID, workloadRunner)
 
-				workloadRunner := RunWorkload(
-					ctx,
-					workload,
-					wc.idService,
-					exchangeService,
-					wc.candleRepository,
-					wc.signalGenerator,
-					wc.positionRepository,
-					wc.orderRepository,
-					wc.eventService,
-					workloadLogger,
+			// Expired leases could have been taken over already.
+			if wc.leaser != nil && !leasesExpired {
+				go wc.releaseLease(workloadRunner)
+			}
+		}
+	}
+
+	wc.metrics.RunningWorkloads(len(wc.workloads))
+
+	return startable
+}
+
+func (wc *WorkloadController) startWorkload(
+	ctx context.Context,
+	workload *Workload,
+) {
+	workloadLogger := wc.logger.WithField("workloadID", workload.ID.String())
+
+	signalGenerator, err := wc.strategyRegistry.SignalGenerator(
+		workload.Strategy,
+	)
+	if err != nil {
+		err = fmt.Errorf(
+			"could not get strategy [%v]: [%w]",
+			workload.Strategy,
+			err,
+		)
+
+		// Unknown strategies require the service to be reconfigured
+		// so retrying won't help.
+		if errors.Is(err, ErrNotFound) {
+			err = NewFatalError(err)
+		}
+
+		wc.handleWorkloadFailure(workload, 0, err, workloadLogger)
+		return
+	}
+
+	exchangeService, err := wc.exchangeConnector.Connect(ctx, workload)
+	if err != nil {
+		wc.handleWorkloadFailure(
+			workload,
+			0,
+			fmt.Errorf("could not connect exchange service: [%w]", err),
+			workloadLogger,
+		)
+		return
+	}
+
+	wc.workloadsMutex.Lock()
+	defer wc.workloadsMutex.Unlock()
+
+	// The shutdown could have begun while connecting.
+	if wc.shuttingDown {
+		return
+	}
+
+	workloadLogger.Infof("starting workload")
+
+	// Simulated exchange services, e.g. paper ones, fill orders at
+	// the price of the shared feed.
+	follower, _ := exchangeService.(MarketFollower)
+
+	// Requests made by the shared feed are reported as requests of
+	// the workload which started it.
+	marketData := wc.marketDataHub.Subscribe(
+		workload,
+		&instrumentedExchangeService{
+			ExchangeService: exchangeService,
+			metrics:         wc.metrics,
+			clock:           wc.clock,
+		},
+		follower,
+	)
+
+	workloadRunner := RunWorkload(
+		ctx,
+		workload,
+		wc.idService,
+		exchangeService,
+		marketData,
+		signalGenerator,
+		wc.positionRepository,
+		wc.orderRepository,
+		wc.eventService,
+		wc.clock,
+		wc.metrics,
+		workloadLogger,
+	)
+
+	wc.workloads[workload.ID.String()] = workloadRunner
+	wc.publishRunners()
+	wc.metrics.RunningWorkloads(len(wc.workloads))
+
+	go func() {
+		<-workloadRunner.Done()
+		marketData.Close()
+
+		// Loops report their errors before they get stopped. Errors
+		// caused by stopping the runner on purpose are not failures.
+		select {
+		case err := <-workloadRunner.ErrChan():
+			if workloadRunner.stopped() || ctx.Err() != nil {
+				workloadLogger.Debugf(
+					"stopped workload reported error: [%v]",
+					err,
 				)
+				break
+			}
 
-				wc.workloads[workload.ID.String()] = workloadRunner
-
-				go func() {
-					select {
-					case err := <-workloadRunner.ErrChan():
-						workloadLogger.Errorf(
-							"workload terminated with error: [%v]",
-							err,
-						)
-					case <-ctx.Done():
-					}
-
-					wc.workloadsMutex.Lock()
-					delete(wc.workloads, workload.ID.String())
-					wc.workloadsMutex.Unlock()
-				}()
+			wc.handleWorkloadFailure(
+				workload,
+				workloadRunner.runTime(),
+				err,
+				workloadLogger,
+			)
+		default:
+		}
+
+		wc.workloadsMutex.Lock()
+		// Runners detached by the shutdown are stopped by it.
+		if wc.workloads[workload.ID.String()] == workloadRunner {
+			wc.stopWorkload(workload.ID.String(), workloadRunner)
+		}
+		wc.workloadsMutex.Unlock()
+	}()
+}
+
+// publishRunners must be called with the workloads mutex held.
+func (wc *WorkloadController) publishRunners() {
+	runners := make([]*WorkloadRunner, 0, len(wc.workloads))
+	for _, workloadRunner := range wc.workloads {
+		runners = append(runners, workloadRunner)
+	}
+
+	wc.runnersMutex.Lock()
+	wc.runners = runners
+	wc.runnersMutex.Unlock()
+}
+
+// releaseLease releases the lease of the stopped runner's workload once
+// the runner is done, so other instances never run the workload while
+// it's still running here.
+func (wc *WorkloadController) releaseLease(workloadRunner *WorkloadRunner) {
+	<-workloadRunner.Done()
+
+	if err := wc.leaser.ReleaseLease(workloadRunner.workload.ID); err != nil {
+		wc.logger.Warningf(
+			"lease will be released once it expires: [%v]",
+			err,
+		)
+	}
+}
+
+// leasedWorkloads keeps disabled workloads as they are not run anyway.
+func (wc *WorkloadController) leasedWorkloads(
+	workloads []*Workload,
+) ([]*Workload, error) {
+	if wc.leaser == nil {
+		return workloads, nil
+	}
+
+	owned, err := wc.leaser.LeaseWorkloads(workloads)
+	if err != nil {
+		return nil, err
+	}
+
+	leasedWorkloads := make([]*Workload, 0)
+	for _, workload := range workloads {
+		if workload.Status == WorkloadDisabled ||
+			owned[workload.ID.String()] {
+			leasedWorkloads = append(leasedWorkloads, workload)
+		}
+	}
+
+	return leasedWorkloads, nil
+}
+
+// handleWorkloadFailure notifies the account owner once the workload gets
+// disabled as it requires a manual intervention.
+func (wc *WorkloadController) handleWorkloadFailure(
+	workload *Workload,
+	runTime time.Duration,
+	err error,
+	workloadLogger Logger,
+) {
+	disable, backoff := wc.supervisor.recordFailure(
+		workload.ID.String(),
+		runTime,
+		err,
+	)
+
+	if !disable {
+		workloadLogger.Errorf(
+			"workload failed with error: [%v]; restarting in [%v]",
+			err,
+			backoff,
+		)
+		return
+	}
+
+	workloadLogger.Errorf(
+		"workload failed with error: [%v]; disabling workload",
+		err,
+	)
+
+	// The workload instance is shared with the runner so the status is
+	// changed on a copy.
+	disabledWorkload := *workload
+	disabledWorkload.Status = WorkloadDisabled
+
+	if err := wc.workloadRepository.UpdateWorkload(
+		&disabledWorkload,
+	); err != nil {
+		workloadLogger.Errorf("could not disable workload: [%v]", err)
+		return
+	}
+
+	wc.eventService.Publish(NewWorkloadDisabledEvent(workload, err))
+}
+
+// Shutdown lets running workloads complete their in-flight actions.
+// Actions still pending once the context is done are aborted.
+func (wc *WorkloadController) Shutdown(ctx context.Context) error {
+	wc.workloadsMutex.Lock()
+	wc.shuttingDown = true
+	workloadRunners := wc.workloads
+	wc.workloads = make(map[string]*WorkloadRunner)
+	wc.publishRunners()
+	wc.workloadsMutex.Unlock()
+
+	wc.logger.Infof("shutting down [%v] workloads", len(workloadRunners))
+
+	var shutdownWait sync.WaitGroup
+	errChan := make(chan error, len(workloadRunners))
+
+	for workloadID, workloadRunner := range workloadRunners {
+		workloadID := workloadID
+		workloadRunner := workloadRunner
+
+		shutdownWait.Add(1)
+
+		go func() {
+			defer shutdownWait.Done()
+
+			if err := workloadRunner.shutdown(ctx); err != nil {
+				errChan <- fmt.Errorf(
+					"could not shutdown workload [%v]: [%w]",
+					workloadID,
+					err,
+				)
 			}
 
-			wc.workloadsMutex.Unlock()
-		case <-ctx.Done():
-			return
+			wc.metrics.WorkloadStopped(workloadRunner.workload)
+		}()
+	}
+
+	shutdownWait.Wait()
+	close(errChan)
+
+	wc.metrics.RunningWorkloads(0)
+
+	errs := make([]string, 0)
+	for err := range errChan {
+		errs = append(errs, err.Error())
+	}
+
+	// Workloads are stopped so other instances can take them over
+	// right away.
+	if wc.leaser != nil {
+		if err := wc.leaser.Release(); err != nil {
+			errs = append(errs, err.Error())
 		}
 	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("workloads shutdown failed: %v", errs)
+	}
+
+	return nil
+}
+
+// stopWorkload must be called with the workloads mutex held.
+func (wc *WorkloadController) stopWorkload(
+	workloadID string,
+	workloadRunner *WorkloadRunner,
+) {
+	workloadRunner.stop()
+
+	// The runner could have been already replaced by a new one.
+	if wc.workloads[workloadID] == workloadRunner {
+		delete(wc.workloads, workloadID)
+		wc.publishRunners()
+		wc.metrics.WorkloadStopped(workloadRunner.workload)
+		wc.metrics.RunningWorkloads(len(wc.workloads))
+	}
 }
 
 type WorkloadRunner struct {
@@ -155,14 +611,32 @@ type WorkloadRunner struct {
 
 	idService          IDService
 	exchangeService    ExchangeService
-	candleRepository   CandleRepository
+	marketData         MarketData
 	signalGenerator    SignalGenerator
 	positionRepository PositionRepository
 	orderRepository    OrderRepository
 	eventService       EventService
+	clock              Clock
+	metrics            Metrics
 
 	logger  Logger
 	errChan chan error
+	// Cancelling the loop context stops both loops once their current
+	// iterations are completed. Cancelling the call context aborts calls
+	// made by those iterations.
+	loopCtx       context.Context
+	cancelLoopCtx context.CancelFunc
+	callCtx       context.Context
+	cancelCallCtx context.CancelFunc
+	done          chan struct{}
+
+	statusMutex   sync.RWMutex
+	status        WorkloadStatus
+	stopRequested bool
+
+	activityMutex  sync.RWMutex
+	startTime      time.Time
+	lastActionTime time.Time
 
 	lastSignalTime time.Time
 }
@@ -172,173 +646,331 @@ func RunWorkload(
 	workload *Workload,
 	idService IDService,
 	exchangeService ExchangeService,
-	candleRepository CandleRepository,
+	marketData MarketData,
 	signalGenerator SignalGenerator,
 	positionRepository PositionRepository,
 	orderRepository OrderRepository,
 	eventService EventService,
+	clock Clock,
+	metrics Metrics,
 	logger Logger,
 ) *WorkloadRunner {
-	workloadRunner := &WorkloadRunner{
-		workload:           workload,
-		idService:          idService,
-		exchangeService:    exchangeService,
-		candleRepository:   candleRepository,
-		signalGenerator:    signalGenerator,
-		positionRepository: positionRepository,
-		orderRepository:    orderRepository,
-		eventService:       eventService,
-		logger:             logger,
-		errChan:            make(chan error, 1),
-		lastSignalTime:     time.Now(),
-	}
+	workloadRunner := newWorkloadRunner(
+		workload,
+		idService,
+		exchangeService,
+		marketData,
+		signalGenerator,
+		positionRepository,
+		orderRepository,
+		eventService,
+		clock,
+		metrics,
+		logger,
+	)
 
-	loopCtx, cancelLoopCtx := context.WithCancel(ctx)
+	callCtx, cancelCallCtx := context.WithCancel(ctx)
+	workloadRunner.callCtx = callCtx
+	workloadRunner.cancelCallCtx = cancelCallCtx
+
+	loopCtx, cancelLoopCtx := context.WithCancel(callCtx)
+	workloadRunner.loopCtx = loopCtx
+	workloadRunner.cancelLoopCtx = cancelLoopCtx
+
+	var loopsWait sync.WaitGroup
+	loopsWait.Add(2)
 
 	go func() {
+		defer loopsWait.Done()
 		workloadRunner.dataLoop(loopCtx)
 		cancelLoopCtx()
 	}()
 
 	go func() {
-		workloadRunner.actionLoop(loopCtx)
+		defer loopsWait.Done()
+		workloadRunner.actionLoop(loopCtx, callCtx)
 		cancelLoopCtx()
 	}()
 
+	go func() {
+		loopsWait.Wait()
+		close(workloadRunner.done)
+	}()
+
 	return workloadRunner
 }
 
-func (wr *WorkloadRunner) dataLoop(ctx context.Context) {
-	defer wr.candleRepository.DeleteCandles(wr.workload.ID.String())
+func newWorkloadRunner(
+	workload *Workload,
+	idService IDService,
+	exchangeService ExchangeService,
+	marketData MarketData,
+	signalGenerator SignalGenerator,
+	positionRepository PositionRepository,
+	orderRepository OrderRepository,
+	eventService EventService,
+	clock Clock,
+	metrics Metrics,
+	logger Logger,
+) *WorkloadRunner {
+	return &WorkloadRunner{
+		workload:  workload,
+		idService: idService,
+		exchangeService: &instrumentedExchangeService{
+			ExchangeService: exchangeService,
+			metrics:         metrics,
+			clock:           clock,
+		},
+		marketData:         marketData,
+		signalGenerator:    signalGenerator,
+		positionRepository: positionRepository,
+		orderRepository:    orderRepository,
+		eventService:       eventService,
+		clock:              clock,
+		metrics:            metrics,
+		logger:             logger,
+		errChan:            make(chan error, 1),
+		done:               make(chan struct{}),
+		status:             workload.Status,
+		startTime:          clock.Now(),
+		lastActionTime:     clock.Now(),
+		lastSignalTime:     clock.Now(),
+	}
+}
 
-	end := time.Now()
-	// Must be set with respect to the CandleInterval constant.
-	start := end.Add(-1 * CandleWindowSize * time.Minute)
+func (wr *WorkloadRunner) updateStatus(status WorkloadStatus) {
+	wr.statusMutex.Lock()
+	defer wr.statusMutex.Unlock()
 
-	candles, err := wr.exchangeService.Candles(ctx, start, end)
-	if err != nil {
-		wr.errChan <- fmt.Errorf("failed to get candles: [%v]", err)
-		return
+	if wr.status != status {
+		wr.logger.Infof(
+			"workload status changed from [%v] to [%v]",
+			wr.status,
+			status,
+		)
 	}
 
-	wr.logger.Debugf("fetched [%v] historical candles", len(candles))
+	wr.status = status
+}
+
+func (wr *WorkloadRunner) paused() bool {
+	wr.statusMutex.RLock()
+	defer wr.statusMutex.RUnlock()
 
-	wr.candleRepository.SaveCandles(wr.workload.ID.String(), candles...)
+	return wr.status == WorkloadPaused
+}
 
-	tickerIdleTimer := time.NewTimer(candleTickerIdleTimeout)
-	tickerChan, tickerErrChan := wr.exchangeService.CandlesTicker(ctx)
+func (wr *WorkloadRunner) stopped() bool {
+	wr.statusMutex.RLock()
+	defer wr.statusMutex.RUnlock()
 
-	for {
+	return wr.stopRequested
+}
+
+func (wr *WorkloadRunner) runTime() time.Duration {
+	wr.activityMutex.RLock()
+	defer wr.activityMutex.RUnlock()
+
+	return wr.clock.Now().Sub(wr.startTime)
+}
+
+func (wr *WorkloadRunner) stop() {
+	wr.statusMutex.Lock()
+	wr.stopRequested = true
+	wr.statusMutex.Unlock()
+
+	if wr.cancelCallCtx != nil {
+		wr.cancelCallCtx()
+	}
+}
+
+// shutdown lets the current action loop iteration complete and records
+// executions of queued orders filled in the meantime.
+func (wr *WorkloadRunner) shutdown(ctx context.Context) error {
+	wr.statusMutex.Lock()
+	wr.stopRequested = true
+	wr.statusMutex.Unlock()
+
+	defer wr.cancelCallCtx()
+
+	go func() {
 		select {
-		case tick := <-tickerChan:
-			wr.logger.Debugf("received candle tick [%v]", tick)
+		case <-ctx.Done():
+			wr.cancelCallCtx()
+		case <-wr.callCtx.Done():
+		}
+	}()
 
-			wr.candleRepository.SaveCandles(
-				wr.workload.ID.String(),
-				tick.Candle,
-			)
+	wr.cancelLoopCtx()
 
-			if !tickerIdleTimer.Stop() {
-				<-tickerIdleTimer.C
-			}
-			tickerIdleTimer.Reset(candleTickerIdleTimeout)
-		case <-tickerIdleTimer.C:
-			wr.errChan <- fmt.Errorf("ticker idle timeout expired")
-			return
-		case err := <-tickerErrChan:
-			wr.errChan <- fmt.Errorf("ticker error: [%v]", err)
+	select {
+	case <-wr.done:
+	case <-ctx.Done():
+		return fmt.Errorf(
+			"in-flight actions not completed: [%w]",
+			ctx.Err(),
+		)
+	}
+
+	if err := wr.recordQueuedExecutions(wr.callCtx); err != nil {
+		return fmt.Errorf(
+			"could not record executions of queued orders: [%w]",
+			err,
+		)
+	}
+
+	wr.logger.Infof("workload has been shut down")
+
+	return nil
+}
+
+func (wr *WorkloadRunner) Done() <-chan struct{} {
+	return wr.done
+}
+
+func (wr *WorkloadRunner) dataLoop(ctx context.Context) {
+	accountUpdatesErrChan := wr.exchangeService.AccountUpdates(ctx)
+
+	for {
+		select {
+		case err := <-wr.marketData.ErrChan():
+			wr.errChan <- fmt.Errorf("market data failed: [%w]", err)
 			return
+		case err := <-accountUpdatesErrChan:
+			wr.logger.Warningf(
+				"account updates not available; "+
+					"falling back to polling: [%v]",
+				err,
+			)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (wr *WorkloadRunner) actionLoop(ctx context.Context) {
+// actionLoop makes calls using the call context so they are not
+// interrupted when the loop is stopped gracefully.
+func (wr *WorkloadRunner) actionLoop(loopCtx, callCtx context.Context) {
 	ticker := time.NewTicker(workloadActionLoopTick)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			signalGeneratorPaused := time.Now().Before(
-				wr.lastSignalTime.Add(signalGeneratorPauseTime),
-			)
+			// Both channels could be ready at the same time.
+			if loopCtx.Err() != nil {
+				return
+			}
 
-			if !signalGeneratorPaused {
-				candles := wr.candleRepository.Candles(wr.workload.ID.String())
-
-				if signal, exists := wr.signalGenerator.Evaluate(
-					candles,
-				); exists {
-					wr.lastSignalTime = time.Now()
-
-					if err := wr.processSignal(ctx, signal); err != nil {
-						wr.errChan <- fmt.Errorf(
-							"error while processing new signal: [%v]",
-							err,
-						)
-						return
-					}
-				}
+			if err := wr.act(callCtx); err != nil {
+				wr.errChan <- err
+				return
 			}
 
-			orders, err := wr.refreshOrdersQueue()
-			if err != nil {
-				wr.errChan <- fmt.Errorf(
-					"error while refreshing orders queue: [%v]",
+			wr.activityMutex.Lock()
+			wr.lastActionTime = wr.clock.Now()
+			wr.activityMutex.Unlock()
+		case <-loopCtx.Done():
+			return
+		}
+	}
+}
+
+func (wr *WorkloadRunner) act(ctx context.Context) error {
+	signalGeneratorPaused := wr.clock.Now().Before(
+		wr.lastSignalTime.Add(signalGeneratorPauseTime),
+	)
+
+	// Paused workloads don't look for new signals but still need to
+	// manage the positions which are already open. The same applies to
+	// runners which are being shut down. Signals are not evaluated until
+	// missing candles are backfilled either.
+	if !signalGeneratorPaused && !wr.paused() && !wr.stopped() &&
+		wr.marketData.UpToDate() {
+		signal, exists, err := wr.marketData.Evaluate(wr.signalGenerator)
+		if err != nil {
+			return fmt.Errorf("could not evaluate signal: [%w]", err)
+		}
+
+		if exists {
+			// Evaluated signals are shared by workloads trading on the
+			// same candles so targets are adjusted on a copy.
+			signal = signal.WithATRTargets(
+				wr.workload.StopLossATRMultiplier,
+				wr.workload.RewardRiskRatio,
+			)
+
+			wr.lastSignalTime = wr.clock.Now()
+			wr.metrics.SignalGenerated(wr.workload, signal)
+
+			if err := wr.processSignal(ctx, signal); err != nil {
+				return fmt.Errorf(
+					"error while processing new signal: [%w]",
 					err,
 				)
-				return
 			}
+		}
+	}
 
-			for _, order := range orders {
-				alreadyExecuted, err := wr.exchangeService.IsOrderExecuted(
-					ctx,
-					order,
+	orders, err := wr.refreshOrdersQueue(ctx)
+	if err != nil {
+		return fmt.Errorf(
+			"error while refreshing orders queue: [%w]",
+			err,
+		)
+	}
+
+	for _, order := range orders {
+		alreadyExecuted, err := wr.exchangeService.IsOrderExecuted(
+			ctx,
+			order,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"error while checking order execution: [%w]",
+				err,
+			)
+		}
+
+		if alreadyExecuted {
+			if err := wr.recordOrderExecution(order); err != nil {
+				return fmt.Errorf(
+					"error while recording order execution: [%w]",
+					err,
 				)
-				if err != nil {
-					wr.errChan <- fmt.Errorf(
-						"error while checking order execution: [%v]",
-						err,
-					)
-					return
-				}
+			}
+			continue
+		}
 
-				if alreadyExecuted {
-					if err := wr.recordOrderExecution(order); err != nil {
-						wr.errChan <- fmt.Errorf(
-							"error while recording order execution: [%v]",
-							err,
-						)
-						return
-					}
-					continue
-				}
+		wr.metrics.OrderSent(wr.workload, order)
 
-				executed, err := wr.exchangeService.ExecuteOrder(ctx, order)
-				if err != nil {
-					wr.errChan <- fmt.Errorf(
-						"error while executing order: [%v]",
-						err,
-					)
-					return
-				}
+		executed, err := wr.exchangeService.ExecuteOrder(ctx, order)
+		if err != nil {
+			wr.metrics.OrderRejected(wr.workload, order)
+			return fmt.Errorf(
+				"error while executing order: [%w]",
+				err,
+			)
+		}
 
-				if executed {
-					if err := wr.recordOrderExecution(order); err != nil {
-						wr.errChan <- fmt.Errorf(
-							"error while recording order execution: [%v]",
-							err,
-						)
-						return
-					}
-					continue
-				}
+		if !executed {
+			// Orders which cannot be filled immediately are cancelled
+			// by the exchange and retried in the next iteration.
+			wr.metrics.OrderRejected(wr.workload, order)
+		}
+
+		if executed {
+			if err := wr.recordOrderExecution(order); err != nil {
+				return fmt.Errorf(
+					"error while recording order execution: [%w]",
+					err,
+				)
 			}
-		case <-ctx.Done():
-			return
+			continue
 		}
 	}
+
+	return nil
 }
 
 func (wr *WorkloadRunner) processSignal(
@@ -349,46 +981,66 @@ func (wr *WorkloadRunner) processSignal(
 
 	balances, err := wr.exchangeService.AccountBalances(ctx)
 	if err != nil {
-		return fmt.Errorf("could not get account balances: [%v]", err)
+		return fmt.Errorf("could not get account balances: [%w]", err)
 	}
 
 	takerCommission, err := wr.exchangeService.AccountTakerCommission(ctx)
 	if err != nil {
-		return fmt.Errorf("could not get account commission: [%v]", err)
+		return fmt.Errorf("could not get account commission: [%w]", err)
+	}
+
+	shortSellingAllowed, err :=
+		wr.exchangeService.AccountShortSellingAllowed(ctx)
+	if err != nil {
+		return fmt.Errorf(
+			"could not determine if short selling is allowed: [%w]",
+			err,
+		)
+	}
+
+	tradingRules, err := wr.exchangeService.TradingRules(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get trading rules: [%w]", err)
 	}
 
 	walletItem := &AccountWalletItem{
-		Account:         wr.workload.Account,
-		Asset:           wr.workload.Pair.Quote,
-		Balance:         balances.BalanceOf(wr.workload.Pair.Quote),
-		TakerCommission: takerCommission,
+		Account:             wr.workload.Account,
+		Asset:               wr.workload.Pair.Quote,
+		Balance:             balances.BalanceOf(wr.workload.Pair.Quote),
+		TakerCommission:     takerCommission,
+		ShortSellingAllowed: shortSellingAllowed,
 	}
 
 	positionOpener := &PositionOpener{
 		workload:           wr.workload,
 		walletItem:         walletItem,
+		tradingRules:       tradingRules,
 		positionRepository: wr.positionRepository,
 		idService:          wr.idService,
 		eventService:       wr.eventService,
+		clock:              wr.clock,
 	}
 	position, dropped, err := positionOpener.OpenPosition(signal)
 	if err != nil {
-		return fmt.Errorf("could not open position: [%v]", err)
+		return fmt.Errorf("could not open position: [%w]", err)
 	}
 
 	if len(dropped) > 0 {
 		wr.logger.Warningf("dropping signal because: [%v]", dropped)
+		wr.metrics.SignalDropped(wr.workload, DropReason(dropped))
 		return nil
 	}
 
 	orderFactory := &OrderFactory{
+		tradingRules:    tradingRules,
 		orderRepository: wr.orderRepository,
 		idService:       wr.idService,
+		clock:           wr.clock,
 	}
 	_, err = orderFactory.CreateEntryOrder(position)
 	if err != nil {
 		return fmt.Errorf(
-			"could not create entry order for position [%v]: [%v]",
+			"could not create entry order for position [%v]: [%w]",
 			position.ID,
 			err,
 		)
@@ -404,7 +1056,9 @@ func (wr *WorkloadRunner) processSignal(
 	return nil
 }
 
-func (wr *WorkloadRunner) refreshOrdersQueue() ([]*Order, error) {
+func (wr *WorkloadRunner) refreshOrdersQueue(
+	ctx context.Context,
+) ([]*Order, error) {
 	openPositions, err := wr.positionRepository.Positions(
 		PositionFilter{
 			WorkloadID: wr.workload.ID,
@@ -412,9 +1066,12 @@ func (wr *WorkloadRunner) refreshOrdersQueue() ([]*Order, error) {
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("could not get open positions: [%v]", err)
+		return nil, fmt.Errorf("could not get open positions: [%w]", err)
 	}
 
+	// Positions closed below are reported by the next refresh.
+	wr.metrics.OpenPositions(wr.workload, len(openPositions))
+
 	sort.SliceStable(openPositions, func(i, j int) bool {
 		return openPositions[i].Time.Before(openPositions[j].Time)
 	})
@@ -422,19 +1079,26 @@ func (wr *WorkloadRunner) refreshOrdersQueue() ([]*Order, error) {
 	currentPrice, err := wr.lastClosePrice()
 	if err != nil {
 		return nil, fmt.Errorf(
-			"could not determine current price: [%v]",
+			"could not determine current price: [%w]",
 			err,
 		)
 	}
 
+	tradingRules, err := wr.exchangeService.TradingRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get trading rules: [%w]", err)
+	}
+
 	positionCloser := &PositionCloser{
 		workload:           wr.workload,
 		positionRepository: wr.positionRepository,
 		eventService:       wr.eventService,
 	}
 	orderFactory := &OrderFactory{
+		tradingRules:    tradingRules,
 		orderRepository: wr.orderRepository,
 		idService:       wr.idService,
+		clock:           wr.clock,
 	}
 
 	pendingOrders := make([]*Order, 0)
@@ -442,18 +1106,19 @@ func (wr *WorkloadRunner) refreshOrdersQueue() ([]*Order, error) {
 	for _, position := range openPositions {
 		entryOrder, exitOrder, err := position.OrdersBreakdown()
 		if err != nil {
-			return nil, fmt.Errorf(
+			// Such a position requires a manual intervention.
+			return nil, NewFatalError(fmt.Errorf(
 				"inconsistent orders state for position [%v]: [%v]",
 				position.ID,
 				err,
-			)
+			))
 		}
 
 		if entryOrder == nil {
 			// just close without trying to recover the entry order
 			if err := positionCloser.ClosePosition(position); err != nil {
 				return nil, fmt.Errorf(
-					"could not close position [%v]: [%v]",
+					"could not close position [%v]: [%w]",
 					position.ID,
 					err,
 				)
@@ -462,10 +1127,15 @@ func (wr *WorkloadRunner) refreshOrdersQueue() ([]*Order, error) {
 		}
 
 		if !entryOrder.Executed {
-			if time.Now().Sub(entryOrder.Time) > entryOrderValidityTime {
+			entryOrderExpired := wr.clock.Now().Sub(entryOrder.Time) >
+				entryOrderValidityTime
+
+			// There is nothing to exit from if the entry order has not
+			// been executed yet.
+			if entryOrderExpired || position.ExitRequested {
 				if err := positionCloser.ClosePosition(position); err != nil {
 					return nil, fmt.Errorf(
-						"could not close position [%v]: [%v]",
+						"could not close position [%v]: [%w]",
 						position.ID,
 						err,
 					)
@@ -478,10 +1148,7 @@ func (wr *WorkloadRunner) refreshOrdersQueue() ([]*Order, error) {
 		}
 
 		if exitOrder == nil {
-			shouldExit := currentPrice.Cmp(position.StopLossPrice) <= 0 ||
-				currentPrice.Cmp(position.TakeProfitPrice) >= 0
-
-			if shouldExit {
+			if position.ExitRequested || position.ShouldExit(currentPrice) {
 				exitOrder, err := orderFactory.CreateExitOrder(
 					position,
 					currentPrice,
@@ -489,7 +1156,7 @@ func (wr *WorkloadRunner) refreshOrdersQueue() ([]*Order, error) {
 				if err != nil {
 					return nil, fmt.Errorf(
 						"could not create exit order "+
-							"for position [%v]: [%v]",
+							"for position [%v]: [%w]",
 						position.ID,
 						err,
 					)
@@ -508,16 +1175,71 @@ func (wr *WorkloadRunner) refreshOrdersQueue() ([]*Order, error) {
 
 		if err := positionCloser.ClosePosition(position); err != nil {
 			return nil, fmt.Errorf(
-				"could not close position [%v]: [%v]",
+				"could not close position [%v]: [%w]",
 				position.ID,
 				err,
 			)
 		}
+
+		if trade, exited, err := NewTrade(position); err == nil && exited {
+			wr.metrics.RealizedProfit(wr.workload, trade.Profit)
+		}
 	}
 
 	return pendingOrders, nil
 }
 
+// recordQueuedExecutions doesn't send any orders nor closes positions so
+// it can be used once the runner is stopped.
+func (wr *WorkloadRunner) recordQueuedExecutions(ctx context.Context) error {
+	openPositions, err := wr.positionRepository.Positions(
+		PositionFilter{
+			WorkloadID: wr.workload.ID,
+			Status:     StatusOpen,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("could not get open positions: [%w]", err)
+	}
+
+	for _, position := range openPositions {
+		entryOrder, exitOrder, err := position.OrdersBreakdown()
+		if err != nil {
+			wr.logger.Warningf(
+				"skipping position [%v] with inconsistent orders: [%v]",
+				position.ID,
+				err,
+			)
+			continue
+		}
+
+		for _, order := range []*Order{entryOrder, exitOrder} {
+			if order == nil || order.Executed {
+				continue
+			}
+
+			executed, err := wr.exchangeService.IsOrderExecuted(ctx, order)
+			if err != nil {
+				return fmt.Errorf(
+					"error while checking order execution: [%w]",
+					err,
+				)
+			}
+
+			if executed {
+				if err := wr.recordOrderExecution(order); err != nil {
+					return fmt.Errorf(
+						"error while recording order execution: [%w]",
+						err,
+					)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 func (wr *WorkloadRunner) recordOrderExecution(order *Order) error {
 	wr.logger.Infof(
 		"recording order [%v] execution",
@@ -527,25 +1249,28 @@ func (wr *WorkloadRunner) recordOrderExecution(order *Order) error {
 	recorder := &OrderExecutionRecorder{wr.orderRepository}
 	if err := recorder.recordOrderExecution(order); err != nil {
 		return fmt.Errorf(
-			"could not record order [%v] execution: [%v]",
+			"could not record order [%v] execution: [%w]",
 			order.ID,
 			err,
 		)
 	}
 
+	wr.metrics.OrderFilled(wr.workload, order)
+
 	return nil
 }
 
-func (wr *WorkloadRunner) lastClosePrice() (*big.Float, error) {
-	candles := wr.candleRepository.Candles(wr.workload.ID.String())
-
-	price := new(big.Float)
-	err := price.UnmarshalText([]byte(candles[len(candles)-1].ClosePrice))
+func (wr *WorkloadRunner) lastClosePrice() (Decimal, error) {
+	candles, err := wr.marketData.Candles()
 	if err != nil {
-		return nil, err
+		return Decimal{}, fmt.Errorf("could not get candles: [%w]", err)
+	}
+
+	if len(candles) == 0 {
+		return Decimal{}, fmt.Errorf("no candles available")
 	}
 
-	return price, nil
+	return candles[len(candles)-1].ClosePrice, nil
 }
 
 func (wr *WorkloadRunner) ErrChan() <-chan error {
@@ -0,0 +1,1235 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkloadRunner_Paused(t *testing.T) {
+	workload := &Workload{
+		ID: testID("workload"),
+		Account: &Account{
+			RiskFactor:         NewDecimal(1, -2),
+			OpenPositionsLimit: 1,
+		},
+		Pair:   Pair{Base: "BTC", Quote: "USDT"},
+		Status: WorkloadPaused,
+	}
+
+	positionRepository := &testPositionRepository{}
+	signalGenerator := &testSignalGenerator{}
+	clock := NewVirtualClock(time.Time{})
+
+	workloadRunner := newWorkloadRunner(
+		workload,
+		&testIDService{},
+		&testExchangeService{workload: workload},
+		&repositoryMarketData{
+			candleRepository: &testCandleRepository{
+				candles: []*Candle{{ClosePrice: NewDecimal(100, 0)}},
+			},
+		},
+		signalGenerator,
+		positionRepository,
+		&testOrderRepository{},
+		&testEventService{},
+		clock,
+		&NoopMetrics{},
+		&testLogger{},
+	)
+
+	// Let the initial signal generator pause expire.
+	clock.Set(time.Time{}.Add(signalGeneratorPauseTime))
+
+	if err := workloadRunner.act(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if signalGenerator.evaluations != 0 {
+		t.Errorf("paused workload evaluated signals")
+	}
+
+	if len(positionRepository.positions) != 0 {
+		t.Errorf("paused workload opened a position")
+	}
+
+	workloadRunner.updateStatus(WorkloadActive)
+
+	if err := workloadRunner.act(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(positionRepository.positions) != 1 {
+		t.Errorf(
+			"unexpected positions count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			1,
+			len(positionRepository.positions),
+		)
+	}
+}
+
+func TestWorkloadRunner_Metrics(t *testing.T) {
+	workload := &Workload{
+		ID: testID("workload"),
+		Account: &Account{
+			RiskFactor:         NewDecimal(1, -2),
+			OpenPositionsLimit: 1,
+		},
+		Pair: Pair{Base: "BTC", Quote: "USDT"},
+	}
+
+	metrics := &testMetrics{}
+	clock := NewVirtualClock(time.Time{})
+
+	workloadRunner := newWorkloadRunner(
+		workload,
+		&testIDService{},
+		&testExchangeService{workload: workload},
+		&repositoryMarketData{
+			candleRepository: &testCandleRepository{
+				candles: []*Candle{{ClosePrice: NewDecimal(100, 0)}},
+			},
+		},
+		&testSignalGenerator{},
+		&testPositionRepository{},
+		&testOrderRepository{},
+		&testEventService{},
+		clock,
+		metrics,
+		&testLogger{},
+	)
+
+	clock.Set(time.Time{}.Add(signalGeneratorPauseTime))
+
+	if err := workloadRunner.act(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Let the next signal be dropped due to the open positions limit. The
+	// entry order expires in the meantime so it's not sent again.
+	clock.Set(time.Time{}.Add(2 * signalGeneratorPauseTime))
+
+	if err := workloadRunner.act(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCounts := map[string]int{
+		"signals generated": 2,
+		"signals dropped":   1,
+		"orders sent":       1,
+		"orders rejected":   1,
+		"orders filled":     0,
+	}
+	actualCounts := map[string]int{
+		"signals generated": metrics.signalsGenerated,
+		"signals dropped":   len(metrics.dropReasons),
+		"orders sent":       metrics.ordersSent,
+		"orders rejected":   metrics.ordersRejected,
+		"orders filled":     metrics.ordersFilled,
+	}
+
+	for name, expected := range expectedCounts {
+		if actualCounts[name] != expected {
+			t.Errorf(
+				"unexpected %v count\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				name,
+				expected,
+				actualCounts[name],
+			)
+		}
+	}
+
+	if metrics.dropReasons[0] != "open position limit violated" {
+		t.Errorf("unexpected drop reason: [%v]", metrics.dropReasons[0])
+	}
+
+	if metrics.exchangeRequests["execute_order"] != 1 {
+		t.Errorf("order executions have not been measured")
+	}
+
+	if metrics.openPositions != 1 {
+		t.Errorf(
+			"unexpected open positions\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			1,
+			metrics.openPositions,
+		)
+	}
+}
+
+func TestWorkloadRunner_SignalTargets(t *testing.T) {
+	workload := &Workload{
+		ID: testID("workload"),
+		Account: &Account{
+			RiskFactor:         NewDecimal(1, -2),
+			OpenPositionsLimit: 1,
+		},
+		Pair:                  Pair{Base: "BTC", Quote: "USDT"},
+		StopLossATRMultiplier: NewDecimal(15, -1),
+		RewardRiskRatio:       NewDecimal(3, 0),
+	}
+
+	signalGenerator := &testSignalGenerator{atr: NewDecimal(2, 0)}
+	metrics := &testMetrics{}
+	clock := NewVirtualClock(time.Time{})
+
+	workloadRunner := newWorkloadRunner(
+		workload,
+		&testIDService{},
+		&testExchangeService{workload: workload},
+		&repositoryMarketData{
+			candleRepository: &testCandleRepository{
+				candles: []*Candle{{ClosePrice: NewDecimal(100, 0)}},
+			},
+		},
+		signalGenerator,
+		&testPositionRepository{},
+		&testOrderRepository{},
+		&testEventService{},
+		clock,
+		metrics,
+		&testLogger{},
+	)
+
+	clock.Set(time.Time{}.Add(signalGeneratorPauseTime))
+
+	if err := workloadRunner.act(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if metrics.lastSignal == nil {
+		t.Fatal("signal has not been generated")
+	}
+
+	expectedTargets := map[string]Decimal{
+		"stop loss":   NewDecimal(97, 0),
+		"take profit": NewDecimal(109, 0),
+	}
+	actualTargets := map[string]Decimal{
+		"stop loss":   metrics.lastSignal.StopLossTarget,
+		"take profit": metrics.lastSignal.TakeProfitTarget,
+	}
+
+	for name, expected := range expectedTargets {
+		if !actualTargets[name].Equal(expected) {
+			t.Errorf(
+				"unexpected %v target\n"+
+					"expected: [%v]\n"+
+					"actual:   [%v]",
+				name,
+				expected,
+				actualTargets[name],
+			)
+		}
+	}
+}
+
+func TestDropReason(t *testing.T) {
+	reason := DropReason(
+		"exchange rules violated: [quantity [0.001] is below minimum]",
+	)
+
+	if reason != "exchange rules violated" {
+		t.Errorf("unexpected drop reason: [%v]", reason)
+	}
+}
+
+func TestWorkload_CandleKey(t *testing.T) {
+	tests := map[string]struct {
+		exchange         string
+		expectedExchange string
+	}{
+		"live": {
+			exchange:         "KRAKEN",
+			expectedExchange: "KRAKEN",
+		},
+		"paper": {
+			exchange:         PaperExchange,
+			expectedExchange: PaperMarketDataExchange,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			workload := &Workload{
+				Account:        &Account{Exchange: test.exchange},
+				Pair:           Pair{Base: "BTC", Quote: "USDT"},
+				CandleInterval: CandleInterval1m,
+			}
+
+			expectedKey := CandleKey{
+				Exchange: test.expectedExchange,
+				Pair:     workload.Pair,
+				Interval: CandleInterval1m,
+			}
+
+			if key := workload.CandleKey(); key != expectedKey {
+				t.Errorf(
+					"unexpected candle key\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					expectedKey,
+					key,
+				)
+			}
+		})
+	}
+}
+
+func TestWorkloadRunner_ExitRequested(t *testing.T) {
+	tests := map[string]struct {
+		entryOrderExecuted bool
+		expectedOrderSide  OrderSide
+		expectedStatus     PositionStatus
+	}{
+		"entry order executed": {
+			entryOrderExecuted: true,
+			expectedOrderSide:  SideSell,
+			expectedStatus:     StatusOpen,
+		},
+		"entry order not executed": {
+			entryOrderExecuted: false,
+			expectedStatus:     StatusClosed,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			workload := &Workload{ID: testID("workload"), Account: &Account{}}
+
+			// Current price is between the targets so the exit is caused
+			// only by the request.
+			position := &Position{
+				ID:              testID("position"),
+				WorkloadID:      workload.ID,
+				Type:            TypeLong,
+				Status:          StatusOpen,
+				EntryPrice:      NewDecimal(100, 0),
+				Size:            NewDecimal(1, 0),
+				TakeProfitPrice: NewDecimal(110, 0),
+				StopLossPrice:   NewDecimal(95, 0),
+				ExitRequested:   true,
+			}
+			position.Orders = []*Order{{
+				ID:       testID("entry"),
+				Position: position,
+				Side:     SideBuy,
+				Price:    position.EntryPrice,
+				Size:     position.Size,
+				Executed: test.entryOrderExecuted,
+			}}
+
+			workloadRunner := newWorkloadRunner(
+				workload,
+				&testIDService{},
+				&testExchangeService{workload: workload},
+				&repositoryMarketData{
+					candleRepository: &testCandleRepository{
+						candles: []*Candle{{ClosePrice: NewDecimal(101, 0)}},
+					},
+				},
+				&testSignalGenerator{},
+				&testPositionRepository{positions: []*Position{position}},
+				&testOrderRepository{},
+				&testEventService{},
+				NewVirtualClock(time.Time{}),
+				&NoopMetrics{},
+				&testLogger{},
+			)
+
+			orders, err := workloadRunner.refreshOrdersQueue(
+				context.Background(),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if position.Status != test.expectedStatus {
+				t.Errorf(
+					"unexpected position status\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedStatus,
+					position.Status,
+				)
+			}
+
+			if test.expectedStatus == StatusClosed {
+				if len(orders) != 0 {
+					t.Errorf("unexpected pending orders: [%v]", orders)
+				}
+				return
+			}
+
+			if len(orders) != 1 || orders[0].Side != test.expectedOrderSide {
+				t.Fatalf("exit order has not been created")
+			}
+
+			assertDecimal(t, "exit price", "101", orders[0].Price)
+		})
+	}
+}
+
+func TestWorkloadController_RefreshWorkloads(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	workloadRepository := &testWorkloadRepository{
+		statuses: map[string]WorkloadStatus{
+			"active":   WorkloadActive,
+			"paused":   WorkloadPaused,
+			"disabled": WorkloadDisabled,
+		},
+	}
+
+	workloadController := &WorkloadController{
+		workloadRepository: workloadRepository,
+		idService:          &testIDService{},
+		exchangeConnector:  &testExchangeConnector{},
+		marketDataHub:      newTestMarketDataHub(ctx),
+		strategyRegistry: &testStrategyRegistry{
+			signalGenerator: &testSignalGenerator{},
+		},
+		positionRepository: &testPositionRepository{},
+		orderRepository:    &testOrderRepository{},
+		eventService:       &testEventService{},
+		clock:              NewVirtualClock(time.Time{}),
+		metrics:            &NoopMetrics{},
+		supervisor: newWorkloadSupervisor(
+			DefaultWorkloadSupervisorConfig,
+			NewVirtualClock(time.Time{}),
+		),
+		workloads: make(map[string]*WorkloadRunner),
+		logger:    &testLogger{},
+	}
+
+	workloadController.refreshWorkloads(ctx)
+
+	assertRunningWorkloads(t, workloadController, "active", "paused")
+
+	activeRunner := workloadController.workloads["active"]
+	pausedRunner := workloadController.workloads["paused"]
+
+	if !pausedRunner.paused() {
+		t.Errorf("paused workload runner is not paused")
+	}
+
+	workloadRepository.setStatus("active", WorkloadDisabled)
+	workloadRepository.setStatus("paused", WorkloadActive)
+	workloadRepository.setStatus("disabled", WorkloadActive)
+
+	workloadController.refreshWorkloads(ctx)
+
+	assertRunningWorkloads(t, workloadController, "paused", "disabled")
+	assertRunnerStopped(t, activeRunner)
+
+	if pausedRunner.paused() {
+		t.Errorf("resumed workload runner is still paused")
+	}
+
+	workloadRepository.remove("paused")
+
+	workloadController.refreshWorkloads(ctx)
+
+	assertRunningWorkloads(t, workloadController, "disabled")
+	assertRunnerStopped(t, pausedRunner)
+}
+
+func TestWorkloadController_LeasedWorkloads(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	clock := NewVirtualClock(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))
+	leaseRepository := newTestLeaseRepository(clock)
+
+	workloadRepository := &testWorkloadRepository{
+		statuses: map[string]WorkloadStatus{
+			"workload-1": WorkloadActive,
+			"workload-2": WorkloadActive,
+		},
+	}
+
+	workloadController := &WorkloadController{
+		workloadRepository: workloadRepository,
+		idService:          &testIDService{},
+		exchangeConnector:  &testExchangeConnector{},
+		marketDataHub:      newTestMarketDataHub(ctx),
+		strategyRegistry: &testStrategyRegistry{
+			signalGenerator: &testSignalGenerator{},
+		},
+		positionRepository: &testPositionRepository{},
+		orderRepository:    &testOrderRepository{},
+		eventService:       &testEventService{},
+		clock:              clock,
+		metrics:            &NoopMetrics{},
+		supervisor: newWorkloadSupervisor(
+			DefaultWorkloadSupervisorConfig,
+			clock,
+		),
+		leaser: NewWorkloadLeaser(
+			"a",
+			leaseRepository,
+			clock,
+			&testLogger{},
+		),
+		workloads: make(map[string]*WorkloadRunner),
+		logger:    &testLogger{},
+	}
+
+	workloadController.refreshWorkloads(ctx)
+
+	assertRunningWorkloads(t, workloadController, "workload-1", "workload-2")
+
+	// Another instance joins so the surplus workload is stopped. Its lease
+	// is released once the runner is done.
+	_, err := NewWorkloadLeaser("b", leaseRepository, clock, &testLogger{}).
+		LeaseWorkloads(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	surplusRunner := workloadController.workloads["workload-2"]
+
+	workloadController.refreshWorkloads(ctx)
+
+	assertRunningWorkloads(t, workloadController, "workload-1")
+	assertRunnerStopped(t, surplusRunner)
+
+	waitFor(t, func() bool {
+		return leaseRepository.leaseOwner("workload-2") == ""
+	})
+
+	// Runners keep running during the database outage as long as their
+	// leases are valid.
+	workloadRepository.setErr(fmt.Errorf("database unavailable"))
+
+	runner := workloadController.workloads["workload-1"]
+
+	workloadController.refreshWorkloads(ctx)
+
+	assertRunningWorkloads(t, workloadController, "workload-1")
+
+	clock.Set(clock.Now().Add(workloadLeaseTTL - workloadLeaseSafetyMargin))
+
+	workloadController.refreshWorkloads(ctx)
+
+	assertRunningWorkloads(t, workloadController)
+	assertRunnerStopped(t, runner)
+}
+
+func TestWorkloadController_CheckHealth(t *testing.T) {
+	clock := NewVirtualClock(time.Time{})
+
+	workloadController := &WorkloadController{
+		clock:           clock,
+		metrics:         &NoopMetrics{},
+		workloads:       make(map[string]*WorkloadRunner),
+		lastRefreshTime: clock.Now(),
+		logger:          &testLogger{},
+	}
+
+	workload := &Workload{ID: testID("workload"), Account: &Account{}}
+
+	workloadRunner := newWorkloadRunner(
+		workload,
+		&testIDService{},
+		&testExchangeService{workload: workload},
+		&repositoryMarketData{candleRepository: &testCandleRepository{}},
+		&testSignalGenerator{},
+		&testPositionRepository{},
+		&testOrderRepository{},
+		&testEventService{},
+		clock,
+		&NoopMetrics{},
+		&testLogger{},
+	)
+	workloadController.workloads["workload"] = workloadRunner
+	workloadController.publishRunners()
+
+	if err := workloadController.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("unexpected error: [%v]", err)
+	}
+
+	// Health checks don't wait for the workloads mutex, held e.g. while
+	// workloads are refreshed.
+	workloadController.workloadsMutex.Lock()
+	err := workloadController.CheckHealth(context.Background())
+	workloadController.workloadsMutex.Unlock()
+
+	if err != nil {
+		t.Fatalf("unexpected error: [%v]", err)
+	}
+
+	// Health checks give up once their context is done.
+	canceledCtx, cancelCtx := context.WithCancel(context.Background())
+	cancelCtx()
+
+	workloadRunner.statusMutex.Lock()
+	err = workloadController.CheckHealth(canceledCtx)
+	workloadRunner.statusMutex.Unlock()
+
+	if err == nil {
+		t.Errorf("blocked health check has not been detected")
+	}
+
+	// The runner's loops made no progress since it was started.
+	clock.Set(time.Time{}.Add(workloadStallTimeout + time.Second))
+
+	workloadController.lastRefreshTime = clock.Now()
+
+	states := workloadController.RunnersState()
+	if len(states) != 1 || !states[0].Stalled {
+		t.Errorf("workload runner is not reported as stalled")
+	}
+
+	if err := workloadController.CheckHealth(context.Background()); err == nil {
+		t.Errorf("stalled workload runner has not been detected")
+	}
+
+	workloadController.stopWorkload("workload", workloadRunner)
+
+	if err := workloadController.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("unexpected error: [%v]", err)
+	}
+
+	// The controller loop made no progress.
+	clock.Set(
+		clock.Now().Add(workloadControllerLoopTick + workloadStallTimeout + 1),
+	)
+
+	if err := workloadController.CheckHealth(context.Background()); err == nil {
+		t.Errorf("stalled workload controller has not been detected")
+	}
+}
+
+func TestWorkloadController_FailedWorkloads(t *testing.T) {
+	tests := map[string]struct {
+		err              error
+		unknownStrategy  bool
+		expectedAttempts int
+		expectedStatus   WorkloadStatus
+	}{
+		// Restarts happen after 1, 2, 4 and 8 minutes and the fifth
+		// failure disables the workload.
+		"transient error": {
+			err:              fmt.Errorf("connection reset"),
+			expectedAttempts: 5,
+			expectedStatus:   WorkloadDisabled,
+		},
+		"fatal error": {
+			err:              NewFatalError(fmt.Errorf("invalid api key")),
+			expectedAttempts: 1,
+			expectedStatus:   WorkloadDisabled,
+		},
+		// Workloads are disabled before connecting the exchange.
+		"unknown strategy": {
+			unknownStrategy:  true,
+			expectedAttempts: 0,
+			expectedStatus:   WorkloadDisabled,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			workloadRepository := &testWorkloadRepository{
+				statuses: map[string]WorkloadStatus{
+					"workload": WorkloadActive,
+				},
+			}
+			exchangeConnector := &testExchangeConnector{err: test.err}
+			eventService := &testEventService{}
+			clock := NewVirtualClock(time.Time{})
+
+			strategyRegistry := &testStrategyRegistry{
+				signalGenerator: &testSignalGenerator{},
+			}
+			if test.unknownStrategy {
+				strategyRegistry = &testStrategyRegistry{}
+			}
+
+			workloadController := &WorkloadController{
+				workloadRepository: workloadRepository,
+				exchangeConnector:  exchangeConnector,
+				strategyRegistry:   strategyRegistry,
+				eventService:       eventService,
+				clock:              clock,
+				metrics:            &NoopMetrics{},
+				supervisor: newWorkloadSupervisor(
+					DefaultWorkloadSupervisorConfig,
+					clock,
+				),
+				workloads: make(map[string]*WorkloadRunner),
+				logger:    &testLogger{},
+			}
+
+			// Simulate two hours of controller loop ticks.
+			for minute := 0; minute < 120; minute++ {
+				clock.Set(time.Time{}.Add(time.Duration(minute) * time.Minute))
+				workloadController.refreshWorkloads(context.Background())
+			}
+
+			if exchangeConnector.attempts != test.expectedAttempts {
+				t.Errorf(
+					"unexpected start attempts\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedAttempts,
+					exchangeConnector.attempts,
+				)
+			}
+
+			if workloadRepository.statuses["workload"] != test.expectedStatus {
+				t.Errorf(
+					"unexpected workload status\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedStatus,
+					workloadRepository.statuses["workload"],
+				)
+			}
+
+			if len(eventService.events) != 1 {
+				t.Errorf("workload disabled event has not been published")
+			}
+		})
+	}
+}
+
+func TestWorkloadController_RestartBackoff(t *testing.T) {
+	workloadRepository := &testWorkloadRepository{
+		statuses: map[string]WorkloadStatus{"workload": WorkloadActive},
+	}
+	exchangeConnector := &testExchangeConnector{
+		err: fmt.Errorf("connection reset"),
+	}
+	clock := NewVirtualClock(time.Time{})
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	workloadController := &WorkloadController{
+		workloadRepository: workloadRepository,
+		idService:          &testIDService{},
+		exchangeConnector:  exchangeConnector,
+		marketDataHub:      newTestMarketDataHub(ctx),
+		strategyRegistry: &testStrategyRegistry{
+			signalGenerator: &testSignalGenerator{},
+		},
+		positionRepository: &testPositionRepository{},
+		orderRepository:    &testOrderRepository{},
+		eventService:       &testEventService{},
+		clock:              clock,
+		metrics:            &NoopMetrics{},
+		supervisor: newWorkloadSupervisor(
+			DefaultWorkloadSupervisorConfig,
+			clock,
+		),
+		workloads: make(map[string]*WorkloadRunner),
+		logger:    &testLogger{},
+	}
+
+	// Attempts at 0, 1 and 3 minutes.
+	for minute := 0; minute < 4; minute++ {
+		clock.Set(time.Time{}.Add(time.Duration(minute) * time.Minute))
+		workloadController.refreshWorkloads(ctx)
+	}
+
+	if exchangeConnector.attempts != 3 {
+		t.Fatalf("unexpected start attempts: [%v]", exchangeConnector.attempts)
+	}
+
+	// The connection recovers and the runner gets started.
+	exchangeConnector.err = nil
+
+	clock.Set(time.Time{}.Add(7 * time.Minute))
+	workloadController.refreshWorkloads(ctx)
+
+	assertRunningWorkloads(t, workloadController, "workload")
+}
+
+func TestWorkloadController_Shutdown(t *testing.T) {
+	tests := map[string]struct {
+		exchangeService       *testExchangeService
+		expectedError         bool
+		expectedOrderExecuted bool
+	}{
+		"queued order executed": {
+			exchangeService:       &testExchangeService{ordersExecuted: true},
+			expectedError:         false,
+			expectedOrderExecuted: true,
+		},
+		"queued order not executed": {
+			exchangeService:       &testExchangeService{},
+			expectedError:         false,
+			expectedOrderExecuted: false,
+		},
+		"exchange not responding": {
+			exchangeService:       &testExchangeService{blocking: true},
+			expectedError:         true,
+			expectedOrderExecuted: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			workload := &Workload{ID: testID("workload"), Account: &Account{}}
+			test.exchangeService.workload = workload
+
+			position := &Position{
+				ID:     testID("position"),
+				Type:   TypeLong,
+				Status: StatusOpen,
+			}
+			entryOrder := &Order{
+				ID:       testID("entry"),
+				Position: position,
+				Side:     SideBuy,
+			}
+			position.Orders = []*Order{entryOrder}
+
+			workloadRepository := &testWorkloadRepository{
+				statuses: map[string]WorkloadStatus{"workload": WorkloadActive},
+			}
+			clock := NewVirtualClock(time.Time{})
+
+			workloadController := &WorkloadController{
+				workloadRepository: workloadRepository,
+				exchangeConnector:  &testExchangeConnector{},
+				clock:              clock,
+				metrics:            &NoopMetrics{},
+				supervisor: newWorkloadSupervisor(
+					DefaultWorkloadSupervisorConfig,
+					clock,
+				),
+				workloads: make(map[string]*WorkloadRunner),
+				logger:    &testLogger{},
+			}
+
+			workloadRunner := RunWorkload(
+				context.Background(),
+				workload,
+				&testIDService{},
+				test.exchangeService,
+				&repositoryMarketData{
+					candleRepository: &testCandleRepository{},
+				},
+				&testSignalGenerator{},
+				&testPositionRepository{positions: []*Position{position}},
+				&testOrderRepository{},
+				&testEventService{},
+				clock,
+				&NoopMetrics{},
+				&testLogger{},
+			)
+			workloadController.workloads["workload"] = workloadRunner
+
+			ctx, cancelCtx := context.WithTimeout(
+				context.Background(),
+				100*time.Millisecond,
+			)
+			defer cancelCtx()
+
+			err := workloadController.Shutdown(ctx)
+			if test.expectedError != (err != nil) {
+				t.Errorf("unexpected shutdown error: [%v]", err)
+			}
+
+			assertRunnerStopped(t, workloadRunner)
+			assertRunningWorkloads(t, workloadController)
+
+			if entryOrder.Executed != test.expectedOrderExecuted {
+				t.Errorf(
+					"unexpected order execution\n"+
+						"expected: [%v]\n"+
+						"actual:   [%v]",
+					test.expectedOrderExecuted,
+					entryOrder.Executed,
+				)
+			}
+
+			// Workloads are not started once the shutdown begins.
+			workloadController.refreshWorkloads(context.Background())
+
+			assertRunningWorkloads(t, workloadController)
+		})
+	}
+}
+
+func assertRunningWorkloads(
+	t *testing.T,
+	workloadController *WorkloadController,
+	expected ...string,
+) {
+	workloadController.workloadsMutex.Lock()
+	defer workloadController.workloadsMutex.Unlock()
+
+	if len(workloadController.workloads) != len(expected) {
+		t.Fatalf(
+			"unexpected running workloads count\n"+
+				"expected: [%v]\n"+
+				"actual:   [%v]",
+			len(expected),
+			len(workloadController.workloads),
+		)
+	}
+
+	for _, workloadID := range expected {
+		if _, running := workloadController.workloads[workloadID]; !running {
+			t.Fatalf("workload [%v] is not running", workloadID)
+		}
+	}
+}
+
+func assertRunnerStopped(t *testing.T, workloadRunner *WorkloadRunner) {
+	select {
+	case <-workloadRunner.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("workload runner has not been stopped")
+	}
+}
+
+type testWorkloadRepository struct {
+	mutex    sync.Mutex
+	statuses map[string]WorkloadStatus
+	err      error
+}
+
+func (twr *testWorkloadRepository) CreateWorkload(_ *Workload) error {
+	return nil
+}
+
+func (twr *testWorkloadRepository) UpdateWorkload(workload *Workload) error {
+	twr.mutex.Lock()
+	defer twr.mutex.Unlock()
+
+	twr.statuses[workload.ID.String()] = workload.Status
+	return nil
+}
+
+func (twr *testWorkloadRepository) Workload(_ ID) (*Workload, error) {
+	return nil, ErrNotFound
+}
+
+func (twr *testWorkloadRepository) Workloads() ([]*Workload, error) {
+	twr.mutex.Lock()
+	defer twr.mutex.Unlock()
+
+	if twr.err != nil {
+		return nil, twr.err
+	}
+
+	workloads := make([]*Workload, 0)
+	for workloadID, status := range twr.statuses {
+		workloads = append(workloads, &Workload{
+			ID:      testID(workloadID),
+			Account: &Account{},
+			Status:  status,
+		})
+	}
+
+	return workloads, nil
+}
+
+func (twr *testWorkloadRepository) setStatus(
+	workloadID string,
+	status WorkloadStatus,
+) {
+	twr.mutex.Lock()
+	defer twr.mutex.Unlock()
+
+	twr.statuses[workloadID] = status
+}
+
+func (twr *testWorkloadRepository) setErr(err error) {
+	twr.mutex.Lock()
+	defer twr.mutex.Unlock()
+
+	twr.err = err
+}
+
+func (twr *testWorkloadRepository) remove(workloadID string) {
+	twr.mutex.Lock()
+	defer twr.mutex.Unlock()
+
+	delete(twr.statuses, workloadID)
+}
+
+type testExchangeConnector struct {
+	err      error
+	attempts int
+}
+
+func (tec *testExchangeConnector) Connect(
+	_ context.Context,
+	workload *Workload,
+) (ExchangeService, error) {
+	tec.attempts++
+
+	if tec.err != nil {
+		return nil, tec.err
+	}
+
+	return &testExchangeService{workload: workload}, nil
+}
+
+type testExchangeService struct {
+	workload *Workload
+	// candles are returned by the Candles method if they fit the
+	// requested range, unless candlesErr is set.
+	candles    []*Candle
+	candlesErr error
+	// ordersExecuted makes all orders reported as executed.
+	ordersExecuted bool
+	// blocking makes order calls block until their context is done.
+	blocking bool
+}
+
+func (tes *testExchangeService) Workload() *Workload {
+	return tes.workload
+}
+
+func (tes *testExchangeService) Candles(
+	ctx context.Context,
+	interval CandleInterval,
+	start, end time.Time,
+) (<-chan *Candle, <-chan error) {
+	return StreamCandles(
+		ctx,
+		interval,
+		start,
+		end,
+		func(_ context.Context, start, end time.Time) ([]*Candle, error) {
+			if tes.candlesErr != nil {
+				return nil, tes.candlesErr
+			}
+
+			candles := make([]*Candle, 0)
+			for _, candle := range tes.candles {
+				if !candle.OpenTime.Before(start) &&
+					!candle.OpenTime.After(end) {
+					candles = append(candles, candle)
+				}
+			}
+
+			return candles, nil
+		},
+	)
+}
+
+func (tes *testExchangeService) CandlesTicker(
+	_ context.Context,
+	_ CandleInterval,
+) (<-chan *CandleTick, <-chan error) {
+	return make(chan *CandleTick), make(chan error)
+}
+
+func (tes *testExchangeService) AccountTakerCommission(
+	_ context.Context,
+) (Decimal, error) {
+	return NewDecimal(1, -3), nil
+}
+
+func (tes *testExchangeService) AccountBalances(
+	_ context.Context,
+) (Balances, error) {
+	return Balances{"USDT": NewDecimal(1000, 0)}, nil
+}
+
+func (tes *testExchangeService) AccountShortSellingAllowed(
+	_ context.Context,
+) (bool, error) {
+	return false, nil
+}
+
+func (tes *testExchangeService) AccountUpdates(
+	_ context.Context,
+) <-chan error {
+	return make(chan error)
+}
+
+func (tes *testExchangeService) TradingRules(
+	_ context.Context,
+) (*TradingRules, error) {
+	return &TradingRules{}, nil
+}
+
+func (tes *testExchangeService) ExecuteOrder(
+	_ context.Context,
+	_ *Order,
+) (bool, error) {
+	return false, nil
+}
+
+func (tes *testExchangeService) IsOrderExecuted(
+	ctx context.Context,
+	_ *Order,
+) (bool, error) {
+	if tes.blocking {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+
+	return tes.ordersExecuted, nil
+}
+
+// testCandleRepository appends saved candles without any validation.
+type testCandleRepository struct {
+	mutex   sync.Mutex
+	candles []*Candle
+}
+
+func (tcr *testCandleRepository) SaveCandles(
+	_ CandleKey,
+	candles ...*Candle,
+) error {
+	tcr.mutex.Lock()
+	defer tcr.mutex.Unlock()
+
+	tcr.candles = append(tcr.candles, candles...)
+	return nil
+}
+
+func (tcr *testCandleRepository) Candles(_ CandleKey) ([]*Candle, error) {
+	tcr.mutex.Lock()
+	defer tcr.mutex.Unlock()
+
+	snapshot := make([]*Candle, len(tcr.candles))
+	copy(snapshot, tcr.candles)
+
+	return snapshot, nil
+}
+
+func (tcr *testCandleRepository) DeleteCandles(_ CandleKey) error {
+	return nil
+}
+
+// testOrderRepository attaches created orders to their positions, the
+// same way real repositories do when positions are fetched.
+type testOrderRepository struct{}
+
+func (tor *testOrderRepository) CreateOrder(order *Order) error {
+	order.Position.Orders = append(order.Position.Orders, order)
+	return nil
+}
+
+func (tor *testOrderRepository) UpdateOrder(_ *Order) error {
+	return nil
+}
+
+// testSignalGenerator emits a LONG signal on every evaluation.
+type testSignalGenerator struct {
+	mutex       sync.Mutex
+	evaluations int
+	// atr is set on emitted signals if not zero.
+	atr Decimal
+}
+
+func (tsg *testSignalGenerator) Evaluate(_ []*Candle) (*Signal, bool) {
+	tsg.mutex.Lock()
+	defer tsg.mutex.Unlock()
+
+	tsg.evaluations++
+
+	return &Signal{
+		Type:             TypeLong,
+		EntryTarget:      NewDecimal(100, 0),
+		TakeProfitTarget: NewDecimal(110, 0),
+		StopLossTarget:   NewDecimal(95, 0),
+		ATR:              tsg.atr,
+	}, true
+}
+
+// testStrategyRegistry provides the same signal generator for all
+// strategies. If the generator is not set, all strategies are unknown.
+type testStrategyRegistry struct {
+	signalGenerator SignalGenerator
+}
+
+func (tsr *testStrategyRegistry) SignalGenerator(
+	_ StrategyRef,
+) (SignalGenerator, error) {
+	if tsr.signalGenerator == nil {
+		return nil, ErrNotFound
+	}
+
+	return tsr.signalGenerator, nil
+}
+
+type testMetrics struct {
+	NoopMetrics
+
+	signalsGenerated int
+	lastSignal       *Signal
+	dropReasons      []string
+	ordersSent       int
+	ordersFilled     int
+	ordersRejected   int
+	exchangeRequests map[string]int
+	openPositions    int
+}
+
+func (tm *testMetrics) SignalGenerated(_ *Workload, signal *Signal) {
+	tm.signalsGenerated++
+	tm.lastSignal = signal
+}
+
+func (tm *testMetrics) SignalDropped(_ *Workload, reason string) {
+	tm.dropReasons = append(tm.dropReasons, reason)
+}
+
+func (tm *testMetrics) OrderSent(_ *Workload, _ *Order) {
+	tm.ordersSent++
+}
+
+func (tm *testMetrics) OrderFilled(_ *Workload, _ *Order) {
+	tm.ordersFilled++
+}
+
+func (tm *testMetrics) OrderRejected(_ *Workload, _ *Order) {
+	tm.ordersRejected++
+}
+
+func (tm *testMetrics) ExchangeRequest(
+	_ *Workload,
+	operation string,
+	_ time.Duration,
+	_ error,
+) {
+	if tm.exchangeRequests == nil {
+		tm.exchangeRequests = make(map[string]int)
+	}
+
+	tm.exchangeRequests[operation]++
+}
+
+func (tm *testMetrics) OpenPositions(_ *Workload, count int) {
+	tm.openPositions = count
+}
+
+type testLogger struct{}
+
+func (tl *testLogger) Debugf(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) Infof(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) Warningf(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) Errorf(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) Fatalf(_ string, _ ...interface{}) {}
+
+func (tl *testLogger) WithField(_ string, _ interface{}) Logger {
+	return tl
+}
+
+func (tl *testLogger) WithFields(_ map[string]interface{}) Logger {
+	return tl
+}